@@ -0,0 +1,346 @@
+// Package bindings exposes the platform objects of the spec package to the
+// JavaScript engine of a spec.ScriptHost, following
+// https://webidl.spec.whatwg.org/#javascript-binding. Platform objects are
+// wrapped in Objects described by Interfaces, which the engine turns into its
+// own objects with the same prototype chains, and a platform object always
+// has the same wrapper.
+//
+// JavaScript values are represented by Go values: Undefined, nil for null,
+// bool, float64, string, []interface{} for sequences,
+// map[string]interface{} for dictionaries, *Object for platform objects and
+// Function for the engine's functions.
+package bindings
+
+import (
+	"errors"
+
+	"github.com/heathj/gobrowse/parser/spec"
+)
+
+// ErrType is a TypeError, thrown for values that don't convert to what an
+// attribute or operation expects.
+var ErrType = errors.New("TypeError")
+
+type undefined struct{}
+
+// Undefined is the JavaScript undefined value.
+var Undefined = undefined{}
+
+// Function is a function of the JavaScript engine, like an event listener
+// or a timer's handler. Functions are compared with == to find the event
+// listeners to remove, so the engine should use the same comparable Function,
+// like a pointer, for the same JavaScript function.
+type Function interface {
+	Call(this interface{}, args ...interface{}) (interface{}, error)
+}
+
+// Bindings wraps the platform objects of the documents of an agent. The
+// wrappers are kept in the realm of the platform object's document, or on
+// the event for events, so they go away with it. It isn't safe to use from
+// several goroutines.
+type Bindings struct {
+	// ReportError is called with the exceptions thrown by callbacks like event
+	// listeners and timer handlers, which aren't thrown to a script.
+	// https://html.spec.whatwg.org/multipage/webappapis.html#report-the-exception
+	ReportError func(err error)
+
+	// detached is the realm of the nodes that aren't in an HTML document.
+	detached *realm
+}
+
+// realm is https://tc39.es/ecma262/#realm
+// It's kept in the document's Realm and holds the document's Window and the
+// wrappers, event listeners and event handlers of its platform objects.
+type realm struct {
+	bindings  *Bindings
+	window    *Window
+	objects   map[interface{}]*Object
+	listeners map[listenerKey]*spec.EventListener
+	handlers  map[handlerKey]Function
+}
+
+// listenerKey finds the event listener added for a callback.
+// https://dom.spec.whatwg.org/#add-an-event-listener
+type listenerKey struct {
+	target    *spec.Node
+	eventType string
+	callback  Function
+	capture   bool
+}
+
+// handlerKey finds the Function an event handler IDL attribute was set to.
+type handlerKey struct {
+	target *spec.Node
+	name   string
+}
+
+// crossOriginKey is the identity of the wrapper of a cross-origin window.
+type crossOriginKey struct {
+	browsingContext spec.BrowsingContext
+}
+
+// New creates Bindings without any wrappers.
+func New() *Bindings {
+	b := &Bindings{}
+	b.detached = b.newRealm()
+	return b
+}
+
+func (b *Bindings) newRealm() *realm {
+	return &realm{
+		bindings:  b,
+		objects:   map[interface{}]*Object{},
+		listeners: map[listenerKey]*spec.EventListener{},
+		handlers:  map[handlerKey]Function{},
+	}
+}
+
+// realm returns the document's realm, creating it the first time.
+func (b *Bindings) realm(doc *spec.HTMLDocument) *realm {
+	if doc == nil {
+		return b.detached
+	}
+	r, ok := doc.Realm.(*realm)
+	if !ok || r.bindings != b {
+		r = b.newRealm()
+		doc.Realm = r
+	}
+	return r
+}
+
+// nodeRealm is the realm of the node's document.
+func (b *Bindings) nodeRealm(n *spec.Node) *realm {
+	return b.realm(n.OwnerHTMLDocument())
+}
+
+// Window is https://html.spec.whatwg.org/multipage/nav-history-apis.html#window
+// It's the platform object of a document's global object.
+type Window struct {
+	Document *spec.HTMLDocument
+}
+
+// GlobalObject is the wrapper of the document's Window, which scripts of the
+// document run with as their global object.
+func (b *Bindings) GlobalObject(doc *spec.HTMLDocument) *Object {
+	w := b.window(doc)
+	return b.object(b.realm(doc), w, w, WindowInterface)
+}
+
+func (b *Bindings) window(doc *spec.HTMLDocument) *Window {
+	r := b.realm(doc)
+	if r.window == nil {
+		r.window = &Window{Document: doc}
+	}
+	return r.window
+}
+
+// object returns the wrapper of the platform object with the key in the
+// realm, creating it the first time.
+func (b *Bindings) object(r *realm, key, value interface{}, iface *Interface) *Object {
+	if o, ok := r.objects[key]; ok {
+		return o
+	}
+	o := &Object{Interface: iface, Value: value, bindings: b}
+	r.objects[key] = o
+	return o
+}
+
+// Wrap converts a Go value to a JavaScript value: platform objects are
+// wrapped, nil pointers are null, integers are numbers and node lists are
+// sequences. Other values are returned as they are.
+func (b *Bindings) Wrap(v interface{}) interface{} {
+	switch v := v.(type) {
+	case *spec.Node:
+		if v == nil {
+			return nil
+		}
+		r := b.nodeRealm(v)
+		switch v.NodeType {
+		case spec.DocumentNode:
+			return b.object(r, v, v, DocumentInterface)
+		case spec.ElementNode:
+			return b.object(r, v, v, ElementInterface)
+		}
+		return b.object(r, v, v, NodeInterface)
+	case *spec.HTMLDocument:
+		if v == nil {
+			return nil
+		}
+		return b.Wrap(v.Node)
+	case *spec.Event:
+		if v == nil {
+			return nil
+		}
+		if o, ok := v.Wrapper.(*Object); ok && o.bindings == b {
+			return o
+		}
+		o := &Object{Interface: EventInterface, Value: v, bindings: b}
+		v.Wrapper = o
+		return o
+	case *spec.HTMLLocation:
+		if v == nil {
+			return nil
+		}
+		return b.object(b.realm(v.Document()), v, v, LocationInterface)
+	case *Window:
+		if v == nil {
+			return nil
+		}
+		return b.GlobalObject(v.Document)
+	case *spec.WindowProxy:
+		// https://html.spec.whatwg.org/multipage/nav-history-apis.html#the-windowproxy-exotic-object
+		if v == nil {
+			return nil
+		}
+		if doc, err := v.Document(); err == nil {
+			return b.GlobalObject(doc)
+		}
+		return b.object(b.realm(v.Accessor()), crossOriginKey{v.BrowsingContext()}, v, CrossOriginWindowInterface)
+	case []*spec.Node:
+		values := make([]interface{}, len(v))
+		for i, n := range v {
+			values[i] = b.Wrap(n)
+		}
+		return values
+	case spec.NodeList:
+		return b.Wrap([]*spec.Node(v))
+	case int:
+		return float64(v)
+	}
+	return v
+}
+
+// Unwrap converts a JavaScript value to a Go value: wrappers are replaced by
+// their platform objects. Other values are returned as they are.
+func (b *Bindings) Unwrap(v interface{}) interface{} {
+	if o, ok := v.(*Object); ok {
+		return o.Value
+	}
+	return v
+}
+
+// reportError reports the exception thrown by a callback.
+func (b *Bindings) reportError(err error) {
+	if err != nil && b.ReportError != nil {
+		b.ReportError(err)
+	}
+}
+
+// Object is the JavaScript object wrapping a platform object.
+type Object struct {
+	Interface *Interface
+	// Value is the platform object: a *spec.Node, *spec.Event, *Window,
+	// *spec.HTMLLocation or *spec.WindowProxy.
+	Value interface{}
+	// HostData is for the ScriptHost to keep the engine's object for the
+	// wrapper.
+	HostData interface{}
+
+	bindings *Bindings
+	// expandos are the properties set by scripts that the interface doesn't
+	// have.
+	expandos map[string]interface{}
+}
+
+// Get is the [[Get]] of the object's property: the value of an attribute or
+// constant, a Function calling an operation, or a property set by a script.
+// It's Undefined for properties that don't exist.
+func (o *Object) Get(name string) (interface{}, error) {
+	if a := o.Interface.Attribute(name); a != nil {
+		v, err := a.Get(o.bindings, o.Value)
+		if err != nil {
+			return nil, err
+		}
+		return o.bindings.Wrap(v), nil
+	}
+	if c := o.Interface.Constant(name); c != nil {
+		return c.Value, nil
+	}
+	if op := o.Interface.Operation(name); op != nil {
+		return &boundOperation{object: o, operation: op}, nil
+	}
+	if v, ok := o.expandos[name]; ok {
+		return v, nil
+	}
+	return Undefined, nil
+}
+
+// Set is the [[Set]] of the object's property. Setting readonly attributes
+// does nothing.
+func (o *Object) Set(name string, v interface{}) error {
+	if a := o.Interface.Attribute(name); a != nil {
+		if a.Set == nil {
+			return nil
+		}
+		return a.Set(o.bindings, o.Value, o.bindings.Unwrap(v))
+	}
+	if o.expandos == nil {
+		o.expandos = map[string]interface{}{}
+	}
+	o.expandos[name] = v
+	return nil
+}
+
+// Call calls the object's operation with the arguments.
+func (o *Object) Call(name string, args ...interface{}) (interface{}, error) {
+	op := o.Interface.Operation(name)
+	if op == nil {
+		return nil, ErrType
+	}
+	return o.call(op, args)
+}
+
+func (o *Object) call(op *Operation, args []interface{}) (interface{}, error) {
+	unwrapped := make([]interface{}, len(args))
+	for i, arg := range args {
+		unwrapped[i] = o.bindings.Unwrap(arg)
+	}
+	v, err := op.Call(o.bindings, o.Value, unwrapped)
+	if err != nil {
+		return nil, err
+	}
+	return o.bindings.Wrap(v), nil
+}
+
+// boundOperation is the Function of an operation read from an object.
+type boundOperation struct {
+	object    *Object
+	operation *Operation
+}
+
+func (f *boundOperation) Call(this interface{}, args ...interface{}) (interface{}, error) {
+	return f.object.call(f.operation, args)
+}
+
+// Construct creates a platform object with the interface's constructor.
+// https://webidl.spec.whatwg.org/#js-constructor
+func (b *Bindings) Construct(iface *Interface, args ...interface{}) (*Object, error) {
+	if iface.Constructor == nil {
+		return nil, ErrType
+	}
+	unwrapped := make([]interface{}, len(args))
+	for i, arg := range args {
+		unwrapped[i] = b.Unwrap(arg)
+	}
+	v, err := iface.Constructor(b, unwrapped)
+	if err != nil {
+		return nil, err
+	}
+	o, ok := b.Wrap(v).(*Object)
+	if !ok {
+		return nil, ErrType
+	}
+	return o, nil
+}
+
+// callback calls a Function from the platform, reporting what it throws.
+// https://webidl.spec.whatwg.org/#invoke-a-callback-function
+func (b *Bindings) callback(f Function, this interface{}, args ...interface{}) interface{} {
+	wrapped := make([]interface{}, len(args))
+	for i, arg := range args {
+		wrapped[i] = b.Wrap(arg)
+	}
+	v, err := f.Call(b.Wrap(this), wrapped...)
+	b.reportError(err)
+	return v
+}
@@ -0,0 +1,363 @@
+package bindings
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/heathj/gobrowse/eventloop"
+	"github.com/heathj/gobrowse/parser"
+	"github.com/heathj/gobrowse/parser/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+// testHost is a ScriptHost whose scripts and event handlers are Go functions
+// using the bindings, looked up by their source.
+type testHost struct {
+	b        *Bindings
+	scripts  map[string]func(window *Object) error
+	handlers map[string]func(this, event *Object)
+	compiled []string
+	errors   []error
+}
+
+func newTestHost() *testHost {
+	return &testHost{b: New(), scripts: map[string]func(*Object) error{}, handlers: map[string]func(*Object, *Object){}}
+}
+
+func (h *testHost) RunScript(doc *spec.HTMLDocument, script *spec.Script) error {
+	run, ok := h.scripts[strings.TrimSpace(script.Source)]
+	if !ok {
+		return spec.ErrSyntax
+	}
+	return run(h.b.GlobalObject(doc))
+}
+
+func (h *testHost) CompileEventHandler(element *spec.Node, name, body string) (spec.EventHandler, error) {
+	handler, ok := h.handlers[body]
+	if !ok {
+		return nil, spec.ErrSyntax
+	}
+	h.compiled = append(h.compiled, name)
+	return func(e *spec.Event) { handler(h.b.Wrap(element).(*Object), h.b.Wrap(e).(*Object)) }, nil
+}
+
+func (h *testHost) ReportError(doc *spec.HTMLDocument, err error) {
+	h.errors = append(h.errors, err)
+}
+
+// testFunction is a Function of the engine.
+type testFunction struct {
+	call func(this interface{}, args ...interface{}) (interface{}, error)
+}
+
+func (f *testFunction) Call(this interface{}, args ...interface{}) (interface{}, error) {
+	return f.call(this, args...)
+}
+
+func function(call func(args ...interface{})) *testFunction {
+	return &testFunction{func(this interface{}, args ...interface{}) (interface{}, error) {
+		call(args...)
+		return Undefined, nil
+	}}
+}
+
+func parse(t *testing.T, h *testHost, html string, opts ...parser.Option) *spec.HTMLDocument {
+	doc, err := parser.NewParser(strings.NewReader(html), append(opts, parser.WithScriptHost(h))...).Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc.OwnerHTMLDocument()
+}
+
+func get(t *testing.T, o interface{}, name string) interface{} {
+	v, err := o.(*Object).Get(name)
+	assert.NoError(t, err, name)
+	return v
+}
+
+func call(t *testing.T, o interface{}, name string, args ...interface{}) interface{} {
+	v, err := o.(*Object).Call(name, args...)
+	assert.NoError(t, err, name)
+	return v
+}
+
+func TestWrapperIdentity(t *testing.T) {
+	h := newTestHost()
+	doc := parse(t, h, "<p id=a>text</p>")
+	window := h.b.GlobalObject(doc)
+	assert.Same(t, window, h.b.GlobalObject(doc))
+	assert.Same(t, window, get(t, window, "window"))
+	assert.Same(t, window, get(t, window, "top"))
+	assert.Nil(t, get(t, window, "location"))
+
+	document := get(t, window, "document")
+	assert.Same(t, h.b.Wrap(doc), document)
+	assert.Equal(t, DocumentInterface, document.(*Object).Interface)
+	p := call(t, document, "getElementById", "a")
+	assert.Same(t, p, call(t, document, "getElementById", "a"))
+	assert.Equal(t, ElementInterface, p.(*Object).Interface)
+	body := get(t, document, "body")
+	assert.Same(t, p, get(t, body, "firstChild"))
+	assert.Same(t, body, get(t, p, "parentNode"))
+	assert.Equal(t, []interface{}{get(t, p, "firstChild")}, get(t, p, "childNodes"))
+	assert.Equal(t, NodeInterface, get(t, p, "firstChild").(*Object).Interface)
+
+	assert.NoError(t, p.(*Object).Set("expando", 1.0))
+	assert.Equal(t, 1.0, get(t, call(t, document, "getElementById", "a"), "expando"))
+	assert.Equal(t, Undefined, get(t, p, "missing"))
+}
+
+func TestWrapperRealms(t *testing.T) {
+	h := newTestHost()
+	doc, other := parse(t, h, "<p id=a>"), parse(t, h, "<p id=a>")
+	p := call(t, h.b.Wrap(doc), "getElementById", "a")
+	assert.Same(t, p, doc.Realm.(*realm).objects[p.(*Object).Value])
+	assert.Same(t, h.b.window(doc), doc.Realm.(*realm).window)
+	assert.Nil(t, other.Realm, "wrappers are kept in the realm of their document")
+	assert.Len(t, h.b.detached.objects, 0)
+
+	listener := function(func(args ...interface{}) {})
+	call(t, p, "addEventListener", "click", listener)
+	assert.Len(t, doc.Realm.(*realm).listeners, 1)
+	call(t, p, "removeEventListener", "click", listener)
+	assert.Len(t, doc.Realm.(*realm).listeners, 0)
+
+	e := spec.NewEvent("click", false, false)
+	event := h.b.Wrap(e)
+	assert.Same(t, event, e.Wrapper)
+	assert.Same(t, event, h.b.Wrap(e))
+	assert.NotSame(t, event, New().Wrap(e), "each Bindings has its own wrappers")
+}
+
+func TestNodeAttributesAndOperations(t *testing.T) {
+	h := newTestHost()
+	document := h.b.Wrap(parse(t, h, "<title> a  title </title><p id=a>text</p>"))
+	p := call(t, document, "getElementById", "a")
+	assert.Equal(t, 1.0, get(t, p, "nodeType"))
+	assert.Equal(t, 1.0, get(t, p, "ELEMENT_NODE"))
+	assert.Equal(t, 9.0, get(t, document, "nodeType"))
+	assert.Equal(t, "P", get(t, p, "tagName"))
+	assert.Equal(t, "#document", get(t, document, "nodeName"))
+	assert.Equal(t, "a title", get(t, document, "title"))
+	assert.Equal(t, "text", get(t, p, "textContent"))
+	assert.Nil(t, get(t, document, "textContent"))
+	assert.Nil(t, call(t, p, "getAttribute", "class"))
+
+	assert.NoError(t, p.(*Object).Set("className", "c"))
+	assert.Equal(t, "c", call(t, p, "getAttribute", "CLASS"))
+	call(t, p, "removeAttribute", "class")
+	assert.Equal(t, false, call(t, p, "hasAttribute", "class"))
+
+	div := call(t, document, "createElement", "DIV")
+	assert.Equal(t, "DIV", get(t, div, "tagName"))
+	assert.Equal(t, false, get(t, div, "isConnected"))
+	text := call(t, document, "createTextNode", "new")
+	assert.Same(t, text, call(t, div, "appendChild", text))
+	assert.Same(t, div, call(t, get(t, document, "body"), "insertBefore", div, p))
+	assert.Equal(t, true, get(t, div, "isConnected"))
+	assert.Equal(t, "<div>new</div><p id=\"a\">text</p>", get(t, get(t, document, "body"), "innerHTML"))
+
+	call(t, p, "appendChild", text)
+	assert.Nil(t, get(t, div, "firstChild"), "appending moves the node")
+	assert.Equal(t, "textnew", get(t, p, "textContent"))
+	assert.Equal(t, true, call(t, document, "contains", text))
+
+	_, err := div.(*Object).Call("appendChild", get(t, document, "body"))
+	assert.ErrorIs(t, err, spec.ErrHierarchyRequest)
+	_, err = text.(*Object).Call("appendChild", div)
+	assert.ErrorIs(t, err, spec.ErrHierarchyRequest)
+	_, err = div.(*Object).Call("removeChild", text)
+	assert.ErrorIs(t, err, spec.ErrNotFound)
+	_, err = div.(*Object).Call("appendChild", "not a node")
+	assert.ErrorIs(t, err, ErrType)
+	_, err = document.(*Object).Call("createElement", "a b")
+	assert.ErrorIs(t, err, spec.ErrInvalidCharacter)
+
+	assert.Same(t, text, call(t, p, "removeChild", text))
+	assert.Nil(t, get(t, text, "parentNode"))
+}
+
+func TestEventListeners(t *testing.T) {
+	h := newTestHost()
+	document := h.b.Wrap(parse(t, h, "<p id=a>text</p>"))
+	p := call(t, document, "getElementById", "a")
+
+	calls := []string{}
+	listener := function(func(args ...interface{}) {
+		e := args[0]
+		calls = append(calls, get(t, e, "type").(string)+":"+get(t, get(t, e, "currentTarget"), "nodeName").(string))
+	})
+	call(t, p, "addEventListener", "ping", listener)
+	call(t, p, "addEventListener", "ping", listener)
+	call(t, document, "addEventListener", "ping", listener, map[string]interface{}{"capture": true, "once": true})
+
+	e, err := h.b.Construct(EventInterface, "ping", map[string]interface{}{"bubbles": true, "cancelable": true})
+	assert.NoError(t, err)
+	assert.Equal(t, true, call(t, p, "dispatchEvent", e))
+	assert.Equal(t, []string{"ping:#document", "ping:P"}, calls)
+	assert.Equal(t, 0.0, get(t, e, "eventPhase"))
+	assert.Equal(t, false, get(t, e, "isTrusted"))
+
+	call(t, p, "removeEventListener", "ping", listener, true)
+	call(t, p, "dispatchEvent", e)
+	assert.Equal(t, []string{"ping:#document", "ping:P", "ping:P"}, calls, "the capture flag must match")
+	call(t, p, "removeEventListener", "ping", listener)
+	call(t, p, "dispatchEvent", e)
+	assert.Len(t, calls, 3)
+
+	cancel := function(func(args ...interface{}) { call(t, args[0], "preventDefault") })
+	call(t, p, "addEventListener", "ping", cancel)
+	assert.Equal(t, false, call(t, p, "dispatchEvent", e))
+	assert.Equal(t, true, get(t, e, "defaultPrevented"))
+
+	var reported error
+	h.b.ReportError = func(err error) { reported = err }
+	call(t, p, "addEventListener", "throw", &testFunction{func(this interface{}, args ...interface{}) (interface{}, error) {
+		return nil, spec.ErrSyntax
+	}})
+	throw, _ := h.b.Construct(EventInterface, "throw")
+	assert.Equal(t, true, call(t, p, "dispatchEvent", throw))
+	assert.ErrorIs(t, reported, spec.ErrSyntax)
+	_, err = h.b.Construct(EventInterface)
+	assert.ErrorIs(t, err, ErrType)
+}
+
+func TestEventHandlers(t *testing.T) {
+	h := newTestHost()
+	clicked := []string{}
+	h.handlers["clicked('a')"] = func(this, event *Object) {
+		clicked = append(clicked, "a:"+get(t, this, "id").(string))
+	}
+	h.handlers["return false"] = func(this, event *Object) { call(t, event, "preventDefault") }
+	document := h.b.Wrap(parse(t, h, `<button id=b onclick="clicked('a')" onfocus="syntax error" onfoo="clicked('a')">`))
+	button := call(t, document, "getElementById", "b")
+	assert.Empty(t, h.compiled, "handlers are compiled when they're first needed")
+
+	click, _ := h.b.Construct(EventInterface, "click", map[string]interface{}{"bubbles": true, "cancelable": true})
+	call(t, button, "dispatchEvent", click)
+	assert.Equal(t, []string{"a:b"}, clicked)
+	call(t, button, "dispatchEvent", click)
+	foo, _ := h.b.Construct(EventInterface, "foo")
+	call(t, button, "dispatchEvent", foo)
+	assert.Equal(t, []string{"onclick"}, h.compiled, "only the known event handlers are content attributes")
+	assert.Nil(t, get(t, button, "onfocus"), "handlers that don't compile are null")
+	assert.Equal(t, []error{spec.ErrSyntax}, h.errors, "compile errors are reported")
+	assert.Nil(t, get(t, button, "onblur"))
+
+	handler := get(t, button, "onclick")
+	assert.Implements(t, (*Function)(nil), handler)
+	_, err := handler.(Function).Call(button, click)
+	assert.NoError(t, err)
+	assert.Len(t, clicked, 3)
+
+	set := function(func(args ...interface{}) { clicked = append(clicked, "set") })
+	assert.NoError(t, button.(*Object).Set("onclick", set))
+	assert.Same(t, set, get(t, button, "onclick"))
+	call(t, button, "dispatchEvent", click)
+	assert.Equal(t, "set", clicked[len(clicked)-1])
+
+	call(t, button, "setAttribute", "onclick", "return false")
+	assert.NotSame(t, set, get(t, button, "onclick"))
+	assert.Equal(t, false, call(t, button, "dispatchEvent", click))
+
+	assert.NoError(t, button.(*Object).Set("onclick", nil))
+	assert.Nil(t, get(t, button, "onclick"))
+	click, _ = h.b.Construct(EventInterface, "click", map[string]interface{}{"bubbles": true, "cancelable": true})
+	assert.Equal(t, true, call(t, button, "dispatchEvent", click))
+
+	falsy := &testFunction{func(this interface{}, args ...interface{}) (interface{}, error) { return false, nil }}
+	assert.NoError(t, document.(*Object).Set("onclick", falsy))
+	click, _ = h.b.Construct(EventInterface, "click", map[string]interface{}{"bubbles": true, "cancelable": true})
+	assert.Equal(t, false, call(t, button, "dispatchEvent", click), "returning false cancels the event")
+}
+
+func TestParserRunsScripts(t *testing.T) {
+	h := newTestHost()
+	order := []string{}
+	h.scripts["first()"] = func(window *Object) error {
+		document := get(t, window, "document")
+		order = append(order, "first:"+get(t, document, "readyState").(string))
+		assert.Equal(t, "first()", get(t, get(t, document, "currentScript"), "textContent"))
+		call(t, window, "queueMicrotask", function(func(args ...interface{}) { order = append(order, "microtask") }))
+		call(t, document, "write", "<p id=written>", "written</p>")
+		return nil
+	}
+	h.scripts["second()"] = func(window *Object) error {
+		document := get(t, window, "document")
+		order = append(order, "second:"+get(t, call(t, document, "getElementById", "written"), "textContent").(string))
+		call(t, document, "addEventListener", "DOMContentLoaded", function(func(args ...interface{}) {
+			order = append(order, "loaded")
+		}))
+		return nil
+	}
+	doc := parse(t, h, "<script>first()</script><script>second()</script><script>throws()</script>")
+	assert.Equal(t, []string{"first:loading", "microtask", "second:written", "loaded"}, order)
+	assert.Nil(t, doc.CurrentScript)
+	assert.Equal(t, []error{spec.ErrSyntax}, h.errors, "the exceptions of scripts are reported")
+}
+
+func TestWindowEventHandlers(t *testing.T) {
+	h := newTestHost()
+	loaded := []string{}
+	h.handlers["loaded()"] = func(this, event *Object) {
+		loaded = append(loaded, get(t, this, "nodeName").(string)+":"+get(t, get(t, event, "target"), "nodeName").(string))
+	}
+	doc := parse(t, h, `<body onload="loaded()" onhashchange="loaded()"><img id=i onload="loaded()">`)
+	assert.Equal(t, []string{"BODY:#document"}, loaded, "the window's load event reaches the body's handler")
+
+	document := h.b.Wrap(doc)
+	body := get(t, document, "body")
+	var this interface{}
+	onload := &testFunction{func(t interface{}, args ...interface{}) (interface{}, error) {
+		this = t
+		return Undefined, nil
+	}}
+	assert.NoError(t, body.(*Object).Set("onload", onload))
+	assert.Same(t, onload, get(t, body, "onload"))
+	doc.Window().DispatchEvent(spec.NewEvent("load", false, false))
+	assert.Same(t, h.b.GlobalObject(doc), this)
+	assert.Equal(t, []string{"BODY:#document"}, loaded)
+	assert.NotNil(t, get(t, call(t, document, "getElementById", "i"), "onload"), "other elements keep their own handlers")
+}
+
+func TestTimers(t *testing.T) {
+	h := newTestHost()
+	loop := eventloop.NewLoop(eventloop.NewManualClock(time.Now()))
+	doc := parse(t, h, "", parser.WithEventLoop(loop))
+	loop.RunUntilIdle()
+	window := h.b.GlobalObject(doc)
+
+	calls := []string{}
+	h.scripts["fromString()"] = func(w *Object) error {
+		assert.Same(t, window, w)
+		calls = append(calls, "string")
+		return nil
+	}
+	call(t, window, "setTimeout", function(func(args ...interface{}) {
+		calls = append(calls, "timeout:"+args[0].(string))
+	}), 20.0, "arg")
+	call(t, window, "setTimeout", "fromString()")
+	cleared := call(t, window, "setTimeout", function(func(args ...interface{}) { calls = append(calls, "cleared") }), 10.0)
+	call(t, window, "clearTimeout", cleared)
+	interval := call(t, window, "setInterval", function(func(args ...interface{}) { calls = append(calls, "interval") }), 15.0)
+	assert.IsType(t, 0.0, interval)
+
+	loop.Advance(30 * time.Millisecond)
+	assert.Equal(t, []string{"string", "interval", "timeout:arg", "interval"}, calls)
+	call(t, window, "clearInterval", interval)
+	loop.Advance(time.Second)
+	assert.Len(t, calls, 4)
+
+	frames := []float64{}
+	call(t, window, "requestAnimationFrame", function(func(args ...interface{}) { frames = append(frames, args[0].(float64)) }))
+	canceled := call(t, window, "requestAnimationFrame", function(func(args ...interface{}) { frames = append(frames, -1) }))
+	call(t, window, "cancelAnimationFrame", canceled)
+	loop.Advance(loop.FrameInterval)
+	if assert.Len(t, frames, 1) {
+		assert.Greater(t, frames[0], 1000.0)
+	}
+
+	_, err := h.b.GlobalObject(parse(t, h, "")).Call("setTimeout", "fromString()")
+	assert.ErrorIs(t, err, spec.ErrNotSupported, "timers need an event loop")
+}
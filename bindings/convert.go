@@ -0,0 +1,146 @@
+package bindings
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/heathj/gobrowse/parser/spec"
+)
+
+// arg returns the i-th argument or Undefined when there are fewer.
+func arg(args []interface{}, i int) interface{} {
+	if i < len(args) {
+		return args[i]
+	}
+	return Undefined
+}
+
+// toString is https://webidl.spec.whatwg.org/#es-DOMString
+// Platform objects aren't converted through their toString operation.
+func toString(v interface{}) string {
+	switch v := v.(type) {
+	case undefined:
+		return "undefined"
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return numberToString(v)
+	case string:
+		return v
+	case *spec.HTMLLocation:
+		return v.Href()
+	case Function:
+		return "function"
+	}
+	return "[object Object]"
+}
+
+// numberToString is https://tc39.es/ecma262/#sec-numeric-types-number-tostring
+func numberToString(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	case f == 0:
+		return "0"
+	}
+	if abs := math.Abs(f); abs >= 1e-6 && abs < 1e21 {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	s := strconv.FormatFloat(f, 'e', -1, 64)
+	i := strings.IndexByte(s, 'e')
+	return s[:i+2] + strings.TrimLeft(s[i+2:], "0")
+}
+
+// toBoolean is https://webidl.spec.whatwg.org/#es-boolean
+func toBoolean(v interface{}) bool {
+	switch v := v.(type) {
+	case undefined, nil:
+		return false
+	case bool:
+		return v
+	case float64:
+		return v != 0 && !math.IsNaN(v)
+	case string:
+		return v != ""
+	}
+	return true
+}
+
+// toNumber is https://tc39.es/ecma262/#sec-tonumber
+func toNumber(v interface{}) float64 {
+	switch v := v.(type) {
+	case undefined:
+		return math.NaN()
+	case nil:
+		return 0
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	case float64:
+		return v
+	case string:
+		s := strings.TrimSpace(v)
+		if s == "" {
+			return 0
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	}
+	return math.NaN()
+}
+
+// toLong is https://webidl.spec.whatwg.org/#es-long
+func toLong(v interface{}) int {
+	f := toNumber(v)
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0
+	}
+	return int(int32(int64(math.Trunc(f))))
+}
+
+// toNode converts the value to a node, or nil when nullable and the value is
+// null.
+func toNode(v interface{}, nullable bool) (*spec.Node, error) {
+	switch v := v.(type) {
+	case *spec.Node:
+		return v, nil
+	case nil:
+		if nullable {
+			return nil, nil
+		}
+	}
+	return nil, ErrType
+}
+
+// toFunction converts the value to a callback function, or nil when it's null
+// or undefined.
+func toFunction(v interface{}) (Function, error) {
+	switch v := v.(type) {
+	case Function:
+		return v, nil
+	case nil, undefined:
+		return nil, nil
+	}
+	return nil, ErrType
+}
+
+// dictionaryMember returns the member of a dictionary, which is Undefined
+// when it's missing or the value isn't a dictionary.
+func dictionaryMember(v interface{}, name string) interface{} {
+	if d, ok := v.(map[string]interface{}); ok {
+		if member, ok := d[name]; ok {
+			return member
+		}
+	}
+	return Undefined
+}
@@ -0,0 +1,505 @@
+package bindings
+
+import (
+	"strings"
+
+	"github.com/heathj/gobrowse/parser/spec"
+)
+
+// The interfaces of https://dom.spec.whatwg.org/. Their members are set in
+// init since they refer back to the interfaces through the wrappers.
+var (
+	EventTargetInterface = &Interface{Name: "EventTarget"}
+	NodeInterface        = &Interface{Name: "Node", Inherits: EventTargetInterface}
+	ElementInterface     = &Interface{Name: "Element", Inherits: NodeInterface}
+	DocumentInterface    = &Interface{Name: "Document", Inherits: NodeInterface}
+	EventInterface       = &Interface{Name: "Event"}
+)
+
+// nodeTypes are the values of https://dom.spec.whatwg.org/#dom-node-nodetype
+var nodeTypes = map[spec.NodeType]float64{
+	spec.ElementNode:               1,
+	spec.AttrNode:                  2,
+	spec.TextNode:                  3,
+	spec.CDATASectionNode:          4,
+	spec.ProcessingInstructionNode: 7,
+	spec.CommentNode:               8,
+	spec.DocumentNode:              9,
+	spec.DocumentTypeNode:          10,
+	spec.DocumentFragmentNode:      11,
+}
+
+func init() {
+	EventTargetInterface.Operations = []*Operation{
+		{Name: "addEventListener", Call: addEventListener},
+		{Name: "removeEventListener", Call: removeEventListener},
+		{Name: "dispatchEvent", Call: func(b *Bindings, this interface{}, args []interface{}) (interface{}, error) {
+			e, ok := arg(args, 0).(*spec.Event)
+			if !ok {
+				return nil, ErrType
+			}
+			if e.Target != nil && e.EventPhase() != 0 {
+				return nil, spec.ErrInvalidState
+			}
+			return this.(*spec.Node).DispatchEvent(e), nil
+		}},
+	}
+
+	NodeInterface.Constants = []*Constant{
+		{"ELEMENT_NODE", 1.0}, {"ATTRIBUTE_NODE", 2.0}, {"TEXT_NODE", 3.0}, {"CDATA_SECTION_NODE", 4.0},
+		{"PROCESSING_INSTRUCTION_NODE", 7.0}, {"COMMENT_NODE", 8.0}, {"DOCUMENT_NODE", 9.0},
+		{"DOCUMENT_TYPE_NODE", 10.0}, {"DOCUMENT_FRAGMENT_NODE", 11.0},
+	}
+	NodeInterface.Attributes = []*Attribute{
+		nodeAttribute("nodeType", func(n *spec.Node) interface{} { return nodeTypes[n.NodeType] }),
+		nodeAttribute("nodeName", func(n *spec.Node) interface{} { return nodeName(n) }),
+		nodeAttribute("parentNode", func(n *spec.Node) interface{} { return n.ParentNode }),
+		nodeAttribute("parentElement", func(n *spec.Node) interface{} {
+			if n.ParentNode == nil || n.ParentNode.NodeType != spec.ElementNode {
+				return nil
+			}
+			return n.ParentNode
+		}),
+		nodeAttribute("firstChild", func(n *spec.Node) interface{} { return n.FirstChild }),
+		nodeAttribute("lastChild", func(n *spec.Node) interface{} { return n.LastChild }),
+		nodeAttribute("previousSibling", func(n *spec.Node) interface{} { return n.PreviousSibling }),
+		nodeAttribute("nextSibling", func(n *spec.Node) interface{} { return n.NextSibling }),
+		nodeAttribute("childNodes", func(n *spec.Node) interface{} { return append([]*spec.Node(nil), n.ChildNodes...) }),
+		nodeAttribute("isConnected", func(n *spec.Node) interface{} { return root(n).NodeType == spec.DocumentNode }),
+		nodeAttribute("ownerDocument", func(n *spec.Node) interface{} {
+			if n.NodeType == spec.DocumentNode {
+				return nil
+			}
+			return n.OwnerDocument
+		}),
+		{Name: "textContent", Get: func(b *Bindings, this interface{}) (interface{}, error) {
+			text, ok := this.(*spec.Node).TextContent()
+			if !ok {
+				return nil, nil
+			}
+			return text, nil
+		}, Set: func(b *Bindings, this interface{}, v interface{}) error {
+			text := ""
+			if v != nil {
+				text = toString(v)
+			}
+			this.(*spec.Node).SetTextContent(text)
+			return nil
+		}},
+	}
+	NodeInterface.Operations = []*Operation{
+		{Name: "hasChildNodes", Call: func(b *Bindings, this interface{}, args []interface{}) (interface{}, error) {
+			return this.(*spec.Node).FirstChild != nil, nil
+		}},
+		{Name: "contains", Call: func(b *Bindings, this interface{}, args []interface{}) (interface{}, error) {
+			other, err := toNode(arg(args, 0), true)
+			if err != nil {
+				return nil, err
+			}
+			for ; other != nil; other = other.ParentNode {
+				if other == this.(*spec.Node) {
+					return true, nil
+				}
+			}
+			return false, nil
+		}},
+		{Name: "appendChild", Call: func(b *Bindings, this interface{}, args []interface{}) (interface{}, error) {
+			node, err := toNode(arg(args, 0), false)
+			if err != nil {
+				return nil, err
+			}
+			return insert(this.(*spec.Node), node, nil)
+		}},
+		{Name: "insertBefore", Call: func(b *Bindings, this interface{}, args []interface{}) (interface{}, error) {
+			node, err := toNode(arg(args, 0), false)
+			if err != nil {
+				return nil, err
+			}
+			child, err := toNode(arg(args, 1), true)
+			if err != nil {
+				return nil, err
+			}
+			return insert(this.(*spec.Node), node, child)
+		}},
+		{Name: "removeChild", Call: func(b *Bindings, this interface{}, args []interface{}) (interface{}, error) {
+			child, err := toNode(arg(args, 0), false)
+			if err != nil {
+				return nil, err
+			}
+			if child.ParentNode != this.(*spec.Node) {
+				return nil, spec.ErrNotFound
+			}
+			return this.(*spec.Node).RemoveChild(child), nil
+		}},
+	}
+
+	ElementInterface.Attributes = append([]*Attribute{
+		nodeAttribute("tagName", func(n *spec.Node) interface{} { return nodeName(n) }),
+		nodeAttribute("localName", func(n *spec.Node) interface{} { return n.NodeName }),
+		reflectAttribute("id", "id"),
+		reflectAttribute("className", "class"),
+		{Name: "innerHTML", Get: func(b *Bindings, this interface{}) (interface{}, error) {
+			return this.(*spec.Node).InnerHTML(), nil
+		}, Set: func(b *Bindings, this interface{}, v interface{}) error {
+			return this.(*spec.Node).SetInnerHTML(toString(v))
+		}},
+		{Name: "outerHTML", Get: func(b *Bindings, this interface{}) (interface{}, error) {
+			return this.(*spec.Node).OuterHTML(), nil
+		}, Set: func(b *Bindings, this interface{}, v interface{}) error {
+			return this.(*spec.Node).SetOuterHTML(toString(v))
+		}},
+	}, eventHandlerAttributes()...)
+	ElementInterface.Operations = []*Operation{
+		{Name: "getAttribute", Call: func(b *Bindings, this interface{}, args []interface{}) (interface{}, error) {
+			n, name := this.(*spec.Node), toString(arg(args, 0))
+			if !n.HasAttribute(name) {
+				return nil, nil
+			}
+			return n.GetAttribute(name), nil
+		}},
+		{Name: "hasAttribute", Call: func(b *Bindings, this interface{}, args []interface{}) (interface{}, error) {
+			return this.(*spec.Node).HasAttribute(toString(arg(args, 0))), nil
+		}},
+		{Name: "setAttribute", Call: func(b *Bindings, this interface{}, args []interface{}) (interface{}, error) {
+			n, name := this.(*spec.Node), toString(arg(args, 0))
+			n.SetAttribute(name, toString(arg(args, 1)))
+			delete(b.nodeRealm(n).handlers, handlerKey{n, strings.ToLower(name)})
+			return Undefined, nil
+		}},
+		{Name: "removeAttribute", Call: func(b *Bindings, this interface{}, args []interface{}) (interface{}, error) {
+			n, name := this.(*spec.Node), toString(arg(args, 0))
+			n.RemoveAttribute(name)
+			delete(b.nodeRealm(n).handlers, handlerKey{n, strings.ToLower(name)})
+			return Undefined, nil
+		}},
+	}
+
+	DocumentInterface.Attributes = append([]*Attribute{
+		documentAttribute("URL", func(d *spec.HTMLDocument) interface{} { return d.URL }),
+		documentAttribute("documentURI", func(d *spec.HTMLDocument) interface{} { return d.DocumentURI }),
+		documentAttribute("compatMode", func(d *spec.HTMLDocument) interface{} { return d.CompatMode }),
+		documentAttribute("readyState", func(d *spec.HTMLDocument) interface{} { return string(d.ReadyState) }),
+		documentAttribute("referrer", func(d *spec.HTMLDocument) interface{} { return d.Referrer }),
+		documentAttribute("lastModified", func(d *spec.HTMLDocument) interface{} { return d.LastModified }),
+		documentAttribute("currentScript", func(d *spec.HTMLDocument) interface{} { return d.CurrentScript }),
+		documentAttribute("location", func(d *spec.HTMLDocument) interface{} {
+			if d.BrowsingContext == nil {
+				return nil
+			}
+			return d.Location
+		}),
+		documentAttribute("documentElement", func(d *spec.HTMLDocument) interface{} { return childElement(d.Node, "html") }),
+		documentAttribute("head", func(d *spec.HTMLDocument) interface{} {
+			return childElement(childElement(d.Node, "html"), "head")
+		}),
+		documentAttribute("body", func(d *spec.HTMLDocument) interface{} {
+			return childElement(childElement(d.Node, "html"), "body")
+		}),
+		documentAttribute("title", func(d *spec.HTMLDocument) interface{} {
+			title := findElement(d.Node, func(n *spec.Node) bool { return n.NodeName == "title" })
+			if title == nil {
+				return ""
+			}
+			text, _ := title.TextContent()
+			return strings.Join(strings.Fields(text), " ")
+		}),
+		{Name: "defaultView", Get: func(b *Bindings, this interface{}) (interface{}, error) {
+			d := this.(*spec.Node).OwnerHTMLDocument()
+			if d.BrowsingContext == nil {
+				return nil, nil
+			}
+			return b.window(d), nil
+		}},
+		{Name: "cookie", Get: func(b *Bindings, this interface{}) (interface{}, error) {
+			return this.(*spec.Node).OwnerHTMLDocument().Cookie()
+		}, Set: func(b *Bindings, this interface{}, v interface{}) error {
+			return this.(*spec.Node).OwnerHTMLDocument().SetCookie(toString(v))
+		}},
+	}, eventHandlerAttributes()...)
+	DocumentInterface.Operations = []*Operation{
+		{Name: "createElement", Call: func(b *Bindings, this interface{}, args []interface{}) (interface{}, error) {
+			localName := strings.ToLower(toString(arg(args, 0)))
+			if localName == "" || strings.ContainsAny(localName, " \t\n\f\r/>") {
+				return nil, spec.ErrInvalidCharacter
+			}
+			return spec.NewDOMElement(this.(*spec.Node), localName, spec.Htmlns), nil
+		}},
+		{Name: "createTextNode", Call: func(b *Bindings, this interface{}, args []interface{}) (interface{}, error) {
+			return spec.NewTextNode(this.(*spec.Node), toString(arg(args, 0))), nil
+		}},
+		{Name: "createComment", Call: func(b *Bindings, this interface{}, args []interface{}) (interface{}, error) {
+			return spec.NewComment(toString(arg(args, 0)), this.(*spec.Node)), nil
+		}},
+		{Name: "getElementById", Call: func(b *Bindings, this interface{}, args []interface{}) (interface{}, error) {
+			id := toString(arg(args, 0))
+			return findElement(this.(*spec.Node), func(n *spec.Node) bool { return n.GetAttribute("id") == id && n.HasAttribute("id") }), nil
+		}},
+		{Name: "write", Call: func(b *Bindings, this interface{}, args []interface{}) (interface{}, error) {
+			return Undefined, this.(*spec.Node).OwnerHTMLDocument().Write(toStrings(args)...)
+		}},
+		{Name: "writeln", Call: func(b *Bindings, this interface{}, args []interface{}) (interface{}, error) {
+			return Undefined, this.(*spec.Node).OwnerHTMLDocument().Writeln(toStrings(args)...)
+		}},
+	}
+
+	EventInterface.Constructor = func(b *Bindings, args []interface{}) (interface{}, error) {
+		if len(args) == 0 {
+			return nil, ErrType
+		}
+		init := arg(args, 1)
+		return spec.NewEvent(toString(args[0]), toBoolean(dictionaryMember(init, "bubbles")), toBoolean(dictionaryMember(init, "cancelable"))), nil
+	}
+	EventInterface.Constants = []*Constant{
+		{"NONE", 0.0}, {"CAPTURING_PHASE", 1.0}, {"AT_TARGET", 2.0}, {"BUBBLING_PHASE", 3.0},
+	}
+	EventInterface.Attributes = []*Attribute{
+		eventAttribute("type", func(e *spec.Event) interface{} { return e.Type }),
+		eventAttribute("target", func(e *spec.Event) interface{} { return e.Target }),
+		eventAttribute("currentTarget", func(e *spec.Event) interface{} { return e.CurrentTarget }),
+		eventAttribute("eventPhase", func(e *spec.Event) interface{} { return e.EventPhase() }),
+		eventAttribute("bubbles", func(e *spec.Event) interface{} { return e.Bubbles }),
+		eventAttribute("cancelable", func(e *spec.Event) interface{} { return e.Cancelable }),
+		eventAttribute("defaultPrevented", func(e *spec.Event) interface{} { return e.DefaultPrevented() }),
+		eventAttribute("isTrusted", func(e *spec.Event) interface{} { return e.IsTrusted }),
+		eventAttribute("timeStamp", func(e *spec.Event) interface{} { return float64(e.TimeStamp.UnixNano()) / 1e6 }),
+	}
+	EventInterface.Operations = []*Operation{
+		eventOperation("preventDefault", (*spec.Event).PreventDefault),
+		eventOperation("stopPropagation", (*spec.Event).StopPropagation),
+		eventOperation("stopImmediatePropagation", (*spec.Event).StopImmediatePropagation),
+	}
+}
+
+func nodeAttribute(name string, get func(n *spec.Node) interface{}) *Attribute {
+	return &Attribute{Name: name, Get: func(b *Bindings, this interface{}) (interface{}, error) {
+		return get(this.(*spec.Node)), nil
+	}}
+}
+
+func documentAttribute(name string, get func(d *spec.HTMLDocument) interface{}) *Attribute {
+	return &Attribute{Name: name, Get: func(b *Bindings, this interface{}) (interface{}, error) {
+		return get(this.(*spec.Node).OwnerHTMLDocument()), nil
+	}}
+}
+
+func eventAttribute(name string, get func(e *spec.Event) interface{}) *Attribute {
+	return &Attribute{Name: name, Get: func(b *Bindings, this interface{}) (interface{}, error) {
+		return get(this.(*spec.Event)), nil
+	}}
+}
+
+func eventOperation(name string, call func(e *spec.Event)) *Operation {
+	return &Operation{Name: name, Call: func(b *Bindings, this interface{}, args []interface{}) (interface{}, error) {
+		call(this.(*spec.Event))
+		return Undefined, nil
+	}}
+}
+
+// reflectAttribute is https://html.spec.whatwg.org/multipage/common-dom-interfaces.html#reflect
+func reflectAttribute(name, contentAttribute string) *Attribute {
+	return &Attribute{Name: name, Get: func(b *Bindings, this interface{}) (interface{}, error) {
+		return this.(*spec.Node).GetAttribute(contentAttribute), nil
+	}, Set: func(b *Bindings, this interface{}, v interface{}) error {
+		this.(*spec.Node).SetAttribute(contentAttribute, toString(v))
+		return nil
+	}}
+}
+
+// eventHandlerAttributes are https://html.spec.whatwg.org/multipage/webappapis.html#event-handler-idl-attributes
+// A handler set from a script is read back as the same Function. Handlers
+// compiled from content attributes are read as Functions calling them.
+func eventHandlerAttributes() []*Attribute {
+	attributes := make([]*Attribute, len(spec.EventHandlerNames))
+	for i, name := range spec.EventHandlerNames {
+		name := name
+		attributes[i] = &Attribute{Name: name, Get: func(b *Bindings, this interface{}) (interface{}, error) {
+			n := this.(*spec.Node)
+			handler := n.EventHandler(name)
+			if handler == nil {
+				return nil, nil
+			}
+			if f, ok := b.nodeRealm(n).handlers[handlerKey{n, name}]; ok {
+				return f, nil
+			}
+			return &handlerFunction{handler}, nil
+		}, Set: func(b *Bindings, this interface{}, v interface{}) error {
+			n := this.(*spec.Node)
+			f, ok := v.(Function)
+			if !ok {
+				delete(b.nodeRealm(n).handlers, handlerKey{n, name})
+				n.SetEventHandler(name, nil)
+				return nil
+			}
+			b.nodeRealm(n).handlers[handlerKey{n, name}] = f
+			// https://html.spec.whatwg.org/multipage/webappapis.html#the-event-handler-processing-algorithm
+			n.SetEventHandler(name, func(e *spec.Event) {
+				// events fired at the window have no current target node.
+				var this interface{} = e.CurrentTarget
+				if e.CurrentTarget == nil {
+					this = b.window(n.OwnerHTMLDocument())
+				}
+				if result := b.callback(f, this, e); result == false {
+					e.PreventDefault()
+				}
+			})
+			return nil
+		}}
+	}
+	return attributes
+}
+
+// handlerFunction is the Function of an event handler the platform has.
+type handlerFunction struct {
+	handler spec.EventHandler
+}
+
+func (f *handlerFunction) Call(this interface{}, args ...interface{}) (interface{}, error) {
+	e, ok := arg(args, 0).(*Object)
+	if !ok {
+		return nil, ErrType
+	}
+	event, ok := e.Value.(*spec.Event)
+	if !ok {
+		return nil, ErrType
+	}
+	f.handler(event)
+	return Undefined, nil
+}
+
+// flattenListenerOptions is https://dom.spec.whatwg.org/#event-flatten-options
+func flattenListenerOptions(options interface{}) (capture, once, passive bool) {
+	if _, ok := options.(map[string]interface{}); !ok {
+		return toBoolean(options), false, false
+	}
+	return toBoolean(dictionaryMember(options, "capture")), toBoolean(dictionaryMember(options, "once")),
+		toBoolean(dictionaryMember(options, "passive"))
+}
+
+// addEventListener is https://dom.spec.whatwg.org/#dom-eventtarget-addeventlistener
+func addEventListener(b *Bindings, this interface{}, args []interface{}) (interface{}, error) {
+	n, eventType := this.(*spec.Node), toString(arg(args, 0))
+	callback, err := toFunction(arg(args, 1))
+	if err != nil || callback == nil {
+		return Undefined, err
+	}
+	capture, once, passive := flattenListenerOptions(arg(args, 2))
+	key := listenerKey{n, eventType, callback, capture}
+	listeners := b.nodeRealm(n).listeners
+	listener, ok := listeners[key]
+	if !ok {
+		listener = &spec.EventListener{Capture: capture, Once: once, Passive: passive, Callback: func(e *spec.Event) {
+			b.callback(callback, e.CurrentTarget, e)
+		}}
+		listeners[key] = listener
+	}
+	n.AddEventListener(eventType, listener)
+	return Undefined, nil
+}
+
+// removeEventListener is https://dom.spec.whatwg.org/#dom-eventtarget-removeeventlistener
+func removeEventListener(b *Bindings, this interface{}, args []interface{}) (interface{}, error) {
+	n, eventType := this.(*spec.Node), toString(arg(args, 0))
+	callback, err := toFunction(arg(args, 1))
+	if err != nil || callback == nil {
+		return Undefined, err
+	}
+	capture, _, _ := flattenListenerOptions(arg(args, 2))
+	key := listenerKey{n, eventType, callback, capture}
+	listeners := b.nodeRealm(n).listeners
+	if listener, ok := listeners[key]; ok {
+		n.RemoveEventListener(eventType, listener)
+		delete(listeners, key)
+	}
+	return Undefined, nil
+}
+
+// insert is https://dom.spec.whatwg.org/#concept-node-pre-insert
+// The node is removed from its parent first.
+func insert(parent, node, child *spec.Node) (*spec.Node, error) {
+	switch parent.NodeType {
+	case spec.DocumentNode, spec.DocumentFragmentNode, spec.ElementNode:
+	default:
+		return nil, spec.ErrHierarchyRequest
+	}
+	for p := parent; p != nil; p = p.ParentNode {
+		if p == node {
+			return nil, spec.ErrHierarchyRequest
+		}
+	}
+	if child != nil && child.ParentNode != parent {
+		return nil, spec.ErrNotFound
+	}
+	if child == node {
+		child = node.NextSibling
+	}
+	if node.ParentNode != nil {
+		node.ParentNode.RemoveChild(node)
+	}
+	return parent.InsertBefore(node, child), nil
+}
+
+// nodeName is https://dom.spec.whatwg.org/#dom-node-nodename
+func nodeName(n *spec.Node) string {
+	switch n.NodeType {
+	case spec.ElementNode:
+		if n.NamespaceURI == spec.Htmlns {
+			return strings.ToUpper(n.NodeName)
+		}
+	case spec.TextNode:
+		return "#text"
+	case spec.CommentNode:
+		return "#comment"
+	case spec.DocumentNode:
+		return "#document"
+	case spec.DocumentFragmentNode:
+		return "#document-fragment"
+	case spec.CDATASectionNode:
+		return "#cdata-section"
+	}
+	return n.NodeName
+}
+
+func root(n *spec.Node) *spec.Node {
+	for n.ParentNode != nil {
+		n = n.ParentNode
+	}
+	return n
+}
+
+// childElement returns the first child element of n with the name.
+func childElement(n *spec.Node, name string) *spec.Node {
+	if n == nil {
+		return nil
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.NodeType == spec.ElementNode && child.NodeName == name {
+			return child
+		}
+	}
+	return nil
+}
+
+// findElement returns the first descendant element of n in tree order that
+// matches.
+func findElement(n *spec.Node, match func(*spec.Node) bool) *spec.Node {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.NodeType != spec.ElementNode {
+			continue
+		}
+		if match(child) {
+			return child
+		}
+		if found := findElement(child, match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func toStrings(args []interface{}) []string {
+	s := make([]string, len(args))
+	for i, arg := range args {
+		s[i] = toString(arg)
+	}
+	return s
+}
@@ -0,0 +1,90 @@
+package bindings
+
+// Interface is https://webidl.spec.whatwg.org/#dfn-interface
+// Hosts make a prototype object for each interface that inherits from the
+// prototype of the interface it inherits from.
+type Interface struct {
+	Name     string
+	Inherits *Interface
+	// Constructor creates the platform object for the interface's
+	// constructor, or is nil for interfaces that can't be constructed.
+	Constructor func(b *Bindings, args []interface{}) (interface{}, error)
+	Constants   []*Constant
+	Attributes  []*Attribute
+	Operations  []*Operation
+}
+
+// Constant is https://webidl.spec.whatwg.org/#dfn-constant
+type Constant struct {
+	Name  string
+	Value interface{}
+}
+
+// Attribute is https://webidl.spec.whatwg.org/#dfn-attribute
+// The getter and setter get the platform object and Set gets the value with
+// its wrappers unwrapped. Set is nil for readonly attributes.
+type Attribute struct {
+	Name string
+	Get  func(b *Bindings, this interface{}) (interface{}, error)
+	Set  func(b *Bindings, this interface{}, v interface{}) error
+}
+
+// Operation is https://webidl.spec.whatwg.org/#dfn-operation
+// Call gets the platform object and the arguments with their wrappers
+// unwrapped.
+type Operation struct {
+	Name string
+	Call func(b *Bindings, this interface{}, args []interface{}) (interface{}, error)
+}
+
+// Attribute finds the interface's attribute with the name, including the
+// inherited ones.
+func (i *Interface) Attribute(name string) *Attribute {
+	for ; i != nil; i = i.Inherits {
+		for _, a := range i.Attributes {
+			if a.Name == name {
+				return a
+			}
+		}
+	}
+	return nil
+}
+
+// Operation finds the interface's operation with the name, including the
+// inherited ones.
+func (i *Interface) Operation(name string) *Operation {
+	for ; i != nil; i = i.Inherits {
+		for _, op := range i.Operations {
+			if op.Name == name {
+				return op
+			}
+		}
+	}
+	return nil
+}
+
+// Constant finds the interface's constant with the name, including the
+// inherited ones.
+func (i *Interface) Constant(name string) *Constant {
+	for ; i != nil; i = i.Inherits {
+		for _, c := range i.Constants {
+			if c.Name == name {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// Interfaces are the interfaces of the platform objects, each after the one
+// it inherits from.
+var Interfaces = []*Interface{
+	EventTargetInterface,
+	NodeInterface,
+	ElementInterface,
+	DocumentInterface,
+	EventInterface,
+	WindowInterface,
+	CrossOriginWindowInterface,
+	LocationInterface,
+}
@@ -0,0 +1,263 @@
+package bindings
+
+import (
+	"time"
+
+	"github.com/heathj/gobrowse/parser/spec"
+)
+
+// The interfaces of https://html.spec.whatwg.org/multipage/nav-history-apis.html.
+// Events fired at windows only reach the window-reflecting event handlers of
+// body and frameset elements so Window doesn't inherit from EventTarget.
+var (
+	WindowInterface = &Interface{Name: "Window"}
+	// CrossOriginWindowInterface has the properties of a window that
+	// documents of other origins can use.
+	// https://html.spec.whatwg.org/multipage/nav-history-apis.html#crossoriginproperties-(-o-)
+	CrossOriginWindowInterface = &Interface{Name: "CrossOriginWindow"}
+	LocationInterface          = &Interface{Name: "Location"}
+)
+
+// Timers is implemented by event loops that have the timers of
+// https://html.spec.whatwg.org/multipage/timers-and-user-prompts.html#timers
+// like *eventloop.Loop. Window's timer operations throw NotSupportedError
+// when the document's event loop doesn't.
+type Timers interface {
+	SetTimeout(handler func(), timeout time.Duration) int
+	SetInterval(handler func(), timeout time.Duration) int
+	ClearTimeout(id int)
+	ClearInterval(id int)
+}
+
+// AnimationFrames is implemented by event loops that run
+// https://html.spec.whatwg.org/multipage/imagebitmap-and-animations.html#animation-frames
+// like *eventloop.Loop.
+type AnimationFrames interface {
+	RequestAnimationFrame(callback func(now time.Duration)) int
+	CancelAnimationFrame(id int)
+}
+
+func init() {
+	WindowInterface.Attributes = []*Attribute{
+		windowAttribute("window", func(w *Window) interface{} { return w.self() }),
+		windowAttribute("self", func(w *Window) interface{} { return w.self() }),
+		windowAttribute("frames", func(w *Window) interface{} { return w.self() }),
+		windowAttribute("document", func(w *Window) interface{} { return w.Document }),
+		windowAttribute("parent", func(w *Window) interface{} {
+			if w.Document.DefaultView == nil {
+				return w
+			}
+			return w.Document.DefaultView.Parent()
+		}),
+		windowAttribute("top", func(w *Window) interface{} {
+			if w.Document.DefaultView == nil {
+				return w
+			}
+			return w.Document.DefaultView.Top()
+		}),
+		windowAttribute("frameElement", func(w *Window) interface{} {
+			if w.Document.DefaultView == nil {
+				return nil
+			}
+			return w.Document.DefaultView.FrameElement()
+		}),
+		windowAttribute("length", func(w *Window) interface{} {
+			if w.Document.DefaultView == nil {
+				return 0
+			}
+			return w.Document.DefaultView.Length()
+		}),
+		{Name: "location", Get: func(b *Bindings, this interface{}) (interface{}, error) {
+			doc := this.(*Window).Document
+			if doc.BrowsingContext == nil {
+				return nil, nil
+			}
+			return doc.Location, nil
+		}, Set: func(b *Bindings, this interface{}, v interface{}) error {
+			doc := this.(*Window).Document
+			if doc.BrowsingContext == nil {
+				return nil
+			}
+			return doc.Location.SetHref(toString(v))
+		}},
+	}
+	WindowInterface.Operations = []*Operation{
+		{Name: "setTimeout", Call: func(b *Bindings, this interface{}, args []interface{}) (interface{}, error) {
+			timers, err := windowTimers(this)
+			if err != nil {
+				return nil, err
+			}
+			return timers.SetTimeout(timerHandler(b, this.(*Window), args), timerTimeout(args)), nil
+		}},
+		{Name: "setInterval", Call: func(b *Bindings, this interface{}, args []interface{}) (interface{}, error) {
+			timers, err := windowTimers(this)
+			if err != nil {
+				return nil, err
+			}
+			return timers.SetInterval(timerHandler(b, this.(*Window), args), timerTimeout(args)), nil
+		}},
+		{Name: "clearTimeout", Call: func(b *Bindings, this interface{}, args []interface{}) (interface{}, error) {
+			timers, err := windowTimers(this)
+			if err != nil {
+				return nil, err
+			}
+			timers.ClearTimeout(toLong(arg(args, 0)))
+			return Undefined, nil
+		}},
+		{Name: "clearInterval", Call: func(b *Bindings, this interface{}, args []interface{}) (interface{}, error) {
+			timers, err := windowTimers(this)
+			if err != nil {
+				return nil, err
+			}
+			timers.ClearInterval(toLong(arg(args, 0)))
+			return Undefined, nil
+		}},
+		{Name: "queueMicrotask", Call: func(b *Bindings, this interface{}, args []interface{}) (interface{}, error) {
+			callback, err := toFunction(arg(args, 0))
+			if err != nil || callback == nil {
+				return nil, ErrType
+			}
+			this.(*Window).Document.QueueMicrotask(func() { b.callback(callback, nil) })
+			return Undefined, nil
+		}},
+		{Name: "requestAnimationFrame", Call: func(b *Bindings, this interface{}, args []interface{}) (interface{}, error) {
+			frames, ok := this.(*Window).Document.EventLoop.(AnimationFrames)
+			if !ok {
+				return nil, spec.ErrNotSupported
+			}
+			callback, err := toFunction(arg(args, 0))
+			if err != nil || callback == nil {
+				return nil, ErrType
+			}
+			return frames.RequestAnimationFrame(func(now time.Duration) {
+				b.callback(callback, nil, float64(now)/float64(time.Millisecond))
+			}), nil
+		}},
+		{Name: "cancelAnimationFrame", Call: func(b *Bindings, this interface{}, args []interface{}) (interface{}, error) {
+			frames, ok := this.(*Window).Document.EventLoop.(AnimationFrames)
+			if !ok {
+				return nil, spec.ErrNotSupported
+			}
+			frames.CancelAnimationFrame(toLong(arg(args, 0)))
+			return Undefined, nil
+		}},
+	}
+
+	CrossOriginWindowInterface.Attributes = []*Attribute{
+		windowProxyAttribute("window", func(w *spec.WindowProxy) interface{} { return w }),
+		windowProxyAttribute("self", func(w *spec.WindowProxy) interface{} { return w }),
+		windowProxyAttribute("frames", func(w *spec.WindowProxy) interface{} { return w }),
+		windowProxyAttribute("parent", func(w *spec.WindowProxy) interface{} { return w.Parent() }),
+		windowProxyAttribute("top", func(w *spec.WindowProxy) interface{} { return w.Top() }),
+		windowProxyAttribute("length", func(w *spec.WindowProxy) interface{} { return w.Length() }),
+	}
+
+	LocationInterface.Attributes = []*Attribute{
+		{Name: "href", Get: func(b *Bindings, this interface{}) (interface{}, error) {
+			return this.(*spec.HTMLLocation).Href(), nil
+		}, Set: func(b *Bindings, this interface{}, v interface{}) error {
+			return this.(*spec.HTMLLocation).SetHref(toString(v))
+		}},
+		{Name: "protocol", Get: func(b *Bindings, this interface{}) (interface{}, error) {
+			return this.(*spec.HTMLLocation).Protocol(), nil
+		}, Set: func(b *Bindings, this interface{}, v interface{}) error {
+			return this.(*spec.HTMLLocation).SetProtocol(toString(v))
+		}},
+		locationAttribute("origin", (*spec.HTMLLocation).Origin, nil),
+		locationAttribute("host", (*spec.HTMLLocation).Host, (*spec.HTMLLocation).SetHost),
+		locationAttribute("hostname", (*spec.HTMLLocation).Hostname, (*spec.HTMLLocation).SetHostname),
+		locationAttribute("port", (*spec.HTMLLocation).Port, (*spec.HTMLLocation).SetPort),
+		locationAttribute("pathname", (*spec.HTMLLocation).Pathname, (*spec.HTMLLocation).SetPathname),
+		locationAttribute("search", (*spec.HTMLLocation).Search, (*spec.HTMLLocation).SetSearch),
+		locationAttribute("hash", (*spec.HTMLLocation).Hash, (*spec.HTMLLocation).SetHash),
+	}
+	LocationInterface.Operations = []*Operation{
+		{Name: "assign", Call: func(b *Bindings, this interface{}, args []interface{}) (interface{}, error) {
+			return Undefined, this.(*spec.HTMLLocation).Assign(toString(arg(args, 0)))
+		}},
+		{Name: "replace", Call: func(b *Bindings, this interface{}, args []interface{}) (interface{}, error) {
+			return Undefined, this.(*spec.HTMLLocation).Replace(toString(arg(args, 0)))
+		}},
+		{Name: "reload", Call: func(b *Bindings, this interface{}, args []interface{}) (interface{}, error) {
+			this.(*spec.HTMLLocation).Reload()
+			return Undefined, nil
+		}},
+		{Name: "toString", Call: func(b *Bindings, this interface{}, args []interface{}) (interface{}, error) {
+			return this.(*spec.HTMLLocation).Href(), nil
+		}},
+	}
+}
+
+// self is the window's WindowProxy, or the window itself if the document
+// doesn't have a browsing context.
+func (w *Window) self() interface{} {
+	if w.Document.DefaultView == nil {
+		return w
+	}
+	return w.Document.DefaultView
+}
+
+func windowAttribute(name string, get func(w *Window) interface{}) *Attribute {
+	return &Attribute{Name: name, Get: func(b *Bindings, this interface{}) (interface{}, error) {
+		return get(this.(*Window)), nil
+	}}
+}
+
+func windowProxyAttribute(name string, get func(w *spec.WindowProxy) interface{}) *Attribute {
+	return &Attribute{Name: name, Get: func(b *Bindings, this interface{}) (interface{}, error) {
+		return get(this.(*spec.WindowProxy)), nil
+	}}
+}
+
+func locationAttribute(name string, get func(*spec.HTMLLocation) string, set func(*spec.HTMLLocation, string)) *Attribute {
+	a := &Attribute{Name: name, Get: func(b *Bindings, this interface{}) (interface{}, error) {
+		return get(this.(*spec.HTMLLocation)), nil
+	}}
+	if set != nil {
+		a.Set = func(b *Bindings, this interface{}, v interface{}) error {
+			set(this.(*spec.HTMLLocation), toString(v))
+			return nil
+		}
+	}
+	return a
+}
+
+func windowTimers(this interface{}) (Timers, error) {
+	timers, ok := this.(*Window).Document.EventLoop.(Timers)
+	if !ok {
+		return nil, spec.ErrNotSupported
+	}
+	return timers, nil
+}
+
+// timerHandler is the handler of https://html.spec.whatwg.org/multipage/timers-and-user-prompts.html#timer-initialisation-steps
+// A Function is called with the arguments after the timeout and a string
+// is run as a classic script with the document's script host.
+func timerHandler(b *Bindings, w *Window, args []interface{}) func() {
+	if f, ok := arg(args, 0).(Function); ok {
+		var rest []interface{}
+		if len(args) > 2 {
+			rest = args[2:]
+		}
+		return func() { b.callback(f, w, rest...) }
+	}
+	source := toString(arg(args, 0))
+	return func() {
+		if w.Document.ScriptHost == nil {
+			return
+		}
+		b.reportError(w.Document.ScriptHost.RunScript(w.Document, &spec.Script{
+			Type:    spec.ClassicScript,
+			Source:  source,
+			BaseURL: w.Document.BaseURL().Href(),
+		}))
+	}
+}
+
+func timerTimeout(args []interface{}) time.Duration {
+	ms := toNumber(arg(args, 1))
+	if ms != ms || ms < 0 {
+		ms = 0
+	}
+	return time.Duration(ms * float64(time.Millisecond))
+}
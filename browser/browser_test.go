@@ -0,0 +1,536 @@
+package browser
+
+import (
+	"html"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/heathj/gobrowse/bindings"
+	"github.com/heathj/gobrowse/cookies"
+	"github.com/heathj/gobrowse/eventloop"
+	"github.com/heathj/gobrowse/fetch"
+	"github.com/heathj/gobrowse/parser/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSite(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `<title>Home</title><a href="about">About</a><a href="#section">Jump</a>
+<form action=/search><input name=q value=go><button>Search</button></form>
+<form method=post action=/login><input name=user value=alice><input type=submit></form>`)
+	})
+	mux.HandleFunc("/validate", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `<!DOCTYPE html><form action=/search><input name=q required><button>Go</button><button formnovalidate>Skip</button></form>
+<form action=/search><fieldset disabled><button>Disabled</button></fieldset><input type=submit disabled></form>
+<form action=/search novalidate><input name=q required><button>Go</button></form>`)
+	})
+	mux.HandleFunc("/about", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `<title>About</title><a href="/"><span>Home</span></a>`)
+	})
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "<title>"+r.URL.Query().Get("q")+"</title>")
+	})
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		http.SetCookie(w, &http.Cookie{Name: "user", Value: r.PostForm.Get("user"), HttpOnly: true})
+		http.SetCookie(w, &http.Cookie{Name: "theme", Value: "dark"})
+		http.Redirect(w, r, "/welcome", http.StatusSeeOther)
+	})
+	mux.HandleFunc("/welcome", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "<title>"+r.Header.Get("Cookie")+"</title>")
+	})
+	mux.HandleFunc("/text", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, "<b>not markup</b>")
+	})
+	mux.HandleFunc("/image.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+	})
+	mux.HandleFunc("/no-content", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/download", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+	})
+	mux.HandleFunc("/frames", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `<!DOCTYPE html><title>Frames</title><base href="/base/">
+<iframe srcdoc="<title>Srcdoc</title><a href=page>Page</a>"></iframe>
+<iframe src="/about"></iframe>
+<iframe src="`+html.EscapeString(r.URL.String())+`#self"></iframe>
+<iframe src="`+r.URL.Query().Get("other")+`"></iframe>`)
+	})
+	mux.HandleFunc("/nested", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `<!DOCTYPE html><iframe src="/nested?n=`+r.URL.Query().Get("n")+`x"></iframe>`)
+	})
+	mux.HandleFunc("/fanout", func(w http.ResponseWriter, r *http.Request) {
+		n := r.URL.Query().Get("n")
+		io.WriteString(w, `<!DOCTYPE html><iframe src="/fanout?n=`+n+`x"></iframe><iframe src="/fanout?n=`+n+`y"></iframe>`)
+	})
+	mux.HandleFunc("/script.js", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/javascript")
+		io.WriteString(w, "external")
+	})
+	mux.HandleFunc("/throw.js", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/javascript")
+		io.WriteString(w, "throw")
+	})
+	mux.HandleFunc("/missing.js", http.NotFound)
+	mux.HandleFunc("/throwing-scripts", func(w http.ResponseWriter, r *http.Request) {
+		other := r.URL.Query().Get("other")
+		io.WriteString(w, `<!DOCTYPE html><script src=throw.js></script><script src="`+other+`/script.js"></script>
+<script src="`+other+`/throw.js"></script><script src="`+other+`/missing.js"></script>`)
+	})
+	mux.HandleFunc("/scripts", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `<!DOCTYPE html><script src=script.js></script><script src=missing.js></script>
+<iframe src="`+r.URL.Query().Get("other")+`"></iframe><script>inline</script>`)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestBrowsingContext(t *testing.T) (*BrowsingContext, *httptest.Server) {
+	server := newTestSite(t)
+	f := fetch.NewFetcher(server.Client().Transport)
+	f.Cookies = cookies.NewJar()
+	return NewBrowsingContext(f), server
+}
+
+func findElements(n *spec.Node, name string) []*spec.Node {
+	found := []*spec.Node{}
+	for _, child := range n.ChildNodes {
+		if child.NodeType == spec.ElementNode && child.NodeName == name {
+			found = append(found, child)
+		}
+		found = append(found, findElements(child, name)...)
+	}
+	return found
+}
+
+func title(bc *BrowsingContext) string {
+	titles := findElements(bc.ActiveDocument().Node, "title")
+	if len(titles) == 0 {
+		return ""
+	}
+	text, _ := titles[0].TextContent()
+	return text
+}
+
+func mustParseURL(t *testing.T, s string) *spec.URL {
+	u, err := spec.ParseURL(s, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func TestNavigate(t *testing.T) {
+	bc, server := newTestBrowsingContext(t)
+	assert.Equal(t, "about:blank", bc.ActiveDocument().URL)
+	assert.Equal(t, 1, bc.SessionHistoryLength())
+
+	assert.NoError(t, bc.Navigate(mustParseURL(t, server.URL+"/"), false))
+	assert.Equal(t, "Home", title(bc))
+	assert.Equal(t, 1, bc.SessionHistoryLength(), "the initial about:blank document is replaced")
+	home := bc.ActiveDocument()
+	assert.Equal(t, bc, home.BrowsingContext)
+
+	assert.NoError(t, bc.Click(findElements(home.Node, "a")[0]))
+	assert.Equal(t, "About", title(bc))
+	assert.Equal(t, server.URL+"/about", bc.ActiveDocument().URL)
+	assert.Equal(t, server.URL+"/", bc.ActiveDocument().Referrer)
+
+	assert.NoError(t, bc.Click(findElements(bc.ActiveDocument().Node, "span")[0]))
+	assert.Equal(t, "Home", title(bc))
+	assert.Equal(t, 3, bc.SessionHistoryLength())
+
+	assert.NoError(t, bc.Back())
+	assert.Equal(t, "About", title(bc))
+	assert.NoError(t, bc.Back())
+	assert.Equal(t, home, bc.ActiveDocument())
+	assert.NoError(t, bc.Back())
+	assert.Equal(t, home, bc.ActiveDocument())
+	assert.NoError(t, bc.Forward())
+	assert.Equal(t, "About", title(bc))
+
+	assert.NoError(t, bc.ActiveDocument().Location.Assign("/search?q=from+location"))
+	assert.Equal(t, "from location", title(bc))
+	assert.Equal(t, 3, bc.SessionHistoryLength(), "navigating removes the forward entries")
+	assert.NoError(t, bc.ActiveDocument().Location.Replace("/text"))
+	assert.Equal(t, 3, bc.SessionHistoryLength())
+	pre := findElements(bc.ActiveDocument().Node, "pre")
+	if assert.Len(t, pre, 1) {
+		text, _ := pre[0].TextContent()
+		assert.Equal(t, "<b>not markup</b>", text)
+	}
+
+	assert.NoError(t, bc.Navigate(mustParseURL(t, server.URL+"/image.png"), false))
+	img := findElements(bc.ActiveDocument().Node, "img")
+	if assert.Len(t, img, 1) {
+		assert.Equal(t, server.URL+"/image.png", img[0].Src())
+	}
+	assert.NoError(t, bc.Navigate(mustParseURL(t, server.URL+"/no-content"), false))
+	assert.Equal(t, server.URL+"/image.png", bc.ActiveDocument().URL)
+	assert.ErrorIs(t, bc.Navigate(mustParseURL(t, server.URL+"/download"), false), ErrUnsupportedMIMEType)
+	assert.ErrorIs(t, bc.Navigate(mustParseURL(t, "http://127.0.0.1:0/"), false), fetch.ErrNetwork)
+	assert.Equal(t, server.URL+"/image.png", bc.ActiveDocument().URL)
+
+	assert.NoError(t, bc.ActiveDocument().History.Go(-2))
+	assert.Equal(t, "About", title(bc))
+	bc.ActiveDocument().Location.Reload()
+	assert.Equal(t, "About", title(bc))
+	assert.Equal(t, 4, bc.SessionHistoryLength())
+}
+
+func TestFormSubmission(t *testing.T) {
+	bc, server := newTestBrowsingContext(t)
+	assert.NoError(t, bc.Navigate(mustParseURL(t, server.URL+"/"), false))
+	home := bc.ActiveDocument()
+
+	assert.NoError(t, bc.Click(findElements(home.Node, "button")[0]))
+	assert.Equal(t, "go", title(bc))
+	assert.Equal(t, server.URL+"/search?q=go", bc.ActiveDocument().URL)
+
+	assert.NoError(t, bc.Back())
+	submit := findElements(home.Node, "input")[2]
+	assert.NoError(t, bc.Click(submit))
+	assert.Equal(t, "user=alice; theme=dark", title(bc))
+	assert.Equal(t, server.URL+"/welcome", bc.ActiveDocument().URL)
+	cookie, err := bc.ActiveDocument().Cookie()
+	assert.NoError(t, err)
+	assert.Equal(t, "theme=dark", cookie)
+
+	canceled := false
+	bc.Back()
+	form := findElements(home.Node, "form")[0]
+	form.AddEventListener("submit", &spec.EventListener{Callback: func(e *spec.Event) {
+		canceled = true
+		e.PreventDefault()
+	}})
+	assert.NoError(t, bc.Click(findElements(home.Node, "button")[0]))
+	assert.True(t, canceled)
+	assert.Equal(t, home, bc.ActiveDocument())
+}
+
+func TestFormValidation(t *testing.T) {
+	bc, server := newTestBrowsingContext(t)
+	assert.NoError(t, bc.Navigate(mustParseURL(t, server.URL+"/validate"), false))
+	page := bc.ActiveDocument()
+	buttons := findElements(page.Node, "button")
+
+	invalid := 0
+	findElements(page.Node, "input")[0].AddEventListener("invalid", &spec.EventListener{Callback: func(e *spec.Event) {
+		invalid++
+	}})
+	assert.NoError(t, bc.Click(buttons[0]))
+	assert.Equal(t, 1, invalid)
+	assert.Equal(t, page, bc.ActiveDocument(), "forms with invalid controls aren't submitted")
+
+	clicked := false
+	disabled := findElements(page.Node, "input")[1]
+	disabled.AddEventListener("click", &spec.EventListener{Callback: func(e *spec.Event) { clicked = true }})
+	assert.NoError(t, bc.Click(buttons[2]))
+	assert.NoError(t, bc.Click(disabled))
+	assert.False(t, clicked)
+	assert.Equal(t, page, bc.ActiveDocument(), "disabled submit buttons don't submit")
+
+	assert.NoError(t, bc.Click(buttons[1]))
+	assert.Equal(t, server.URL+"/search?q=", bc.ActiveDocument().URL)
+	assert.NoError(t, bc.Back())
+	assert.NoError(t, bc.Click(buttons[3]))
+	assert.Equal(t, server.URL+"/search?q=", bc.ActiveDocument().URL)
+	assert.Equal(t, 1, invalid)
+}
+
+func TestSameDocumentHistory(t *testing.T) {
+	bc, server := newTestBrowsingContext(t)
+	assert.NoError(t, bc.Navigate(mustParseURL(t, server.URL+"/"), false))
+	doc := bc.ActiveDocument()
+	events := []string{}
+	for _, eventType := range []string{"hashchange", "popstate"} {
+		doc.AddEventListener(eventType, &spec.EventListener{Callback: func(e *spec.Event) {
+			events = append(events, e.Type)
+		}})
+	}
+
+	assert.NoError(t, bc.Click(findElements(doc.Node, "a")[1]))
+	assert.Equal(t, doc, bc.ActiveDocument())
+	assert.Equal(t, server.URL+"/#section", doc.URL)
+	assert.Equal(t, []string{"hashchange"}, events)
+
+	history := doc.History
+	assert.NoError(t, history.PushState("state", "", "/pushed?x"))
+	assert.Equal(t, server.URL+"/pushed?x", doc.URL)
+	assert.NoError(t, history.ReplaceState(map[string]int{"n": 1}, ""))
+	state, _ := history.State()
+	assert.Equal(t, map[string]int{"n": 1}, state)
+	length, _ := history.Length()
+	assert.Equal(t, 3, length)
+	assert.ErrorIs(t, history.PushState(nil, "", "https://other.example/"), spec.ErrSecurity)
+	assert.ErrorIs(t, history.PushState(nil, "", "http://[::"), spec.ErrSecurity)
+	assert.Equal(t, []string{"hashchange"}, events, "pushState doesn't fire events")
+
+	assert.NoError(t, history.Back())
+	assert.Equal(t, server.URL+"/#section", doc.URL)
+	state, _ = history.State()
+	assert.Nil(t, state)
+	assert.NoError(t, history.Back())
+	assert.Equal(t, server.URL+"/", doc.URL)
+	assert.Equal(t, []string{"hashchange", "popstate", "hashchange", "popstate", "hashchange"}, events)
+	assert.NoError(t, history.Go(2))
+	assert.Equal(t, server.URL+"/pushed?x", doc.URL)
+
+	doc.Location.SetHash("top")
+	assert.Equal(t, server.URL+"/pushed?x#top", doc.URL)
+	length, _ = history.Length()
+	assert.Equal(t, 4, length)
+
+	assert.NoError(t, bc.Click(findElements(doc.Node, "a")[0]))
+	_, err := doc.History.Length()
+	assert.ErrorIs(t, err, spec.ErrSecurity, "the document isn't active anymore")
+}
+
+func TestNestedBrowsingContexts(t *testing.T) {
+	bc, server := newTestBrowsingContext(t)
+	other := newTestSite(t)
+	assert.NoError(t, bc.Navigate(mustParseURL(t, server.URL+"/frames?other="+other.URL+"/about"), false))
+	doc := bc.ActiveDocument()
+	iframes := findElements(doc.Node, "iframe")
+	children := bc.Children()
+	if !assert.Len(t, children, 4) || !assert.Len(t, iframes, 4) {
+		return
+	}
+
+	srcdoc := children[0].(*BrowsingContext)
+	assert.Equal(t, "Srcdoc", title(srcdoc))
+	assert.Equal(t, "about:srcdoc", srcdoc.ActiveDocument().URL)
+	assert.Equal(t, "no-quirks", srcdoc.ActiveDocument().Mode)
+	assert.Equal(t, doc.Origin, srcdoc.ActiveDocument().Origin)
+	assert.Equal(t, server.URL+"/base/page", findElements(srcdoc.ActiveDocument().Node, "a")[0].Href())
+	assert.Equal(t, srcdoc.ActiveDocument(), iframes[0].ContentDocument())
+	assert.Equal(t, bc, srcdoc.Parent())
+	assert.Equal(t, iframes[0], srcdoc.Container())
+	assert.Equal(t, bc, srcdoc.Top())
+
+	sameOrigin := children[1].(*BrowsingContext)
+	assert.Equal(t, "About", title(sameOrigin))
+	assert.Equal(t, server.URL+"/frames?other="+other.URL+"/about", sameOrigin.ActiveDocument().Referrer)
+	window := iframes[1].ContentWindow()
+	content, err := window.Document()
+	assert.NoError(t, err)
+	assert.Equal(t, sameOrigin.ActiveDocument(), content)
+	assert.Equal(t, iframes[1], window.FrameElement())
+	assert.Equal(t, doc.DefaultView.BrowsingContext(), window.Parent().BrowsingContext())
+	assert.Equal(t, 4, doc.DefaultView.Length())
+
+	self := children[2].(*BrowsingContext)
+	assert.Equal(t, "about:blank", self.ActiveDocument().URL, "iframes can't nest their own document")
+
+	crossOrigin := children[3].(*BrowsingContext)
+	assert.Equal(t, "About", title(crossOrigin))
+	assert.Nil(t, iframes[3].ContentDocument())
+	_, err = iframes[3].ContentWindow().Document()
+	assert.ErrorIs(t, err, spec.ErrSecurity)
+	_, err = iframes[3].ContentWindow().Location()
+	assert.ErrorIs(t, err, spec.ErrSecurity)
+	_, err = crossOrigin.ActiveDocument().DefaultView.Parent().Document()
+	assert.ErrorIs(t, err, spec.ErrSecurity)
+	assert.Nil(t, crossOrigin.ActiveDocument().DefaultView.FrameElement())
+
+	assert.NoError(t, crossOrigin.Click(findElements(crossOrigin.ActiveDocument().Node, "span")[0]))
+	assert.Equal(t, other.URL+"/", crossOrigin.ActiveDocument().URL)
+	assert.Equal(t, doc, bc.ActiveDocument(), "nested navigations don't navigate the parent")
+	assert.Equal(t, 1, bc.SessionHistoryLength())
+	assert.Len(t, crossOrigin.Children(), 0)
+
+	urls := []string{}
+	bc.Walk(func(c *BrowsingContext) { urls = append(urls, c.ActiveDocument().URL) })
+	assert.Equal(t, []string{doc.URL, "about:srcdoc", server.URL + "/about", "about:blank", other.URL + "/"}, urls)
+
+	iframes[0].SetSrcdoc("<title>Changed</title>")
+	assert.Equal(t, "Changed", title(srcdoc))
+	iframes[2].SetSrc("/search?q=set")
+	assert.Equal(t, "set", title(self))
+
+	iframes[1].ParentNode.RemoveChild(iframes[1])
+	assert.Nil(t, iframes[1].ContentBrowsingContext())
+	assert.Nil(t, iframes[1].ContentDocument())
+	assert.Len(t, bc.Children(), 3)
+}
+
+func TestNestingDepth(t *testing.T) {
+	bc, server := newTestBrowsingContext(t)
+	assert.NoError(t, bc.Navigate(mustParseURL(t, server.URL+"/nested"), false))
+	depth := 0
+	for c := bc; len(c.Children()) > 0; c = c.Children()[0].(*BrowsingContext) {
+		depth++
+		if depth == 11 {
+			assert.Equal(t, "about:blank", c.Children()[0].ActiveDocument().URL)
+		}
+	}
+	assert.Equal(t, 11, depth, "browsing contexts nested 10 deep don't navigate")
+
+	loop := eventloop.NewLoop(eventloop.NewManualClock(time.Now()))
+	bc.EventLoop = loop
+	assert.NoError(t, bc.Navigate(mustParseURL(t, server.URL+"/nested?n=y"), false))
+	child := bc.Children()[0]
+	assert.Equal(t, "about:blank", child.ActiveDocument().URL, "iframes navigate from a task")
+	loop.RunUntilIdle()
+	assert.Equal(t, server.URL+"/nested?n=yx", child.ActiveDocument().URL)
+}
+
+func TestNestedBrowsingContextBudget(t *testing.T) {
+	bc, server := newTestBrowsingContext(t)
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, bc.Navigate(mustParseURL(t, server.URL+"/fanout?n="+strconv.Itoa(i)), false))
+		contexts, navigated := 0, 0
+		bc.Walk(func(c *BrowsingContext) {
+			contexts++
+			if c.ActiveDocument().URL != "about:blank" {
+				navigated++
+			}
+		})
+		// the iframes after the budget ran out still get a browsing context,
+		// at most one per document being parsed, which is 11 deep.
+		assert.LessOrEqual(t, contexts, maxNestedBrowsingContexts+1+11, "frames embedding themselves twice don't grow exponentially")
+		assert.Greater(t, navigated, maxNestedBrowsingContexts/2)
+	}
+
+	var child *BrowsingContext
+	bc.Walk(func(c *BrowsingContext) {
+		if c.parent != nil && c.ActiveDocument().URL == "about:blank" {
+			child = c
+		}
+	})
+	assert.ErrorIs(t, child.Navigate(mustParseURL(t, server.URL+"/about"), false), ErrTooManyBrowsingContexts)
+}
+
+func TestFileURLs(t *testing.T) {
+	server := newTestSite(t)
+	f := fetch.NewFetcher(server.Client().Transport)
+	f.Transports["file"] = &fetch.FileTransport{FS: fstest.MapFS{
+		"local.html": {Data: []byte(`<title>Local</title><iframe src=frame.html></iframe>`)},
+		"frame.html": {Data: []byte(`<title>Frame</title>`)},
+	}}
+
+	local := NewBrowsingContext(f)
+	assert.NoError(t, local.Navigate(mustParseURL(t, "file:///local.html"), false))
+	assert.Equal(t, "Local", title(local))
+	if children := local.Children(); assert.Len(t, children, 1) {
+		assert.Equal(t, "Frame", title(children[0].(*BrowsingContext)))
+	}
+
+	bc := NewBrowsingContext(f)
+	assert.NoError(t, bc.Navigate(mustParseURL(t, server.URL+"/frames?other=file:///frame.html"), false))
+	if children := bc.Children(); assert.Len(t, children, 4) {
+		assert.Equal(t, "about:blank", children[3].ActiveDocument().URL)
+	}
+	assert.ErrorIs(t, bc.Navigate(mustParseURL(t, "file:///local.html"), false), fetch.ErrNetwork)
+	assert.Equal(t, "Frames", title(bc))
+}
+
+func TestEventLoop(t *testing.T) {
+	bc, server := newTestBrowsingContext(t)
+	loop := eventloop.NewLoop(eventloop.NewManualClock(time.Now()))
+	bc.EventLoop = loop
+	assert.NoError(t, bc.Navigate(mustParseURL(t, server.URL+"/"), false))
+	doc := bc.ActiveDocument()
+	assert.Equal(t, spec.Interactive, doc.ReadyState)
+
+	events := []string{}
+	for _, eventType := range []string{"DOMContentLoaded", "hashchange"} {
+		doc.AddEventListener(eventType, &spec.EventListener{Callback: func(e *spec.Event) {
+			events = append(events, e.Type)
+		}})
+	}
+	loop.RunUntilIdle()
+	assert.Equal(t, spec.Complete, doc.ReadyState)
+	assert.Equal(t, []string{"DOMContentLoaded"}, events)
+
+	assert.NoError(t, bc.Click(findElements(doc.Node, "a")[1]))
+	assert.Equal(t, []string{"DOMContentLoaded"}, events, "hashchange is fired from a task")
+	loop.RunUntilIdle()
+	assert.Equal(t, []string{"DOMContentLoaded", "hashchange"}, events)
+}
+
+// testScriptHost records the scripts it runs.
+type testScriptHost struct {
+	bindings *bindings.Bindings
+	ran      []string
+	errors   []error
+}
+
+func (h *testScriptHost) RunScript(doc *spec.HTMLDocument, script *spec.Script) error {
+	h.ran = append(h.ran, doc.URL+" "+script.Source)
+	if script.Source == "throw" {
+		return spec.ErrSyntax
+	}
+	return nil
+}
+
+func (h *testScriptHost) ReportError(doc *spec.HTMLDocument, err error) {
+	h.errors = append(h.errors, err)
+}
+
+func (h *testScriptHost) CompileEventHandler(element *spec.Node, name, body string) (spec.EventHandler, error) {
+	return nil, spec.ErrSyntax
+}
+
+func TestScriptHost(t *testing.T) {
+	bc, server := newTestBrowsingContext(t)
+	other := newTestSite(t)
+	host := &testScriptHost{bindings: bindings.New()}
+	bc.ScriptHost = host
+	assert.NoError(t, bc.Navigate(mustParseURL(t, server.URL+"/scripts?other="+other.URL+"/scripts"), false))
+	doc := bc.ActiveDocument()
+	assert.Equal(t, []string{
+		doc.URL + " external",
+		other.URL + "/scripts external",
+		other.URL + "/scripts inline",
+		doc.URL + " inline",
+	}, host.ran, "nested browsing contexts run scripts with the same host")
+
+	b := host.bindings
+	window := b.GlobalObject(doc)
+	frame := b.Wrap(doc.DefaultView.Frame(0)).(*bindings.Object)
+	assert.Equal(t, bindings.CrossOriginWindowInterface, frame.Interface)
+	assert.Same(t, frame, b.Wrap(doc.DefaultView.Frame(0)))
+	location, err := frame.Get("location")
+	assert.NoError(t, err)
+	assert.Equal(t, bindings.Undefined, location, "cross-origin windows don't expose their location")
+	parent, err := frame.Get("parent")
+	assert.NoError(t, err)
+	assert.Same(t, window, parent)
+
+	self, err := window.Get("self")
+	assert.NoError(t, err)
+	assert.Same(t, window, self)
+	location, err = window.Get("location")
+	assert.NoError(t, err)
+	href, err := location.(*bindings.Object).Get("href")
+	assert.NoError(t, err)
+	assert.Equal(t, doc.URL, href)
+	url := doc.URL
+	assert.NoError(t, location.(*bindings.Object).Set("hash", "top"))
+	assert.Equal(t, url+"#top", bc.ActiveDocument().URL)
+}
+
+func TestCrossOriginScripts(t *testing.T) {
+	bc, server := newTestBrowsingContext(t)
+	other := newTestSite(t)
+	host := &testScriptHost{}
+	bc.ScriptHost = host
+	assert.NoError(t, bc.Navigate(mustParseURL(t, server.URL+"/throwing-scripts?other="+other.URL), false))
+	doc := bc.ActiveDocument()
+	assert.Equal(t, []string{doc.URL + " throw", doc.URL + " external", doc.URL + " throw"}, host.ran,
+		"classic scripts of other origins run from opaque responses")
+	assert.Len(t, host.errors, 2)
+	assert.ErrorIs(t, host.errors[0], spec.ErrSyntax)
+	assert.Equal(t, ErrMutedScript, host.errors[1], "the errors of scripts from other origins are muted")
+}
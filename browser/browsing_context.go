@@ -0,0 +1,323 @@
+// Package browser navigates browsing contexts: it fetches and parses the
+// documents they display and keeps their session history, following
+// https://html.spec.whatwg.org/multipage/browsing-the-web.html.
+package browser
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/heathj/gobrowse/fetch"
+	"github.com/heathj/gobrowse/parser"
+	"github.com/heathj/gobrowse/parser/spec"
+)
+
+// BrowsingContext is https://html.spec.whatwg.org/multipage/document-sequences.html#browsing-context
+// It's either a top-level browsing context or one nested in an iframe, and
+// each keeps its own session history. It isn't safe to use from several
+// goroutines.
+type BrowsingContext struct {
+	// Fetcher fetches the documents navigated to. Its cookie jar backs
+	// document.cookie.
+	Fetcher *fetch.Fetcher
+	// ParserOptions are added to the options of the parsers of HTML
+	// documents.
+	ParserOptions []parser.Option
+	// EventLoop runs the tasks the documents queue, like firing hashchange
+	// and DOMContentLoaded. The tasks run right away when it's nil.
+	EventLoop spec.EventLoop
+	// ScriptHost runs the scripts of the documents and compiles their event
+	// handler content attributes. Scripting is disabled when it's nil.
+	ScriptHost spec.ScriptHost
+
+	document *spec.HTMLDocument
+	history  []*SessionHistoryEntry
+	current  int
+	// initial is set while the active document is the initial about:blank
+	// document, which the first navigation replaces.
+	initial bool
+
+	parent    *BrowsingContext
+	container *spec.Node
+	// nested is the number of browsing contexts created under a top-level
+	// browsing context since its active document was loaded.
+	nested int
+}
+
+// maxNestedBrowsingContexts is the number of browsing contexts a top-level
+// browsing context's document can nest before the others stop navigating.
+// The nesting depth is bounded by the iframes themselves but a document
+// embedding itself several times still grows exponentially until then.
+const maxNestedBrowsingContexts = 100
+
+// ErrTooManyBrowsingContexts is returned when a nested browsing context
+// navigates after its top-level browsing context went over
+// maxNestedBrowsingContexts.
+var ErrTooManyBrowsingContexts = errors.New("too many nested browsing contexts")
+
+// resource is what a session history entry's document was fetched from.
+// https://html.spec.whatwg.org/multipage/browsing-the-web.html#document-state-resource
+type resource struct {
+	url         *spec.URL
+	method      string
+	contentType string
+	body        []byte
+}
+
+// NewBrowsingContext creates a browsing context displaying the initial
+// about:blank document that fetches documents with f.
+// https://html.spec.whatwg.org/multipage/document-sequences.html#creating-a-new-browsing-context
+func NewBrowsingContext(f *fetch.Fetcher) *BrowsingContext {
+	bc := &BrowsingContext{Fetcher: f, initial: true}
+	bc.createInitialDocument()
+	return bc
+}
+
+// CreateChild is https://html.spec.whatwg.org/multipage/document-sequences.html#create-a-new-child-navigable
+// The nested browsing context fetches documents like its parent and its
+// initial about:blank document has the origin of the container's document.
+func (bc *BrowsingContext) CreateChild(container *spec.Node) spec.BrowsingContext {
+	bc.Top().nested++
+	child := &BrowsingContext{
+		Fetcher:       bc.Fetcher,
+		ParserOptions: bc.ParserOptions,
+		EventLoop:     bc.EventLoop,
+		ScriptHost:    bc.ScriptHost,
+		initial:       true,
+		parent:        bc,
+		container:     container,
+	}
+	child.createInitialDocument()
+	return child
+}
+
+func (bc *BrowsingContext) createInitialDocument() {
+	doc, _ := bc.parseDocument(context.Background(), strings.NewReader(""), "about:blank")
+	bc.inheritOrigin(doc)
+	blank := doc.URLRecord()
+	bc.document = doc
+	bc.history = []*SessionHistoryEntry{{URL: blank, Document: doc, resource: &resource{url: blank, method: http.MethodGet}}}
+}
+
+// ActiveDocument is https://html.spec.whatwg.org/multipage/document-sequences.html#nav-document
+func (bc *BrowsingContext) ActiveDocument() *spec.HTMLDocument { return bc.document }
+
+// Parent is https://html.spec.whatwg.org/multipage/document-sequences.html#nav-parent
+// It's nil for top-level browsing contexts.
+func (bc *BrowsingContext) Parent() spec.BrowsingContext {
+	if bc.parent == nil {
+		return nil
+	}
+	return bc.parent
+}
+
+// Container is https://html.spec.whatwg.org/multipage/document-sequences.html#nav-container
+// It's nil for top-level browsing contexts.
+func (bc *BrowsingContext) Container() *spec.Node { return bc.container }
+
+// Top is https://html.spec.whatwg.org/multipage/document-sequences.html#nav-top
+func (bc *BrowsingContext) Top() *BrowsingContext {
+	top := bc
+	for top.parent != nil {
+		top = top.parent
+	}
+	return top
+}
+
+// Children returns the browsing contexts nested in the iframes of the active
+// document in tree order.
+// https://html.spec.whatwg.org/multipage/document-sequences.html#document-tree-child-navigables
+func (bc *BrowsingContext) Children() []spec.BrowsingContext {
+	children := []spec.BrowsingContext{}
+	var walk func(n *spec.Node)
+	walk = func(n *spec.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.NodeType == spec.ElementNode && child.HTMLIFrame != nil && child.ContentBrowsingContext() != nil {
+				children = append(children, child.ContentBrowsingContext())
+			}
+			walk(child)
+		}
+	}
+	walk(bc.document.Node)
+	return children
+}
+
+// Walk calls f for the browsing context and then the browsing contexts nested
+// in it, depth first in tree order.
+// https://html.spec.whatwg.org/multipage/document-sequences.html#inclusive-descendant-navigables
+func (bc *BrowsingContext) Walk(f func(*BrowsingContext)) {
+	f(bc)
+	for _, child := range bc.Children() {
+		child.(*BrowsingContext).Walk(f)
+	}
+}
+
+// Navigate navigates to the URL like NavigateContext without a deadline.
+func (bc *BrowsingContext) Navigate(u *spec.URL, replace bool) error {
+	return bc.NavigateContext(context.Background(), u, replace)
+}
+
+// NavigateContext is https://html.spec.whatwg.org/multipage/browsing-the-web.html#navigate
+// The document is fetched and parsed before it returns. A navigation that
+// fails returns its error and leaves the active document as it is. The
+// navigation replaces the current session history entry when replace is set.
+// javascript: URLs are ignored. The active document makes the request, so
+// only file: documents and the initial about:blank document can navigate to
+// file: URLs.
+func (bc *BrowsingContext) NavigateContext(ctx context.Context, u *spec.URL, replace bool) error {
+	return bc.navigate(ctx, &resource{url: u, method: http.MethodGet}, replace)
+}
+
+func (bc *BrowsingContext) navigate(ctx context.Context, res *resource, replace bool) error {
+	if res.url.Scheme() == "javascript" {
+		return nil
+	}
+	if bc.initial || bc.document.ReadyState != spec.Complete {
+		replace = true
+	}
+	// https://html.spec.whatwg.org/multipage/browsing-the-web.html#navigate-fragid
+	if res.method == http.MethodGet && hasFragment(res.url) && equalExcludingFragments(res.url, bc.document.URLRecord()) {
+		bc.navigateToFragment(res.url, replace)
+		return nil
+	}
+
+	if bc.parent == nil {
+		bc.nested = 0
+	} else if bc.Top().nested > maxNestedBrowsingContexts {
+		return ErrTooManyBrowsingContexts
+	}
+	doc, err := bc.load(ctx, res)
+	if err != nil || doc == nil {
+		return err
+	}
+	entry := &SessionHistoryEntry{URL: doc.URLRecord(), Document: doc, resource: res}
+	bc.addEntry(entry, replace)
+	bc.initial = false
+	bc.document = doc
+	return nil
+}
+
+// Reload is https://html.spec.whatwg.org/multipage/browsing-the-web.html#reload
+// The current session history entry's document is fetched again and replaces
+// the old one in every entry it was in.
+func (bc *BrowsingContext) Reload() error {
+	entry := bc.history[bc.current]
+	res := *entry.resource
+	res.url = entry.URL
+	doc, err := bc.load(context.Background(), &res)
+	if err != nil || doc == nil {
+		return err
+	}
+	for _, e := range bc.history {
+		if e.Document == bc.document {
+			e.Document = doc
+		}
+	}
+	entry.URL = doc.URLRecord()
+	bc.document = doc
+	return nil
+}
+
+// Click fires a click event at the node and, unless it's canceled, runs the
+// activation behavior of the node or its nearest ancestor that has one:
+// hyperlinks are followed and submit buttons submit their form. Disabled form
+// controls can't be clicked and disabled submit buttons don't submit.
+// https://html.spec.whatwg.org/multipage/interaction.html#activation-behaviour
+func (bc *BrowsingContext) Click(n *spec.Node) error {
+	// https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#enabling-and-disabling-form-controls:-the-disabled-attribute
+	if n.IsDisabledFormControl() {
+		return nil
+	}
+	click := spec.NewEvent("click", true, true)
+	click.IsTrusted = true
+	if !n.DispatchEvent(click) {
+		return nil
+	}
+	for ; n != nil; n = n.ParentNode {
+		switch {
+		case n.IsHyperlink():
+			return bc.followHyperlink(n)
+		case n.IsSubmitButton():
+			if form := n.Form(); form != nil && !n.IsDisabledFormControl() {
+				return bc.Submit(form, n)
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// followHyperlink is https://html.spec.whatwg.org/multipage/links.html#following-hyperlinks-2
+// Links that don't resolve are ignored and the target attribute isn't
+// supported.
+func (bc *BrowsingContext) followHyperlink(n *spec.Node) error {
+	u, err := spec.ParseURL(n.Href(), nil)
+	if err != nil {
+		return nil
+	}
+	return bc.Navigate(u, false)
+}
+
+// Submit validates the form's controls and fires a submit event at the form.
+// Unless a control is invalid or the event is canceled, it navigates to the
+// form submission. submitter is the submit button that submitted the form, if
+// any. The controls aren't validated when the form has the novalidate
+// attribute or the submitter has the formnovalidate attribute.
+// https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#form-submission-algorithm
+func (bc *BrowsingContext) Submit(form, submitter *spec.Node) error {
+	// https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#no-validate-state
+	noValidate := form.HasAttribute("novalidate") || (submitter != nil && submitter.HasAttribute("formnovalidate"))
+	if !noValidate && !form.HTMLForm.ReportValidity() {
+		return nil
+	}
+	submit := spec.NewEvent("submit", true, true)
+	submit.IsTrusted = true
+	if !form.DispatchEvent(submit) {
+		return nil
+	}
+	submission, err := form.HTMLForm.Submission(submitter)
+	if err != nil {
+		return err
+	}
+	if submission.Method == "dialog" {
+		return nil
+	}
+	u, err := spec.ParseURL(submission.Action.String(), nil)
+	if err != nil {
+		return err
+	}
+	res := &resource{url: u, method: http.MethodGet}
+	if submission.Method == "post" {
+		res.method, res.contentType, res.body = http.MethodPost, submission.ContentType, submission.Body
+	}
+	return bc.navigate(context.Background(), res, false)
+}
+
+// hasFragment reports if the URL's fragment isn't null.
+func hasFragment(u *spec.URL) bool {
+	return u.Hash() != "" || strings.HasSuffix(u.Href(), "#")
+}
+
+// equalExcludingFragments reports if the URLs are equal when their fragments
+// are ignored.
+// https://url.spec.whatwg.org/#concept-url-equals
+func equalExcludingFragments(a, b *spec.URL) bool {
+	a, b = a.Clone(), b.Clone()
+	a.SetHash("")
+	b.SetHash("")
+	return a.Href() == b.Href()
+}
+
+func fireEvent(doc *spec.HTMLDocument, eventType string) {
+	e := spec.NewEvent(eventType, false, false)
+	e.IsTrusted = true
+	doc.Node.DispatchEvent(e)
+}
+
+// queueEvent fires the event at the document from a task on the DOM
+// manipulation task source.
+func queueEvent(doc *spec.HTMLDocument, eventType string) {
+	doc.QueueTask(spec.DOMManipulationTaskSource, func() { fireEvent(doc, eventType) })
+}
@@ -0,0 +1,209 @@
+package browser
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/heathj/gobrowse/fetch"
+	"github.com/heathj/gobrowse/parser"
+	"github.com/heathj/gobrowse/parser/spec"
+)
+
+// ErrUnsupportedMIMEType is returned for navigations to responses that can't
+// be displayed as a document, which browsers hand to other software.
+var ErrUnsupportedMIMEType = errors.New("unsupported MIME type")
+
+// load fetches the resource and loads the document of its response. It
+// returns a nil document for responses that don't navigate.
+// https://html.spec.whatwg.org/multipage/browsing-the-web.html#populating-a-session-history-entry
+func (bc *BrowsingContext) load(ctx context.Context, res *resource) (*spec.HTMLDocument, error) {
+	if bc.container != nil && res.url.Href() == "about:srcdoc" {
+		return bc.loadSrcdoc(ctx)
+	}
+	req, err := fetch.NewRequest(res.method, res.url.Href())
+	if err != nil {
+		return nil, err
+	}
+	req.Mode = fetch.NavigateMode
+	req.Destination = fetch.DocumentDestination
+	if bc.container != nil {
+		req.Destination = fetch.IframeDestination
+	}
+	req.Credentials = fetch.IncludeCredentials
+	// the initial about:blank document of a top-level browsing context
+	// didn't start the navigation, the embedder did.
+	if bc.container != nil || !bc.initial {
+		req.Referrer = bc.sourceDocument().URLRecord()
+		if origin := req.Referrer.Origin(); origin != "null" {
+			req.Origin = origin
+		}
+	}
+	req.Body = res.body
+	if res.contentType != "" {
+		req.Header = http.Header{"Content-Type": {res.contentType}}
+	}
+	resp, err := bc.Fetcher.Fetch(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	// https://html.spec.whatwg.org/multipage/browsing-the-web.html#create-navigation-params-by-fetching
+	if resp.Status == http.StatusNoContent || resp.Status == http.StatusResetContent {
+		return nil, nil
+	}
+
+	doc, err := bc.loadDocument(ctx, resp)
+	if err != nil {
+		return nil, err
+	}
+	doc.Referrer = req.Header.Get("Referer")
+	doc.LastModified = resp.Header.Get("Last-Modified")
+	if resp.URL().Scheme() == "about" {
+		bc.inheritOrigin(doc)
+	}
+	return doc, nil
+}
+
+// sourceDocument is the document navigations are made from: the container's
+// document for nested browsing contexts and otherwise the active document.
+func (bc *BrowsingContext) sourceDocument() *spec.HTMLDocument {
+	if bc.container != nil {
+		return bc.container.OwnerHTMLDocument()
+	}
+	return bc.document
+}
+
+// inheritOrigin gives an about:blank or about:srcdoc document the origin of
+// the document it was navigated to from.
+// https://html.spec.whatwg.org/multipage/browsers.html#determining-the-origin
+func (bc *BrowsingContext) inheritOrigin(doc *spec.HTMLDocument) {
+	if source := bc.sourceDocument(); source != nil {
+		doc.Origin = source.Origin
+	}
+}
+
+// loadSrcdoc parses the srcdoc attribute of the container as an iframe srcdoc
+// document.
+// https://html.spec.whatwg.org/multipage/document-lifecycle.html#navigate-html
+func (bc *BrowsingContext) loadSrcdoc(ctx context.Context) (*spec.HTMLDocument, error) {
+	doc, err := bc.parseDocument(ctx, strings.NewReader(bc.container.Srcdoc()), "about:srcdoc", parser.WithIframeSrcdoc())
+	if err != nil {
+		return nil, err
+	}
+	doc.Referrer = bc.sourceDocument().URL
+	bc.inheritOrigin(doc)
+	return doc, nil
+}
+
+// loadDocument is https://html.spec.whatwg.org/multipage/browsing-the-web.html#loading-a-document
+// The MIME type is sniffed from the body when the response doesn't have one.
+func (bc *BrowsingContext) loadDocument(ctx context.Context, resp *fetch.Response) (*spec.HTMLDocument, error) {
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(resp.Body)
+	}
+	mimeType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedMIMEType, contentType)
+	}
+
+	url := resp.URL().Href()
+	switch {
+	case mimeType == "text/html":
+		return bc.parseDocument(ctx, bytes.NewReader(resp.Body), url)
+	case isTextMIMEType(mimeType):
+		return bc.loadTextDocument(ctx, resp.Body, url)
+	case strings.HasPrefix(mimeType, "image/"):
+		return bc.loadMediaDocument(ctx, "img", url)
+	case strings.HasPrefix(mimeType, "audio/"), strings.HasPrefix(mimeType, "video/"):
+		return bc.loadMediaDocument(ctx, mimeType[:strings.IndexByte(mimeType, '/')], url)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedMIMEType, mimeType)
+}
+
+// isTextMIMEType reports if documents of the MIME type are displayed as text.
+// https://html.spec.whatwg.org/multipage/browsing-the-web.html#loading-a-document
+func isTextMIMEType(mimeType string) bool {
+	switch mimeType {
+	case "text/plain", "text/css", "text/javascript", "application/javascript", "application/ecmascript",
+		"text/ecmascript", "application/json", "text/json":
+		return true
+	}
+	return strings.HasSuffix(mimeType, "+json")
+}
+
+// parseDocument parses an HTML document for the browsing context. about:blank
+// and about:srcdoc documents resolve URLs against the base URL of the document
+// they were navigated to from.
+// https://html.spec.whatwg.org/multipage/document-lifecycle.html#navigate-html
+func (bc *BrowsingContext) parseDocument(ctx context.Context, r io.Reader, url string, opts ...parser.Option) (*spec.HTMLDocument, error) {
+	opts = append([]parser.Option{parser.WithDocumentURL(url), parser.WithBrowsingContext(bc)}, opts...)
+	if source := bc.sourceDocument(); source != nil {
+		opts = append(opts, parser.WithAboutBaseURL(source.BaseURL().Href()))
+	}
+	if bc.EventLoop != nil {
+		opts = append(opts, parser.WithEventLoop(bc.EventLoop))
+	}
+	if bc.Fetcher != nil && bc.Fetcher.Cookies != nil {
+		opts = append(opts, parser.WithCookieStore(bc.Fetcher.Cookies))
+	}
+	if bc.ScriptHost != nil {
+		opts = append(opts, parser.WithScriptHost(bc.ScriptHost))
+	}
+	p := parser.NewParser(r, append(opts, bc.ParserOptions...)...)
+	if bc.ScriptHost != nil {
+		p.SetScriptExecutor(&scriptExecutor{ctx: ctx, bc: bc})
+	}
+	doc, err := p.StartContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return doc.OwnerHTMLDocument(), nil
+}
+
+// loadTextDocument is https://html.spec.whatwg.org/multipage/document-lifecycle.html#navigate-text
+func (bc *BrowsingContext) loadTextDocument(ctx context.Context, text []byte, url string) (*spec.HTMLDocument, error) {
+	doc, err := bc.parseDocument(ctx, bytes.NewReader(text), url, parser.WithInitialState(parser.PLAINTEXTState))
+	if err != nil {
+		return nil, err
+	}
+	body := childElement(childElement(doc.Node, "html"), "body")
+	if body == nil {
+		return doc, nil
+	}
+	pre := spec.NewDOMElement(doc.Node, "pre", spec.Htmlns)
+	for body.FirstChild != nil {
+		pre.AppendChild(body.RemoveChild(body.FirstChild))
+	}
+	body.AppendChild(pre)
+	return doc, nil
+}
+
+// childElement returns the first child element of n with the name.
+func childElement(n *spec.Node, name string) *spec.Node {
+	if n == nil {
+		return nil
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.NodeType == spec.ElementNode && child.NodeName == name {
+			return child
+		}
+	}
+	return nil
+}
+
+// loadMediaDocument is https://html.spec.whatwg.org/multipage/document-lifecycle.html#navigate-media
+// The document is an img, audio or video element displaying the URL.
+func (bc *BrowsingContext) loadMediaDocument(ctx context.Context, element, url string) (*spec.HTMLDocument, error) {
+	markup := fmt.Sprintf(`<!DOCTYPE html><%s src="%s">`, element, html.EscapeString(url))
+	if element != "img" {
+		markup = fmt.Sprintf(`<!DOCTYPE html><%s src="%s" controls autoplay></%s>`, element, html.EscapeString(url), element)
+	}
+	return bc.parseDocument(ctx, strings.NewReader(markup), url)
+}
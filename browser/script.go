@@ -0,0 +1,83 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/heathj/gobrowse/fetch"
+	"github.com/heathj/gobrowse/parser/spec"
+)
+
+var (
+	// ErrScriptFetch is returned for external scripts whose response isn't ok.
+	ErrScriptFetch = errors.New("script fetch failed")
+	// ErrMutedScript is reported instead of the exceptions thrown by scripts
+	// with muted errors.
+	// https://html.spec.whatwg.org/multipage/webappapis.html#report-the-exception
+	ErrMutedScript = errors.New("script error")
+)
+
+// scriptExecutor fetches the external scripts of the browsing context's
+// documents with its Fetcher and runs scripts with its ScriptHost.
+type scriptExecutor struct {
+	ctx context.Context
+	bc  *BrowsingContext
+	// muted are the script elements whose classic script came from an opaque
+	// response.
+	muted map[*spec.Node]bool
+}
+
+// FetchScript is https://html.spec.whatwg.org/multipage/webappapis.html#fetch-a-classic-script
+// Module scripts are fetched in cors mode.
+func (e *scriptExecutor) FetchScript(element *spec.Node, src string, scriptType spec.ScriptType) (string, error) {
+	u, err := element.ResolveURL(src)
+	if err != nil {
+		return "", err
+	}
+	req, err := fetch.NewRequest(http.MethodGet, u.Href())
+	if err != nil {
+		return "", err
+	}
+	req.Destination = fetch.ScriptDestination
+	req.Mode, req.Credentials = fetch.NoCORSMode, fetch.IncludeCredentials
+	if scriptType == spec.ModuleScript {
+		req.Mode, req.Credentials = fetch.CORSMode, fetch.SameOriginCredentials
+	}
+	if doc := element.OwnerHTMLDocument(); doc != nil {
+		req.Referrer = doc.URLRecord()
+		req.Origin = doc.Origin
+	}
+	resp, err := e.bc.Fetcher.Fetch(e.ctx, req)
+	if err != nil {
+		return "", err
+	}
+	// a classic script of another origin is run from the opaque response's
+	// internal response, with its errors muted.
+	if resp.Type == fetch.OpaqueResponse && resp.Internal != nil {
+		resp = resp.Internal
+		if e.muted == nil {
+			e.muted = map[*spec.Node]bool{}
+		}
+		e.muted[element] = true
+	}
+	if !resp.OK() {
+		return "", fmt.Errorf("%w: %s: %d", ErrScriptFetch, u.Href(), resp.Status)
+	}
+	return string(resp.Body), nil
+}
+
+// ExecuteScript runs the script with the browsing context's ScriptHost and
+// reports what it throws.
+func (e *scriptExecutor) ExecuteScript(script *spec.Script) {
+	doc := script.Element.OwnerHTMLDocument()
+	script.MutedErrors = e.muted[script.Element]
+	delete(e.muted, script.Element)
+	if err := e.bc.ScriptHost.RunScript(doc, script); err != nil {
+		if script.MutedErrors {
+			err = ErrMutedScript
+		}
+		e.bc.ScriptHost.ReportError(doc, err)
+	}
+}
@@ -0,0 +1,89 @@
+package browser
+
+import "github.com/heathj/gobrowse/parser/spec"
+
+// SessionHistoryEntry is https://html.spec.whatwg.org/multipage/browsing-the-web.html#session-history-entry
+// Entries made by fragment navigations and history.pushState share the
+// document of the entry they were made from.
+type SessionHistoryEntry struct {
+	URL      *spec.URL
+	Document *spec.HTMLDocument
+	// State is the state given to history.pushState or history.replaceState.
+	State interface{}
+
+	resource *resource
+}
+
+// SessionHistory returns the entries of the session history, oldest first.
+func (bc *BrowsingContext) SessionHistory() []*SessionHistoryEntry { return bc.history }
+
+// CurrentEntry is https://html.spec.whatwg.org/multipage/document-sequences.html#nav-current-history-entry
+func (bc *BrowsingContext) CurrentEntry() *SessionHistoryEntry { return bc.history[bc.current] }
+
+// SessionHistoryLength is the number of entries in the session history.
+func (bc *BrowsingContext) SessionHistoryLength() int { return len(bc.history) }
+
+// State is the state of the current session history entry.
+func (bc *BrowsingContext) State() interface{} { return bc.history[bc.current].State }
+
+// Back moves one entry back in the session history.
+func (bc *BrowsingContext) Back() error { return bc.Traverse(-1) }
+
+// Forward moves one entry forward in the session history.
+func (bc *BrowsingContext) Forward() error { return bc.Traverse(1) }
+
+// addEntry makes the entry the current one. It replaces the current entry
+// when replace is set and otherwise the entries after the current one are
+// removed before it's appended.
+func (bc *BrowsingContext) addEntry(entry *SessionHistoryEntry, replace bool) {
+	if replace {
+		bc.history[bc.current] = entry
+		return
+	}
+	bc.history = append(bc.history[:bc.current+1], entry)
+	bc.current++
+}
+
+// Traverse is https://html.spec.whatwg.org/multipage/browsing-the-web.html#traverse-the-history-by-a-delta
+// Deltas past either end of the session history do nothing. Documents are
+// kept in the session history so traversing to an entry doesn't fetch its
+// document again.
+func (bc *BrowsingContext) Traverse(delta int) error {
+	target := bc.current + delta
+	if delta == 0 || target < 0 || target >= len(bc.history) {
+		return nil
+	}
+	entry := bc.history[target]
+	bc.current = target
+	if entry.Document != bc.document {
+		bc.document = entry.Document
+		bc.document.Document.SetURL(entry.URL)
+		return nil
+	}
+
+	// https://html.spec.whatwg.org/multipage/browsing-the-web.html#update-document-for-history-step-application
+	oldURL := bc.document.URLRecord()
+	bc.document.Document.SetURL(entry.URL)
+	fireEvent(bc.document, "popstate")
+	if oldURL.Hash() != entry.URL.Hash() {
+		queueEvent(bc.document, "hashchange")
+	}
+	return nil
+}
+
+// UpdateHistory is https://html.spec.whatwg.org/multipage/browsing-the-web.html#url-and-history-update-steps
+func (bc *BrowsingContext) UpdateHistory(u *spec.URL, state interface{}, replace bool) {
+	current := bc.history[bc.current]
+	bc.addEntry(&SessionHistoryEntry{URL: u, Document: bc.document, State: state, resource: current.resource}, replace)
+	bc.document.Document.SetURL(u)
+}
+
+// navigateToFragment is https://html.spec.whatwg.org/multipage/browsing-the-web.html#navigate-fragid
+// Documents aren't scrolled to the fragment.
+func (bc *BrowsingContext) navigateToFragment(u *spec.URL, replace bool) {
+	oldURL := bc.document.URLRecord()
+	bc.UpdateHistory(u, nil, replace)
+	if oldURL.Hash() != u.Hash() {
+		queueEvent(bc.document, "hashchange")
+	}
+}
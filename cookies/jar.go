@@ -0,0 +1,397 @@
+// Package cookies is a cookie store implementing
+// https://datatracker.ietf.org/doc/html/draft-ietf-httpbis-rfc6265bis. The
+// fetch layer and document.cookie share a Jar.
+package cookies
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/heathj/gobrowse/parser/spec"
+)
+
+// Cookie is a stored cookie.
+// https://datatracker.ietf.org/doc/html/draft-ietf-httpbis-rfc6265bis#section-5.7
+type Cookie struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Domain string `json:"domain"`
+	Path   string `json:"path"`
+	// Expires is zero for session cookies.
+	Expires      time.Time `json:"expires,omitempty"`
+	Created      time.Time `json:"created"`
+	LastAccessed time.Time `json:"lastAccessed"`
+	HostOnly     bool      `json:"hostOnly,omitempty"`
+	Secure       bool      `json:"secure,omitempty"`
+	HTTPOnly     bool      `json:"httpOnly,omitempty"`
+	SameSite     SameSite  `json:"sameSite"`
+}
+
+func (c *Cookie) expired(now time.Time) bool {
+	return !c.Expires.IsZero() && !c.Expires.After(now)
+}
+
+// String is the cookie's name-value pair as it's sent in a Cookie header.
+func (c *Cookie) String() string {
+	if c.Name == "" {
+		return c.Value
+	}
+	return c.Name + "=" + c.Value
+}
+
+// Source describes the request or API cookies are stored or retrieved for.
+// The zero value is a same-site HTTP request.
+type Source struct {
+	// NonHTTP is set for APIs like document.cookie, which can't read or
+	// write HttpOnly cookies.
+	NonHTTP bool
+	// CrossSite is set when the request isn't same-site with the site that
+	// made it.
+	CrossSite bool
+	// TopLevelNavigation is set for requests navigating a top-level
+	// browsing context.
+	TopLevelNavigation bool
+	// Method is the request's method.
+	Method string
+}
+
+// Jar stores cookies. It's safe to use from several goroutines.
+type Jar struct {
+	// PublicSuffixes decides which domains cookies can't be set for.
+	PublicSuffixes *PublicSuffixList
+	// Now returns the current time.
+	Now func() time.Time
+
+	mu      sync.Mutex
+	cookies []*Cookie
+}
+
+// NewJar creates an empty jar using DefaultPublicSuffixList, which is only a
+// subset of the public suffixes.
+func NewJar() *Jar {
+	return NewJarWithPublicSuffixes(DefaultPublicSuffixList)
+}
+
+// NewJarWithPublicSuffixes creates an empty jar using the public suffix list,
+// usually the full list loaded with ParsePublicSuffixList.
+func NewJarWithPublicSuffixes(list *PublicSuffixList) *Jar {
+	return &Jar{PublicSuffixes: list, Now: time.Now}
+}
+
+// https://datatracker.ietf.org/doc/html/draft-ietf-httpbis-rfc6265bis#section-5.1.3
+func domainMatch(host, domain string) bool {
+	if host == domain {
+		return true
+	}
+	return strings.HasSuffix(host, "."+domain) && net.ParseIP(strings.Trim(host, "[]")) == nil
+}
+
+// https://datatracker.ietf.org/doc/html/draft-ietf-httpbis-rfc6265bis#section-5.1.4
+func defaultPath(u *spec.URL) string {
+	path := u.Pathname()
+	if !strings.HasPrefix(path, "/") || strings.Count(path, "/") == 1 {
+		return "/"
+	}
+	return path[:strings.LastIndexByte(path, '/')]
+}
+
+// https://datatracker.ietf.org/doc/html/draft-ietf-httpbis-rfc6265bis#section-5.1.4
+func pathMatch(requestPath, cookiePath string) bool {
+	if requestPath == cookiePath {
+		return true
+	}
+	return strings.HasPrefix(requestPath, cookiePath) &&
+		(strings.HasSuffix(cookiePath, "/") || requestPath[len(cookiePath)] == '/')
+}
+
+// isSecure reports if a URL's scheme is secure for cookies.
+func isSecure(u *spec.URL) bool {
+	return u.Scheme() == "https" || u.Scheme() == "wss" || (u.Scheme() == "http" && u.IsPotentiallyTrustworthy())
+}
+
+// https://httpwg.org/specs/rfc9110.html#safe.methods
+func isSafeMethod(method string) bool {
+	switch method {
+	case "", "GET", "HEAD", "OPTIONS", "TRACE":
+		return true
+	}
+	return false
+}
+
+// SetCookies stores the cookies of the Set-Cookie header values of a response
+// for u.
+func (j *Jar) SetCookies(u *spec.URL, headers []string, src Source) {
+	for _, header := range headers {
+		j.SetCookie(u, header, src)
+	}
+}
+
+// SetCookie stores the cookie of a Set-Cookie header value and reports if it
+// was stored.
+// https://datatracker.ietf.org/doc/html/draft-ietf-httpbis-rfc6265bis#section-5.7
+func (j *Jar) SetCookie(u *spec.URL, header string, src Source) bool {
+	parsed, ok := parseSetCookie(header)
+	if !ok {
+		return false
+	}
+	host := u.Hostname()
+	if host == "" {
+		return false
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := j.Now()
+	c := &Cookie{Name: parsed.name, Value: parsed.value, Created: now, LastAccessed: now, SameSite: parsed.sameSite}
+	switch {
+	case parsed.maxAge != nil && *parsed.maxAge <= 0:
+		c.Expires = time.Unix(0, 0).UTC()
+	case parsed.maxAge != nil:
+		c.Expires = now.Add(*parsed.maxAge)
+	case !parsed.expires.IsZero():
+		c.Expires = parsed.expires
+		if c.Expires.After(now.Add(maxAge)) {
+			c.Expires = now.Add(maxAge)
+		}
+	}
+
+	domain := ""
+	if parsed.domain != nil {
+		domain = *parsed.domain
+	}
+	if domain != "" && j.PublicSuffixes != nil && j.PublicSuffixes.IsPublicSuffix(domain) {
+		if domain != host {
+			return false
+		}
+		domain = ""
+	}
+	if domain != "" {
+		if !domainMatch(host, domain) {
+			return false
+		}
+		c.Domain = domain
+	} else {
+		c.HostOnly, c.Domain = true, host
+	}
+
+	c.Path = parsed.path
+	if !strings.HasPrefix(c.Path, "/") {
+		c.Path = defaultPath(u)
+	}
+	c.Secure, c.HTTPOnly = parsed.secure, parsed.httpOnly
+	secure := isSecure(u)
+	if (c.Secure && !secure) || (c.HTTPOnly && src.NonHTTP) {
+		return false
+	}
+	if !c.Secure && !secure {
+		// insecure origins can't overwrite secure cookies.
+		for _, old := range j.cookies {
+			if old.Secure && old.Name == c.Name && (domainMatch(old.Domain, c.Domain) || domainMatch(c.Domain, old.Domain)) && pathMatch(c.Path, old.Path) {
+				return false
+			}
+		}
+	}
+	if c.SameSite == SameSiteNone && !c.Secure {
+		return false
+	}
+	if c.SameSite != SameSiteNone && src.CrossSite && !src.TopLevelNavigation {
+		return false
+	}
+
+	// https://datatracker.ietf.org/doc/html/draft-ietf-httpbis-rfc6265bis#section-4.1.3
+	name := strings.ToLower(c.Name)
+	if c.Name == "" {
+		value := strings.ToLower(c.Value)
+		if strings.HasPrefix(value, "__secure-") || strings.HasPrefix(value, "__host-") {
+			return false
+		}
+	}
+	if strings.HasPrefix(name, "__secure-") && !c.Secure {
+		return false
+	}
+	if strings.HasPrefix(name, "__host-") && (!c.Secure || !c.HostOnly || c.Path != "/") {
+		return false
+	}
+
+	for i, old := range j.cookies {
+		if old.Name == c.Name && old.Domain == c.Domain && old.HostOnly == c.HostOnly && old.Path == c.Path {
+			if old.HTTPOnly && src.NonHTTP {
+				return false
+			}
+			c.Created = old.Created
+			j.cookies = append(j.cookies[:i], j.cookies[i+1:]...)
+			break
+		}
+	}
+	if c.expired(now) {
+		return false
+	}
+	j.cookies = append(j.cookies, c)
+	return true
+}
+
+// Cookies returns copies of the cookies sent with a request for u, in the
+// order of the Cookie header.
+// https://datatracker.ietf.org/doc/html/draft-ietf-httpbis-rfc6265bis#section-5.8.3
+func (j *Jar) Cookies(u *spec.URL, src Source) []Cookie {
+	host, path := u.Hostname(), u.Pathname()
+	if path == "" {
+		path = "/"
+	}
+	secure := isSecure(u)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := j.Now()
+	j.removeExpired(now)
+	matched := []*Cookie{}
+	for _, c := range j.cookies {
+		if (c.HostOnly && host != c.Domain) || (!c.HostOnly && !domainMatch(host, c.Domain)) ||
+			!pathMatch(path, c.Path) || (c.Secure && !secure) || (c.HTTPOnly && src.NonHTTP) {
+			continue
+		}
+		if src.CrossSite && c.SameSite != SameSiteNone {
+			if c.SameSite == SameSiteStrict || !src.TopLevelNavigation || !isSafeMethod(src.Method) {
+				continue
+			}
+		}
+		matched = append(matched, c)
+	}
+	sort.SliceStable(matched, func(a, b int) bool {
+		if len(matched[a].Path) != len(matched[b].Path) {
+			return len(matched[a].Path) > len(matched[b].Path)
+		}
+		return matched[a].Created.Before(matched[b].Created)
+	})
+
+	cookies := make([]Cookie, len(matched))
+	for i, c := range matched {
+		c.LastAccessed = now
+		cookies[i] = *c
+	}
+	return cookies
+}
+
+// CookieString is the Cookie header value for a request for u.
+// https://datatracker.ietf.org/doc/html/draft-ietf-httpbis-rfc6265bis#section-5.8.3
+func (j *Jar) CookieString(u *spec.URL, src Source) string {
+	pairs := []string{}
+	for _, c := range j.Cookies(u, src) {
+		pairs = append(pairs, c.String())
+	}
+	return strings.Join(pairs, "; ")
+}
+
+// DocumentCookie returns document.cookie for a document at u.
+func (j *Jar) DocumentCookie(u *spec.URL) string {
+	return j.CookieString(u, Source{NonHTTP: true})
+}
+
+// SetDocumentCookie stores a cookie set with document.cookie for a document
+// at u.
+func (j *Jar) SetDocumentCookie(u *spec.URL, cookie string) {
+	j.SetCookie(u, cookie, Source{NonHTTP: true})
+}
+
+// All returns copies of the stored cookies that haven't expired.
+func (j *Jar) All() []Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.removeExpired(j.Now())
+	cookies := make([]Cookie, len(j.cookies))
+	for i, c := range j.cookies {
+		cookies[i] = *c
+	}
+	return cookies
+}
+
+func (j *Jar) removeExpired(now time.Time) {
+	cookies := j.cookies[:0]
+	for _, c := range j.cookies {
+		if !c.expired(now) {
+			cookies = append(cookies, c)
+		}
+	}
+	j.cookies = cookies
+}
+
+// SameSite reports if two URLs are same-site.
+// https://html.spec.whatwg.org/multipage/browsers.html#same-site
+func (j *Jar) SameSite(a, b *spec.URL) bool {
+	if a.Origin() == "null" || b.Origin() == "null" || a.Scheme() != b.Scheme() {
+		return false
+	}
+	site := func(host string) string {
+		if j.PublicSuffixes == nil || net.ParseIP(strings.Trim(host, "[]")) != nil {
+			return host
+		}
+		if domain := j.PublicSuffixes.RegistrableDomain(host); domain != "" {
+			return domain
+		}
+		return host
+	}
+	return site(a.Hostname()) == site(b.Hostname())
+}
+
+// Save writes the cookies that haven't expired, session cookies included, as
+// JSON so a later session can Load them.
+func (j *Jar) Save(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(j.All())
+}
+
+// Load adds the cookies written by Save, replacing stored cookies with the
+// same name, domain and path.
+func (j *Jar) Load(r io.Reader) error {
+	var loaded []*Cookie
+	if err := json.NewDecoder(r).Decode(&loaded); err != nil {
+		return err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, c := range loaded {
+		for i, old := range j.cookies {
+			if old.Name == c.Name && old.Domain == c.Domain && old.HostOnly == c.HostOnly && old.Path == c.Path {
+				j.cookies = append(j.cookies[:i], j.cookies[i+1:]...)
+				break
+			}
+		}
+		j.cookies = append(j.cookies, c)
+	}
+	j.removeExpired(j.Now())
+	return nil
+}
+
+// SaveFile saves the jar to a file, replacing it if it exists.
+func (j *Jar) SaveFile(name string) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := j.Save(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadFile loads a file written by SaveFile. A file that doesn't exist leaves
+// the jar unchanged so a new session can be started with the same name.
+func (j *Jar) LoadFile(name string) error {
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return j.Load(f)
+}
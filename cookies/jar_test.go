@@ -0,0 +1,221 @@
+package cookies
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/heathj/gobrowse/parser/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func mustParseURL(t *testing.T, s string) *spec.URL {
+	u, err := spec.ParseURL(s, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func newTestJar() (*Jar, *time.Time) {
+	now := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	j := NewJar()
+	j.Now = func() time.Time { return now }
+	return j, &now
+}
+
+func TestParseSetCookie(t *testing.T) {
+	c, ok := parseSetCookie(" a = b c ; Path=/p; DOMAIN=.Example.COM; Secure; HttpOnly; SameSite=strict; Max-Age=60; unknown")
+	if assert.True(t, ok) {
+		assert.Equal(t, "a", c.name)
+		assert.Equal(t, "b c", c.value)
+		assert.Equal(t, "/p", c.path)
+		assert.Equal(t, "example.com", *c.domain)
+		assert.True(t, c.secure)
+		assert.True(t, c.httpOnly)
+		assert.Equal(t, SameSiteStrict, c.sameSite)
+		assert.Equal(t, time.Minute, *c.maxAge)
+	}
+
+	c, ok = parseSetCookie("novalue; SameSite=bogus; Max-Age=1x; Max-Age=99999999999999999999")
+	if assert.True(t, ok) {
+		assert.Equal(t, "", c.name)
+		assert.Equal(t, "novalue", c.value)
+		assert.Equal(t, SameSiteDefault, c.sameSite)
+		assert.Equal(t, maxAge, *c.maxAge)
+	}
+
+	for _, header := range []string{"", "=", "a=b\x00", "a=" + strings.Repeat("x", 4096)} {
+		_, ok := parseSetCookie(header)
+		assert.False(t, ok, header)
+	}
+}
+
+func TestParseCookieDate(t *testing.T) {
+	for _, tt := range []struct {
+		value    string
+		expected time.Time
+	}{
+		{"Wed, 21 Oct 2015 07:28:00 GMT", time.Date(2015, time.October, 21, 7, 28, 0, 0, time.UTC)},
+		{"Sunday, 06-Nov-94 08:49:37 GMT", time.Date(1994, time.November, 6, 8, 49, 37, 0, time.UTC)},
+		{"Sun Nov  6 8:49:37 1994", time.Date(1994, time.November, 6, 8, 49, 37, 0, time.UTC)},
+		{"1 january 30 0:0:0", time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)},
+	} {
+		date, ok := parseCookieDate(tt.value)
+		assert.True(t, ok, tt.value)
+		assert.Equal(t, tt.expected, date, tt.value)
+	}
+	for _, value := range []string{"", "Wed, 21 Oct 2015", "31 Feb 2015 00:00:00", "21 Oct 1600 00:00:00", "21 Oct 2015 24:00:00"} {
+		_, ok := parseCookieDate(value)
+		assert.False(t, ok, value)
+	}
+}
+
+func TestPublicSuffixList(t *testing.T) {
+	l := DefaultPublicSuffixList
+	for _, tt := range []struct{ domain, suffix, registrable string }{
+		{"example.com", "com", "example.com"},
+		{"a.b.example.co.uk", "co.uk", "example.co.uk"},
+		{"user.github.io", "github.io", "user.github.io"},
+		{"a.b.ck", "b.ck", "a.b.ck"},
+		{"www.ck", "ck", "www.ck"},
+		{"co.uk", "co.uk", ""},
+		{"example.unknowntld", "unknowntld", "example.unknowntld"},
+	} {
+		assert.Equal(t, tt.suffix, l.PublicSuffix(tt.domain), tt.domain)
+		assert.Equal(t, tt.registrable, l.RegistrableDomain(tt.domain), tt.domain)
+	}
+
+	custom, err := ParsePublicSuffixList(strings.NewReader("// comment\nexample\n*.wild.example\n"))
+	assert.NoError(t, err)
+	assert.True(t, custom.IsPublicSuffix("x.wild.example"))
+	assert.False(t, custom.IsPublicSuffix("wild.example"))
+
+	u := mustParseURL(t, "https://a.b.wild.example/")
+	assert.True(t, NewJar().SetCookie(u, "c=1; Domain=b.wild.example", Source{}), "the bundled list doesn't know the suffix")
+	assert.False(t, NewJarWithPublicSuffixes(custom).SetCookie(u, "c=1; Domain=b.wild.example", Source{}))
+}
+
+func TestStorageModel(t *testing.T) {
+	j, now := newTestJar()
+	u := mustParseURL(t, "https://www.example.com/dir/page")
+	for _, tt := range []struct {
+		header string
+		stored bool
+	}{
+		{"host=1", true},
+		{"domain=1; Domain=example.com", true},
+		{"other=1; Domain=other.com", false},
+		{"suffix=1; Domain=com", false},
+		{"path=1; Path=/dir/sub", true},
+		{"none=1; SameSite=None", false},
+		{"none=2; SameSite=None; Secure", true},
+		{"__Secure-a=1", false},
+		{"__Secure-a=1; Secure", true},
+		{"__Host-a=1; Secure; Path=/; Domain=example.com", false},
+		{"__host-a=1; Secure", false},
+		{"__Host-a=1; Secure; Path=/", true},
+		{"=__Host-a", false},
+	} {
+		assert.Equal(t, tt.stored, j.SetCookie(u, tt.header, Source{}), tt.header)
+	}
+
+	cookies := j.Cookies(mustParseURL(t, "https://www.example.com/dir/sub/x"), Source{})
+	names := []string{}
+	for _, c := range cookies {
+		names = append(names, c.Name)
+	}
+	assert.Equal(t, []string{"path", "host", "domain", "none", "__Secure-a", "__Host-a"}, names)
+	assert.Equal(t, "/dir", cookies[1].Path)
+	assert.True(t, cookies[1].HostOnly)
+	assert.Equal(t, "domain=1", j.CookieString(mustParseURL(t, "https://example.com/dir"), Source{}))
+	assert.Equal(t, "", j.CookieString(mustParseURL(t, "https://example.com/"), Source{}))
+	assert.Equal(t, "domain=1", j.CookieString(mustParseURL(t, "http://a.example.com/dir/x"), Source{}))
+
+	assert.False(t, j.SetCookie(mustParseURL(t, "http://www.example.com/dir/"), "__Secure-a=2", Source{}))
+	assert.False(t, j.SetCookie(mustParseURL(t, "http://www.example.com/dir/"), "none=3", Source{}))
+	assert.True(t, j.SetCookie(mustParseURL(t, "http://localhost/"), "local=1; Secure", Source{}))
+
+	created := cookies[1].Created
+	*now = now.Add(time.Hour)
+	assert.True(t, j.SetCookie(u, "host=2; Max-Age=60", Source{}))
+	assert.Contains(t, j.CookieString(mustParseURL(t, "https://www.example.com/dir/"), Source{}), "host=2")
+	for _, c := range j.All() {
+		if c.Name == "host" {
+			assert.Equal(t, created, c.Created)
+		}
+	}
+	*now = now.Add(time.Minute)
+	assert.NotContains(t, j.CookieString(mustParseURL(t, "https://www.example.com/dir/"), Source{}), "host=")
+
+	assert.True(t, j.SetCookie(u, "domain=2; Domain=example.com; Expires=Wed, 21 Oct 2099 07:28:00 GMT", Source{}))
+	for _, c := range j.All() {
+		if c.Name == "domain" {
+			assert.Equal(t, now.Add(maxAge), c.Expires)
+		}
+	}
+	assert.False(t, j.SetCookie(u, "domain=3; Domain=example.com; Max-Age=0", Source{}))
+	assert.Equal(t, "", j.CookieString(mustParseURL(t, "https://example.com/dir"), Source{}))
+
+	ip := mustParseURL(t, "http://127.0.0.1/")
+	assert.True(t, j.SetCookie(ip, "ip=1", Source{}))
+	assert.False(t, j.SetCookie(ip, "ip=1; Domain=0.0.1", Source{}))
+}
+
+func TestHTTPOnlyAndSameSite(t *testing.T) {
+	j, _ := newTestJar()
+	u := mustParseURL(t, "https://example.com/")
+	j.SetCookie(u, "http=1; HttpOnly", Source{})
+	j.SetCookie(u, "lax=1; SameSite=Lax", Source{})
+	j.SetCookie(u, "strict=1; SameSite=Strict", Source{})
+	j.SetCookie(u, "none=1; SameSite=None; Secure", Source{})
+
+	assert.False(t, j.SetCookie(u, "http=2", Source{NonHTTP: true}))
+	assert.False(t, j.SetCookie(u, "script=1; HttpOnly", Source{NonHTTP: true}))
+	assert.Equal(t, "lax=1; strict=1; none=1", j.DocumentCookie(u))
+	j.SetDocumentCookie(u, "script=1")
+	assert.Equal(t, "lax=1; strict=1; none=1; script=1", j.DocumentCookie(u))
+
+	assert.Equal(t, "none=1", j.CookieString(u, Source{CrossSite: true}))
+	assert.Equal(t, "http=1; lax=1; none=1; script=1", j.CookieString(u, Source{CrossSite: true, TopLevelNavigation: true, Method: "GET"}))
+	assert.Equal(t, "none=1", j.CookieString(u, Source{CrossSite: true, TopLevelNavigation: true, Method: "POST"}))
+
+	assert.False(t, j.SetCookie(u, "cross=1", Source{CrossSite: true}))
+	assert.True(t, j.SetCookie(u, "cross=1; SameSite=None; Secure", Source{CrossSite: true}))
+	assert.True(t, j.SetCookie(u, "nav=1", Source{CrossSite: true, TopLevelNavigation: true}))
+
+	assert.True(t, j.SameSite(u, mustParseURL(t, "https://a.b.example.com/x")))
+	assert.False(t, j.SameSite(u, mustParseURL(t, "http://example.com/")))
+	assert.False(t, j.SameSite(mustParseURL(t, "https://a.github.io/"), mustParseURL(t, "https://b.github.io/")))
+	assert.False(t, j.SameSite(u, mustParseURL(t, "data:,x")))
+}
+
+func TestSaveLoad(t *testing.T) {
+	j, now := newTestJar()
+	u := mustParseURL(t, "https://example.com/")
+	j.SetCookie(u, "session=1", Source{})
+	j.SetCookie(u, "persistent=1; Max-Age=3600; HttpOnly", Source{})
+	j.SetCookie(u, "short=1; Max-Age=60", Source{})
+
+	var buf bytes.Buffer
+	assert.NoError(t, j.Save(&buf))
+
+	loaded, _ := newTestJar()
+	*now = now.Add(2 * time.Minute)
+	loaded.Now = j.Now
+	assert.NoError(t, loaded.Load(&buf))
+	assert.Equal(t, j.All(), loaded.All())
+	assert.Equal(t, "session=1; persistent=1", loaded.CookieString(u, Source{}))
+
+	assert.Error(t, loaded.Load(strings.NewReader("not json")))
+
+	name := filepath.Join(t.TempDir(), "cookies.json")
+	assert.NoError(t, loaded.LoadFile(name))
+	assert.NoError(t, j.SaveFile(name))
+	fromFile, _ := newTestJar()
+	fromFile.Now = j.Now
+	assert.NoError(t, fromFile.LoadFile(name))
+	assert.Equal(t, "session=1; persistent=1", fromFile.CookieString(u, Source{}))
+}
@@ -0,0 +1,197 @@
+package cookies
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SameSite is the value of the SameSite attribute.
+// https://datatracker.ietf.org/doc/html/draft-ietf-httpbis-rfc6265bis#section-4.1.2.7
+type SameSite string
+
+const (
+	// SameSiteDefault is used when the attribute is missing or invalid. It's
+	// enforced like Lax.
+	SameSiteDefault SameSite = "Default"
+	SameSiteNone    SameSite = "None"
+	SameSiteLax     SameSite = "Lax"
+	SameSiteStrict  SameSite = "Strict"
+)
+
+// maxAge is the longest a cookie can live.
+// https://datatracker.ietf.org/doc/html/draft-ietf-httpbis-rfc6265bis#section-5.6.1
+const maxAge = 400 * 24 * time.Hour
+
+// setCookie is a parsed Set-Cookie header value. The attributes are the
+// values of the last occurrence of each.
+type setCookie struct {
+	name, value string
+	expires     time.Time
+	maxAge      *time.Duration
+	domain      *string
+	path        string
+	secure      bool
+	httpOnly    bool
+	sameSite    SameSite
+}
+
+// parseSetCookie is https://datatracker.ietf.org/doc/html/draft-ietf-httpbis-rfc6265bis#section-5.6
+// It reports false for a value the cookie should be ignored for.
+func parseSetCookie(header string) (*setCookie, bool) {
+	for _, c := range header {
+		if (c < 0x20 && c != '\t') || c == 0x7F {
+			return nil, false
+		}
+	}
+	nameValue, unparsed := header, ""
+	if i := strings.IndexByte(header, ';'); i >= 0 {
+		nameValue, unparsed = header[:i], header[i:]
+	}
+	c := &setCookie{sameSite: SameSiteDefault}
+	if i := strings.IndexByte(nameValue, '='); i >= 0 {
+		c.name, c.value = nameValue[:i], nameValue[i+1:]
+	} else {
+		c.value = nameValue
+	}
+	c.name, c.value = strings.Trim(c.name, " \t"), strings.Trim(c.value, " \t")
+	if len(c.name)+len(c.value) > 4096 || (c.name == "" && c.value == "") {
+		return nil, false
+	}
+
+	for _, av := range strings.Split(unparsed, ";")[1:] {
+		name, value := av, ""
+		if i := strings.IndexByte(av, '='); i >= 0 {
+			name, value = av[:i], av[i+1:]
+		}
+		name, value = strings.Trim(name, " \t"), strings.Trim(value, " \t")
+		if len(value) > 1024 {
+			continue
+		}
+		switch strings.ToLower(name) {
+		case "expires":
+			if expires, ok := parseCookieDate(value); ok {
+				c.expires = expires
+			}
+		case "max-age":
+			if value == "" || !(value[0] == '-' || isDigit(value[0])) || strings.IndexFunc(value[1:], func(r rune) bool { return r < '0' || r > '9' }) != -1 {
+				continue
+			}
+			seconds, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				// the value is all digits so it's too big.
+				seconds = int64(maxAge / time.Second)
+			}
+			age := time.Duration(seconds) * time.Second
+			if seconds > int64(maxAge/time.Second) {
+				age = maxAge
+			}
+			c.maxAge = &age
+		case "domain":
+			if value == "" {
+				continue
+			}
+			domain := strings.ToLower(strings.TrimPrefix(value, "."))
+			c.domain = &domain
+		case "path":
+			c.path = value
+		case "secure":
+			c.secure = true
+		case "httponly":
+			c.httpOnly = true
+		case "samesite":
+			switch strings.ToLower(value) {
+			case "none":
+				c.sameSite = SameSiteNone
+			case "strict":
+				c.sameSite = SameSiteStrict
+			case "lax":
+				c.sameSite = SameSiteLax
+			default:
+				c.sameSite = SameSiteDefault
+			}
+		}
+	}
+	return c, true
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+// isCookieDateDelimiter is the delimiter production of
+// https://datatracker.ietf.org/doc/html/draft-ietf-httpbis-rfc6265bis#section-5.1.1
+func isCookieDateDelimiter(r rune) bool {
+	return r == 0x09 || (r >= 0x20 && r <= 0x2F) || (r >= 0x3B && r <= 0x40) ||
+		(r >= 0x5B && r <= 0x60) || (r >= 0x7B && r <= 0x7E)
+}
+
+// leadingDigits returns the number made of the 1 to max digits that start
+// token when they're followed by a non-digit or the end.
+func leadingDigits(token string, min, max int) (int, string, bool) {
+	n := 0
+	for n < len(token) && isDigit(token[n]) {
+		n++
+	}
+	if n < min || n > max {
+		return 0, "", false
+	}
+	v, _ := strconv.Atoi(token[:n])
+	return v, token[n:], true
+}
+
+var cookieMonths = map[string]time.Month{
+	"jan": time.January, "feb": time.February, "mar": time.March, "apr": time.April,
+	"may": time.May, "jun": time.June, "jul": time.July, "aug": time.August,
+	"sep": time.September, "oct": time.October, "nov": time.November, "dec": time.December,
+}
+
+// parseCookieDate is https://datatracker.ietf.org/doc/html/draft-ietf-httpbis-rfc6265bis#section-5.1.1
+func parseCookieDate(value string) (time.Time, bool) {
+	var hour, minute, second, day, year int
+	var month time.Month
+	foundTime, foundDay, foundMonth, foundYear := false, false, false, false
+	for _, token := range strings.FieldsFunc(value, isCookieDateDelimiter) {
+		if !foundTime {
+			if h, rest, ok := leadingDigits(token, 1, 2); ok && strings.HasPrefix(rest, ":") {
+				if m, rest, ok := leadingDigits(rest[1:], 1, 2); ok && strings.HasPrefix(rest, ":") {
+					if s, _, ok := leadingDigits(rest[1:], 1, 2); ok {
+						hour, minute, second, foundTime = h, m, s, true
+						continue
+					}
+				}
+			}
+		}
+		if !foundDay {
+			if d, _, ok := leadingDigits(token, 1, 2); ok {
+				day, foundDay = d, true
+				continue
+			}
+		}
+		if !foundMonth && len(token) >= 3 {
+			if m, ok := cookieMonths[strings.ToLower(token[:3])]; ok {
+				month, foundMonth = m, true
+				continue
+			}
+		}
+		if !foundYear {
+			if y, _, ok := leadingDigits(token, 2, 4); ok {
+				year, foundYear = y, true
+				continue
+			}
+		}
+	}
+	if year >= 70 && year <= 99 {
+		year += 1900
+	} else if year >= 0 && year <= 69 {
+		year += 2000
+	}
+	if !foundTime || !foundDay || !foundMonth || !foundYear ||
+		day < 1 || day > 31 || year < 1601 || hour > 23 || minute > 59 || second > 59 {
+		return time.Time{}, false
+	}
+	date := time.Date(year, month, day, hour, minute, second, 0, time.UTC)
+	if date.Day() != day {
+		// the day doesn't exist in the month.
+		return time.Time{}, false
+	}
+	return date, true
+}
@@ -0,0 +1,94 @@
+package cookies
+
+import (
+	"bufio"
+	_ "embed"
+	"io"
+	"strings"
+)
+
+//go:embed public_suffix_list.dat
+var bundledPublicSuffixList string
+
+// PublicSuffixList is https://publicsuffix.org/list/. Rules are looked up with
+// the algorithm at https://github.com/publicsuffix/list/wiki/Format.
+type PublicSuffixList struct {
+	rules, wildcards, exceptions map[string]bool
+}
+
+// DefaultPublicSuffixList is the bundled subset of the list with the
+// generic and country code TLDs, their common second level domains and some
+// popular hosting domains. Any other public suffix is treated as a
+// registrable domain, so a site under it can set cookies that every other
+// site under it receives and is same-site with them. Embedders should load
+// the full list from https://publicsuffix.org/list/public_suffix_list.dat with
+// ParsePublicSuffixList, keep it up to date and use it with
+// NewJarWithPublicSuffixes.
+var DefaultPublicSuffixList = mustParsePublicSuffixList(strings.NewReader(bundledPublicSuffixList))
+
+func mustParsePublicSuffixList(r io.Reader) *PublicSuffixList {
+	list, err := ParsePublicSuffixList(r)
+	if err != nil {
+		panic(err)
+	}
+	return list
+}
+
+// ParsePublicSuffixList reads a list in the public_suffix_list.dat format.
+func ParsePublicSuffixList(r io.Reader) (*PublicSuffixList, error) {
+	list := &PublicSuffixList{rules: map[string]bool{}, wildcards: map[string]bool{}, exceptions: map[string]bool{}}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+		// rules end at the first white space.
+		rule := strings.ToLower(strings.Fields(line)[0])
+		switch {
+		case strings.HasPrefix(rule, "!"):
+			list.exceptions[rule[1:]] = true
+		case strings.HasPrefix(rule, "*."):
+			list.wildcards[rule[2:]] = true
+		default:
+			list.rules[rule] = true
+		}
+	}
+	return list, scanner.Err()
+}
+
+// PublicSuffix returns the public suffix of a lowercase ASCII domain. Domains
+// no rule matches have their TLD as the public suffix.
+func (l *PublicSuffixList) PublicSuffix(domain string) string {
+	domain = strings.TrimSuffix(domain, ".")
+	labels := strings.Split(domain, ".")
+	// the longest matching rule is found first and exception rules are
+	// longer than the wildcard rules they're exceptions to.
+	for i := range labels {
+		suffix := strings.Join(labels[i:], ".")
+		if l.exceptions[suffix] {
+			return strings.Join(labels[i+1:], ".")
+		}
+		if l.rules[suffix] || (i+1 < len(labels) && l.wildcards[strings.Join(labels[i+1:], ".")]) {
+			return suffix
+		}
+	}
+	return labels[len(labels)-1]
+}
+
+// IsPublicSuffix reports if the domain is a public suffix.
+func (l *PublicSuffixList) IsPublicSuffix(domain string) bool {
+	return l.PublicSuffix(domain) == strings.TrimSuffix(domain, ".")
+}
+
+// RegistrableDomain is https://url.spec.whatwg.org/#host-registrable-domain
+// It's empty when the domain is a public suffix.
+func (l *PublicSuffixList) RegistrableDomain(domain string) string {
+	domain = strings.TrimSuffix(domain, ".")
+	suffix := l.PublicSuffix(domain)
+	if suffix == domain {
+		return ""
+	}
+	rest := strings.TrimSuffix(domain, "."+suffix)
+	return rest[strings.LastIndexByte(rest, '.')+1:] + "." + suffix
+}
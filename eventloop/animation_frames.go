@@ -0,0 +1,68 @@
+package eventloop
+
+import (
+	"sort"
+	"time"
+)
+
+// RequestAnimationFrame is https://html.spec.whatwg.org/multipage/imagebitmap-and-animations.html#dom-animationframeprovider-requestanimationframe
+// The callback runs at the next rendering update with the time of the frame.
+// It returns the callback's ID for CancelAnimationFrame.
+func (l *Loop) RequestAnimationFrame(callback func(now time.Duration)) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.animationFrameID++
+	l.animationFrames[l.animationFrameID] = callback
+	l.wake()
+	return l.animationFrameID
+}
+
+// CancelAnimationFrame is https://html.spec.whatwg.org/multipage/imagebitmap-and-animations.html#animationframeprovider-cancelanimationframe
+func (l *Loop) CancelAnimationFrame(id int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.animationFrames, id)
+}
+
+// nextFrame is when the next rendering update is due. The first one is due
+// right away.
+func (l *Loop) nextFrame() time.Time {
+	if l.lastFrame.IsZero() {
+		return l.timeOrigin
+	}
+	return l.lastFrame.Add(l.FrameInterval)
+}
+
+// updateTheRendering is the part of https://html.spec.whatwg.org/multipage/webappapis.html#update-the-rendering
+// that runs the animation frame callbacks, when there are some and a
+// rendering update is due. Callbacks requested by callbacks run at the next
+// one. It reports if the callbacks ran.
+func (l *Loop) updateTheRendering() bool {
+	now := l.clock.Now()
+	l.mu.Lock()
+	if len(l.animationFrames) == 0 || now.Before(l.nextFrame()) {
+		l.mu.Unlock()
+		return false
+	}
+	l.lastFrame = now
+	// https://html.spec.whatwg.org/multipage/imagebitmap-and-animations.html#run-the-animation-frame-callbacks
+	handles := make([]int, 0, len(l.animationFrames))
+	for id := range l.animationFrames {
+		handles = append(handles, id)
+	}
+	l.mu.Unlock()
+
+	sort.Ints(handles)
+	timestamp := now.Sub(l.timeOrigin)
+	for _, id := range handles {
+		l.mu.Lock()
+		callback, ok := l.animationFrames[id]
+		delete(l.animationFrames, id)
+		l.mu.Unlock()
+		if ok {
+			callback(timestamp)
+			l.PerformMicrotaskCheckpoint()
+		}
+	}
+	return true
+}
@@ -0,0 +1,269 @@
+// Package eventloop is the event loop of
+// https://html.spec.whatwg.org/multipage/webappapis.html#event-loops. Parsing,
+// fetching, scripts and event dispatch queue tasks and microtasks on a Loop,
+// which also runs the timers of setTimeout and setInterval and the callbacks
+// of requestAnimationFrame. The loop's Clock can be a ManualClock so tests
+// fast-forward through time deterministically.
+package eventloop
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/heathj/gobrowse/parser/spec"
+)
+
+// DefaultFrameInterval is the time between the rendering updates running
+// animation frame callbacks, which is a 60Hz display's.
+const DefaultFrameInterval = time.Second / 60
+
+// task is https://html.spec.whatwg.org/multipage/webappapis.html#concept-task
+type task struct {
+	source spec.TaskSource
+	steps  func()
+}
+
+// Loop is https://html.spec.whatwg.org/multipage/webappapis.html#event-loop
+// Tasks can be queued from any goroutine but the loop runs them, and their
+// microtasks, timers and animation frame callbacks, on the goroutine calling
+// Run, RunOnce, RunUntilIdle or Advance.
+type Loop struct {
+	// FrameInterval is the time between rendering updates.
+	FrameInterval time.Duration
+
+	clock Clock
+	// timeOrigin is https://w3c.github.io/hr-time/#dfn-time-origin
+	timeOrigin time.Time
+
+	mu sync.Mutex
+	// tasks holds the task queues of every task source in a single queue.
+	// Tasks are run in the order they were queued, which keeps the tasks of
+	// each task source in order.
+	tasks      []*task
+	microtasks []func()
+	// performingMicrotaskCheckpoint is https://html.spec.whatwg.org/multipage/webappapis.html#performing-a-microtask-checkpoint
+	performingMicrotaskCheckpoint bool
+	// currentTask is https://html.spec.whatwg.org/multipage/webappapis.html#currently-running-task
+	currentTask *task
+	inParallel  int
+	wakeup      chan struct{}
+
+	timers            map[int]*timer
+	timerID           int
+	timerSeq          uint64
+	timerNestingLevel int
+
+	// animationFrames is https://html.spec.whatwg.org/multipage/imagebitmap-and-animations.html#list-of-animation-frame-callbacks
+	animationFrames  map[int]func(now time.Duration)
+	animationFrameID int
+	lastFrame        time.Time
+}
+
+// NewLoop creates an event loop scheduling timers and animation frames
+// against the clock, which is SystemClock when nil.
+func NewLoop(clock Clock) *Loop {
+	if clock == nil {
+		clock = SystemClock
+	}
+	now := clock.Now()
+	return &Loop{
+		FrameInterval:   DefaultFrameInterval,
+		clock:           clock,
+		timeOrigin:      now,
+		wakeup:          make(chan struct{}, 1),
+		timers:          map[int]*timer{},
+		animationFrames: map[int]func(now time.Duration){},
+	}
+}
+
+// Now is https://w3c.github.io/hr-time/#dfn-current-high-resolution-time
+// It's the time since the loop was created.
+func (l *Loop) Now() time.Duration { return l.clock.Now().Sub(l.timeOrigin) }
+
+// QueueTask is https://html.spec.whatwg.org/multipage/webappapis.html#queue-a-task
+// It's safe to call from any goroutine.
+func (l *Loop) QueueTask(source spec.TaskSource, steps func()) {
+	l.mu.Lock()
+	l.tasks = append(l.tasks, &task{source: source, steps: steps})
+	l.mu.Unlock()
+	l.wake()
+}
+
+// QueueMicrotask is https://html.spec.whatwg.org/multipage/webappapis.html#queue-a-microtask
+func (l *Loop) QueueMicrotask(steps func()) {
+	l.mu.Lock()
+	l.microtasks = append(l.microtasks, steps)
+	l.mu.Unlock()
+}
+
+// InParallel runs the steps on another goroutine. Run doesn't return until
+// they're done.
+// https://html.spec.whatwg.org/multipage/infrastructure.html#in-parallel
+func (l *Loop) InParallel(steps func()) {
+	l.mu.Lock()
+	l.inParallel++
+	l.mu.Unlock()
+	go func() {
+		defer func() {
+			l.mu.Lock()
+			l.inParallel--
+			l.mu.Unlock()
+			l.wake()
+		}()
+		steps()
+	}()
+}
+
+// CurrentTaskSource is the task source of the currently running task. It's
+// empty outside of tasks.
+func (l *Loop) CurrentTaskSource() spec.TaskSource {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.currentTask == nil {
+		return ""
+	}
+	return l.currentTask.source
+}
+
+// wake tells a waiting Run there's new work.
+func (l *Loop) wake() {
+	select {
+	case l.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// PerformMicrotaskCheckpoint is https://html.spec.whatwg.org/multipage/webappapis.html#perform-a-microtask-checkpoint
+// Microtasks queued by microtasks run in the same checkpoint. It does nothing
+// when called from a microtask.
+func (l *Loop) PerformMicrotaskCheckpoint() {
+	l.mu.Lock()
+	if l.performingMicrotaskCheckpoint {
+		l.mu.Unlock()
+		return
+	}
+	l.performingMicrotaskCheckpoint = true
+	for len(l.microtasks) > 0 {
+		microtask := l.microtasks[0]
+		l.microtasks = l.microtasks[1:]
+		l.mu.Unlock()
+		microtask()
+		l.mu.Lock()
+	}
+	l.performingMicrotaskCheckpoint = false
+	l.mu.Unlock()
+}
+
+// RunOnce runs an iteration of https://html.spec.whatwg.org/multipage/webappapis.html#event-loop-processing-model:
+// the oldest task, if any, followed by a microtask checkpoint, and then the
+// animation frame callbacks if a rendering update is due. It reports if it
+// ran anything.
+func (l *Loop) RunOnce() bool {
+	l.queueDueTimers()
+	l.mu.Lock()
+	var t *task
+	if len(l.tasks) > 0 {
+		t = l.tasks[0]
+		l.tasks = l.tasks[1:]
+		l.currentTask = t
+	}
+	l.mu.Unlock()
+
+	if t != nil {
+		t.steps()
+		l.mu.Lock()
+		l.currentTask = nil
+		l.mu.Unlock()
+		l.PerformMicrotaskCheckpoint()
+	}
+	rendered := l.updateTheRendering()
+	return t != nil || rendered
+}
+
+// RunUntilIdle runs the loop until it has no tasks, no timers and no
+// rendering updates due at the clock's current time.
+func (l *Loop) RunUntilIdle() {
+	for l.RunOnce() {
+	}
+}
+
+// Run runs the loop, waiting for its timers and for tasks queued from other
+// goroutines, until it has nothing left to do or the context is done. A loop
+// with a ManualClock doesn't wait for its timers but moves the clock to them.
+func (l *Loop) Run(ctx context.Context) error {
+	for {
+		l.RunUntilIdle()
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		next, scheduled := l.nextWakeup()
+		l.mu.Lock()
+		waiting := l.inParallel > 0 || len(l.tasks) > 0
+		l.mu.Unlock()
+		if !scheduled && !waiting {
+			return nil
+		}
+
+		if clock, ok := l.clock.(*ManualClock); ok && scheduled && !waiting {
+			clock.Set(next)
+			continue
+		}
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if scheduled {
+			timer = time.NewTimer(next.Sub(l.clock.Now()))
+			timeout = timer.C
+		}
+		select {
+		case <-ctx.Done():
+		case <-l.wakeup:
+		case <-timeout:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// Advance moves the loop's ManualClock forward by d, stopping at each timer
+// and rendering update that's due on the way to run it, and then runs the
+// loop until it's idle. It panics if the loop's clock isn't a ManualClock.
+func (l *Loop) Advance(d time.Duration) {
+	clock, ok := l.clock.(*ManualClock)
+	if !ok {
+		panic("eventloop: Advance needs a ManualClock")
+	}
+	end := clock.Now().Add(d)
+	l.RunUntilIdle()
+	for {
+		next, ok := l.nextWakeup()
+		if !ok || next.After(end) || !next.After(clock.Now()) {
+			break
+		}
+		clock.Set(next)
+		l.RunUntilIdle()
+	}
+	clock.Set(end)
+	l.RunUntilIdle()
+}
+
+// nextWakeup returns the earliest time a timer or rendering update is due.
+func (l *Loop) nextWakeup() (time.Time, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var next time.Time
+	scheduled := false
+	for _, t := range l.timers {
+		if !scheduled || t.due.Before(next) {
+			next, scheduled = t.due, true
+		}
+	}
+	if len(l.animationFrames) > 0 {
+		frame := l.nextFrame()
+		if !scheduled || frame.Before(next) {
+			next, scheduled = frame, true
+		}
+	}
+	return next, scheduled
+}
@@ -0,0 +1,144 @@
+package eventloop
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/heathj/gobrowse/fetch"
+	"github.com/heathj/gobrowse/parser/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLoop() (*Loop, *ManualClock) {
+	clock := NewManualClock(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC))
+	return NewLoop(clock), clock
+}
+
+func TestTasksAndMicrotasks(t *testing.T) {
+	l, _ := newTestLoop()
+	log := []string{}
+	l.QueueTask(spec.DOMManipulationTaskSource, func() {
+		log = append(log, "task 1")
+		l.QueueMicrotask(func() {
+			log = append(log, "microtask 1")
+			l.QueueMicrotask(func() { log = append(log, "microtask 2") })
+			l.PerformMicrotaskCheckpoint()
+		})
+		l.QueueTask(spec.DOMManipulationTaskSource, func() { log = append(log, "task 3") })
+		assert.Equal(t, spec.DOMManipulationTaskSource, l.CurrentTaskSource())
+	})
+	l.QueueTask(spec.NetworkingTaskSource, func() { log = append(log, "task 2") })
+
+	assert.True(t, l.RunOnce())
+	assert.Equal(t, []string{"task 1", "microtask 1", "microtask 2"}, log)
+	l.RunUntilIdle()
+	assert.Equal(t, []string{"task 1", "microtask 1", "microtask 2", "task 2", "task 3"}, log)
+	assert.False(t, l.RunOnce())
+	assert.Equal(t, spec.TaskSource(""), l.CurrentTaskSource())
+}
+
+func TestTimers(t *testing.T) {
+	l, clock := newTestLoop()
+	log := []string{}
+	l.SetTimeout(func() { log = append(log, "b") }, 20*time.Millisecond)
+	l.SetTimeout(func() { log = append(log, "a") }, 10*time.Millisecond)
+	l.SetTimeout(func() { log = append(log, "c") }, 20*time.Millisecond)
+	cleared := l.SetTimeout(func() { log = append(log, "cleared") }, 5*time.Millisecond)
+	l.ClearTimeout(cleared)
+	l.SetTimeout(func() { log = append(log, "negative") }, -time.Second)
+
+	ticks := 0
+	var interval int
+	interval = l.SetInterval(func() {
+		ticks++
+		if ticks == 3 {
+			l.ClearInterval(interval)
+		}
+	}, 15*time.Millisecond)
+
+	l.RunUntilIdle()
+	assert.Equal(t, []string{"negative"}, log)
+	l.Advance(9 * time.Millisecond)
+	assert.Equal(t, []string{"negative"}, log)
+	l.Advance(11 * time.Millisecond)
+	assert.Equal(t, []string{"negative", "a", "b", "c"}, log)
+	assert.Equal(t, 1, ticks)
+	assert.Equal(t, 20*time.Millisecond, l.Now())
+
+	l.Advance(time.Second)
+	assert.Equal(t, 3, ticks)
+	assert.Equal(t, clock.Now(), l.timeOrigin.Add(time.Second+20*time.Millisecond))
+	_, scheduled := l.nextWakeup()
+	assert.False(t, scheduled)
+}
+
+func TestNestedTimersAreClamped(t *testing.T) {
+	l, _ := newTestLoop()
+	times := []time.Duration{}
+	var nest func()
+	nest = func() {
+		times = append(times, l.Now())
+		if len(times) < 8 {
+			l.SetTimeout(nest, 0)
+		}
+	}
+	l.SetTimeout(nest, 0)
+	l.Advance(time.Second)
+	ms := time.Millisecond
+	assert.Equal(t, []time.Duration{0, 0, 0, 0, 0, 0, 4 * ms, 8 * ms}, times)
+}
+
+func TestAnimationFrames(t *testing.T) {
+	l, _ := newTestLoop()
+	frames := []time.Duration{}
+	var frame func(now time.Duration)
+	frame = func(now time.Duration) {
+		frames = append(frames, now)
+		if len(frames) < 3 {
+			l.RequestAnimationFrame(frame)
+		}
+	}
+	l.RequestAnimationFrame(frame)
+	canceled := l.RequestAnimationFrame(func(time.Duration) { t.Error("canceled callback ran") })
+	l.CancelAnimationFrame(canceled)
+
+	l.RunUntilIdle()
+	assert.Equal(t, []time.Duration{0}, frames)
+	l.Advance(time.Second)
+	assert.Equal(t, []time.Duration{0, DefaultFrameInterval, 2 * DefaultFrameInterval}, frames)
+}
+
+func TestRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("body"))
+	}))
+	defer server.Close()
+	f := fetch.NewFetcher(server.Client().Transport)
+	req, _ := fetch.NewRequest(http.MethodGet, server.URL)
+
+	l, clock := newTestLoop()
+	start := clock.Now()
+	log := []string{}
+	f.FetchInParallel(context.Background(), req, l, func(resp *fetch.Response, err error) {
+		if assert.NoError(t, err) {
+			log = append(log, string(resp.Body))
+		}
+	})
+	l.SetTimeout(func() { log = append(log, "timeout") }, time.Hour)
+	assert.NoError(t, l.Run(context.Background()))
+	assert.Equal(t, []string{"body", "timeout"}, log)
+	assert.Equal(t, start.Add(time.Hour), clock.Now(), "the manual clock is moved to the timer")
+
+	real := NewLoop(nil)
+	real.SetTimeout(func() { log = append(log, "real") }, time.Millisecond)
+	assert.NoError(t, real.Run(context.Background()))
+	assert.Equal(t, "real", log[len(log)-1])
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	real.SetTimeout(func() {}, time.Hour)
+	assert.ErrorIs(t, real.Run(ctx), context.DeadlineExceeded)
+}
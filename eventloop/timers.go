@@ -0,0 +1,126 @@
+package eventloop
+
+import (
+	"sort"
+	"time"
+
+	"github.com/heathj/gobrowse/parser/spec"
+)
+
+// minNestedTimeout is the timeout deeply nested timers are clamped to.
+// https://html.spec.whatwg.org/multipage/timers-and-user-prompts.html#timer-initialisation-steps
+const minNestedTimeout = 4 * time.Millisecond
+
+// timer is an entry in https://html.spec.whatwg.org/multipage/timers-and-user-prompts.html#map-of-active-timers
+type timer struct {
+	id      int
+	handler func()
+	timeout time.Duration
+	repeat  bool
+	due     time.Time
+	// nestingLevel is https://html.spec.whatwg.org/multipage/timers-and-user-prompts.html#timer-nesting-level
+	nestingLevel int
+	// seq orders timers that are due at the same time by when they were
+	// scheduled.
+	seq    uint64
+	queued bool
+}
+
+// SetTimeout is https://html.spec.whatwg.org/multipage/timers-and-user-prompts.html#dom-settimeout
+// It returns the timer's ID for ClearTimeout.
+func (l *Loop) SetTimeout(handler func(), timeout time.Duration) int {
+	return l.initializeTimer(&timer{handler: handler, timeout: timeout})
+}
+
+// SetInterval is https://html.spec.whatwg.org/multipage/timers-and-user-prompts.html#dom-setinterval
+// It returns the timer's ID for ClearInterval.
+func (l *Loop) SetInterval(handler func(), timeout time.Duration) int {
+	return l.initializeTimer(&timer{handler: handler, timeout: timeout, repeat: true})
+}
+
+// ClearTimeout is https://html.spec.whatwg.org/multipage/timers-and-user-prompts.html#dom-cleartimeout
+func (l *Loop) ClearTimeout(id int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.timers, id)
+}
+
+// ClearInterval is https://html.spec.whatwg.org/multipage/timers-and-user-prompts.html#dom-clearinterval
+// Timeouts and intervals share their IDs so it's the same as ClearTimeout.
+func (l *Loop) ClearInterval(id int) { l.ClearTimeout(id) }
+
+// initializeTimer is https://html.spec.whatwg.org/multipage/timers-and-user-prompts.html#timer-initialisation-steps
+// Timers that repeat keep their ID.
+func (l *Loop) initializeTimer(t *timer) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if t.id == 0 {
+		l.timerID++
+		t.id = l.timerID
+	}
+	if t.timeout < 0 {
+		t.timeout = 0
+	}
+	timeout := t.timeout
+	if l.timerNestingLevel > 5 && timeout < minNestedTimeout {
+		timeout = minNestedTimeout
+	}
+	t.nestingLevel = l.timerNestingLevel + 1
+	t.due = l.clock.Now().Add(timeout)
+	l.timerSeq++
+	t.seq = l.timerSeq
+	t.queued = false
+	l.timers[t.id] = t
+	return t.id
+}
+
+// queueDueTimers queues the tasks of the timers that are due, in the order
+// they're due.
+func (l *Loop) queueDueTimers() {
+	now := l.clock.Now()
+	l.mu.Lock()
+	due := []*timer{}
+	for _, t := range l.timers {
+		if !t.queued && !t.due.After(now) {
+			due = append(due, t)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].due.Equal(due[j].due) {
+			return due[i].due.Before(due[j].due)
+		}
+		return due[i].seq < due[j].seq
+	})
+	for _, t := range due {
+		t := t
+		t.queued = true
+		l.tasks = append(l.tasks, &task{source: spec.TimerTaskSource, steps: func() { l.runTimer(t) }})
+	}
+	l.mu.Unlock()
+}
+
+// runTimer is the task of https://html.spec.whatwg.org/multipage/timers-and-user-prompts.html#timer-initialisation-steps
+func (l *Loop) runTimer(t *timer) {
+	l.mu.Lock()
+	if l.timers[t.id] != t {
+		l.mu.Unlock()
+		return
+	}
+	if !t.repeat {
+		delete(l.timers, t.id)
+	}
+	l.timerNestingLevel = t.nestingLevel
+	l.mu.Unlock()
+
+	t.handler()
+
+	l.mu.Lock()
+	active := l.timers[t.id] == t
+	l.mu.Unlock()
+	if t.repeat && active {
+		l.initializeTimer(t)
+	}
+	l.mu.Lock()
+	l.timerNestingLevel = 0
+	l.mu.Unlock()
+}
@@ -0,0 +1,371 @@
+// Package fetch is the networking layer of https://fetch.spec.whatwg.org/.
+// Documents, scripts, stylesheets and images are all loaded through a
+// Fetcher, which dispatches requests to a Transport for each URL scheme.
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/heathj/gobrowse/cookies"
+	"github.com/heathj/gobrowse/parser/spec"
+)
+
+// ErrNetwork is https://fetch.spec.whatwg.org/#concept-network-error
+// Fetches that fail return an error wrapping it.
+var ErrNetwork = errors.New("network error")
+
+func networkError(reason string) error {
+	return fmt.Errorf("%w: %s", ErrNetwork, reason)
+}
+
+// maxRedirects is the number of redirects a request follows before failing.
+// https://fetch.spec.whatwg.org/#http-redirect-fetch
+const maxRedirects = 20
+
+// Fetcher runs the fetch algorithm.
+type Fetcher struct {
+	// Transports maps URL schemes to the transport fetching them.
+	Transports map[string]Transport
+	// UserAgent is sent in the User-Agent header.
+	UserAgent string
+	// Cookies stores the cookies of responses and sends them with requests
+	// that include credentials. Cookies aren't used when it's nil.
+	Cookies *cookies.Jar
+}
+
+// NewFetcher creates a Fetcher making HTTP requests with rt, which is
+// http.DefaultTransport when nil, that also fetches data: and about:blank
+// URLs. Local files are only fetched once a FileTransport is added to its
+// Transports.
+func NewFetcher(rt http.RoundTripper) *Fetcher {
+	httpTransport := &HTTPTransport{RoundTripper: rt}
+	return &Fetcher{
+		Transports: map[string]Transport{
+			"http":  httpTransport,
+			"https": httpTransport,
+			"data":  DataTransport{},
+			"about": AboutTransport{},
+		},
+		UserAgent: "gobrowse",
+	}
+}
+
+// https://fetch.spec.whatwg.org/#fetching
+var defaultAccept = map[Destination]string{
+	DocumentDestination: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+	FrameDestination:    "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+	IframeDestination:   "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+	ImageDestination:    "image/png,image/svg+xml,image/*;q=0.8,*/*;q=0.5",
+	StyleDestination:    "text/css,*/*;q=0.1",
+}
+
+// Fetch fetches the request and returns its response, which is filtered
+// according to the request's response tainting. Network errors are returned
+// as errors wrapping ErrNetwork.
+// https://fetch.spec.whatwg.org/#concept-fetch
+func (f *Fetcher) Fetch(ctx context.Context, req *Request) (*Response, error) {
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	if req.Method == "" {
+		req.Method = http.MethodGet
+	}
+	if req.Header.Get("Accept") == "" {
+		accept, ok := defaultAccept[req.Destination]
+		if !ok {
+			accept = "*/*"
+		}
+		req.Header.Set("Accept", accept)
+	}
+	if req.Header.Get("User-Agent") == "" && f.UserAgent != "" {
+		req.Header.Set("User-Agent", f.UserAgent)
+	}
+	return f.mainFetch(ctx, req)
+}
+
+// FetchInParallel fetches the request in parallel and queues a task on the
+// networking task source of the event loop that calls processResponse with
+// the result of Fetch.
+// https://fetch.spec.whatwg.org/#process-response
+func (f *Fetcher) FetchInParallel(ctx context.Context, req *Request, loop spec.EventLoop, processResponse func(*Response, error)) {
+	loop.InParallel(func() {
+		resp, err := f.Fetch(ctx, req)
+		loop.QueueTask(spec.NetworkingTaskSource, func() { processResponse(resp, err) })
+	})
+}
+
+// mainFetch is https://fetch.spec.whatwg.org/#concept-main-fetch
+// CORS preflights aren't made.
+func (f *Fetcher) mainFetch(ctx context.Context, req *Request) (*Response, error) {
+	if req.ReferrerPolicy == "" {
+		req.ReferrerPolicy = StrictOriginWhenCrossOriginPolicy
+	}
+	req.Header.Del("Referer")
+	if referrer := determineReferrer(req); referrer != nil {
+		req.Header.Set("Referer", referrer.Href())
+	}
+
+	scheme := req.URL().Scheme()
+	// only file: documents can load local files, besides the navigations
+	// that no document made, like the ones users start.
+	if scheme == "file" && req.Referrer != nil && req.Referrer.Scheme() != "file" {
+		return nil, networkError("file: URL requested by a " + req.Referrer.Protocol() + " document")
+	}
+	var resp *Response
+	var err error
+	switch {
+	case (req.sameOrigin(req.URL()) && req.ResponseTainting == BasicTainting) || scheme == "data" ||
+		req.Mode == NavigateMode || req.Mode == WebSocketMode:
+		req.ResponseTainting = BasicTainting
+		resp, err = f.schemeFetch(ctx, req)
+	case req.Mode == SameOriginMode:
+		return nil, networkError("cross-origin request in same-origin mode")
+	case req.Mode == NoCORSMode:
+		if req.Redirect != FollowRedirects {
+			return nil, networkError("no-cors requests must follow redirects")
+		}
+		req.ResponseTainting = OpaqueTainting
+		resp, err = f.schemeFetch(ctx, req)
+	case scheme != "http" && scheme != "https":
+		return nil, networkError("cross-origin request for a non-HTTP URL")
+	default:
+		req.ResponseTainting = CORSTainting
+		resp, err = f.httpFetch(ctx, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.Internal != nil {
+		// redirects that were followed are already filtered.
+		return resp, nil
+	}
+
+	switch req.ResponseTainting {
+	case CORSTainting:
+		return resp.corsFiltered(req.Credentials == IncludeCredentials), nil
+	case OpaqueTainting:
+		return resp.opaqueFiltered(), nil
+	}
+	return resp.basicFiltered(), nil
+}
+
+// schemeFetch is https://fetch.spec.whatwg.org/#scheme-fetch
+func (f *Fetcher) schemeFetch(ctx context.Context, req *Request) (*Response, error) {
+	scheme := req.URL().Scheme()
+	if scheme == "http" || scheme == "https" {
+		return f.httpFetch(ctx, req)
+	}
+	return f.transportFetch(ctx, req)
+}
+
+func (f *Fetcher) transportFetch(ctx context.Context, req *Request) (*Response, error) {
+	transport, ok := f.Transports[req.URL().Scheme()]
+	if !ok {
+		return nil, networkError("no transport for " + req.URL().Protocol())
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, networkError(err.Error())
+	}
+	return transport.Fetch(ctx, req)
+}
+
+// httpFetch is https://fetch.spec.whatwg.org/#concept-http-fetch
+func (f *Fetcher) httpFetch(ctx context.Context, req *Request) (*Response, error) {
+	if req.ResponseTainting == CORSTainting {
+		req.Header.Set("Origin", req.origin())
+	} else if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		// https://fetch.spec.whatwg.org/#append-a-request-origin-header
+		origin := req.origin()
+		if req.ReferrerPolicy == NoReferrerPolicy {
+			origin = "null"
+		}
+		req.Header.Set("Origin", origin)
+	}
+	req.Header.Del("Cookie")
+	if f.Cookies != nil && req.includeCredentials() {
+		if cookie := f.Cookies.CookieString(req.URL(), f.cookieSource(req)); cookie != "" {
+			req.Header.Set("Cookie", cookie)
+		}
+	}
+
+	resp, err := f.transportFetch(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if f.Cookies != nil && req.includeCredentials() {
+		f.Cookies.SetCookies(req.URL(), resp.Header.Values("Set-Cookie"), f.cookieSource(req))
+	}
+	if req.ResponseTainting == CORSTainting && !corsCheck(req, resp) {
+		return nil, networkError("CORS check failed")
+	}
+	if !isRedirectStatus(resp.Status) {
+		return resp, nil
+	}
+	switch req.Redirect {
+	case ErrorRedirects:
+		return nil, networkError("redirected with redirect mode error")
+	case ManualRedirects:
+		// navigations need the redirect itself to follow it.
+		if req.Mode == NavigateMode {
+			return resp, nil
+		}
+		return resp.opaqueRedirectFiltered(), nil
+	}
+	return f.httpRedirectFetch(ctx, req, resp)
+}
+
+// cookieSource describes the request to the cookie jar. Requests are
+// cross-site when their URL isn't same-site with their origin.
+// https://datatracker.ietf.org/doc/html/draft-ietf-httpbis-rfc6265bis#section-5.2
+func (f *Fetcher) cookieSource(req *Request) cookies.Source {
+	crossSite := false
+	if origin, err := spec.ParseURL(req.origin(), nil); err != nil || !f.Cookies.SameSite(origin, req.URL()) {
+		crossSite = true
+	}
+	return cookies.Source{
+		CrossSite:          crossSite,
+		TopLevelNavigation: req.Mode == NavigateMode && req.Destination == DocumentDestination,
+		Method:             req.Method,
+	}
+}
+
+// corsCheck is https://fetch.spec.whatwg.org/#concept-cors-check
+func corsCheck(req *Request, resp *Response) bool {
+	origin := resp.Header.Get("Access-Control-Allow-Origin")
+	if origin == "" {
+		return false
+	}
+	if req.Credentials != IncludeCredentials && origin == "*" {
+		return true
+	}
+	if origin != req.origin() {
+		return false
+	}
+	return req.Credentials != IncludeCredentials || resp.Header.Get("Access-Control-Allow-Credentials") == "true"
+}
+
+// https://fetch.spec.whatwg.org/#request-body-header-name
+var requestBodyHeaders = []string{"Content-Encoding", "Content-Language", "Content-Location", "Content-Type"}
+
+// httpRedirectFetch is https://fetch.spec.whatwg.org/#http-redirect-fetch
+func (f *Fetcher) httpRedirectFetch(ctx context.Context, req *Request, resp *Response) (*Response, error) {
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return resp, nil
+	}
+	locationURL, err := resp.URL().Parse(location)
+	if err != nil {
+		return nil, networkError("invalid Location header")
+	}
+	if locationURL.Hash() == "" && req.URL().Hash() != "" {
+		locationURL.SetHash(req.URL().Hash())
+	}
+
+	if scheme := locationURL.Scheme(); scheme != "http" && scheme != "https" {
+		return nil, networkError("redirected to a non-HTTP URL")
+	}
+	if req.RedirectCount == maxRedirects {
+		return nil, networkError("too many redirects")
+	}
+	req.RedirectCount++
+	if req.Mode == CORSMode && locationURL.IncludesCredentials() && !req.sameOrigin(locationURL) {
+		return nil, networkError("cross-origin redirect to a URL with credentials")
+	}
+	if req.ResponseTainting == CORSTainting && locationURL.IncludesCredentials() {
+		return nil, networkError("CORS redirect to a URL with credentials")
+	}
+
+	if ((resp.Status == 301 || resp.Status == 302) && req.Method == http.MethodPost) ||
+		(resp.Status == 303 && req.Method != http.MethodGet && req.Method != http.MethodHead) {
+		req.Method = http.MethodGet
+		req.Body = nil
+		for _, name := range requestBodyHeaders {
+			req.Header.Del(name)
+		}
+	}
+	if req.URL().Origin() != locationURL.Origin() {
+		req.Header.Del("Authorization")
+	}
+	req.URLList = append(req.URLList, locationURL)
+	if policy := ParseReferrerPolicy(resp.Header.Get("Referrer-Policy")); policy != "" {
+		req.ReferrerPolicy = policy
+	}
+	req.Header.Del("Origin")
+	return f.mainFetch(ctx, req)
+}
+
+// determineReferrer is https://w3c.github.io/webappsec-referrer-policy/#determine-requests-referrer
+func determineReferrer(req *Request) *spec.URL {
+	if req.Referrer == nil {
+		return nil
+	}
+	referrerURL := stripForReferrer(req.Referrer, false)
+	if referrerURL == nil {
+		return nil
+	}
+	referrerOrigin := stripForReferrer(req.Referrer, true)
+	if len(referrerURL.Href()) > 4096 {
+		referrerURL = referrerOrigin
+	}
+
+	current := req.URL()
+	sameOrigin := referrerURL.Origin() != "null" && referrerURL.Origin() == current.Origin()
+	downgrade := referrerURL.IsPotentiallyTrustworthy() && !current.IsPotentiallyTrustworthy()
+	switch req.ReferrerPolicy {
+	case NoReferrerPolicy:
+		return nil
+	case OriginPolicy:
+		return referrerOrigin
+	case UnsafeURLPolicy:
+		return referrerURL
+	case StrictOriginPolicy:
+		if downgrade {
+			return nil
+		}
+		return referrerOrigin
+	case SameOriginPolicy:
+		if sameOrigin {
+			return referrerURL
+		}
+		return nil
+	case OriginWhenCrossOriginPolicy:
+		if sameOrigin {
+			return referrerURL
+		}
+		return referrerOrigin
+	case NoReferrerWhenDowngradePolicy:
+		if downgrade {
+			return nil
+		}
+		return referrerURL
+	}
+	// strict-origin-when-cross-origin
+	if sameOrigin {
+		return referrerURL
+	}
+	if downgrade {
+		return nil
+	}
+	return referrerOrigin
+}
+
+// stripForReferrer is https://w3c.github.io/webappsec-referrer-policy/#strip-url
+func stripForReferrer(u *spec.URL, originOnly bool) *spec.URL {
+	// https://fetch.spec.whatwg.org/#local-scheme
+	switch u.Scheme() {
+	case "about", "blob", "data":
+		return nil
+	}
+	u = u.Clone()
+	u.SetUsername("")
+	u.SetPassword("")
+	u.SetHash("")
+	if originOnly {
+		u.SetPathname("")
+		u.SetSearch("")
+	}
+	return u
+}
@@ -0,0 +1,255 @@
+package fetch
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/heathj/gobrowse/cookies"
+	"github.com/heathj/gobrowse/parser/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "a=b")
+		w.Header().Set("X-Referer", r.Header.Get("Referer"))
+		w.Header().Set("X-Accept", r.Header.Get("Accept"))
+		io.WriteString(w, r.Method+" page")
+	})
+	mux.HandleFunc("/cookie", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Header.Get("Cookie"))
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/page", http.StatusFound)
+	})
+	mux.HandleFunc("/see-other", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "page", http.StatusSeeOther)
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusTemporaryRedirect)
+	})
+	mux.HandleFunc("/cors", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", r.Header.Get("Origin"))
+		w.Header().Set("Access-Control-Expose-Headers", "X-Exposed")
+		w.Header().Set("X-Exposed", "1")
+		w.Header().Set("X-Hidden", "1")
+		io.WriteString(w, "cors")
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFetchHTTP(t *testing.T) {
+	server := newTestServer(t)
+	f := NewFetcher(server.Client().Transport)
+
+	req, err := NewRequest(http.MethodGet, server.URL+"/redirect#frag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Destination = DocumentDestination
+	req.Referrer, _ = spec.ParseURL(server.URL+"/from?secret#x", nil)
+	resp, err := f.Fetch(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, BasicResponse, resp.Type)
+	assert.Equal(t, 200, resp.Status)
+	assert.Equal(t, "OK", resp.StatusText)
+	assert.Equal(t, "GET page", string(resp.Body))
+	assert.Equal(t, server.URL+"/page#frag", resp.URL().Href())
+	assert.Len(t, resp.URLList, 2)
+	assert.Empty(t, resp.Header.Get("Set-Cookie"))
+	assert.Equal(t, "a=b", resp.Internal.Header.Get("Set-Cookie"))
+	assert.Equal(t, server.URL+"/from?secret", resp.Header.Get("X-Referer"))
+	assert.Equal(t, defaultAccept[DocumentDestination], resp.Header.Get("X-Accept"))
+
+	req, _ = NewRequest(http.MethodPost, server.URL+"/see-other")
+	req.Body = []byte("x=1")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err = f.Fetch(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, "GET page", string(resp.Body))
+	assert.Nil(t, req.Body)
+
+	req, _ = NewRequest(http.MethodGet, server.URL+"/loop")
+	_, err = f.Fetch(context.Background(), req)
+	assert.ErrorIs(t, err, ErrNetwork)
+	assert.Equal(t, maxRedirects, req.RedirectCount)
+
+	req, _ = NewRequest(http.MethodGet, server.URL+"/redirect")
+	req.Redirect = ErrorRedirects
+	_, err = f.Fetch(context.Background(), req)
+	assert.ErrorIs(t, err, ErrNetwork)
+
+	req, _ = NewRequest(http.MethodGet, server.URL+"/redirect")
+	req.Redirect = ManualRedirects
+	resp, err = f.Fetch(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, OpaqueRedirectResponse, resp.Type)
+	assert.Equal(t, 0, resp.Status)
+	assert.Equal(t, http.StatusFound, resp.Internal.Status)
+}
+
+func TestFetchModes(t *testing.T) {
+	server := newTestServer(t)
+	f := NewFetcher(server.Client().Transport)
+	fetch := func(path string, mode Mode, origin string) (*Response, error) {
+		req, err := NewRequest(http.MethodGet, server.URL+path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Mode = mode
+		req.Origin = origin
+		return f.Fetch(context.Background(), req)
+	}
+
+	_, err := fetch("/page", SameOriginMode, "https://other.example")
+	assert.ErrorIs(t, err, ErrNetwork)
+
+	resp, err := fetch("/page", NoCORSMode, "https://other.example")
+	assert.NoError(t, err)
+	assert.Equal(t, OpaqueResponse, resp.Type)
+	assert.Nil(t, resp.Body)
+	assert.Equal(t, "GET page", string(resp.Internal.Body))
+
+	_, err = fetch("/page", CORSMode, "https://other.example")
+	assert.ErrorIs(t, err, ErrNetwork)
+
+	resp, err = fetch("/cors", CORSMode, "https://other.example")
+	assert.NoError(t, err)
+	assert.Equal(t, CORSResponse, resp.Type)
+	assert.Equal(t, "1", resp.Header.Get("X-Exposed"))
+	assert.Empty(t, resp.Header.Get("X-Hidden"))
+	assert.Equal(t, "cors", string(resp.Body))
+}
+
+func TestFetchCookies(t *testing.T) {
+	server := newTestServer(t)
+	f := NewFetcher(server.Client().Transport)
+	f.Cookies = cookies.NewJar()
+	fetch := func(path string, credentials CredentialsMode, origin string) string {
+		req, err := NewRequest(http.MethodGet, server.URL+path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Mode = NoCORSMode
+		req.Credentials = credentials
+		req.Origin = origin
+		req.Header.Set("Cookie", "forged=1")
+		resp, err := f.Fetch(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Internal != nil {
+			resp = resp.Internal
+		}
+		return string(resp.Body)
+	}
+
+	fetch("/redirect", OmitCredentials, "")
+	assert.Empty(t, f.Cookies.All())
+	fetch("/redirect", SameOriginCredentials, "")
+	assert.Len(t, f.Cookies.All(), 1)
+	assert.Equal(t, "a=b", fetch("/cookie", SameOriginCredentials, ""))
+	assert.Equal(t, "", fetch("/cookie", OmitCredentials, ""))
+	assert.Equal(t, "", fetch("/cookie", SameOriginCredentials, "https://other.example"))
+	// the default SameSite is enforced like Lax.
+	assert.Equal(t, "", fetch("/cookie", IncludeCredentials, "https://other.example"))
+}
+
+func TestReferrerPolicy(t *testing.T) {
+	tests := []struct {
+		policy           ReferrerPolicy
+		referrer, target string
+		expected         string
+	}{
+		{"", "https://a.example/p?q", "https://a.example/x", "https://a.example/p?q"},
+		{"", "https://a.example/p?q", "https://b.example/x", "https://a.example/"},
+		{"", "https://a.example/p?q", "http://b.example/x", ""},
+		{NoReferrerPolicy, "https://a.example/p", "https://a.example/x", ""},
+		{OriginPolicy, "https://u:p@a.example/p#f", "https://a.example/x", "https://a.example/"},
+		{UnsafeURLPolicy, "https://u:p@a.example/p#f", "http://b.example/x", "https://a.example/p"},
+		{SameOriginPolicy, "https://a.example/p", "https://b.example/x", ""},
+		{NoReferrerWhenDowngradePolicy, "https://a.example/p", "https://b.example/x", "https://a.example/p"},
+		{StrictOriginPolicy, "https://a.example/p", "http://a.example/x", ""},
+		{UnsafeURLPolicy, "data:text/html,x", "https://a.example/x", ""},
+	}
+	for _, tt := range tests {
+		req, err := NewRequest(http.MethodGet, tt.target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Referrer, _ = spec.ParseURL(tt.referrer, nil)
+		req.ReferrerPolicy = tt.policy
+		if tt.policy == "" {
+			req.ReferrerPolicy = StrictOriginWhenCrossOriginPolicy
+		}
+		referrer := ""
+		if u := determineReferrer(req); u != nil {
+			referrer = u.Href()
+		}
+		assert.Equal(t, tt.expected, referrer, "%s %s -> %s", tt.policy, tt.referrer, tt.target)
+	}
+	assert.Equal(t, StrictOriginPolicy, ParseReferrerPolicy("unsafe-url, bogus, Strict-Origin"))
+}
+
+func TestSchemeTransports(t *testing.T) {
+	f := NewFetcher(nil)
+	f.Transports["file"] = &FileTransport{FS: fstest.MapFS{"dir/a.html": {Data: []byte("<p>file")}}}
+	fetch := func(url string) (*Response, error) {
+		req, err := NewRequest(http.MethodGet, url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Mode = NavigateMode
+		return f.Fetch(context.Background(), req)
+	}
+
+	for _, tt := range []struct{ url, contentType, body string }{
+		{"data:,Hello%2C%20World!", "text/plain;charset=US-ASCII", "Hello, World!"},
+		{"data:text/html;base64,PHA+aGk=", "text/html", "<p>hi"},
+		{"data:;charset=utf-8,x", "text/plain;charset=utf-8", "x"},
+		{"data:text/plain ; base64 , aGk", "text/plain", "hi"},
+		{"file:///dir/a.html", "text/html; charset=utf-8", "<p>file"},
+		{"about:blank#x", "text/html;charset=utf-8", ""},
+	} {
+		resp, err := fetch(tt.url)
+		if !assert.NoError(t, err, tt.url) {
+			continue
+		}
+		assert.Equal(t, tt.contentType, resp.Header.Get("Content-Type"), tt.url)
+		assert.Equal(t, tt.body, string(resp.Body), tt.url)
+	}
+
+	for _, url := range []string{"data:text/html;base64,!!", "data:nocomma", "file:///missing", "file:///dir", "about:config", "ftp://example.com/"} {
+		_, err := fetch(url)
+		assert.ErrorIs(t, err, ErrNetwork, url)
+	}
+
+	// only file: documents can load local files.
+	for referrer, allowed := range map[string]bool{"file:///dir/b.html": true, "https://example.com/": false, "about:blank": false} {
+		req, _ := NewRequest(http.MethodGet, "file:///dir/a.html")
+		req.Mode, req.Destination = NoCORSMode, IframeDestination
+		req.Referrer, _ = spec.ParseURL(referrer, nil)
+		_, err := f.Fetch(context.Background(), req)
+		assert.Equal(t, allowed, err == nil, referrer)
+	}
+	req, _ := NewRequest(http.MethodGet, "file:///etc/passwd")
+	req.Mode = NavigateMode
+	_, err := NewFetcher(nil).Fetch(context.Background(), req)
+	assert.ErrorIs(t, err, ErrNetwork, "fetchers don't read local files by default")
+
+	f.Transports["test"] = TransportFunc(func(ctx context.Context, req *Request) (*Response, error) {
+		return &Response{Status: 200, URLList: req.URLList, Body: []byte(req.URL().Pathname())}, nil
+	})
+	resp, err := fetch("test:path")
+	assert.NoError(t, err)
+	assert.Equal(t, "path", string(resp.Body))
+}
@@ -0,0 +1,167 @@
+package fetch
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/heathj/gobrowse/parser/spec"
+)
+
+// Mode is https://fetch.spec.whatwg.org/#concept-request-mode
+type Mode string
+
+const (
+	SameOriginMode Mode = "same-origin"
+	NoCORSMode     Mode = "no-cors"
+	CORSMode       Mode = "cors"
+	NavigateMode   Mode = "navigate"
+	WebSocketMode  Mode = "websocket"
+)
+
+// CredentialsMode is https://fetch.spec.whatwg.org/#concept-request-credentials-mode
+type CredentialsMode string
+
+const (
+	OmitCredentials       CredentialsMode = "omit"
+	SameOriginCredentials CredentialsMode = "same-origin"
+	IncludeCredentials    CredentialsMode = "include"
+)
+
+// Destination is https://fetch.spec.whatwg.org/#concept-request-destination
+type Destination string
+
+const (
+	NoDestination       Destination = ""
+	AudioDestination    Destination = "audio"
+	DocumentDestination Destination = "document"
+	EmbedDestination    Destination = "embed"
+	FontDestination     Destination = "font"
+	FrameDestination    Destination = "frame"
+	IframeDestination   Destination = "iframe"
+	ImageDestination    Destination = "image"
+	ObjectDestination   Destination = "object"
+	ScriptDestination   Destination = "script"
+	StyleDestination    Destination = "style"
+	TrackDestination    Destination = "track"
+	VideoDestination    Destination = "video"
+	WorkerDestination   Destination = "worker"
+)
+
+// RedirectMode is https://fetch.spec.whatwg.org/#concept-request-redirect-mode
+type RedirectMode string
+
+const (
+	FollowRedirects RedirectMode = "follow"
+	ErrorRedirects  RedirectMode = "error"
+	ManualRedirects RedirectMode = "manual"
+)
+
+// ReferrerPolicy is https://w3c.github.io/webappsec-referrer-policy/#referrer-policies
+type ReferrerPolicy string
+
+const (
+	NoReferrerPolicy                  ReferrerPolicy = "no-referrer"
+	NoReferrerWhenDowngradePolicy     ReferrerPolicy = "no-referrer-when-downgrade"
+	SameOriginPolicy                  ReferrerPolicy = "same-origin"
+	OriginPolicy                      ReferrerPolicy = "origin"
+	StrictOriginPolicy                ReferrerPolicy = "strict-origin"
+	OriginWhenCrossOriginPolicy       ReferrerPolicy = "origin-when-cross-origin"
+	StrictOriginWhenCrossOriginPolicy ReferrerPolicy = "strict-origin-when-cross-origin"
+	UnsafeURLPolicy                   ReferrerPolicy = "unsafe-url"
+)
+
+// ParseReferrerPolicy returns the last known policy in a comma-separated
+// Referrer-Policy header value or the empty string if there isn't one.
+// https://w3c.github.io/webappsec-referrer-policy/#parse-referrer-policy-from-header
+func ParseReferrerPolicy(value string) ReferrerPolicy {
+	var policy ReferrerPolicy
+	for _, token := range strings.Split(value, ",") {
+		switch p := ReferrerPolicy(strings.ToLower(strings.TrimSpace(token))); p {
+		case NoReferrerPolicy, NoReferrerWhenDowngradePolicy, SameOriginPolicy, OriginPolicy,
+			StrictOriginPolicy, OriginWhenCrossOriginPolicy, StrictOriginWhenCrossOriginPolicy, UnsafeURLPolicy:
+			policy = p
+		}
+	}
+	return policy
+}
+
+// ResponseTainting is https://fetch.spec.whatwg.org/#concept-request-response-tainting
+type ResponseTainting string
+
+const (
+	BasicTainting  ResponseTainting = "basic"
+	CORSTainting   ResponseTainting = "cors"
+	OpaqueTainting ResponseTainting = "opaque"
+)
+
+// Request is https://fetch.spec.whatwg.org/#concept-request
+type Request struct {
+	Method string
+	// URLList has the URL the request was made for followed by the URLs it
+	// was redirected to.
+	URLList []*spec.URL
+	Header  http.Header
+	Body    []byte
+
+	// Origin is the serialized origin of the request's client. It's the
+	// origin of the first URL when empty, as for a navigation the user
+	// started.
+	Origin string
+	// Referrer is the URL of the client that made the request or nil for no
+	// referrer.
+	Referrer       *spec.URL
+	ReferrerPolicy ReferrerPolicy
+
+	Mode        Mode
+	Credentials CredentialsMode
+	Destination Destination
+	Redirect    RedirectMode
+
+	RedirectCount    int
+	ResponseTainting ResponseTainting
+}
+
+// NewRequest creates a GET request for url with the defaults of the Request
+// constructor: cors mode, same-origin credentials and following redirects.
+// https://fetch.spec.whatwg.org/#dom-request
+func NewRequest(method, url string) (*Request, error) {
+	u, err := spec.ParseURL(url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Request{
+		Method:           method,
+		URLList:          []*spec.URL{u},
+		Header:           http.Header{},
+		Mode:             CORSMode,
+		Credentials:      SameOriginCredentials,
+		Redirect:         FollowRedirects,
+		ResponseTainting: BasicTainting,
+	}, nil
+}
+
+// URL is https://fetch.spec.whatwg.org/#concept-request-current-url
+func (r *Request) URL() *spec.URL { return r.URLList[len(r.URLList)-1] }
+
+// origin returns the serialized origin of the request.
+func (r *Request) origin() string {
+	if r.Origin == "" {
+		return r.URLList[0].Origin()
+	}
+	return r.Origin
+}
+
+// sameOrigin reports if u has the request's origin. Opaque origins are only
+// the same as themselves so they never match.
+// https://html.spec.whatwg.org/multipage/browsers.html#same-origin
+func (r *Request) sameOrigin(u *spec.URL) bool {
+	origin := u.Origin()
+	return origin != "null" && origin == r.origin()
+}
+
+// includeCredentials reports if cookies and HTTP authentication are sent.
+// https://fetch.spec.whatwg.org/#http-network-or-cache-fetch
+func (r *Request) includeCredentials() bool {
+	return r.Credentials == IncludeCredentials ||
+		(r.Credentials == SameOriginCredentials && r.ResponseTainting == BasicTainting)
+}
@@ -0,0 +1,113 @@
+package fetch
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/heathj/gobrowse/parser/spec"
+)
+
+// ResponseType is https://fetch.spec.whatwg.org/#concept-response-type
+type ResponseType string
+
+const (
+	BasicResponse          ResponseType = "basic"
+	CORSResponse           ResponseType = "cors"
+	DefaultResponse        ResponseType = "default"
+	OpaqueResponse         ResponseType = "opaque"
+	OpaqueRedirectResponse ResponseType = "opaqueredirect"
+)
+
+// Response is https://fetch.spec.whatwg.org/#concept-response
+type Response struct {
+	Type       ResponseType
+	URLList    []*spec.URL
+	Status     int
+	StatusText string
+	Header     http.Header
+	Body       []byte
+	// Internal is the unfiltered response of a filtered response.
+	// https://fetch.spec.whatwg.org/#concept-internal-response
+	Internal *Response
+}
+
+// URL is https://fetch.spec.whatwg.org/#concept-response-url
+// It's nil when the URL list is empty.
+func (r *Response) URL() *spec.URL {
+	if len(r.URLList) == 0 {
+		return nil
+	}
+	return r.URLList[len(r.URLList)-1]
+}
+
+// OK reports if the status is an ok status.
+// https://fetch.spec.whatwg.org/#ok-status
+func (r *Response) OK() bool { return r.Status >= 200 && r.Status <= 299 }
+
+// isRedirectStatus is https://fetch.spec.whatwg.org/#redirect-status
+func isRedirectStatus(status int) bool {
+	switch status {
+	case 301, 302, 303, 307, 308:
+		return true
+	}
+	return false
+}
+
+// https://fetch.spec.whatwg.org/#forbidden-response-header-name
+var forbiddenResponseHeaders = []string{"Set-Cookie", "Set-Cookie2"}
+
+// https://fetch.spec.whatwg.org/#cors-safelisted-response-header-name
+var corsSafelistedResponseHeaders = []string{
+	"Cache-Control", "Content-Language", "Content-Length", "Content-Type", "Expires", "Last-Modified", "Pragma",
+}
+
+// basicFiltered is https://fetch.spec.whatwg.org/#concept-filtered-response-basic
+func (r *Response) basicFiltered() *Response {
+	filtered := *r
+	filtered.Type = BasicResponse
+	filtered.Header = r.Header.Clone()
+	for _, name := range forbiddenResponseHeaders {
+		filtered.Header.Del(name)
+	}
+	filtered.Internal = r
+	return &filtered
+}
+
+// corsFiltered is https://fetch.spec.whatwg.org/#concept-filtered-response-cors
+func (r *Response) corsFiltered(includeCredentials bool) *Response {
+	exposed := map[string]bool{}
+	for _, name := range corsSafelistedResponseHeaders {
+		exposed[name] = true
+	}
+	exposeAll := false
+	for _, value := range r.Header.Values("Access-Control-Expose-Headers") {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if name == "*" && !includeCredentials {
+				exposeAll = true
+			}
+			exposed[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+
+	filtered := *r
+	filtered.Type = CORSResponse
+	filtered.Header = http.Header{}
+	for name, values := range r.Header {
+		if (exposed[name] || exposeAll) && name != "Set-Cookie" && name != "Set-Cookie2" {
+			filtered.Header[name] = append([]string{}, values...)
+		}
+	}
+	filtered.Internal = r
+	return &filtered
+}
+
+// opaqueFiltered is https://fetch.spec.whatwg.org/#concept-filtered-response-opaque
+func (r *Response) opaqueFiltered() *Response {
+	return &Response{Type: OpaqueResponse, Header: http.Header{}, Internal: r}
+}
+
+// opaqueRedirectFiltered is https://fetch.spec.whatwg.org/#concept-filtered-response-opaque-redirect
+func (r *Response) opaqueRedirectFiltered() *Response {
+	return &Response{Type: OpaqueRedirectResponse, URLList: r.URLList, Header: http.Header{}, Internal: r}
+}
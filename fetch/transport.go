@@ -0,0 +1,212 @@
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/heathj/gobrowse/parser/spec"
+)
+
+// Transport fetches the responses of requests for the URL schemes it's
+// registered for. Redirects are handled by the Fetcher so transports return
+// them as they are.
+// https://fetch.spec.whatwg.org/#scheme-fetch
+type Transport interface {
+	Fetch(ctx context.Context, req *Request) (*Response, error)
+}
+
+// TransportFunc is a function used as a Transport.
+type TransportFunc func(ctx context.Context, req *Request) (*Response, error)
+
+// Fetch calls f.
+func (f TransportFunc) Fetch(ctx context.Context, req *Request) (*Response, error) {
+	return f(ctx, req)
+}
+
+// HTTPTransport fetches http: and https: URLs over a RoundTripper.
+// https://fetch.spec.whatwg.org/#concept-http-network-fetch
+type HTTPTransport struct {
+	RoundTripper http.RoundTripper
+}
+
+// Fetch makes the request with the round tripper.
+func (t *HTTPTransport) Fetch(ctx context.Context, req *Request) (*Response, error) {
+	u, err := req.URL().NetURL()
+	if err != nil {
+		return nil, networkError(err.Error())
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, u.String(), bytes.NewReader(req.Body))
+	if err != nil {
+		return nil, networkError(err.Error())
+	}
+	httpReq.Header = req.Header.Clone()
+	if len(req.Body) == 0 {
+		httpReq.Body = http.NoBody
+	}
+
+	rt := t.RoundTripper
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	httpResp, err := rt.RoundTrip(httpReq)
+	if err != nil {
+		return nil, networkError(err.Error())
+	}
+	defer httpResp.Body.Close()
+	body, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, networkError(err.Error())
+	}
+	return &Response{
+		Type:       DefaultResponse,
+		URLList:    append([]*spec.URL{}, req.URLList...),
+		Status:     httpResp.StatusCode,
+		StatusText: strings.TrimSpace(strings.TrimPrefix(httpResp.Status, strconv.Itoa(httpResp.StatusCode))),
+		Header:     httpResp.Header,
+		Body:       body,
+	}, nil
+}
+
+// DataTransport fetches data: URLs.
+type DataTransport struct{}
+
+// Fetch is the data: branch of https://fetch.spec.whatwg.org/#scheme-fetch
+func (DataTransport) Fetch(ctx context.Context, req *Request) (*Response, error) {
+	mimeType, body, err := ProcessDataURL(req.URL())
+	if err != nil {
+		return nil, err
+	}
+	return &Response{
+		Type:       DefaultResponse,
+		URLList:    append([]*spec.URL{}, req.URLList...),
+		Status:     http.StatusOK,
+		StatusText: "OK",
+		Header:     http.Header{"Content-Type": {mimeType}},
+		Body:       body,
+	}, nil
+}
+
+// ProcessDataURL returns the MIME type and body of a data: URL.
+// https://fetch.spec.whatwg.org/#data-url-processor
+func ProcessDataURL(u *spec.URL) (string, []byte, error) {
+	if u.Scheme() != "data" {
+		return "", nil, networkError("not a data: URL")
+	}
+	input := strings.TrimPrefix(u.Href(), "data:")
+	if i := strings.IndexByte(input, '#'); i >= 0 {
+		input = input[:i]
+	}
+	comma := strings.IndexByte(input, ',')
+	if comma < 0 {
+		return "", nil, networkError("data: URL without a comma")
+	}
+	mimeType := strings.Trim(input[:comma], "\t\n\f\r ")
+	body := spec.PercentDecode(input[comma+1:])
+
+	if i := strings.LastIndexByte(mimeType, ';'); i >= 0 && strings.EqualFold(strings.TrimLeft(mimeType[i+1:], " "), "base64") {
+		decoded, err := forgivingBase64Decode(string(body))
+		if err != nil {
+			return "", nil, networkError("invalid base64 in data: URL")
+		}
+		body = decoded
+		mimeType = mimeType[:i]
+	}
+	if strings.HasPrefix(mimeType, ";") {
+		mimeType = "text/plain" + mimeType
+	}
+	if mediaType, params, err := mime.ParseMediaType(mimeType); err != nil {
+		mimeType = "text/plain;charset=US-ASCII"
+	} else {
+		// https://mimesniff.spec.whatwg.org/#serialize-a-mime-type
+		mimeType = strings.ReplaceAll(mime.FormatMediaType(mediaType, params), "; ", ";")
+	}
+	return mimeType, body, nil
+}
+
+// forgivingBase64Decode is https://infra.spec.whatwg.org/#forgiving-base64-decode
+func forgivingBase64Decode(s string) ([]byte, error) {
+	s = strings.Map(func(r rune) rune {
+		if strings.ContainsRune("\t\n\f\r ", r) {
+			return -1
+		}
+		return r
+	}, s)
+	if len(s)%4 == 0 {
+		s = strings.TrimSuffix(s, "=")
+		s = strings.TrimSuffix(s, "=")
+	}
+	if len(s)%4 == 1 || strings.ContainsRune(s, '=') {
+		return nil, errors.New("invalid base64")
+	}
+	return base64.RawStdEncoding.DecodeString(s)
+}
+
+// FileTransport fetches file: URLs from a file system. Paths are relative to
+// its root. Fetchers don't read local files unless one is registered.
+type FileTransport struct {
+	FS fs.FS
+}
+
+// Fetch reads the file of a GET request.
+func (t *FileTransport) Fetch(ctx context.Context, req *Request) (*Response, error) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return nil, networkError("file: URLs only support GET")
+	}
+	name := strings.TrimPrefix(string(spec.PercentDecode(req.URL().Pathname())), "/")
+	if name == "" {
+		name = "."
+	}
+	if t.FS == nil || !fs.ValidPath(name) {
+		return nil, networkError("file not found")
+	}
+	f, err := t.FS.Open(name)
+	if err != nil {
+		return nil, networkError(err.Error())
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err != nil || info.IsDir() {
+		return nil, networkError("not a file")
+	}
+	body, err := io.ReadAll(f)
+	if err != nil {
+		return nil, networkError(err.Error())
+	}
+	header := http.Header{}
+	if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	return &Response{
+		Type:       DefaultResponse,
+		URLList:    append([]*spec.URL{}, req.URLList...),
+		Status:     http.StatusOK,
+		StatusText: "OK",
+		Header:     header,
+		Body:       body,
+	}, nil
+}
+
+// AboutTransport fetches about:blank. Other about: URLs are network errors.
+type AboutTransport struct{}
+
+// Fetch is the about: branch of https://fetch.spec.whatwg.org/#scheme-fetch
+func (AboutTransport) Fetch(ctx context.Context, req *Request) (*Response, error) {
+	if req.URL().Pathname() != "blank" {
+		return nil, networkError("unknown about: URL")
+	}
+	return &Response{
+		Type:       DefaultResponse,
+		URLList:    append([]*spec.URL{}, req.URLList...),
+		Status:     http.StatusOK,
+		StatusText: "OK",
+		Header:     http.Header{"Content-Type": {"text/html;charset=utf-8"}},
+	}, nil
+}
@@ -1,17 +1,83 @@
 package main
 
 import (
+	"bufio"
+	"flag"
 	"fmt"
-	"strings"
+	"io"
+	"os"
 
 	"github.com/heathj/gobrowse/parser"
 )
 
+// Prints every table of an HTML file as CSV, TSV or JSON. The file is read
+// from stdin when there isn't one.
+//
+//	gobrowse [-format csv|tsv|json] [-header rows] [-no-repeat] [file]
 func main() {
-	p := parser.NewParser(strings.NewReader("&#x0000;"))
-	tokens, err := p.Start()
+	format := flag.String("format", "csv", "output format: csv, tsv or json")
+	header := flag.Int("header", 0, "number of header rows, 0 to detect them and -1 for none")
+	noRepeat := flag.Bool("no-repeat", false, "only write the text of spanning cells in their first row and column")
+	flag.Parse()
+
+	if err := run(*format, *header, !*noRepeat, flag.Arg(0)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(format string, headerRows int, repeatSpans bool, path string) error {
+	switch format {
+	case "csv", "tsv", "json":
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+	var in io.Reader = os.Stdin
+	if path != "" && path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	doc, err := parser.NewParser(in).Start()
 	if err != nil {
-		panic(err)
+		return err
+	}
+
+	config := parser.DefaultTableExportConfig
+	config.HeaderRows = headerRows
+	config.RepeatSpans = repeatSpans
+	tables := parser.NewTableExporter(config).Export(doc)
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	if format == "json" {
+		out.WriteString("[")
+	}
+	for i, table := range tables {
+		if i > 0 {
+			if format == "json" {
+				out.WriteString(",")
+			} else {
+				out.WriteString("\n")
+			}
+		}
+		switch format {
+		case "csv":
+			err = table.WriteCSV(out)
+		case "tsv":
+			err = table.WriteTSV(out)
+		case "json":
+			err = table.WriteJSON(out)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if format == "json" {
+		out.WriteString("]\n")
 	}
-	fmt.Printf("%+v", tokens)
+	return nil
 }
@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/heathj/gobrowse/parser/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocumentBaseURL(t *testing.T) {
+	doc, err := NewParser(strings.NewReader(`<base target=_top><base href="../cdn/"><base href="/ignored/"><a href="x y?q#f">`),
+		WithDocumentURL("https://example.com/dir/page.html")).Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "https://example.com/cdn/", doc.Document.BaseURL().Href())
+	assert.Equal(t, "https://example.com/dir/page.html", doc.Document.FallbackBaseURL().Href())
+	assert.Equal(t, "https://example.com/cdn/x%20y?q#f", findElement(doc, "a").Href())
+
+	base := findElement(doc, "base")
+	assert.Equal(t, "https://example.com/dir/page.html", base.Href())
+	base.SetHref("https://other.example/")
+	assert.Equal(t, "https://other.example/x%20y?q#f", findElement(doc, "a").Href())
+	base.SetHref("javascript:alert(1)")
+	assert.Equal(t, "https://example.com/dir/x%20y?q#f", findElement(doc, "a").Href())
+}
+
+func TestFallbackBaseURL(t *testing.T) {
+	doc, err := NewParser(strings.NewReader(`<img src=a.png>`), WithIframeSrcdoc(),
+		WithDocumentURL("about:srcdoc"), WithAboutBaseURL("https://parent.example/p/")).Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "https://parent.example/p/a.png", findElement(doc, "img").Src())
+
+	doc, err = NewParser(strings.NewReader(`<img src=a.png><a href=x>`)).Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "a.png", findElement(doc, "img").Src())
+	assert.Equal(t, "x", findElement(doc, "a").Href())
+	assert.Equal(t, "", findElement(doc, "body").Href())
+}
+
+func TestURLAttributes(t *testing.T) {
+	doc, err := NewParser(strings.NewReader(`<link rel=stylesheet href=s.css>
+<form id=empty></form><form id=rel action="search?x"><button formaction="//cdn.example/b">b</button></form>
+<img src="http://[::1" srcset="a.png 1x, b.png 2.5x, c.png 480w, d.png 1x 2x, e.png (x) 2x, f.png 10h, g.png,, h.png 100w 50h">`),
+		WithDocumentURL("https://example.com/dir/page.html")).Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "https://example.com/dir/s.css", findElement(doc, "link").Href())
+	form := findElement(doc, "form")
+	assert.Equal(t, "https://example.com/dir/page.html", form.Action())
+	assert.Equal(t, "https://example.com/dir/search?x", form.NextSibling.Action())
+	assert.Equal(t, "https://cdn.example/b", findElement(doc, "button").FormAction())
+
+	img := findElement(doc, "img")
+	assert.Equal(t, "http://[::1", img.Src())
+	assert.Equal(t, []spec.ImageCandidate{
+		{URL: "https://example.com/dir/a.png", Density: 1},
+		{URL: "https://example.com/dir/b.png", Density: 2.5},
+		{URL: "https://example.com/dir/c.png", Width: 480},
+		{URL: "https://example.com/dir/g.png", Density: 1},
+		{URL: "https://example.com/dir/h.png", Width: 100},
+	}, img.SrcsetCandidates())
+}
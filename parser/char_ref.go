@@ -0,0 +1,69 @@
+package parser
+
+// charRefTrieNode is a node in the trie of named character references. Its
+// children are charRefTrieEdges[first:first+count], sorted by byte. value is
+// an index into charRefValues, 0 if no reference ends at this node.
+type charRefTrieNode struct {
+	first, count, value uint16
+}
+
+type charRefTrieEdge struct {
+	b    byte
+	node uint16
+}
+
+// next follows the edge for b out of the node. It returns false if there
+// isn't one.
+func (n charRefTrieNode) next(b byte) (uint16, bool) {
+	edges := charRefTrieEdges[n.first : n.first+n.count]
+	lo, hi := 0, len(edges)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		if edges[mid].b < b {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	if lo < len(edges) && edges[lo].b == b {
+		return edges[lo].node, true
+	}
+	return 0, false
+}
+
+// matchCharRef returns the length of the longest named character reference
+// that is a prefix of input along with the code points it stands for. Only the
+// first maxCharRefLength bytes of input are looked at. The length is 0 if no
+// reference matches.
+// https://html.spec.whatwg.org/multipage/parsing.html#named-character-reference-state
+func matchCharRef(input []byte) (int, []rune) {
+	if len(input) > maxCharRefLength {
+		input = input[:maxCharRefLength]
+	}
+
+	var (
+		node    uint16
+		longest int
+		value   uint16
+	)
+	for i, b := range input {
+		next, ok := charRefTrie[node].next(b)
+		if !ok {
+			break
+		}
+		node = next
+		if v := charRefTrie[node].value; v != 0 {
+			longest = i + 1
+			value = v
+		}
+	}
+
+	if longest == 0 {
+		return 0, nil
+	}
+	runes := charRefValues[value][:]
+	if runes[1] == 0 {
+		runes = runes[:1]
+	}
+	return longest, runes
+}
@@ -1,5 +1,7 @@
 package parser
 
+//go:generate go run ./internal/gencharref
+
 var charRefTable = map[string][]rune{
 	"AElig":                            {198},
 	"AElig;":                           {198},
@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchCharRefTable(t *testing.T) {
+	for name, value := range charRefTable {
+		length, runes := matchCharRef([]byte(name))
+		assert.Equal(t, len(name), length, name)
+		assert.Equal(t, value, runes, name)
+	}
+}
+
+func TestMatchCharRefLongest(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"notit;", "not"},
+		{"notin;", "notin;"},
+		{"amp;lt;", "amp;"},
+		{"ampx", "amp"},
+		{"CounterClockwiseContourIntegral;x", "CounterClockwiseContourIntegral;"},
+		{"xyz", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		length, runes := matchCharRef([]byte(tt.input))
+		assert.Equal(t, len(tt.expected), length, tt.input)
+		if tt.expected != "" {
+			assert.Equal(t, charRefTable[tt.expected], runes, tt.input)
+		}
+	}
+}
+
+func BenchmarkMatchCharRef(b *testing.B) {
+	inputs := [][]byte{
+		[]byte("amp;"),
+		[]byte("notit;"),
+		[]byte("CounterClockwiseContourIntegral;"),
+		[]byte("nonexistent;"),
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, input := range inputs {
+			matchCharRef(input)
+		}
+	}
+}
+
+func BenchmarkTokenizerCharRefs(b *testing.B) {
+	input := strings.Repeat("&amp;&lt;&notit;&CounterClockwiseContourIntegral;&bogus; ", 200)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(input)))
+	for i := 0; i < b.N; i++ {
+		tokenizer := NewHTMLTokenizer(strings.NewReader(input))
+		progress := MakeProgress(nil, nil)
+		for tokenizer.Next() {
+			if _, err := tokenizer.Token(progress); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/heathj/gobrowse/cookies"
+	"github.com/heathj/gobrowse/parser/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocumentCookie(t *testing.T) {
+	jar := cookies.NewJar()
+	u, _ := spec.ParseURL("https://example.com/", nil)
+	jar.SetCookie(u, "session=1; HttpOnly", cookies.Source{})
+	jar.SetCookie(u, "theme=dark", cookies.Source{})
+
+	doc, err := NewParser(strings.NewReader("<p>"), WithDocumentURL("https://example.com/page"), WithCookieStore(jar)).Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	document := doc.OwnerHTMLDocument()
+	cookie, err := document.Cookie()
+	assert.NoError(t, err)
+	assert.Equal(t, "theme=dark", cookie)
+
+	assert.NoError(t, document.SetCookie("lang=en; Path=/"))
+	assert.NoError(t, document.SetCookie("session=2"))
+	assert.NoError(t, document.SetCookie("other=1; HttpOnly"))
+	cookie, _ = document.Cookie()
+	assert.Equal(t, "theme=dark; lang=en", cookie)
+	assert.Equal(t, "session=1; theme=dark; lang=en", jar.CookieString(u, cookies.Source{}))
+
+	for _, opts := range [][]Option{
+		{WithDocumentURL("https://example.com/")},
+		{WithDocumentURL("data:text/html,x"), WithCookieStore(jar)},
+	} {
+		doc, err := NewParser(strings.NewReader("<p>"), opts...).Start()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.NoError(t, doc.OwnerHTMLDocument().SetCookie("averse=1"))
+		cookie, err := doc.OwnerHTMLDocument().Cookie()
+		assert.NoError(t, err)
+		assert.Equal(t, "", cookie)
+	}
+}
@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"github.com/heathj/gobrowse/parser/spec"
+)
+
+func init() {
+	spec.NewScriptCreatedParser = func(doc *spec.HTMLDocument, previous spec.DocumentParser) spec.DocumentParser {
+		return newScriptCreatedParser(doc, previous)
+	}
+}
+
+// newScriptCreatedParser creates the parser used by document.open(). Its input
+// stream starts out empty and it waits for an explicit EOF from document.close().
+// It keeps the scripting flag, script executor, limits and context of the
+// previous parser.
+// https://html.spec.whatwg.org/multipage/dynamic-markup-insertion.html#document-open-steps
+func newScriptCreatedParser(doc *spec.HTMLDocument, previous spec.DocumentParser) *Parser {
+	var opts []Option
+	prev, ok := previous.(*Parser)
+	if ok {
+		opts = append(opts,
+			WithScripting(prev.TreeConstructor.scriptingEnabled),
+			WithLimits(prev.TreeConstructor.limits))
+	}
+	p := newParser(NewHTMLTokenizer(nil), &HTMLTreeConstructor{HTMLDocument: doc}, opts...)
+	if ok {
+		p.SetScriptExecutor(prev.TreeConstructor.scriptExecutor)
+		p.ctx = prev.ctx
+	}
+	p.scriptCreated = true
+	p.Tokenizer.inputStream.setInsertionPointBeforeNextChar()
+	return p
+}
+
+// Active reports if the parser hasn't been stopped or aborted yet.
+func (p *Parser) Active() bool {
+	return !p.TreeConstructor.stopped
+}
+
+// ScriptCreated reports if the parser was created by document.open().
+func (p *Parser) ScriptCreated() bool {
+	return p.scriptCreated
+}
+
+// https://html.spec.whatwg.org/multipage/parsing.html#script-nesting-level
+func (p *Parser) ScriptNestingLevel() int {
+	return p.TreeConstructor.scriptNestingLevel
+}
+
+// https://html.spec.whatwg.org/multipage/scripting.html#pending-parsing-blocking-script
+func (p *Parser) HasPendingParsingBlockingScript() bool {
+	return p.TreeConstructor.pendingParsingBlockingScript != nil
+}
+
+// https://html.spec.whatwg.org/multipage/parsing.html#insertion-point
+func (p *Parser) InsertionPointDefined() bool {
+	return p.Tokenizer.inputStream.insertionPointDefined()
+}
+
+// Write inserts input into the input stream just before the insertion point and
+// then processes it, stopping when the tokenizer reaches the insertion point or
+// when the tree construction stage pauses the parser. It returns the error
+// that stopped the parser, like a LimitError.
+// https://html.spec.whatwg.org/multipage/dynamic-markup-insertion.html#document-write-steps
+func (p *Parser) Write(input string) error {
+	p.Tokenizer.inputStream.insert(input)
+	if p.TreeConstructor.pendingParsingBlockingScript != nil {
+		return nil
+	}
+
+	stop := p.Tokenizer.inputStream.stopAtInsertionPoint
+	p.Tokenizer.inputStream.stopAtInsertionPoint = true
+	err := p.run(MakeProgress(p.TreeConstructor.getAdjustedCurrentNode(), nil))
+	p.Tokenizer.inputStream.stopAtInsertionPoint = stop
+	return err
+}
+
+// Close inserts an explicit EOF character at the end of the input stream and
+// runs the tokenizer until it reaches it. It returns the error that stopped
+// the parser.
+// https://html.spec.whatwg.org/multipage/dynamic-markup-insertion.html#dom-document-close
+func (p *Parser) Close() error {
+	p.Tokenizer.inputStream.explicitEOF = true
+	if p.TreeConstructor.pendingParsingBlockingScript != nil {
+		return nil
+	}
+	return p.run(MakeProgress(p.TreeConstructor.getAdjustedCurrentNode(), nil))
+}
+
+// Abort throws away any pending input and stops the parser.
+// https://html.spec.whatwg.org/multipage/parsing.html#abort-a-parser
+func (p *Parser) Abort() {
+	p.Tokenizer.inputStream.discard()
+	p.TreeConstructor.HTMLDocument.ReadyState = spec.Interactive
+	for len(p.TreeConstructor.stackOfOpenElements.NodeList) > 0 {
+		p.TreeConstructor.stackOfOpenElements.Pop()
+	}
+	p.TreeConstructor.HTMLDocument.ReadyState = spec.Complete
+	p.TreeConstructor.aborted = true
+	p.TreeConstructor.stopped = true
+}
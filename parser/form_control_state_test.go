@@ -0,0 +1,178 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/heathj/gobrowse/parser/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValueSanitization(t *testing.T) {
+	tests := []struct {
+		htmlIn, expected string
+	}{
+		{"<input value=\"a\r\nb\">", "ab"},
+		{`<input type=url value=" http://a/ ">`, "http://a/"},
+		{`<input type=email multiple value=" a@b.c , d@e.f ">`, "a@b.c,d@e.f"},
+		{`<input type=number value=abc>`, ""},
+		{`<input type=number value=1e3>`, "1e3"},
+		{`<input type=range>`, "50"},
+		{`<input type=range min=0 max=10 value=11>`, "10"},
+		{`<input type=range min=0 max=10 step=4 value=7>`, "8"},
+		{`<input type=date value=2020-02-30>`, ""},
+		{`<input type=week value=2020-W53>`, "2020-W53"},
+		{`<input type=week value=2021-W53>`, ""},
+		{`<input type="datetime-local" value="2020-01-02 03:04:00">`, "2020-01-02T03:04"},
+		{`<input type=color value=#ABCDEF>`, "#abcdef"},
+		{`<input type=color value=red>`, "#000000"},
+		{`<input type=checkbox>`, "on"},
+		{`<input type=hidden value=" x ">`, " x "},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.htmlIn, func(t *testing.T) {
+			t.Parallel()
+			doc := parseTestDocument(t, tt.htmlIn)
+			assert.Equal(t, tt.expected, findElement(doc, "input").ControlValue())
+		})
+	}
+}
+
+func TestControlValue(t *testing.T) {
+	doc := parseTestDocument(t, "<input value=a><input type=file><input type=checkbox value=x><textarea>\n\na\r\nb</textarea>")
+	input := findElement(doc, "input")
+	assert.NoError(t, input.SetControlValue("b\nc"))
+	assert.Equal(t, "bc", input.ControlValue())
+	assert.Equal(t, "a", input.DefaultValue())
+
+	file := input.NextSibling
+	assert.ErrorIs(t, file.SetControlValue("x"), spec.ErrInvalidState)
+	file.SetFiles([]spec.FormFile{{Name: "a.txt"}, {Name: "b.txt"}})
+	assert.Equal(t, `C:\fakepath\a.txt`, file.ControlValue())
+	assert.Len(t, file.Files(), 1)
+	assert.NoError(t, file.SetControlValue(""))
+	assert.Empty(t, file.Files())
+
+	checkbox := file.NextSibling
+	assert.NoError(t, checkbox.SetControlValue("y"))
+	assert.Equal(t, "y", checkbox.DefaultValue())
+
+	textarea := findElement(doc, "textarea")
+	assert.Equal(t, "\na\nb", textarea.ControlValue())
+	textarea.SetDefaultValue("c")
+	assert.Equal(t, "c", textarea.ControlValue())
+	assert.NoError(t, textarea.SetControlValue("d\re"))
+	assert.Equal(t, "d\ne", textarea.ControlValue())
+	textarea.SetDefaultValue("f")
+	assert.Equal(t, "d\ne", textarea.ControlValue())
+}
+
+func TestCheckedness(t *testing.T) {
+	doc := parseTestDocument(t, `<form><input type=radio name=r checked><input type=radio name=r checked><input type=radio name=r></form><input type=radio name=r checked>`)
+	form := findElement(doc, "form")
+	first := findElement(form, "input")
+	second, third := first.NextSibling, first.NextSibling.NextSibling
+	outside := form.NextSibling
+	assert.False(t, first.Checked())
+	assert.True(t, second.Checked())
+	assert.True(t, outside.Checked())
+
+	third.SetChecked(true)
+	assert.Equal(t, []bool{false, false, true, true}, []bool{first.Checked(), second.Checked(), third.Checked(), outside.Checked()})
+
+	third.SetChecked(false)
+	assert.False(t, third.Checked())
+	assert.False(t, second.Checked())
+
+	// the others in the group aren't dirty so they follow the attribute.
+	second.SetAttribute("checked", "")
+	assert.True(t, second.Checked())
+	second.RemoveAttribute("checked")
+	assert.False(t, second.Checked())
+	third.SetAttribute("checked", "")
+	assert.False(t, third.Checked())
+}
+
+func TestSelectedness(t *testing.T) {
+	doc := parseTestDocument(t, `<select><option disabled>a<option selected>b<option selected>c</select><select multiple><option>x<option selected>y</select>`)
+	sel := findElement(doc, "select")
+	options := sel.ChildNodes
+	assert.Equal(t, 2, sel.SelectedIndex())
+	assert.Equal(t, "c", sel.ControlValue())
+	assert.False(t, options[1].Selected())
+
+	options[1].SetSelected(true)
+	assert.Equal(t, 1, sel.SelectedIndex())
+	assert.False(t, options[2].Selected())
+
+	options[1].SetSelected(false)
+	assert.Equal(t, 1, sel.SelectedIndex())
+
+	assert.NoError(t, sel.SetControlValue("missing"))
+	assert.Equal(t, -1, sel.SelectedIndex())
+	assert.Equal(t, "", sel.ControlValue())
+	sel.SetSelectedIndex(2)
+	assert.Equal(t, "c", sel.ControlValue())
+
+	multiple := sel.NextSibling
+	multiple.ChildNodes[0].SetSelected(true)
+	assert.Len(t, multiple.SelectedOptions(), 2)
+
+	// y isn't dirty so it follows the attribute, x is.
+	multiple.ChildNodes[1].RemoveAttribute("selected")
+	multiple.ChildNodes[0].RemoveAttribute("selected")
+	assert.Equal(t, []*spec.Node{multiple.ChildNodes[0]}, multiple.SelectedOptions())
+}
+
+func TestFormReset(t *testing.T) {
+	doc := parseTestDocument(t, `<form>
+<input name=a value=x>
+<input type=checkbox name=b checked>
+<select name=c><option>1<option selected>2</select>
+<textarea name=d>text</textarea>
+</form>`)
+	form := findElement(doc, "form")
+	input := findElement(form, "input")
+	checkbox := input.NextSibling.NextSibling
+	sel := findElement(form, "select")
+	textarea := findElement(form, "textarea")
+	definition := &formAssociatedCustomElement{}
+	custom := spec.NewDOMElement(doc, "my-control", spec.Htmlns)
+	custom.FormAssociatedCustomElement = definition
+	form.AppendChild(custom)
+
+	assert.NoError(t, input.SetControlValue("changed"))
+	checkbox.SetChecked(false)
+	sel.SetSelectedIndex(0)
+	assert.NoError(t, textarea.SetControlValue("changed"))
+	assert.Equal(t, []spec.FormDataEntry{{Name: "a", Value: "changed"}, {Name: "c", Value: "1"}, {Name: "d", Value: "changed"}}, form.HTMLForm.EntryList(nil))
+
+	canceled := true
+	listener := &spec.EventListener{Callback: func(e *spec.Event) {
+		if canceled {
+			e.PreventDefault()
+		}
+	}}
+	form.AddEventListener("reset", listener)
+	form.HTMLForm.Reset()
+	assert.Equal(t, "changed", input.ControlValue())
+
+	canceled = false
+	form.HTMLForm.Reset()
+	assert.Equal(t, []spec.FormDataEntry{{Name: "a", Value: "x"}, {Name: "b", Value: "on"}, {Name: "c", Value: "2"}, {Name: "d", Value: "text"}}, form.HTMLForm.EntryList(nil))
+	assert.Equal(t, 1, definition.resets)
+}
+
+func TestLengthValidityAfterInput(t *testing.T) {
+	doc := parseTestDocument(t, `<input maxlength=3 minlength=2 value=abcdef>`)
+	input := findElement(doc, "input")
+	assert.True(t, input.Validity().Valid)
+
+	assert.NoError(t, input.SetControlValue("a"))
+	assert.True(t, input.Validity().TooShort)
+	assert.NoError(t, input.SetControlValue("abcd"))
+	assert.True(t, input.Validity().TooLong)
+	assert.NoError(t, input.SetControlValue("abc"))
+	assert.True(t, input.Validity().Valid)
+}
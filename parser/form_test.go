@@ -0,0 +1,204 @@
+package parser
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/heathj/gobrowse/parser/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormOwner(t *testing.T) {
+	doc := parseTestDocument(t, `<form id=f1><input name=a><div><select id=b></select></div><input type=image name=img></form>`+
+		`<textarea form=f1 name=c></textarea><output form=nope></output><img name=pic><form id=f2><button name=d>`)
+	f1 := findElement(doc, "form")
+	input := findElement(doc, "input")
+	textarea := findElement(doc, "textarea")
+	output := findElement(doc, "output")
+	button := findElement(doc, "button")
+
+	assert.Same(t, f1, input.Form())
+	assert.Same(t, f1, textarea.Form())
+	assert.Nil(t, output.Form())
+	assert.NotEqual(t, f1, button.Form())
+
+	elements := f1.HTMLForm.Elements()
+	assert.Equal(t, 3, elements.Length())
+	assert.Equal(t, 3, f1.HTMLForm.Length())
+	assert.Same(t, input, elements.Item(0))
+	assert.Same(t, textarea, elements.Item(2))
+	assert.Nil(t, elements.Item(3))
+	assert.Same(t, findElement(doc, "select"), elements.NamedItem("b"))
+	assert.Same(t, textarea, elements.NamedItem("c"))
+
+	// the collection is live and removing a control resets its form owner.
+	input.ParentNode.RemoveChild(input)
+	assert.Nil(t, input.Form())
+	assert.Equal(t, 2, elements.Length())
+	button.ParentNode.RemoveChild(button)
+	f1.AppendChild(button)
+	assert.Same(t, f1, button.Form())
+	assert.Same(t, button, elements.Item(1))
+
+	// controls associated through the form attribute lose their owner once the
+	// form leaves the document.
+	f1.ParentNode.RemoveChild(f1)
+	assert.Nil(t, textarea.Form())
+	assert.Same(t, f1, button.Form())
+
+	assert.True(t, textarea.IsSubmittable())
+	assert.True(t, output.IsResettable())
+	assert.False(t, output.IsSubmittable())
+	assert.False(t, findElement(doc, "img").IsListed())
+	assert.True(t, findElement(doc, "img").IsFormAssociated())
+}
+
+func TestFormControlsCollectionUpdates(t *testing.T) {
+	doc := parseTestDocument(t, `<form id=f><div><input name=a></div><input name=b></form><input form=f name=c>`)
+	form := findElement(doc, "form")
+	elements := form.HTMLForm.Elements()
+	assert.Equal(t, 3, elements.Length())
+	a, b, c := elements.Item(0), elements.Item(1), elements.Item(2)
+
+	// moving a control changes its place in the collection.
+	a.ParentNode.RemoveChild(a)
+	form.AppendChild(a)
+	assert.Same(t, b, elements.Item(0))
+	assert.Same(t, a, elements.Item(1))
+	assert.Same(t, c, elements.Item(2))
+
+	// moving an ancestor of controls moves them too.
+	div := findElement(doc, "div")
+	form.RemoveChild(b)
+	div.AppendChild(b)
+	form.RemoveChild(div)
+	form.AppendChild(div)
+	assert.Same(t, a, elements.Item(0))
+	assert.Same(t, b, elements.Item(1))
+
+	b.SetAttribute("type", "image")
+	assert.Equal(t, 2, elements.Length())
+	b.RemoveAttribute("type")
+	assert.Equal(t, 3, elements.Length())
+
+	b.SetAttribute("name", "renamed")
+	assert.Same(t, b, elements.NamedItem("renamed"))
+
+	form.ParentNode.RemoveChild(form)
+	assert.Nil(t, c.Form())
+	assert.Equal(t, 2, elements.Length())
+}
+
+func TestFormOwnerInsideTemplate(t *testing.T) {
+	doc := parseTestDocument(t, `<form><template><input></template></form>`)
+	assert.Nil(t, findElement(doc, "input").Form())
+}
+
+type formAssociatedCustomElement struct {
+	forms  []*spec.Node
+	resets int
+}
+
+func (c *formAssociatedCustomElement) FormAssociatedCallback(element, form *spec.Node) {
+	c.forms = append(c.forms, form)
+}
+
+func (c *formAssociatedCustomElement) FormResetCallback(element *spec.Node) {
+	c.resets++
+}
+
+func TestFormAssociatedCustomElement(t *testing.T) {
+	doc := parseTestDocument(t, `<form></form>`)
+	form := findElement(doc, "form")
+	el := spec.NewDOMElement(doc, "my-control", spec.Htmlns)
+	definition := &formAssociatedCustomElement{}
+	el.FormAssociatedCustomElement = definition
+
+	internals, err := el.AttachInternals()
+	assert.NoError(t, err)
+	_, err = el.AttachInternals()
+	assert.ErrorIs(t, err, spec.ErrNotSupported)
+
+	form.AppendChild(el)
+	assert.Same(t, form, internals.Form())
+	assert.Same(t, el, form.HTMLForm.Elements().Item(0))
+	form.RemoveChild(el)
+	assert.Nil(t, internals.Form())
+	assert.Equal(t, []*spec.Node{form, nil}, definition.forms)
+
+	_, err = findElement(doc, "form").AttachInternals()
+	assert.ErrorIs(t, err, spec.ErrNotSupported)
+}
+
+func TestFormEntryList(t *testing.T) {
+	doc := parseTestDocument(t, `<form>
+<input name=q value="a b">
+<input type=checkbox name=c1 checked><input type=checkbox name=c2 value=x><input type=checkbox name=c3 value=y checked>
+<input type=radio name=r value=1><input type=radio name=r value=2 checked>
+<select name=s><option>one</option><option selected value=2>two</option></select>
+<select name=m multiple><option selected>a</option><option selected disabled>b</option><optgroup><option selected> c  d </option></optgroup></select>
+<select name=first><option disabled>x</option><option>y</option></select>
+<textarea name=t dirname=t.dir>
+line1
+line2</textarea>
+<input type=file name=f>
+<input type=hidden name=_charset_>
+<input name=disabled disabled><fieldset disabled><input name=infieldset></fieldset>
+<datalist><input name=indatalist></datalist>
+<input type=submit name=go value=Go><button name=other value=o></button>
+<input type=image name=img>
+<input value=noname>
+</form>`)
+	form := findElement(doc, "form")
+	submitter := findElement(doc, "button")
+	entries := form.HTMLForm.EntryList(submitter)
+	assert.Equal(t, []spec.FormDataEntry{
+		{Name: "q", Value: "a b"},
+		{Name: "c1", Value: "on"},
+		{Name: "c3", Value: "y"},
+		{Name: "r", Value: "2"},
+		{Name: "s", Value: "2"},
+		{Name: "m", Value: "a"},
+		{Name: "m", Value: "c d"},
+		{Name: "first", Value: "y"},
+		{Name: "t", Value: "line1\nline2"},
+		{Name: "t.dir", Value: "ltr"},
+		{Name: "f", File: &spec.FormFile{Type: "application/octet-stream"}},
+		{Name: "_charset_", Value: "UTF-8"},
+		{Name: "other", Value: "o"},
+	}, entries)
+
+	assert.Equal(t, "q=a+b&t=line1%0D%0Aline2&x=%7E%26%3D%E2%98%83*-._",
+		spec.EncodeURLEncoded([]spec.FormDataEntry{{Name: "q", Value: "a b"}, {Name: "t", Value: "line1\nline2"}, {Name: "x", Value: "~&=☃*-._"}}))
+	assert.Equal(t, "a=1\r\nb=x\r\ny\r\n",
+		spec.EncodeTextPlain([]spec.FormDataEntry{{Name: "a", Value: "1"}, {Name: "b", Value: "x\ny"}}))
+	assert.Equal(t, "--B\r\nContent-Disposition: form-data; name=\"a%22b\"\r\n\r\nv\r\n"+
+		"--B\r\nContent-Disposition: form-data; name=\"f\"; filename=\"\"\r\nContent-Type: application/octet-stream\r\n\r\n\r\n--B--\r\n",
+		string(spec.EncodeMultipart([]spec.FormDataEntry{{Name: "a\"b", Value: "v"}, {Name: "f", File: &spec.FormFile{Type: "application/octet-stream"}}}, "B")))
+}
+
+func TestFormSubmission(t *testing.T) {
+	doc, err := NewParser(strings.NewReader(`<form action="search?old=1"><input name=q value="go lang"><button formmethod=post formenctype=multipart/form-data formaction=/post>`),
+		WithDocumentURL("https://example.com/dir/page.html")).Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	form := findElement(doc, "form")
+
+	submission, err := form.HTMLForm.Submission(nil)
+	assert.NoError(t, err)
+	req, err := submission.NewRequest()
+	assert.NoError(t, err)
+	assert.Equal(t, http.MethodGet, req.Method)
+	assert.Equal(t, "https://example.com/dir/search?q=go+lang", req.URL.String())
+
+	submission, err = form.HTMLForm.Submission(findElement(doc, "button"))
+	assert.NoError(t, err)
+	req, err = submission.NewRequest()
+	assert.NoError(t, err)
+	assert.Equal(t, http.MethodPost, req.Method)
+	assert.Equal(t, "https://example.com/post", req.URL.String())
+	assert.NoError(t, req.ParseMultipartForm(1<<20))
+	assert.Equal(t, "go lang", req.FormValue("q"))
+}
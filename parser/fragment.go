@@ -13,18 +13,31 @@ func escapeString(s string, attrVal bool) string {
 	s = strings.Replace(s, "\u00A0", "&nbsp;", -1)
 	if attrVal {
 		s = strings.Replace(s, "\"", "&quot;", -1)
-	} else {
-		s = strings.Replace(s, "<", "&lt;", -1)
-		s = strings.Replace(s, ">", "&gt;", -1)
 	}
+	s = strings.Replace(s, "<", "&lt;", -1)
+	s = strings.Replace(s, ">", "&gt;", -1)
 
 	return s
 }
 
+// https://html.spec.whatwg.org/multipage/parsing.html#serializes-as-void
+func serializesAsVoid(n *spec.Node) bool {
+	if n.NodeType != spec.ElementNode || n.NamespaceURI != spec.Htmlns {
+		return false
+	}
+	switch n.NodeName {
+	case "area", "base", "basefont", "bgsound", "br", "col", "embed", "frame",
+		"hr", "img", "input", "keygen", "link", "meta", "param", "source",
+		"track", "wbr":
+		return true
+	}
+	return false
+}
+
+// https://html.spec.whatwg.org/multipage/parsing.html#serialising-html-fragments
 func SerializeHTMLFragement(fragment *spec.Node) string {
 	ret := ""
-	switch fragment.NodeName {
-	case "basefont", "bgsound", "frame", "keygen":
+	if serializesAsVoid(fragment) {
 		return ret
 	}
 
@@ -43,6 +56,9 @@ func SerializeHTMLFragement(fragment *spec.Node) string {
 				ret += " " + k + "=" + "\"" + escapeString(string(child.Attributes.Attrs[k].Value), true) + "\""
 			}
 			ret += ">"
+			if serializesAsVoid(child) {
+				continue
+			}
 			ret += SerializeHTMLFragement(child) + "</" + string(child.NodeName) + ">"
 		case spec.TextNode:
 			switch child.ParentNode.NodeName {
@@ -76,10 +92,56 @@ func SerializeHTMLFragement(fragment *spec.Node) string {
 	return ret
 }
 
-func ParseHTMLFragment(context *spec.Node, input string, quirks quirksMode, scriptingEnabled bool) []*spec.Node {
-	parser := NewParser(strings.NewReader(input))
+// ParseHTMLFragment parses input as if it were the contents of the context
+// element. The returned nodes belong to the context element's node document
+// and have no parent. The context element is left untouched.
+// https://html.spec.whatwg.org/multipage/parsing.html#parsing-html-fragments
+func ParseHTMLFragment(context *spec.Node, input string, scriptingEnabled bool) []*spec.Node {
+	mode := NoQuirks
+	if context.OwnerDocument != nil && context.OwnerDocument.Document != nil {
+		mode = quirksModeFromDocumentMode(context.OwnerDocument.Mode)
+	}
+	nodes := parseHTMLFragment(context, input, mode, scriptingEnabled)
+
+	for _, node := range nodes {
+		node.ParentNode = nil
+		node.ParentElement = nil
+		if context.OwnerDocument != nil {
+			adopt(node, context.OwnerDocument)
+		}
+	}
+	if len(nodes) > 0 {
+		nodes[0].PreviousSibling = nil
+		nodes[len(nodes)-1].NextSibling = nil
+	}
+	return nodes
+}
+
+// quirksModeFromDocumentMode converts a document's mode to the parser's quirks
+// mode.
+// https://dom.spec.whatwg.org/#concept-document-mode
+func quirksModeFromDocumentMode(mode string) QuirksMode {
+	switch mode {
+	case Quirks.String():
+		return Quirks
+	case LimitedQuirks.String():
+		return LimitedQuirks
+	}
+	return NoQuirks
+}
+
+// adopt sets the node document of node and its descendants.
+// https://dom.spec.whatwg.org/#concept-node-adopt
+func adopt(node, document *spec.Node) {
+	node.OwnerDocument = document
+	for _, child := range node.ChildNodes {
+		adopt(child, document)
+	}
+}
+
+func parseHTMLFragment(context *spec.Node, input string, mode QuirksMode, scriptingEnabled bool) []*spec.Node {
+	parser := NewParser(strings.NewReader(input), WithScripting(scriptingEnabled), WithQuirksMode(mode))
 	parser.TreeConstructor.context = context
-	parser.TreeConstructor.quirksMode = quirks
 	var startState tokenizerState
 	switch context.NodeName {
 	case "title", "textarea":
@@ -106,7 +168,7 @@ func ParseHTMLFragment(context *spec.Node, input string, quirks quirksMode, scri
 	parser.TreeConstructor.stackOfOpenElements.Push(n)
 
 	if context.NodeName == "template" {
-		parser.TreeConstructor.stackOfTemplateInsertionModes[0] = inTemplate
+		parser.TreeConstructor.stackOfTemplateInsertionModes = append(parser.TreeConstructor.stackOfTemplateInsertionModes, inTemplate)
 	}
 
 	parser.TreeConstructor.curInsertionMode = parser.TreeConstructor.resetInsertionModeWithContext(context)
@@ -126,3 +188,20 @@ func ParseHTMLFragment(context *spec.Node, input string, quirks quirksMode, scri
 	parser.startAt(&startState)
 	return n.ChildNodes
 }
+
+func init() {
+	spec.ParseHTMLFragment = func(context *spec.Node, markup string) []*spec.Node {
+		return ParseHTMLFragment(context, markup, scriptingEnabledFor(context.OwnerHTMLDocument()))
+	}
+	spec.SerializeHTMLFragment = SerializeHTMLFragement
+}
+
+// scriptingEnabledFor reports if scripting is enabled for the document. That's
+// the case when the document was built by a parser with scripting enabled.
+func scriptingEnabledFor(document *spec.HTMLDocument) bool {
+	if document == nil {
+		return false
+	}
+	p, ok := document.Parser.(*Parser)
+	return ok && p.TreeConstructor.scriptingEnabled
+}
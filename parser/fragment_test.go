@@ -0,0 +1,87 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/heathj/gobrowse/parser/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func parseTestDocument(t *testing.T, htmlIn string) *spec.Node {
+	doc, err := NewParser(strings.NewReader(htmlIn)).Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+// findElement returns the first element with the name in tree order.
+func findElement(n *spec.Node, name string) *spec.Node {
+	for _, child := range n.ChildNodes {
+		if child.NodeType == spec.ElementNode && child.NodeName == name {
+			return child
+		}
+		if found := findElement(child, name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func TestInnerHTML(t *testing.T) {
+	doc := parseTestDocument(t, "<div id=a><p>one<br>two</p></div>")
+	div := findElement(doc, "div")
+	assert.Equal(t, "<p>one<br>two</p>", div.InnerHTML())
+	assert.Equal(t, `<div id="a"><p>one<br>two</p></div>`, div.OuterHTML())
+
+	assert.NoError(t, div.SetInnerHTML("<td>cell</td><b>x"))
+	assert.Equal(t, "cell<b>x</b>", div.InnerHTML())
+	for _, child := range div.ChildNodes {
+		assert.Equal(t, div, child.ParentNode)
+		assert.Equal(t, doc, child.OwnerDocument)
+	}
+
+	table := findElement(parseTestDocument(t, "<table></table>"), "table")
+	assert.NoError(t, table.SetInnerHTML("<tr><td>cell</td></tr>"))
+	assert.Equal(t, "<tbody><tr><td>cell</td></tr></tbody>", table.InnerHTML())
+}
+
+func TestSetOuterHTML(t *testing.T) {
+	doc := parseTestDocument(t, "<div><p>a</p><span>b</span></div>")
+	p := findElement(doc, "p")
+	assert.NoError(t, p.SetOuterHTML("<i>x</i>y"))
+	assert.Equal(t, "<i>x</i>y<span>b</span>", findElement(doc, "div").InnerHTML())
+	assert.Nil(t, p.ParentNode)
+
+	html := findElement(doc, "html")
+	assert.True(t, errors.Is(html.SetOuterHTML("x"), spec.ErrNoModificationAllowed))
+}
+
+func TestInsertAdjacentHTML(t *testing.T) {
+	doc := parseTestDocument(t, "<div><p>b</p></div>")
+	p := findElement(doc, "p")
+	assert.NoError(t, p.InsertAdjacentHTML("beforebegin", "<i>1</i>"))
+	assert.NoError(t, p.InsertAdjacentHTML("afterbegin", "2"))
+	assert.NoError(t, p.InsertAdjacentHTML("BeforeEnd", "<b>3</b>"))
+	assert.NoError(t, p.InsertAdjacentHTML("afterend", "4"))
+	assert.Equal(t, "<i>1</i><p>2b<b>3</b></p>4", findElement(doc, "div").InnerHTML())
+
+	assert.True(t, errors.Is(p.InsertAdjacentHTML("middle", "x"), spec.ErrSyntax))
+	assert.True(t, errors.Is(findElement(doc, "html").InsertAdjacentHTML("afterend", "x"), spec.ErrNoModificationAllowed))
+
+	_, err := p.InsertAdjacentElement("afterend", findElement(doc, "i"))
+	assert.NoError(t, err)
+	assert.NoError(t, p.InsertAdjacentText("beforebegin", "0"))
+	assert.Equal(t, "0<p>2b<b>3</b></p><i>1</i>4", findElement(doc, "div").InnerHTML())
+
+	detached := spec.NewDOMElement(doc, "span", spec.Htmlns)
+	i := findElement(doc, "i")
+	moved, err := detached.InsertAdjacentElement("beforebegin", i)
+	assert.NoError(t, err)
+	assert.Nil(t, moved)
+	_, err = i.InsertAdjacentElement("afterbegin", findElement(doc, "div"))
+	assert.True(t, errors.Is(err, spec.ErrHierarchyRequest))
+	assert.Equal(t, "0<p>2b<b>3</b></p><i>1</i>4", findElement(doc, "div").InnerHTML())
+}
@@ -0,0 +1,252 @@
+package parser
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// errInsertionPointReached is returned when a nested invocation of the
+	// tokenizer has consumed everything up to the insertion point.
+	errInsertionPointReached = errors.New("reached the insertion point")
+	// errNeedMoreInput is returned by a script-created parser's input stream
+	// when it has run out of characters but hasn't seen an explicit EOF yet.
+	errNeedMoreInput = errors.New("waiting for more input")
+)
+
+// inputStream is the input stream of the tokenizer. It reads from the network
+// (an io.Reader) and also holds the characters inserted by document.write
+// in front of it.
+// https://html.spec.whatwg.org/multipage/parsing.html#the-input-byte-stream
+type inputStream struct {
+	reader *bufio.Reader
+	// characters inserted by document.write. the ones from pos onwards haven't
+	// been consumed yet and always come before anything left in the reader.
+	inserted []byte
+	pos      int
+	// insertionPoint is the number of bytes at the end of inserted that come
+	// after the insertion point. -1 when the insertion point is undefined.
+	insertionPoint int
+	// stopAtInsertionPoint makes reads stop when the insertion point is reached
+	// as is required for nested invocations of the tokenizer.
+	stopAtInsertionPoint bool
+	explicitEOF          bool
+	lastFromInserted     bool
+	lastSize             int
+
+	// the number of bytes read from the underlying reader and the most that
+	// may be. maxBytes is 0 when there is no limit.
+	readBytes, maxBytes int64
+}
+
+func newInputStream(r io.Reader) *inputStream {
+	s := &inputStream{insertionPoint: -1}
+	if r != nil {
+		s.reader = bufio.NewReader(&countingReader{r: r, s: s})
+	}
+	return s
+}
+
+// countingReader counts the bytes read for the input stream and stops reading
+// once the stream's limit is exceeded.
+type countingReader struct {
+	r io.Reader
+	s *inputStream
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	if c.s.maxBytes > 0 && c.s.readBytes >= c.s.maxBytes {
+		// read one byte past the limit so input of exactly the limit is fine.
+		if n, err := c.r.Read(make([]byte, 1)); n == 0 {
+			return 0, err
+		}
+		return 0, &LimitError{Limit: "MaxInputBytes", Max: c.s.maxBytes}
+	}
+	if c.s.maxBytes > 0 && int64(len(p)) > c.s.maxBytes-c.s.readBytes {
+		p = p[:c.s.maxBytes-c.s.readBytes]
+	}
+	n, err := c.r.Read(p)
+	c.s.readBytes += int64(n)
+	return n, err
+}
+
+// https://html.spec.whatwg.org/multipage/parsing.html#insertion-point
+func (s *inputStream) insertionPointDefined() bool {
+	return s != nil && s.insertionPoint != -1
+}
+
+// setInsertionPointBeforeNextChar sets the insertion point to just before the
+// next input character.
+func (s *inputStream) setInsertionPointBeforeNextChar() {
+	if s == nil {
+		return
+	}
+	s.insertionPoint = len(s.inserted) - s.pos
+}
+
+func (s *inputStream) undefineInsertionPoint() {
+	if s == nil {
+		return
+	}
+	s.insertionPoint = -1
+}
+
+// insert adds str to the input stream just before the insertion point.
+func (s *inputStream) insert(str string) {
+	if s.pos == len(s.inserted) {
+		s.inserted = s.inserted[:0]
+		s.pos = 0
+	}
+	at := len(s.inserted)
+	if s.insertionPoint != -1 {
+		at -= s.insertionPoint
+	}
+	rest := append([]byte(str), s.inserted[at:]...)
+	s.inserted = append(s.inserted[:at], rest...)
+}
+
+// discard throws away everything pending in the input stream and any content
+// that would have been added to it later.
+func (s *inputStream) discard() {
+	s.inserted = nil
+	s.pos = 0
+	s.reader = nil
+	s.insertionPoint = -1
+	s.explicitEOF = true
+}
+
+// stopsAtInsertionPoint reports if reads are bounded by the insertion point.
+func (s *inputStream) stopsAtInsertionPoint() bool {
+	return s.stopAtInsertionPoint && s.insertionPoint != -1
+}
+
+// available returns the number of inserted bytes that may be read right now.
+func (s *inputStream) available() int {
+	n := len(s.inserted) - s.pos
+	if s.stopsAtInsertionPoint() {
+		n -= s.insertionPoint
+	}
+	return n
+}
+
+func (s *inputStream) ReadRune() (rune, int, error) {
+	s.lastSize = 0
+	if s.available() > 0 {
+		r, size := utf8.DecodeRune(s.inserted[s.pos:])
+		s.pos += size
+		s.lastFromInserted = true
+		s.lastSize = size
+		return r, size, nil
+	}
+
+	if s.stopsAtInsertionPoint() {
+		return 0, 0, errInsertionPointReached
+	}
+
+	if s.reader == nil {
+		if s.explicitEOF {
+			return 0, 0, io.EOF
+		}
+		return 0, 0, errNeedMoreInput
+	}
+
+	r, size, err := s.reader.ReadRune()
+	s.lastFromInserted = false
+	s.lastSize = size
+	return r, size, err
+}
+
+func (s *inputStream) UnreadRune() error {
+	if s.lastSize == 0 {
+		return bufio.ErrInvalidUnreadRune
+	}
+	defer func() { s.lastSize = 0 }()
+	if s.lastFromInserted {
+		s.pos -= s.lastSize
+		return nil
+	}
+	return s.reader.UnreadRune()
+}
+
+// Peek returns the next n bytes without consuming them. If fewer than n bytes
+// are available an error is returned along with the bytes that are.
+func (s *inputStream) Peek(n int) ([]byte, error) {
+	avail := s.available()
+	if avail >= n {
+		return s.inserted[s.pos : s.pos+n], nil
+	}
+	if s.reader == nil || s.stopsAtInsertionPoint() {
+		return s.inserted[s.pos : s.pos+avail], io.EOF
+	}
+
+	rest, err := s.reader.Peek(n - avail)
+	if avail == 0 {
+		return rest, err
+	}
+	peeked := make([]byte, 0, avail+len(rest))
+	peeked = append(peeked, s.inserted[s.pos:s.pos+avail]...)
+	peeked = append(peeked, rest...)
+	return peeked, err
+}
+
+// Discard skips the next n bytes.
+func (s *inputStream) Discard(n int) (int, error) {
+	avail := s.available()
+	if n <= avail {
+		s.pos += n
+		return n, nil
+	}
+	s.pos += avail
+	if s.reader == nil || s.stopsAtInsertionPoint() {
+		return avail, io.EOF
+	}
+	d, err := s.reader.Discard(n - avail)
+	return avail + d, err
+}
+
+// scanText consumes the run of characters up to the next byte in stops, the
+// next invalid UTF-8 sequence or the end of what's buffered, whichever comes
+// first. It lets the tokenizer skip the state machine for plain text.
+func (s *inputStream) scanText(stops string) string {
+	var buf []byte
+	if avail := s.available(); avail > 0 {
+		buf = s.inserted[s.pos : s.pos+avail]
+	} else if s.reader != nil && !s.stopsAtInsertionPoint() {
+		if s.reader.Buffered() == 0 {
+			if _, err := s.reader.Peek(1); err != nil {
+				return ""
+			}
+		}
+		buf, _ = s.reader.Peek(s.reader.Buffered())
+	}
+
+	n := 0
+	for n < len(buf) {
+		b := buf[n]
+		if b < utf8.RuneSelf {
+			if strings.IndexByte(stops, b) != -1 {
+				break
+			}
+			n++
+			continue
+		}
+		// stop at a rune that's invalid or that might continue past the end of
+		// the buffer. ReadRune will deal with it.
+		r, size := utf8.DecodeRune(buf[n:])
+		if r == utf8.RuneError && size == 1 {
+			break
+		}
+		n += size
+	}
+	if n == 0 {
+		return ""
+	}
+
+	text := string(buf[:n])
+	s.Discard(n)
+	s.lastSize = 0
+	return text
+}
@@ -0,0 +1,158 @@
+// Command gencharref compiles the named character references in
+// char_ref_table.go into the static trie in char_ref_trie.go.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"sort"
+	"strconv"
+)
+
+const (
+	tableFile = "char_ref_table.go"
+	trieFile  = "char_ref_trie.go"
+)
+
+type node struct {
+	children map[byte]*node
+	value    []rune
+	index    int
+}
+
+func newNode() *node {
+	return &node{children: map[byte]*node{}}
+}
+
+// readTable parses the charRefTable composite literal out of the source file.
+func readTable(filename string) map[string][]rune {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, nil, 0)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	table := map[string][]rune{}
+	ast.Inspect(f, func(n ast.Node) bool {
+		spec, ok := n.(*ast.ValueSpec)
+		if !ok || len(spec.Names) != 1 || spec.Names[0].Name != "charRefTable" {
+			return true
+		}
+		for _, elt := range spec.Values[0].(*ast.CompositeLit).Elts {
+			kv := elt.(*ast.KeyValueExpr)
+			name, err := strconv.Unquote(kv.Key.(*ast.BasicLit).Value)
+			if err != nil {
+				log.Fatal(err)
+			}
+			var runes []rune
+			for _, r := range kv.Value.(*ast.CompositeLit).Elts {
+				v, err := strconv.ParseInt(r.(*ast.BasicLit).Value, 0, 32)
+				if err != nil {
+					log.Fatal(err)
+				}
+				runes = append(runes, rune(v))
+			}
+			table[name] = runes
+		}
+		return false
+	})
+	if len(table) == 0 {
+		log.Fatalf("no charRefTable found in %s", filename)
+	}
+	return table
+}
+
+func main() {
+	table := readTable(tableFile)
+	root := newNode()
+	maxLength := 0
+	for name, value := range table {
+		n := root
+		for i := 0; i < len(name); i++ {
+			child, ok := n.children[name[i]]
+			if !ok {
+				child = newNode()
+				n.children[name[i]] = child
+			}
+			n = child
+		}
+		n.value = value
+		if len(name) > maxLength {
+			maxLength = len(name)
+		}
+	}
+
+	// number the nodes breadth first so the children of every node are
+	// next to each other in the edge list.
+	nodes := []*node{root}
+	for i := 0; i < len(nodes); i++ {
+		nodes[i].index = i
+		for _, b := range sortedKeys(nodes[i].children) {
+			nodes = append(nodes, nodes[i].children[b])
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by \"go run ./internal/gencharref\"; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package parser\n\n")
+	fmt.Fprintf(&buf, "// maxCharRefLength is the length of the longest named character reference.\n")
+	fmt.Fprintf(&buf, "const maxCharRefLength = %d\n\n", maxLength)
+
+	var values [][]rune
+	fmt.Fprintf(&buf, "var charRefTrie = [...]charRefTrieNode{\n")
+	edge := 0
+	for _, n := range nodes {
+		value := 0
+		if n.value != nil {
+			values = append(values, n.value)
+			value = len(values)
+		}
+		fmt.Fprintf(&buf, "{%d, %d, %d},\n", edge, len(n.children), value)
+		edge += len(n.children)
+	}
+	fmt.Fprintf(&buf, "}\n\n")
+
+	fmt.Fprintf(&buf, "var charRefTrieEdges = [...]charRefTrieEdge{\n")
+	for i, n := range nodes {
+		for _, b := range sortedKeys(n.children) {
+			fmt.Fprintf(&buf, "{%q, %d}, ", b, n.children[b].index)
+		}
+		if len(n.children) > 0 || i == 0 {
+			fmt.Fprintf(&buf, "\n")
+		}
+	}
+	fmt.Fprintf(&buf, "}\n\n")
+
+	fmt.Fprintf(&buf, "var charRefValues = [...][2]rune{\n{},\n")
+	for _, v := range values {
+		if len(v) == 1 {
+			fmt.Fprintf(&buf, "{%d},\n", v[0])
+		} else {
+			fmt.Fprintf(&buf, "{%d, %d},\n", v[0], v[1])
+		}
+	}
+	fmt.Fprintf(&buf, "}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(trieFile, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func sortedKeys(m map[byte]*node) []byte {
+	keys := make([]byte, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
@@ -0,0 +1,77 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Limits bound the resources a parser may use on untrusted input. A zero value
+// for any of the fields means there is no limit. Parsers created by NewParser
+// use DefaultLimits unless they're given others with WithLimits, and the
+// parsers created by document.open() keep the limits of the parser they
+// replace.
+type Limits struct {
+	// MaxDepth is the deepest elements are nested in the tree. Elements that
+	// would be nested deeper are inserted as siblings of the deepest element
+	// instead, like browsers do.
+	MaxDepth int
+	// MaxNodes is the number of nodes the parser will create before giving up.
+	MaxNodes int
+	// MaxAttributes is the number of attributes kept per element. Any that come
+	// after are dropped.
+	MaxAttributes int
+	// MaxAttributeValueLength is the number of bytes kept for an attribute
+	// value. Longer values are truncated.
+	MaxAttributeValueLength int
+	// MaxTokenSize is the number of bytes a single tag, comment or DOCTYPE
+	// token may hold before the parser gives up.
+	MaxTokenSize int
+	// MaxTextLength is the number of bytes of character tokens a single text
+	// node may hold before the parser gives up.
+	MaxTextLength int
+	// MaxInputBytes is the number of bytes read from the input before the
+	// parser gives up.
+	MaxInputBytes int64
+}
+
+// DefaultLimits are reasonable limits for parsing documents from the web.
+var DefaultLimits = Limits{
+	MaxDepth:                512,
+	MaxNodes:                1 << 20,
+	MaxAttributes:           1024,
+	MaxAttributeValueLength: 1 << 20,
+	MaxTokenSize:            1 << 24,
+	MaxTextLength:           1 << 24,
+	MaxInputBytes:           1 << 28,
+}
+
+// ErrLimitExceeded is matched by every LimitError using errors.Is.
+var ErrLimitExceeded = errors.New("parser limit exceeded")
+
+// LimitError is returned when parsing stopped because the input went over one
+// of the parser's Limits.
+type LimitError struct {
+	// Limit is the name of the field in Limits that was exceeded.
+	Limit string
+	Max   int64
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s: %s of %d", ErrLimitExceeded, e.Limit, e.Max)
+}
+
+func (e *LimitError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// WithLimits bounds the resources used by the parser instead of
+// DefaultLimits. WithLimits(Limits{}) removes every limit.
+func WithLimits(limits Limits) Option {
+	return func(p *Parser) {
+		p.TreeConstructor.limits = limits
+		p.Tokenizer.maxTokenSize = limits.MaxTokenSize
+		p.Tokenizer.inputStream.maxBytes = limits.MaxInputBytes
+		p.Tokenizer.tokenBuilder.maxAttributes = limits.MaxAttributes
+		p.Tokenizer.tokenBuilder.maxAttributeValueLength = limits.MaxAttributeValueLength
+	}
+}
@@ -0,0 +1,85 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/heathj/gobrowse/parser/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+type parserLimitsTestcase struct {
+	htmlIn     string
+	limits     Limits
+	serialized string
+	limit      string
+}
+
+func TestParserLimits(t *testing.T) {
+	tests := []parserLimitsTestcase{
+		{"<div><div><div><p>x</div>", Limits{MaxDepth: 3}, "<html><head></head><body><div><div></div><div></div><p></p>x</div></body></html>", ""},
+		{"<p a=1 b=2 c=3>", Limits{MaxAttributes: 2}, `<html><head></head><body><p a="1" b="2"></p></body></html>`, ""},
+		{"<p a=12345 b=1>", Limits{MaxAttributeValueLength: 3}, `<html><head></head><body><p a="123" b="1"></p></body></html>`, ""},
+		{"<p><p><p><p>", Limits{MaxNodes: 5}, "", "MaxNodes"},
+		{"<p><!--" + strings.Repeat("x", 100) + "-->", Limits{MaxTokenSize: 64}, "", "MaxTokenSize"},
+		{"<p>" + strings.Repeat("x", 100), Limits{MaxTokenSize: 64}, "<html><head></head><body><p>" + strings.Repeat("x", 100) + "</p></body></html>", ""},
+		{"<p>" + strings.Repeat("x", 100), Limits{MaxTextLength: 64}, "", "MaxTextLength"},
+		{"<textarea>" + strings.Repeat("x&amp;", 40), Limits{MaxTextLength: 64}, "", "MaxTextLength"},
+		{"<p>" + strings.Repeat("x", 60) + "<b>" + strings.Repeat("x", 60), Limits{MaxTextLength: 64}, "<html><head></head><body><p>" + strings.Repeat("x", 60) + "<b>" + strings.Repeat("x", 60) + "</b></p></body></html>", ""},
+		{"<p>" + strings.Repeat("x", 100), Limits{MaxInputBytes: 50}, "", "MaxInputBytes"},
+		{"<p>" + strings.Repeat("x", 47), Limits{MaxInputBytes: 50}, "<html><head></head><body><p>" + strings.Repeat("x", 47) + "</p></body></html>", ""},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.htmlIn, func(t *testing.T) {
+			t.Parallel()
+			doc, err := NewParser(strings.NewReader(tt.htmlIn), WithLimits(tt.limits)).Start()
+			if tt.limit != "" {
+				assert.True(t, errors.Is(err, ErrLimitExceeded))
+				var limitErr *LimitError
+				if assert.True(t, errors.As(err, &limitErr)) {
+					assert.Equal(t, tt.limit, limitErr.Limit)
+				}
+				assert.NotNil(t, doc)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tt.serialized, findElement(doc, "html").OuterHTML())
+		})
+	}
+}
+
+func TestStartContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	doc, err := NewParser(strings.NewReader("<p>x")).StartContext(ctx)
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.NotNil(t, doc)
+
+	doc, err = NewParser(strings.NewReader("<p>x")).StartContext(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "<p>x</p>", findElement(doc, "body").InnerHTML())
+}
+
+func TestDefaultLimits(t *testing.T) {
+	depth := func(doc *spec.Node) int {
+		depth := 0
+		for n := findElement(doc, "body"); len(n.ChildNodes) > 0; n = n.ChildNodes[0] {
+			depth++
+		}
+		return depth
+	}
+	input := strings.Repeat("<div>", DefaultLimits.MaxDepth+10)
+	doc, err := NewParser(strings.NewReader(input)).Start()
+	assert.NoError(t, err)
+	assert.Less(t, depth(doc), DefaultLimits.MaxDepth, "parsers use DefaultLimits unless told otherwise")
+
+	doc, err = NewParser(strings.NewReader(input), WithLimits(Limits{})).Start()
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultLimits.MaxDepth+10, depth(doc), "WithLimits replaces them")
+}
@@ -0,0 +1,135 @@
+package parser
+
+import "github.com/heathj/gobrowse/parser/spec"
+
+// Option configures a Parser created with NewParser.
+type Option func(*Parser)
+
+// WithScripting sets the scripting flag of the parser. Scripts are only run
+// when it is enabled and noscript elements are parsed as raw text.
+// https://html.spec.whatwg.org/multipage/parsing.html#scripting-flag
+func WithScripting(enabled bool) Option {
+	return func(p *Parser) {
+		p.TreeConstructor.scriptingEnabled = enabled
+	}
+}
+
+// WithIframeSrcdoc parses the input as an iframe srcdoc document, which is never
+// put in quirks mode because of a missing or legacy DOCTYPE.
+// https://html.spec.whatwg.org/multipage/iframe-embed-object.html#an-iframe-srcdoc-document
+func WithIframeSrcdoc() Option {
+	return func(p *Parser) {
+		p.TreeConstructor.iframeSrcdoc = true
+	}
+}
+
+// WithQuirksMode forces the mode of the document instead of deriving it from
+// the DOCTYPE.
+func WithQuirksMode(mode QuirksMode) Option {
+	return func(p *Parser) {
+		p.TreeConstructor.setQuirksMode(mode)
+		p.TreeConstructor.forcedQuirksMode = true
+	}
+}
+
+// InitialState is a tokenizer state the parser can be started in.
+type InitialState uint
+
+const (
+	DataState InitialState = iota
+	RCDATAState
+	RAWTEXTState
+	ScriptDataState
+	PLAINTEXTState
+	CDATASectionState
+)
+
+func (s InitialState) tokenizerState() tokenizerState {
+	switch s {
+	case RCDATAState:
+		return rcDataState
+	case RAWTEXTState:
+		return rawTextState
+	case ScriptDataState:
+		return scriptDataState
+	case PLAINTEXTState:
+		return plaintextState
+	case CDATASectionState:
+		return cdataSectionState
+	}
+	return dataState
+}
+
+// WithInitialState starts the tokenizer in the state instead of the data state.
+func WithInitialState(state InitialState) Option {
+	return func(p *Parser) {
+		p.initialState = state.tokenizerState()
+	}
+}
+
+// WithDocumentURL sets the URL of the document being parsed and its origin. A
+// URL that can't be parsed is kept as the document's URL string but leaves the
+// URL record about:blank and the origin opaque.
+// https://dom.spec.whatwg.org/#concept-document-url
+func WithDocumentURL(url string) Option {
+	return func(p *Parser) {
+		doc := p.TreeConstructor.HTMLDocument
+		if u, err := spec.ParseURL(url, nil); err == nil {
+			doc.Document.SetURL(u)
+			doc.Origin = u.Origin()
+			return
+		}
+		doc.URL = url
+		doc.DocumentURI = url
+	}
+}
+
+// WithAboutBaseURL sets the base URL of the document that created the one
+// being parsed. It's the fallback base URL of about:blank and about:srcdoc
+// documents.
+// https://html.spec.whatwg.org/multipage/urls-and-fetching.html#fallback-base-url
+func WithAboutBaseURL(url string) Option {
+	return func(p *Parser) {
+		if u, err := spec.ParseURL(url, nil); err == nil {
+			p.TreeConstructor.HTMLDocument.AboutBaseURL = u
+		}
+	}
+}
+
+// WithCookieStore sets the cookie store backing document.cookie. Documents
+// without one are cookie-averse.
+// https://html.spec.whatwg.org/multipage/dom.html#dom-document-cookie
+func WithCookieStore(store spec.CookieStore) Option {
+	return func(p *Parser) {
+		p.TreeConstructor.HTMLDocument.CookieStore = store
+	}
+}
+
+// WithEventLoop sets the event loop the document queues its tasks on. The
+// document becomes complete and DOMContentLoaded is fired when the loop runs
+// them instead of as soon as parsing stops.
+func WithEventLoop(loop spec.EventLoop) Option {
+	return func(p *Parser) {
+		p.TreeConstructor.HTMLDocument.EventLoop = loop
+	}
+}
+
+// WithScriptHost sets the script host running the document's scripts when
+// the parser doesn't have a ScriptExecutor, and enables scripting.
+func WithScriptHost(host spec.ScriptHost) Option {
+	return func(p *Parser) {
+		p.TreeConstructor.HTMLDocument.ScriptHost = host
+		p.TreeConstructor.scriptingEnabled = true
+	}
+}
+
+// WithBrowsingContext sets the browsing context the document is parsed for.
+// It navigates for the document's Location and keeps the session history of
+// its History, and the document's iframes get nested browsing contexts.
+func WithBrowsingContext(bc spec.BrowsingContext) Option {
+	return func(p *Parser) {
+		doc := p.TreeConstructor.HTMLDocument
+		doc.BrowsingContext = bc
+		doc.DefaultView = spec.NewWindowProxy(bc, doc)
+	}
+}
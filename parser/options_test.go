@@ -0,0 +1,46 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type parserOptionsTestcase struct {
+	htmlIn             string
+	opts               []Option
+	mode, compatMode   string
+	serialized, docURL string
+}
+
+func TestParserOptions(t *testing.T) {
+	tests := []parserOptionsTestcase{
+		{"<!DOCTYPE html><p>", nil, "no-quirks", "CSS1Compat", "<html><head></head><body><p></p></body></html>", ""},
+		{"<p>", nil, "quirks", "BackCompat", "<html><head></head><body><p></p></body></html>", ""},
+		{`<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN"><p>`, nil, "limited-quirks", "CSS1Compat", "<html><head></head><body><p></p></body></html>", ""},
+		{"<p>", []Option{WithIframeSrcdoc()}, "no-quirks", "CSS1Compat", "<html><head></head><body><p></p></body></html>", ""},
+		{"<!DOCTYPE html><p>", []Option{WithQuirksMode(Quirks)}, "quirks", "BackCompat", "<html><head></head><body><p></p></body></html>", ""},
+		{"<p><table>", []Option{WithQuirksMode(Quirks)}, "quirks", "BackCompat", "<html><head></head><body><p><table></table></p></body></html>", ""},
+		{"<noscript><p></noscript>", []Option{WithScripting(true)}, "quirks", "BackCompat", "<html><head><noscript><p></noscript></head><body></body></html>", ""},
+		{"<noscript><p></noscript>", []Option{WithScripting(false)}, "quirks", "BackCompat", "<html><head><noscript></noscript></head><body><p></p></body></html>", ""},
+		{"<p>x", []Option{WithInitialState(PLAINTEXTState)}, "quirks", "BackCompat", "<html><head></head><body>&lt;p&gt;x</body></html>", ""},
+		{"<p>", []Option{WithDocumentURL("https://example.com/")}, "quirks", "BackCompat", "<html><head></head><body><p></p></body></html>", "https://example.com/"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.htmlIn, func(t *testing.T) {
+			t.Parallel()
+			p := NewParser(strings.NewReader(tt.htmlIn), tt.opts...)
+			doc, err := p.Start()
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tt.mode, doc.Mode)
+			assert.Equal(t, tt.compatMode, doc.CompatMode)
+			assert.Equal(t, tt.docURL, doc.URL)
+			assert.Equal(t, tt.serialized, findElement(doc, "html").OuterHTML())
+		})
+	}
+}
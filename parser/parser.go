@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"context"
 	"io"
 
 	"github.com/heathj/gobrowse/parser/spec"
@@ -9,15 +10,32 @@ import (
 type Parser struct {
 	Tokenizer       *HTMLTokenizer
 	TreeConstructor *HTMLTreeConstructor
+	scriptCreated   bool
+	initialState    tokenizerState
+	// ctx is checked between tokens so parsing can be cancelled. It's the
+	// context given to StartContext, which script-created parsers replacing
+	// this one keep.
+	ctx context.Context
 }
 
-func NewParser(htmlIn io.Reader) *Parser {
-	tokenizer := NewHTMLTokenizer(htmlIn)
-	treeConstructor := NewHTMLTreeConstructor()
-	return &Parser{
+func NewParser(htmlIn io.Reader, opts ...Option) *Parser {
+	opts = append([]Option{WithLimits(DefaultLimits)}, opts...)
+	return newParser(NewHTMLTokenizer(htmlIn), NewHTMLTreeConstructor(), opts...)
+}
+
+// newParser associates the tokenizer and tree constructor with each other and
+// with the document being built, then applies the options.
+func newParser(tokenizer *HTMLTokenizer, treeConstructor *HTMLTreeConstructor, opts ...Option) *Parser {
+	treeConstructor.inputStream = tokenizer.inputStream
+	p := &Parser{
 		Tokenizer:       tokenizer,
 		TreeConstructor: treeConstructor,
 	}
+	treeConstructor.HTMLDocument.Parser = p
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
 }
 
 type Progress struct {
@@ -32,28 +50,81 @@ func MakeProgress(adjCurNode *spec.Node, tokenizerState *tokenizerState) *Progre
 	}
 }
 
+// SetScriptExecutor sets the executor used to fetch and run the scripts found
+// in the document.
+func (p *Parser) SetScriptExecutor(e ScriptExecutor) {
+	p.TreeConstructor.scriptExecutor = e
+}
+
 func (p *Parser) Start() (*spec.Node, error) {
-	start := dataState
+	return p.StartContext(context.Background())
+}
+
+// StartContext parses the document like Start but stops with the context's
+// error once it is done. The document built so far is returned along with any
+// error so callers can decide whether a partial tree is useful.
+func (p *Parser) StartContext(ctx context.Context) (*spec.Node, error) {
+	p.ctx = ctx
+	start := p.initialState
 	if err := p.startAt(&start); err != nil {
-		return nil, err
+		return p.TreeConstructor.HTMLDocument.Node, err
 	}
 	return p.TreeConstructor.HTMLDocument.Node, nil
 }
 
 // start parsing the tokens at a specific start point
 func (p *Parser) startAt(startState *tokenizerState) error {
-	progress := MakeProgress(nil, startState)
-	for p.Tokenizer.Next() {
+	return p.run(MakeProgress(nil, startState))
+}
+
+// run processes tokens until the input is exhausted. Nested invocations of the
+// tokenizer also return when they reach the insertion point or when the tree
+// construction stage pauses the parser.
+func (p *Parser) run(progress *Progress) error {
+	// scripts can look at the tree once the parser yields.
+	defer p.TreeConstructor.flushText()
+	for p.Tokenizer.Next() && !p.TreeConstructor.aborted {
+		if p.ctx != nil {
+			select {
+			case <-p.ctx.Done():
+				return p.ctx.Err()
+			default:
+			}
+		}
 		t, err := p.Tokenizer.Token(progress)
+		if err == errInsertionPointReached || err == errNeedMoreInput {
+			return nil
+		}
 		if err != nil {
 			return err
 		}
 		progress = p.TreeConstructor.ProcessToken(*t)
+		if p.TreeConstructor.err != nil {
+			return p.TreeConstructor.err
+		}
+		if p.TreeConstructor.parserPause && p.TreeConstructor.scriptNestingLevel > 0 {
+			return nil
+		}
+		p.resumeAfterScripts()
 	}
 
 	return nil
 }
 
+// resumeAfterScripts runs the scripts that are waiting on the parser to yield.
+// A paused parser only resumes tokenizing once the pending parsing-blocking
+// script has been executed.
+// https://html.spec.whatwg.org/multipage/parsing.html#scriptEndTag
+func (p *Parser) resumeAfterScripts() {
+	if p.TreeConstructor.scriptNestingLevel > 0 {
+		return
+	}
+	if p.TreeConstructor.parserPause {
+		p.TreeConstructor.runPendingParsingBlockingScripts()
+	}
+	p.TreeConstructor.runReadyScripts()
+}
+
 // startAtTokens returns the set of tokens that were produced from this input.
 // mainly used for testing and debugging tokenizer.
 func (p *Parser) startAtTokens(startState *tokenizerState) ([]Token, error) {
@@ -0,0 +1,301 @@
+package parser
+
+import (
+	"io"
+	"strings"
+
+	"github.com/heathj/gobrowse/parser/spec"
+)
+
+// RequestDestination is the destination of a fetch request.
+// https://fetch.spec.whatwg.org/#concept-request-destination
+type RequestDestination string
+
+const (
+	AudioDestination    RequestDestination = "audio"
+	DocumentDestination RequestDestination = "document"
+	EmbedDestination    RequestDestination = "embed"
+	FetchDestination    RequestDestination = ""
+	FontDestination     RequestDestination = "font"
+	ImageDestination    RequestDestination = "image"
+	ManifestDestination RequestDestination = "manifest"
+	ObjectDestination   RequestDestination = "object"
+	ScriptDestination   RequestDestination = "script"
+	StyleDestination    RequestDestination = "style"
+	TrackDestination    RequestDestination = "track"
+	VideoDestination    RequestDestination = "video"
+	WorkerDestination   RequestDestination = "worker"
+)
+
+// https://html.spec.whatwg.org/multipage/links.html#translate-a-preload-destination
+var preloadDestinations = map[string]RequestDestination{
+	"audio":    AudioDestination,
+	"document": DocumentDestination,
+	"embed":    EmbedDestination,
+	"fetch":    FetchDestination,
+	"font":     FontDestination,
+	"image":    ImageDestination,
+	"manifest": ManifestDestination,
+	"object":   ObjectDestination,
+	"script":   ScriptDestination,
+	"style":    StyleDestination,
+	"track":    TrackDestination,
+	"video":    VideoDestination,
+	"worker":   WorkerDestination,
+}
+
+// PreloadRequest is a subresource found by the PreloadScanner.
+type PreloadRequest struct {
+	// URL is the attribute value the request was found in.
+	URL string
+	// ResolvedURL is URL resolved against the document's base URL at the point
+	// the element was found.
+	ResolvedURL string
+	Destination RequestDestination
+	// TagName is the name of the element the request was found on.
+	TagName string
+	// CrossOrigin is the state of the crossorigin attribute. It's empty when
+	// the attribute isn't set.
+	CrossOrigin string
+	Module      bool
+}
+
+// PreloadScanner finds subresources in a document without building a DOM so
+// they can be fetched ahead of the parser. It follows the same tokenizer state
+// changes the tree constructor would make but otherwise ignores the tree.
+// https://html.spec.whatwg.org/multipage/parsing.html#speculative-html-parsing
+type PreloadScanner struct {
+	tokenizer   *HTMLTokenizer
+	documentURL *spec.URL
+	baseURL     *spec.URL
+	seenBase    bool
+
+	// elements that change how their contents are treated.
+	templateDepth, foreignDepth int
+	mediaElement                string
+}
+
+// NewPreloadScanner creates a scanner over the HTML in r. Relative URLs are
+// resolved against documentURL until a base element is found.
+func NewPreloadScanner(r io.Reader, documentURL string) (*PreloadScanner, error) {
+	u, err := spec.ParseURL(documentURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &PreloadScanner{
+		tokenizer:   NewHTMLTokenizer(r),
+		documentURL: u,
+		baseURL:     u,
+	}, nil
+}
+
+// Scan tokenizes the whole input and returns the requests found in document
+// order.
+func (s *PreloadScanner) Scan() ([]PreloadRequest, error) {
+	requests := []PreloadRequest{}
+	progress := MakeProgress(nil, nil)
+	for s.tokenizer.Next() {
+		t, err := s.tokenizer.Token(progress)
+		if err != nil {
+			return requests, err
+		}
+
+		progress = MakeProgress(nil, nil)
+		switch t.TokenType {
+		case startTagToken:
+			requests = s.processStartTag(t, requests)
+			if state, ok := s.nextTokenizerState(t); ok {
+				progress = MakeProgress(nil, &state)
+			}
+		case endTagToken:
+			s.processEndTag(t)
+		}
+	}
+
+	return requests, nil
+}
+
+// nextTokenizerState returns the state the tree constructor would switch the
+// tokenizer to after inserting the element for the start tag.
+func (s *PreloadScanner) nextTokenizerState(t *Token) (tokenizerState, bool) {
+	if s.foreignDepth > 0 || t.SelfClosing {
+		return 0, false
+	}
+
+	switch t.TagName {
+	case "title", "textarea":
+		return rcDataState, true
+	// the scanner speculates for a browser with scripting enabled so the
+	// contents of noscript are never parsed.
+	case "style", "xmp", "iframe", "noembed", "noframes", "noscript":
+		return rawTextState, true
+	case "script":
+		return scriptDataState, true
+	case "plaintext":
+		return plaintextState, true
+	}
+	return 0, false
+}
+
+func (s *PreloadScanner) processEndTag(t *Token) {
+	switch t.TagName {
+	case "template":
+		if s.templateDepth > 0 {
+			s.templateDepth--
+		}
+	case "svg", "math":
+		if s.foreignDepth > 0 {
+			s.foreignDepth--
+		}
+	case "picture", "video", "audio":
+		if s.mediaElement == t.TagName {
+			s.mediaElement = ""
+		}
+	}
+}
+
+func (s *PreloadScanner) processStartTag(t *Token, requests []PreloadRequest) []PreloadRequest {
+	switch t.TagName {
+	case "template":
+		s.templateDepth++
+		return requests
+	case "svg", "math":
+		if !t.SelfClosing {
+			s.foreignDepth++
+		}
+		return requests
+	case "picture", "video", "audio":
+		s.mediaElement = t.TagName
+	}
+
+	// template contents are inert and foreign elements aren't fetched the same
+	// way as their HTML counterparts.
+	if s.templateDepth > 0 || s.foreignDepth > 0 {
+		return requests
+	}
+
+	crossOrigin := corsSettingsState(t)
+	add := func(u string, dest RequestDestination, module bool) {
+		resolved, ok := s.resolve(u)
+		if !ok {
+			return
+		}
+		requests = append(requests, PreloadRequest{
+			URL:         u,
+			ResolvedURL: resolved,
+			Destination: dest,
+			TagName:     t.TagName,
+			CrossOrigin: crossOrigin,
+			Module:      module,
+		})
+	}
+
+	switch t.TagName {
+	case "base":
+		// only the first base element with an href attribute is used.
+		// https://html.spec.whatwg.org/multipage/semantics.html#frozen-base-url
+		if href, ok := tokenAttribute(t, "href"); ok && !s.seenBase {
+			s.seenBase = true
+			if u, err := s.documentURL.Parse(href); err == nil && u.Scheme() != "data" && u.Scheme() != "javascript" {
+				s.baseURL = u
+			}
+		}
+	case "link":
+		href, ok := tokenAttribute(t, "href")
+		if !ok {
+			break
+		}
+		rel, _ := tokenAttribute(t, "rel")
+		for _, keyword := range strings.Fields(strings.ToLower(rel)) {
+			switch keyword {
+			case "stylesheet":
+				add(href, StyleDestination, false)
+			case "modulepreload":
+				add(href, ScriptDestination, true)
+			case "preload":
+				as, _ := tokenAttribute(t, "as")
+				if dest, ok := preloadDestinations[strings.ToLower(strings.TrimSpace(as))]; ok {
+					add(href, dest, false)
+				}
+			}
+		}
+	case "script":
+		src, ok := tokenAttribute(t, "src")
+		if !ok {
+			break
+		}
+		typeAttr, hasType := tokenAttribute(t, "type")
+		langAttr, hasLang := tokenAttribute(t, "language")
+		scriptType, ok := scriptTypeFromAttributes(typeAttr, hasType, langAttr, hasLang)
+		if !ok || scriptType == spec.ImportMapScript {
+			break
+		}
+		if _, noModule := tokenAttribute(t, "nomodule"); noModule && scriptType == spec.ClassicScript {
+			break
+		}
+		add(src, ScriptDestination, scriptType == spec.ModuleScript)
+	case "img":
+		if srcset, ok := tokenAttribute(t, "srcset"); ok {
+			for _, candidate := range spec.ParseSrcset(srcset) {
+				add(candidate.URL, ImageDestination, false)
+			}
+		}
+		if src, ok := tokenAttribute(t, "src"); ok {
+			add(src, ImageDestination, false)
+		}
+	case "source":
+		switch s.mediaElement {
+		case "picture":
+			if srcset, ok := tokenAttribute(t, "srcset"); ok {
+				for _, candidate := range spec.ParseSrcset(srcset) {
+					add(candidate.URL, ImageDestination, false)
+				}
+			}
+		case "video":
+			if src, ok := tokenAttribute(t, "src"); ok {
+				add(src, VideoDestination, false)
+			}
+		case "audio":
+			if src, ok := tokenAttribute(t, "src"); ok {
+				add(src, AudioDestination, false)
+			}
+		}
+	}
+
+	return requests
+}
+
+// resolve parses u relative to the current base URL. Empty URLs are never
+// fetched.
+// https://html.spec.whatwg.org/multipage/urls-and-fetching.html#parse-a-url
+func (s *PreloadScanner) resolve(u string) (string, bool) {
+	u = strings.Trim(u, "\u0009\u000A\u000C\u000D ")
+	if u == "" {
+		return "", false
+	}
+	resolved, err := s.baseURL.Parse(u)
+	if err != nil {
+		return "", false
+	}
+	return resolved.Href(), true
+}
+
+// https://html.spec.whatwg.org/multipage/urls-and-fetching.html#cors-settings-attributes
+func corsSettingsState(t *Token) string {
+	v, ok := tokenAttribute(t, "crossorigin")
+	if !ok {
+		return ""
+	}
+	if strings.EqualFold(v, "use-credentials") {
+		return "use-credentials"
+	}
+	return "anonymous"
+}
+
+func tokenAttribute(t *Token, name string) (string, bool) {
+	attr, ok := t.Attributes[name]
+	if !ok {
+		return "", false
+	}
+	return attr.Value, true
+}
@@ -0,0 +1,83 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type preloadScannerTestcase struct {
+	htmlIn   string
+	expected []PreloadRequest
+}
+
+func TestPreloadScanner(t *testing.T) {
+	tests := []preloadScannerTestcase{
+		{
+			`<link rel=stylesheet href=a.css><script src=/b.js></script><img src=c.png>`,
+			[]PreloadRequest{
+				{URL: "a.css", ResolvedURL: "https://example.com/dir/a.css", Destination: StyleDestination, TagName: "link"},
+				{URL: "/b.js", ResolvedURL: "https://example.com/b.js", Destination: ScriptDestination, TagName: "script"},
+				{URL: "c.png", ResolvedURL: "https://example.com/dir/c.png", Destination: ImageDestination, TagName: "img"},
+			},
+		},
+		{
+			`<img src=a.png><base href="https://cdn.example.com/x/"><base href=/ignored/><img src=b.png>`,
+			[]PreloadRequest{
+				{URL: "a.png", ResolvedURL: "https://example.com/dir/a.png", Destination: ImageDestination, TagName: "img"},
+				{URL: "b.png", ResolvedURL: "https://cdn.example.com/x/b.png", Destination: ImageDestination, TagName: "img"},
+			},
+		},
+		{
+			`<link rel="Preload" href=f.woff2 as=font crossorigin><link rel=preload href=x as=bogus><link rel=modulepreload href=m.js>`,
+			[]PreloadRequest{
+				{URL: "f.woff2", ResolvedURL: "https://example.com/dir/f.woff2", Destination: FontDestination, TagName: "link", CrossOrigin: "anonymous"},
+				{URL: "m.js", ResolvedURL: "https://example.com/dir/m.js", Destination: ScriptDestination, TagName: "link", Module: true},
+			},
+		},
+		{
+			`<script type=module src=m.js></script><script nomodule src=n.js></script><script type=text/template src=t.js></script>`,
+			[]PreloadRequest{
+				{URL: "m.js", ResolvedURL: "https://example.com/dir/m.js", Destination: ScriptDestination, TagName: "script", Module: true},
+			},
+		},
+		{
+			`<img srcset="a.png 1x, b,c.png 2x, d.png (x, y) 3x,e.png 100w">`,
+			[]PreloadRequest{
+				{URL: "a.png", ResolvedURL: "https://example.com/dir/a.png", Destination: ImageDestination, TagName: "img"},
+				{URL: "b,c.png", ResolvedURL: "https://example.com/dir/b,c.png", Destination: ImageDestination, TagName: "img"},
+				{URL: "e.png", ResolvedURL: "https://example.com/dir/e.png", Destination: ImageDestination, TagName: "img"},
+			},
+		},
+		{
+			`<picture><source srcset=p.webp></picture><video><source src=v.mp4></video><source src=s.mp4>`,
+			[]PreloadRequest{
+				{URL: "p.webp", ResolvedURL: "https://example.com/dir/p.webp", Destination: ImageDestination, TagName: "source"},
+				{URL: "v.mp4", ResolvedURL: "https://example.com/dir/v.mp4", Destination: VideoDestination, TagName: "source"},
+			},
+		},
+		{
+			`<script>document.write('<img src=a.png>')</script><textarea><img src=b.png></textarea><template><img src=c.png></template><svg><script src=d.js></script></svg><img src=e.png>`,
+			[]PreloadRequest{
+				{URL: "e.png", ResolvedURL: "https://example.com/dir/e.png", Destination: ImageDestination, TagName: "img"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.htmlIn, func(t *testing.T) {
+			t.Parallel()
+			s, err := NewPreloadScanner(strings.NewReader(tt.htmlIn), "https://example.com/dir/page.html")
+			if err != nil {
+				t.Fatal(err)
+			}
+			requests, err := s.Scan()
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tt.expected, requests)
+		})
+	}
+}
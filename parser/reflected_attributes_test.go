@@ -0,0 +1,52 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReflectedAttributes(t *testing.T) {
+	doc := parseTestDocument(t, `<!DOCTYPE html><a title=t target=_blank type=text/html>a</a><ol type=i></ol><iframe name=f></iframe>`)
+	a := findElement(doc, "a")
+	assert.Equal(t, "t", a.Title())
+	assert.Equal(t, "_blank", a.Target())
+	assert.Equal(t, "text/html", a.Type())
+	assert.Equal(t, "i", findElement(doc, "ol").Type())
+	assert.Equal(t, "f", findElement(doc, "iframe").Name())
+
+	a.SetTitle("u")
+	assert.Equal(t, "u", a.GetAttribute("title"))
+	assert.Equal(t, "", findElement(doc, "ol").Title())
+}
+
+func TestGlobalAttributes(t *testing.T) {
+	doc := parseTestDocument(t, `<!DOCTYPE html><div translate=no spellcheck=false dir=RTL hidden><p translate=maybe spellcheck=x dir=up>p</p></div>
+<a href=/>a</a><img draggable=false><form autocapitalize=words><input><textarea autocapitalize=off></textarea></form>`)
+	div, p := findElement(doc, "div"), findElement(doc, "p")
+	assert.False(t, div.Translate())
+	assert.False(t, p.Translate(), "invalid values inherit the parent's translation mode")
+	assert.True(t, findElement(doc, "a").Translate())
+	p.SetTranslate(true)
+	assert.Equal(t, "yes", p.GetAttribute("translate"))
+	assert.True(t, p.Translate())
+
+	assert.False(t, p.Spellcheck())
+	assert.True(t, findElement(doc, "a").Spellcheck())
+	assert.Equal(t, "rtl", div.Dir())
+	assert.Equal(t, "", p.Dir())
+	assert.True(t, div.Hidden())
+	assert.False(t, p.Hidden())
+	assert.Equal(t, "", div.AccessKeyLabel())
+
+	assert.True(t, findElement(doc, "a").Draggable())
+	assert.False(t, findElement(doc, "img").Draggable())
+	assert.False(t, div.Draggable())
+	div.SetDraggable(true)
+	assert.True(t, div.Draggable())
+
+	assert.Equal(t, "words", findElement(doc, "form").Autocapitalize())
+	assert.Equal(t, "words", findElement(doc, "input").Autocapitalize(), "form controls inherit from their form owner")
+	assert.Equal(t, "none", findElement(doc, "textarea").Autocapitalize())
+	assert.Equal(t, "", div.Autocapitalize())
+}
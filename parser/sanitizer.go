@@ -0,0 +1,359 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	"github.com/heathj/gobrowse/parser/spec"
+)
+
+// ErrSanitizerUnstable is returned when sanitized markup keeps changing when
+// it's parsed again. That happens with markup designed to mutate between
+// serialization and parsing (mXSS) and the result can't be trusted.
+var ErrSanitizerUnstable = errors.New("sanitized markup does not round trip")
+
+// maxSanitizePasses is the number of times markup is parsed and sanitized
+// before giving up on it reaching a stable form.
+const maxSanitizePasses = 4
+
+// SanitizerConfig configures which elements and attributes a Sanitizer keeps.
+// Element names are the local name for HTML elements and are prefixed with
+// "svg:" or "math:" for SVG and MathML elements, e.g. "svg:circle". Attribute
+// names are the qualified name of the attribute, e.g. "xlink:href".
+// https://wicg.github.io/sanitizer-api/#config
+type SanitizerConfig struct {
+	// Elements are the only elements kept when it isn't nil. Any others are
+	// removed with their contents.
+	Elements []string
+	// RemoveElements are removed with their contents.
+	RemoveElements []string
+	// ReplaceWithChildrenElements are removed but their contents are kept.
+	ReplaceWithChildrenElements []string
+	// Attributes are the only attributes kept when it isn't nil.
+	Attributes []string
+	// RemoveAttributes are removed from every element.
+	RemoveAttributes []string
+	// Comments keeps comments when it's set.
+	Comments bool
+	// DataAttributes keeps data-* attributes even if they aren't in Attributes.
+	DataAttributes bool
+}
+
+// DefaultSanitizerConfig keeps markup for formatting and structure and drops
+// anything that can run script, load content or change how the rest of the
+// document is parsed.
+// https://wicg.github.io/sanitizer-api/#built-in-safe-default-configuration
+var DefaultSanitizerConfig = SanitizerConfig{
+	Elements: []string{
+		"a", "abbr", "address", "article", "aside", "b", "bdi", "bdo",
+		"blockquote", "body", "br", "caption", "cite", "code", "col",
+		"colgroup", "data", "dd", "del", "dfn", "div", "dl", "dt", "em",
+		"figcaption", "figure", "footer", "h1", "h2", "h3", "h4", "h5", "h6",
+		"header", "hgroup", "hr", "i", "img", "ins", "kbd", "li", "main", "mark",
+		"menu", "nav", "ol", "p", "pre", "q", "rp", "rt", "ruby", "s", "samp",
+		"search", "section", "small", "span", "strong", "sub", "summary", "sup",
+		"details", "table", "tbody", "td", "tfoot", "th", "thead", "time", "tr",
+		"u", "ul", "var", "wbr",
+
+		"svg:svg", "svg:g", "svg:circle", "svg:ellipse", "svg:line", "svg:path",
+		"svg:polygon", "svg:polyline", "svg:rect", "svg:text", "svg:tspan",
+		"svg:title", "svg:desc", "svg:defs", "svg:linearGradient",
+		"svg:radialGradient", "svg:stop",
+
+		"math:math", "math:mi", "math:mn", "math:mo", "math:ms", "math:mtext",
+		"math:mrow", "math:mfrac", "math:msqrt", "math:mroot", "math:msub",
+		"math:msup", "math:msubsup", "math:munder", "math:mover",
+		"math:munderover", "math:mtable", "math:mtr", "math:mtd", "math:mspace",
+		"math:mpadded", "math:mphantom", "math:semantics",
+	},
+	Attributes: []string{
+		"abbr", "alt", "cite", "colspan", "datetime", "dir", "height", "href",
+		"headers", "lang", "open", "reversed", "rowspan", "scope", "span",
+		"src", "start", "title", "type", "value", "width",
+
+		"cx", "cy", "d", "fill", "fill-opacity", "gradientTransform",
+		"gradientUnits", "offset", "points", "r", "rx", "ry", "stop-color",
+		"stop-opacity", "stroke", "stroke-linecap", "stroke-linejoin",
+		"stroke-opacity", "stroke-width", "transform", "viewBox", "x", "x1",
+		"x2", "y", "y1", "y2",
+
+		"displaystyle", "mathvariant", "scriptlevel",
+	},
+	DataAttributes: true,
+}
+
+// unsafeElements are never kept, whatever the configuration says. Besides
+// the elements that run script or load other documents that includes the ones
+// that change how the document around them behaves and the SVG animations,
+// which can set a URL attribute after the sanitizer has looked at it.
+// https://wicg.github.io/sanitizer-api/#sanitize-remove-unsafe
+var unsafeElements = map[string]bool{
+	"script":               true,
+	"frame":                true,
+	"iframe":               true,
+	"object":               true,
+	"embed":                true,
+	"base":                 true,
+	"meta":                 true,
+	"link":                 true,
+	"svg:script":           true,
+	"svg:use":              true,
+	"svg:animate":          true,
+	"svg:animateMotion":    true,
+	"svg:animateTransform": true,
+	"svg:set":              true,
+	"math:script":          true,
+}
+
+// urlAttributes are attributes whose value is navigated to or fetched and
+// must not hold javascript: URLs.
+var urlAttributes = map[string]bool{
+	"action":     true,
+	"formaction": true,
+	"href":       true,
+	"src":        true,
+	"xlink:href": true,
+}
+
+// Sanitizer removes the elements and attributes that its configuration doesn't
+// allow from node trees.
+// https://wicg.github.io/sanitizer-api/#sanitizer
+type Sanitizer struct {
+	elements, removeElements, replaceWithChildren map[string]bool
+	attributes, removeAttributes                  map[string]bool
+	comments, dataAttributes                      bool
+}
+
+// NewSanitizer creates a Sanitizer for the configuration.
+func NewSanitizer(config SanitizerConfig) *Sanitizer {
+	s := &Sanitizer{
+		removeElements:      stringSet(config.RemoveElements),
+		replaceWithChildren: stringSet(config.ReplaceWithChildrenElements),
+		removeAttributes:    stringSet(config.RemoveAttributes),
+		comments:            config.Comments,
+		dataAttributes:      config.DataAttributes,
+	}
+	if config.Elements != nil {
+		s.elements = stringSet(config.Elements)
+	}
+	if config.Attributes != nil {
+		s.attributes = stringSet(config.Attributes)
+	}
+	return s
+}
+
+func stringSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
+
+// Sanitize removes everything the configuration doesn't allow from the
+// descendants of node. Template contents are sanitized like any other
+// children.
+// https://wicg.github.io/sanitizer-api/#sanitize-core
+func (s *Sanitizer) Sanitize(node *spec.Node) {
+	for _, child := range append(spec.NodeList{}, node.ChildNodes...) {
+		switch child.NodeType {
+		case spec.TextNode:
+		case spec.CommentNode:
+			if !s.comments {
+				node.RemoveChild(child)
+			}
+		case spec.ElementNode:
+			s.sanitizeElement(node, child)
+		default:
+			node.RemoveChild(child)
+		}
+	}
+}
+
+func (s *Sanitizer) sanitizeElement(parent, element *spec.Node) {
+	name := sanitizerElementName(element)
+	if unsafeElements[name] || s.removeElements[name] {
+		parent.RemoveChild(element)
+		return
+	}
+
+	s.Sanitize(element)
+	if s.replaceWithChildren[name] {
+		for _, child := range append(spec.NodeList{}, element.ChildNodes...) {
+			element.RemoveChild(child)
+			parent.InsertBefore(child, element)
+		}
+		parent.RemoveChild(element)
+		return
+	}
+	if s.elements != nil && !s.elements[name] {
+		parent.RemoveChild(element)
+		return
+	}
+
+	if element.Attributes == nil {
+		return
+	}
+	for attrName, attr := range element.Attributes.Attrs {
+		if !s.allowAttribute(attrName, attr.Value) {
+			delete(element.Attributes.Attrs, attrName)
+		}
+	}
+	element.Attributes.Length = len(element.Attributes.Attrs)
+}
+
+func (s *Sanitizer) allowAttribute(name, value string) bool {
+	lower := strings.ToLower(name)
+	switch {
+	// event handler content attributes.
+	case strings.HasPrefix(lower, "on"):
+		return false
+	case urlAttributes[lower] && isJavaScriptURL(value):
+		return false
+	case s.removeAttributes[name]:
+		return false
+	case s.attributes == nil, s.attributes[name]:
+		return true
+	}
+	return s.dataAttributes && strings.HasPrefix(name, "data-")
+}
+
+// sanitizerElementName is the name the configuration uses for the element.
+func sanitizerElementName(element *spec.Node) string {
+	switch element.NamespaceURI {
+	case spec.Svgns:
+		return "svg:" + string(element.NodeName)
+	case spec.Mathmlns:
+		return "math:" + string(element.NodeName)
+	}
+	return string(element.NodeName)
+}
+
+// isJavaScriptURL reports if the URL parser would give the value the
+// javascript scheme. Character references are decoded and all whitespace and
+// control characters removed first, which is stricter than the URL parser, so
+// that values which only become javascript: URLs after another round of
+// parsing are caught too.
+// https://url.spec.whatwg.org/#concept-basic-url-parser
+func isJavaScriptURL(value string) bool {
+	value = strings.Map(func(r rune) rune {
+		if r <= 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, decodeCharRefs(value))
+	return len(value) >= len("javascript:") && strings.EqualFold(value[:len("javascript:")], "javascript:")
+}
+
+// decodeCharRefs replaces the character references in s with the code points
+// they stand for. References that don't decode are left as they are.
+// https://html.spec.whatwg.org/multipage/parsing.html#character-reference-state
+func decodeCharRefs(s string) string {
+	if !strings.Contains(s, "&") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if s[i] != '&' {
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+		if n, r := decodeNumericCharRef(s[i+1:]); n > 0 {
+			b.WriteRune(r)
+			i += 1 + n
+			continue
+		}
+		if n, runes := matchCharRef([]byte(s[i+1:])); n > 0 {
+			b.WriteString(string(runes))
+			i += 1 + n
+			continue
+		}
+		b.WriteByte('&')
+		i++
+	}
+	return b.String()
+}
+
+// decodeNumericCharRef decodes the numeric character reference at the start
+// of s, which follows the ampersand. It returns the number of bytes used, 0 if
+// there isn't one.
+// https://html.spec.whatwg.org/multipage/parsing.html#numeric-character-reference-state
+func decodeNumericCharRef(s string) (int, rune) {
+	if len(s) < 2 || s[0] != '#' {
+		return 0, 0
+	}
+	i, base := 1, rune(10)
+	if s[1] == 'x' || s[1] == 'X' {
+		i, base = 2, 16
+	}
+	start := i
+	var r rune
+	for ; i < len(s); i++ {
+		var d rune
+		switch c := rune(s[i]); {
+		case c >= '0' && c <= '9':
+			d = c - '0'
+		case base == 16 && c >= 'a' && c <= 'f':
+			d = c - 'a' + 10
+		case base == 16 && c >= 'A' && c <= 'F':
+			d = c - 'A' + 10
+		default:
+			d = -1
+		}
+		if d < 0 {
+			break
+		}
+		if r <= unicode.MaxRune {
+			r = r*base + d
+		}
+	}
+	if i == start {
+		return 0, 0
+	}
+	if i < len(s) && s[i] == ';' {
+		i++
+	}
+	if r == 0 || r > unicode.MaxRune || r >= 0xD800 && r <= 0xDFFF {
+		r = unicode.ReplacementChar
+	}
+	return i, r
+}
+
+// SanitizeHTML parses markup as the contents of the context element and
+// returns it serialized with everything the configuration doesn't allow
+// removed. The result is parsed again until it's stable so that markup which
+// changes meaning when serialized can't sneak past the sanitizer. It has to be
+// stable with scripting both enabled and disabled since noscript is parsed
+// differently in each and the result may end up in a document either way.
+func (s *Sanitizer) SanitizeHTML(context *spec.Node, markup string) (string, error) {
+	scriptingEnabled := scriptingEnabledFor(context.OwnerHTMLDocument())
+	for i := 0; i < maxSanitizePasses; i++ {
+		sanitized := s.sanitizeFragment(context, markup, scriptingEnabled)
+		if sanitized == markup && s.sanitizeFragment(context, markup, !scriptingEnabled) == markup {
+			return sanitized, nil
+		}
+		markup = sanitized
+	}
+	return "", ErrSanitizerUnstable
+}
+
+func (s *Sanitizer) sanitizeFragment(context *spec.Node, markup string, scriptingEnabled bool) string {
+	container := spec.NewDOMElement(context.OwnerDocument, string(context.NodeName), context.NamespaceURI)
+	for _, node := range ParseHTMLFragment(context, markup, scriptingEnabled) {
+		container.AppendChild(node)
+	}
+	s.Sanitize(container)
+	return SerializeHTMLFragement(container)
+}
+
+// SetHTML replaces the children of element with the sanitized markup.
+// https://wicg.github.io/sanitizer-api/#dom-element-sethtml
+func (s *Sanitizer) SetHTML(element *spec.Node, markup string) error {
+	sanitized, err := s.SanitizeHTML(element, markup)
+	if err != nil {
+		return err
+	}
+	return element.SetInnerHTML(sanitized)
+}
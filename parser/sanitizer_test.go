@@ -0,0 +1,57 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type sanitizerTestcase struct {
+	htmlIn   string
+	config   SanitizerConfig
+	expected string
+}
+
+func TestSanitizer(t *testing.T) {
+	tests := []sanitizerTestcase{
+		{`<p onclick="x()">a<script>alert(1)</script><b>b</b></p>`, DefaultSanitizerConfig, `<p>a<b>b</b></p>`},
+		{`<a href=" JAVA&#x09;script:alert(1)" title=t>x</a><a href=/ok>y</a>`, DefaultSanitizerConfig, `<a title="t">x</a><a href="/ok">y</a>`},
+		{`<div data-x=1 style="color:red" id=a><!--c--><custom>gone</custom></div>`, DefaultSanitizerConfig, `<div data-x="1"></div>`},
+		{`<template><p>x<img src=x onerror=y()></template><p>y`, SanitizerConfig{RemoveElements: []string{"img"}}, `<template><p>x</p></template><p>y</p>`},
+		{`<svg><script>a</script><circle r=1 onload=x()></circle><foreignObject><p>x</p></foreignObject></svg>`, DefaultSanitizerConfig, `<svg><circle r="1"></circle></svg>`},
+		{`<math><mi xlink:href="javascript:x">a</mi></math>`, SanitizerConfig{}, `<math><mi>a</mi></math>`},
+		{`<svg><a><animate attributeName=href values="javascript:alert(1)"/><set attributeName=xlink:href to="javascript:x"/><set attributeName=fill to=red /><text>x</text></a></svg>`, SanitizerConfig{}, `<svg><a><text>x</text></a></svg>`},
+		{`<ul><li><em>a</em> b</li></ul>`, SanitizerConfig{Elements: []string{"ul", "li"}, ReplaceWithChildrenElements: []string{"em"}}, `<ul><li>a b</li></ul>`},
+		{`<p id=a class=b>x<!--c--></p>`, SanitizerConfig{RemoveAttributes: []string{"class"}, Comments: true}, `<p id="a">x<!--c--></p>`},
+		// nested forms put the style element in a different namespace when the
+		// serialized markup is parsed again.
+		{`<base href=/x><meta http-equiv=refresh content=0><link rel=stylesheet href=/s><p>x`, SanitizerConfig{}, `<p>x</p>`},
+		{`<math href="javascript:x"><mi>a</mi></math><a href="&amp;#x6a;avascript:x">b</a><a href="&#x01;javascript:x">c</a>`, SanitizerConfig{}, `<math><mi>a</mi></math><a>b</a><a>c</a>`},
+		{`<p title="<b>">x</p>`, SanitizerConfig{}, `<p title="&lt;b&gt;">x</p>`},
+		// the p breaks out of the svg so the style is parsed as raw text in the
+		// fragment just as it is in a document.
+		{`<svg><p><style><img title="</style><img src=x onerror=alert(1)>">`, SanitizerConfig{}, `<svg></svg><p><style><img title="</style><img src="x">"&gt;</p>`},
+		{`<noscript><p title="</noscript><img src=x onerror=alert(1)>">`, SanitizerConfig{}, `<noscript><p title="&lt;/noscript&gt;&lt;img src=x onerror=alert(1)&gt;"></p></noscript>`},
+		{`<form><math><mtext></form><form><mglyph><style></math><img src onerror=alert(1)>`, DefaultSanitizerConfig, ``},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.htmlIn, func(t *testing.T) {
+			t.Parallel()
+			body := findElement(parseTestDocument(t, ""), "body")
+			sanitized, err := NewSanitizer(tt.config).SanitizeHTML(body, tt.htmlIn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tt.expected, sanitized)
+		})
+	}
+}
+
+func TestSanitizerSetHTML(t *testing.T) {
+	doc := parseTestDocument(t, "<div>old</div>")
+	div := findElement(doc, "div")
+	assert.NoError(t, NewSanitizer(DefaultSanitizerConfig).SetHTML(div, `<b onclick=x()>new</b><script>x()</script>`))
+	assert.Equal(t, "<b>new</b>", div.InnerHTML())
+}
@@ -0,0 +1,387 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/heathj/gobrowse/parser/spec"
+)
+
+// ScriptExecutor runs the scripts found while parsing a document. Embedders
+// implement it to plug a scripting engine into the parser.
+type ScriptExecutor interface {
+	// FetchScript returns the source text of the external script that the
+	// element's src attribute points to.
+	FetchScript(element *spec.Node, src string, scriptType spec.ScriptType) (string, error)
+	// ExecuteScript runs a prepared script. Any errors thrown by the script
+	// should be reported by the executor itself, they never stop the parser.
+	ExecuteScript(script *spec.Script)
+}
+
+// https://mimesniff.spec.whatwg.org/#javascript-mime-type
+var javaScriptMIMETypes = []string{
+	"application/ecmascript",
+	"application/javascript",
+	"application/x-ecmascript",
+	"application/x-javascript",
+	"text/ecmascript",
+	"text/javascript",
+	"text/javascript1.0",
+	"text/javascript1.1",
+	"text/javascript1.2",
+	"text/javascript1.3",
+	"text/javascript1.4",
+	"text/javascript1.5",
+	"text/jscript",
+	"text/livescript",
+	"text/x-ecmascript",
+	"text/x-javascript",
+}
+
+// https://mimesniff.spec.whatwg.org/#javascript-mime-type-essence-match
+func isJavaScriptMIMETypeEssenceMatch(s string) bool {
+	for _, t := range javaScriptMIMETypes {
+		if strings.EqualFold(s, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func getAttribute(n *spec.Node, name string) (string, bool) {
+	if n.Element == nil || n.Attributes == nil {
+		return "", false
+	}
+	attr, ok := n.Attributes.Attrs[name]
+	if !ok {
+		return "", false
+	}
+	return attr.Value, true
+}
+
+func hasAttribute(n *spec.Node, name string) bool {
+	_, ok := getAttribute(n, name)
+	return ok
+}
+
+func isConnected(n *spec.Node) bool {
+	for i := n; i != nil; i = i.ParentNode {
+		if i.NodeType == spec.DocumentNode {
+			return true
+		}
+	}
+	return false
+}
+
+// https://dom.spec.whatwg.org/#concept-child-text-content
+func childTextContent(n *spec.Node) string {
+	var b strings.Builder
+	for _, child := range n.ChildNodes {
+		if child.NodeType == spec.TextNode {
+			b.WriteString(child.Text.Data)
+		}
+	}
+	return b.String()
+}
+
+// determineScriptType returns the type of the script block or false if the
+// element doesn't represent a script that should be run.
+// https://html.spec.whatwg.org/multipage/scripting.html#prepare-the-script-element
+func determineScriptType(el *spec.Node) (spec.ScriptType, bool) {
+	typeAttr, hasType := getAttribute(el, "type")
+	langAttr, hasLang := getAttribute(el, "language")
+	return scriptTypeFromAttributes(typeAttr, hasType, langAttr, hasLang)
+}
+
+func scriptTypeFromAttributes(typeAttr string, hasType bool, langAttr string, hasLang bool) (spec.ScriptType, bool) {
+	var typeString string
+	if (hasType && typeAttr == "") ||
+		(!hasType && hasLang && langAttr == "") ||
+		(!hasType && !hasLang) {
+		typeString = "text/javascript"
+	} else if hasType {
+		typeString = typeAttr
+	} else {
+		typeString = "text/" + langAttr
+	}
+	typeString = strings.Trim(typeString, "\u0009\u000A\u000C\u000D ")
+
+	switch {
+	case isJavaScriptMIMETypeEssenceMatch(typeString):
+		return spec.ClassicScript, true
+	case strings.EqualFold(typeString, "module"):
+		return spec.ModuleScript, true
+	case strings.EqualFold(typeString, "importmap"):
+		return spec.ImportMapScript, true
+	}
+	return 0, false
+}
+
+// https://html.spec.whatwg.org/multipage/scripting.html#prepare-the-script-element
+func (c *HTMLTreeConstructor) prepareScript(el *spec.Node) {
+	if el.AlreadyStated {
+		return
+	}
+
+	parserDocument := el.ParserDocument
+	el.ParserDocument = nil
+	if parserDocument != nil && !hasAttribute(el, "async") {
+		el.NonBlocking = true
+	}
+
+	sourceText := childTextContent(el)
+	if !hasAttribute(el, "src") && sourceText == "" {
+		return
+	}
+
+	if !isConnected(el) {
+		return
+	}
+
+	scriptType, ok := determineScriptType(el)
+	if !ok {
+		return
+	}
+	el.ScriptType = scriptType
+
+	if parserDocument != nil {
+		el.ParserDocument = parserDocument
+		el.NonBlocking = false
+	}
+	el.AlreadyStated = true
+	el.PreparationTimeDocument = el.OwnerDocument
+
+	if parserDocument != nil && parserDocument.Node != el.PreparationTimeDocument {
+		return
+	}
+
+	if !c.scriptingEnabled {
+		return
+	}
+
+	if hasAttribute(el, "nomodule") && el.ScriptType == spec.ClassicScript {
+		return
+	}
+
+	event, hasEvent := getAttribute(el, "event")
+	forAttr, hasFor := getAttribute(el, "for")
+	if el.ScriptType == spec.ClassicScript && hasEvent && hasFor {
+		event = strings.Trim(event, "\u0009\u000A\u000C\u000D ")
+		forAttr = strings.Trim(forAttr, "\u0009\u000A\u000C\u000D ")
+		if !strings.EqualFold(forAttr, "window") {
+			return
+		}
+		if !strings.EqualFold(event, "onload") && !strings.EqualFold(event, "onload()") {
+			return
+		}
+	}
+
+	src, hasSrc := getAttribute(el, "src")
+	if hasSrc {
+		if el.ScriptType == spec.ImportMapScript || src == "" {
+			c.HTMLDocument.QueueTask(spec.DOMManipulationTaskSource, func() {
+				fireEvent(el, "error", false)
+			})
+			return
+		}
+		el.FromExternalFile = true
+		c.fetchScript(el, src)
+	} else {
+		el.Result = &spec.Script{
+			Type:    el.ScriptType,
+			Source:  sourceText,
+			Element: el,
+		}
+		el.Ready = true
+	}
+
+	isParserInserted := el.ParserDocument != nil
+	hasAsync := hasAttribute(el, "async")
+	switch {
+	case (hasSrc && el.ScriptType == spec.ClassicScript && hasAttribute(el, "defer") && isParserInserted && !hasAsync) ||
+		(el.ScriptType == spec.ModuleScript && isParserInserted && !hasAsync):
+		c.scriptsAfterParsing = append(c.scriptsAfterParsing, el)
+	case hasSrc && el.ScriptType == spec.ClassicScript && isParserInserted && !hasAsync:
+		c.pendingParsingBlockingScript = el
+	case (hasSrc || el.ScriptType == spec.ModuleScript) && !hasAsync && !el.NonBlocking:
+		c.scriptsInOrder = append(c.scriptsInOrder, el)
+	case hasSrc || el.ScriptType == spec.ModuleScript:
+		c.scriptsASAP = append(c.scriptsASAP, el)
+	default:
+		// we don't have style sheets that are blocking scripts, so an inline
+		// parser-inserted script always runs right away.
+		c.executeScript(el)
+	}
+}
+
+// fetchScript fetches the external script using the embedder's executor. The
+// element is marked as ready once the fetch is complete.
+func (c *HTMLTreeConstructor) fetchScript(el *spec.Node, src string) {
+	if c.scriptExecutor != nil {
+		source, err := c.scriptExecutor.FetchScript(el, src, el.ScriptType)
+		if err == nil {
+			el.Result = &spec.Script{
+				Type:    el.ScriptType,
+				Source:  source,
+				BaseURL: src,
+				Element: el,
+			}
+		}
+	}
+	el.Ready = true
+}
+
+// https://html.spec.whatwg.org/multipage/scripting.html#execute-the-script-element
+func (c *HTMLTreeConstructor) executeScript(el *spec.Node) {
+	if el.PreparationTimeDocument != el.OwnerDocument {
+		return
+	}
+
+	if el.Result == nil {
+		fireEvent(el, "error", false)
+		return
+	}
+
+	c.flushText()
+	if el.FromExternalFile {
+		c.HTMLDocument.IgnoreDestructiveWritesCounter++
+	}
+
+	old := c.HTMLDocument.CurrentScript
+	switch el.ScriptType {
+	case spec.ClassicScript:
+		c.HTMLDocument.CurrentScript = el
+	case spec.ModuleScript, spec.ImportMapScript:
+		c.HTMLDocument.CurrentScript = nil
+	}
+	c.runScript(el.Result)
+	c.HTMLDocument.CurrentScript = old
+	if el.FromExternalFile {
+		c.HTMLDocument.IgnoreDestructiveWritesCounter--
+		fireEvent(el, "load", false)
+	}
+}
+
+// fireEvent is https://dom.spec.whatwg.org/#concept-event-fire
+func fireEvent(target *spec.Node, eventType string, bubbles bool) {
+	e := spec.NewEvent(eventType, bubbles, false)
+	e.IsTrusted = true
+	target.DispatchEvent(e)
+}
+
+// runScript runs the script with the embedder's executor or otherwise the
+// document's script host. Microtasks queued by the script run once it returns
+// unless it was run by another script.
+// https://html.spec.whatwg.org/multipage/webappapis.html#clean-up-after-running-script
+func (c *HTMLTreeConstructor) runScript(script *spec.Script) {
+	switch {
+	case c.scriptExecutor != nil:
+		c.scriptExecutor.ExecuteScript(script)
+	case c.HTMLDocument.ScriptHost != nil:
+		if err := c.HTMLDocument.ScriptHost.RunScript(c.HTMLDocument, script); err != nil {
+			c.HTMLDocument.ScriptHost.ReportError(c.HTMLDocument, err)
+		}
+	default:
+		return
+	}
+	if c.scriptNestingLevel <= 1 {
+		c.HTMLDocument.PerformMicrotaskCheckpoint()
+	}
+}
+
+// processScriptEndTag runs the steps for a script end tag in the text
+// insertion mode.
+// https://html.spec.whatwg.org/multipage/parsing.html#scriptEndTag
+func (c *HTMLTreeConstructor) processScriptEndTag(script *spec.Node) {
+	c.stackOfOpenElements.Pop()
+	oldInsertionPoint := c.inputStream.insertionPoint
+	c.inputStream.setInsertionPointBeforeNextChar()
+	c.scriptNestingLevel++
+	c.prepareScript(script)
+	c.scriptNestingLevel--
+	if c.scriptNestingLevel == 0 {
+		c.parserPause = false
+	}
+	c.inputStream.insertionPoint = oldInsertionPoint
+
+	// pause the parser. the blocking script runs once tokenization has yielded
+	// back to the outermost invocation of the parser.
+	if c.pendingParsingBlockingScript != nil {
+		c.parserPause = true
+	}
+}
+
+// runPendingParsingBlockingScripts executes the pending parsing-blocking
+// script, and any that script creates, before tokenization resumes.
+// https://html.spec.whatwg.org/multipage/parsing.html#scriptEndTag
+func (c *HTMLTreeConstructor) runPendingParsingBlockingScripts() {
+	for c.pendingParsingBlockingScript != nil {
+		script := c.pendingParsingBlockingScript
+		c.pendingParsingBlockingScript = nil
+		// a script-created parser keeps its insertion point so document.write
+		// can keep feeding it. for any other parser it is undefined here.
+		oldInsertionPoint := c.inputStream.insertionPoint
+		c.inputStream.setInsertionPointBeforeNextChar()
+		c.scriptNestingLevel++
+		c.executeScript(script)
+		c.scriptNestingLevel--
+		c.inputStream.insertionPoint = oldInsertionPoint
+	}
+	if c.scriptNestingLevel == 0 {
+		c.parserPause = false
+	}
+}
+
+// runReadyScripts executes the scripts that should run as soon as possible
+// and have finished being fetched.
+// https://html.spec.whatwg.org/multipage/scripting.html#prepare-the-script-element
+func (c *HTMLTreeConstructor) runReadyScripts() {
+	for len(c.scriptsInOrder) > 0 && c.scriptsInOrder[0].Ready {
+		script := c.scriptsInOrder[0]
+		c.scriptsInOrder = c.scriptsInOrder[1:]
+		c.executeScript(script)
+	}
+
+	remaining := c.scriptsASAP[:0]
+	for _, script := range c.scriptsASAP {
+		if script.Ready {
+			c.executeScript(script)
+			continue
+		}
+		remaining = append(remaining, script)
+	}
+	c.scriptsASAP = remaining
+}
+
+// runScriptsAfterParsing executes the deferred scripts and any remaining as soon
+// as possible scripts once the document has finished parsing.
+// https://html.spec.whatwg.org/multipage/parsing.html#stop-parsing
+func (c *HTMLTreeConstructor) runScriptsAfterParsing() {
+	for len(c.scriptsAfterParsing) > 0 {
+		script := c.scriptsAfterParsing[0]
+		c.scriptsAfterParsing = c.scriptsAfterParsing[1:]
+		script.ReadyToBeParserExecuted = true
+		c.executeScript(script)
+	}
+	c.HTMLDocument.QueueTask(spec.DOMManipulationTaskSource, func() {
+		fireEvent(c.HTMLDocument.Node, "DOMContentLoaded", true)
+	})
+	c.runReadyScripts()
+}
+
+// processSVGScript runs an SVG script element once its end tag has been seen.
+// https://html.spec.whatwg.org/multipage/parsing.html#parsing-main-inforeign
+func (c *HTMLTreeConstructor) processSVGScript(el *spec.Node) {
+	if !c.scriptingEnabled || (c.scriptExecutor == nil && c.HTMLDocument.ScriptHost == nil) {
+		return
+	}
+	c.scriptNestingLevel++
+	c.runScript(&spec.Script{
+		Type:    spec.ClassicScript,
+		Source:  childTextContent(el),
+		Element: el,
+	})
+	c.scriptNestingLevel--
+	if c.scriptNestingLevel == 0 {
+		c.parserPause = false
+	}
+}
@@ -0,0 +1,159 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/heathj/gobrowse/parser/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingExecutor records the order scripts are executed in and serves
+// external scripts from a map.
+type recordingExecutor struct {
+	sources  map[string]string
+	executed []string
+}
+
+func (r *recordingExecutor) FetchScript(element *spec.Node, src string, scriptType spec.ScriptType) (string, error) {
+	source, ok := r.sources[src]
+	if !ok {
+		return "", errors.New("no script at " + src)
+	}
+	return source, nil
+}
+
+func (r *recordingExecutor) ExecuteScript(script *spec.Script) {
+	r.executed = append(r.executed, script.Source)
+}
+
+type scriptOrderTestcase struct {
+	htmlIn   string
+	expected []string
+}
+
+func TestScriptExecutionOrder(t *testing.T) {
+	tests := []scriptOrderTestcase{
+		{"<script>a</script><script>b</script>", []string{"a", "b"}},
+		{"<script src=d defer></script><script>a</script>", []string{"a", "d"}},
+		{"<script src=e></script><script>a</script>", []string{"e", "a"}},
+		{"<script type=module>m</script><script>a</script>", []string{"a", "m"}},
+		{"<script type=text/plain>x</script><script>a</script>", []string{"a"}},
+		{"<script nomodule>n</script><script type=module>m</script>", []string{"m"}},
+		{"<script src=d defer></script><script src=e async></script><script>a</script>", []string{"e", "a", "d"}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.htmlIn, func(t *testing.T) {
+			t.Parallel()
+			exec := &recordingExecutor{
+				sources: map[string]string{"d": "d", "e": "e"},
+			}
+			p := NewParser(strings.NewReader(tt.htmlIn), WithScripting(true))
+			p.SetScriptExecutor(exec)
+			if _, err := p.Start(); err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tt.expected, exec.executed)
+			assert.Equal(t, spec.Complete, p.TreeConstructor.HTMLDocument.ReadyState)
+		})
+	}
+}
+
+func TestScriptEvents(t *testing.T) {
+	p := NewParser(strings.NewReader(`<script src=""></script><script type=importmap src=m></script>
+<script src=e></script><script src=missing></script><script>a</script>`), WithScripting(true))
+	p.SetScriptExecutor(&recordingExecutor{sources: map[string]string{"e": "e"}})
+	doc := p.TreeConstructor.HTMLDocument
+	fired := []string{}
+	record := &spec.EventListener{Capture: true, Callback: func(e *spec.Event) {
+		src, _ := getAttribute(e.Target, "src")
+		fired = append(fired, e.Type+":"+src)
+	}}
+	doc.AddEventListener("load", record)
+	doc.AddEventListener("error", record)
+	doc.Window().AddEventListener("load", &spec.EventListener{Callback: func(e *spec.Event) {
+		fired = append(fired, "window:"+string(doc.ReadyState))
+	}})
+	if _, err := p.Start(); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"error:", "error:m", "load:e", "error:missing", "window:complete"}, fired)
+}
+
+func TestScriptingDisabledDoesNotExecute(t *testing.T) {
+	exec := &recordingExecutor{}
+	p := NewParser(strings.NewReader("<script>a</script>"))
+	p.SetScriptExecutor(exec)
+	if _, err := p.Start(); err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, exec.executed)
+}
+
+// writingExecutor treats each script's source as markup to document.write.
+type writingExecutor struct {
+	doc *spec.HTMLDocument
+}
+
+func (w *writingExecutor) FetchScript(element *spec.Node, src string, scriptType spec.ScriptType) (string, error) {
+	return src, nil
+}
+
+func (w *writingExecutor) ExecuteScript(script *spec.Script) {
+	w.doc.Write(script.Source)
+}
+
+func TestDocumentWrite(t *testing.T) {
+	tests := []struct {
+		htmlIn   string
+		expected string
+	}{
+		{"<script><b>x</b></script>y", "<html><head><script><b>x</b></script></head><body><b>x</b>y</body></html>"},
+		{"<p>a<script><i>b</script>c</p>", "<html><head></head><body><p>a<script><i>b</script><i>bc</i></p></body></html>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.htmlIn, func(t *testing.T) {
+			p := NewParser(strings.NewReader(tt.htmlIn), WithScripting(true))
+			p.SetScriptExecutor(&writingExecutor{doc: p.TreeConstructor.HTMLDocument})
+			doc, err := p.Start()
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tt.expected, SerializeHTMLFragement(doc))
+		})
+	}
+}
+
+func TestDocumentOpenWriteClose(t *testing.T) {
+	doc := spec.NewHTMLDocumentNode()
+	if _, err := doc.Open("", ""); err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, doc.Write("<p>hel"))
+	assert.NoError(t, doc.Write("lo</p>"))
+	assert.NoError(t, doc.Close())
+	assert.Equal(t, "<html><head></head><body><p>hello</p></body></html>", SerializeHTMLFragement(doc.Node))
+	assert.Equal(t, spec.Complete, doc.ReadyState)
+}
+
+func TestScriptCreatedParserSettings(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	node, err := NewParser(strings.NewReader("<p>x"), WithLimits(Limits{MaxNodes: 8})).StartContext(ctx)
+	assert.NoError(t, err)
+	doc := node.OwnerHTMLDocument()
+	_, err = doc.Open("", "")
+	assert.NoError(t, err)
+	err = doc.Write(strings.Repeat("<p>", 10))
+	assert.True(t, errors.Is(err, ErrLimitExceeded), "the limits of the previous parser are kept")
+
+	cancel()
+	_, err = doc.Open("", "")
+	assert.NoError(t, err)
+	assert.True(t, errors.Is(doc.Write("<p>"), context.Canceled), "so is its context")
+	assert.True(t, errors.Is(doc.Close(), context.Canceled))
+}
@@ -8,3 +8,18 @@ type AbstractRange struct {
 	endOffSet      uint
 	collapsed      bool
 }
+
+// StartContainer is https://dom.spec.whatwg.org/#dom-range-startcontainer
+func (r *AbstractRange) StartContainer() *Node { return r.startContainer }
+
+// StartOffset is https://dom.spec.whatwg.org/#dom-range-startoffset
+func (r *AbstractRange) StartOffset() uint { return r.startOffset }
+
+// EndContainer is https://dom.spec.whatwg.org/#dom-range-endcontainer
+func (r *AbstractRange) EndContainer() *Node { return r.endContainer }
+
+// EndOffset is https://dom.spec.whatwg.org/#dom-range-endoffset
+func (r *AbstractRange) EndOffset() uint { return r.endOffSet }
+
+// Collapsed is https://dom.spec.whatwg.org/#dom-range-collapsed
+func (r *AbstractRange) Collapsed() bool { return r.collapsed }
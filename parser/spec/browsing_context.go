@@ -0,0 +1,59 @@
+package spec
+
+// BrowsingContext is https://html.spec.whatwg.org/multipage/document-sequences.html#browsing-context
+// It navigates and keeps the session history the Location and History
+// interfaces use. The spec package can't depend on its implementation so the
+// browsing context hands itself to its documents through this interface.
+type BrowsingContext interface {
+	// ActiveDocument is the document the browsing context displays.
+	ActiveDocument() *HTMLDocument
+	// Navigate navigates to the URL. The navigation replaces the current
+	// session history entry when replace is set.
+	Navigate(u *URL, replace bool) error
+	// Reload navigates to the current session history entry again.
+	Reload() error
+	// Traverse moves delta entries through the session history.
+	Traverse(delta int) error
+	// SessionHistoryLength is the number of entries in the session history.
+	SessionHistoryLength() int
+	// State is the state of the current session history entry.
+	State() interface{}
+	// UpdateHistory is https://html.spec.whatwg.org/multipage/browsing-the-web.html#url-and-history-update-steps
+	UpdateHistory(u *URL, state interface{}, replace bool)
+
+	// Parent is the browsing context of the container's document or nil for
+	// a top-level browsing context.
+	Parent() BrowsingContext
+	// Container is the iframe element the browsing context is nested in.
+	// https://html.spec.whatwg.org/multipage/document-sequences.html#nav-container
+	Container() *Node
+	// Children are the browsing contexts of the iframes in the active
+	// document, in tree order.
+	// https://html.spec.whatwg.org/multipage/document-sequences.html#document-tree-child-navigables
+	Children() []BrowsingContext
+	// CreateChild creates a browsing context nested in the container. Its
+	// active document is an about:blank document with the origin of the
+	// container's document.
+	// https://html.spec.whatwg.org/multipage/document-sequences.html#create-a-new-child-navigable
+	CreateChild(container *Node) BrowsingContext
+}
+
+// fullyActive is https://html.spec.whatwg.org/multipage/document-sequences.html#fully-active
+func (d *HTMLDocument) fullyActive() bool {
+	if d.BrowsingContext == nil || d.BrowsingContext.ActiveDocument() != d {
+		return false
+	}
+	container := d.BrowsingContext.Container()
+	if container == nil {
+		return true
+	}
+	parent := container.OwnerHTMLDocument()
+	return container.ContentBrowsingContext() == d.BrowsingContext && parent != nil && parent.fullyActive()
+}
+
+// sameOrigin reports if the documents have the same origin. Opaque origins are
+// only the same as themselves so they never match.
+// https://html.spec.whatwg.org/multipage/browsers.html#same-origin
+func sameOrigin(a, b *HTMLDocument) bool {
+	return a.Origin != "" && a.Origin != "null" && a.Origin == b.Origin
+}
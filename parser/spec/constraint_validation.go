@@ -0,0 +1,429 @@
+package spec
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// ErrValidityMessageRequired is returned when validity flags are set without a
+// message to go with them.
+var ErrValidityMessageRequired = errors.New("a validation message is required when a validity flag is set")
+
+// ErrInvalidPattern is returned for pattern attributes that aren't a regular
+// expression this package can compile. Patterns are compiled as RE2, so
+// ECMAScript-only syntax like lookaround and backreferences is invalid.
+var ErrInvalidPattern = errors.New("invalid pattern")
+
+// https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#candidate-for-constraint-validation
+func (n *Node) WillValidate() bool {
+	if !n.IsSubmittable() || n.IsDisabledFormControl() || hasAncestor(n, "datalist") {
+		return false
+	}
+	switch n.NodeName {
+	case "input":
+		switch inputTypeState(n) {
+		case "hidden", "reset", "button":
+			return false
+		}
+		return !n.hasAttribute("readonly") || !readonlyApplies(n)
+	case "textarea":
+		return !n.hasAttribute("readonly")
+	case "button":
+		switch strings.ToLower(n.attribute("type")) {
+		case "reset", "button":
+			return false
+		}
+	}
+	return true
+}
+
+// https://html.spec.whatwg.org/multipage/input.html#the-readonly-attribute
+func readonlyApplies(n *Node) bool {
+	switch inputTypeState(n) {
+	case "text", "search", "url", "tel", "email", "password", "date", "month",
+		"week", "time", "datetime-local", "number":
+		return true
+	}
+	return false
+}
+
+// Validity is https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#dom-cva-validity
+func (n *Node) Validity() ValidityState {
+	flags, _ := n.validityFlags()
+	return newValidityState(flags)
+}
+
+// ValidationMessage is https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#dom-cva-validationmessage
+func (n *Node) ValidationMessage() string {
+	if !n.WillValidate() {
+		return ""
+	}
+	_, message := n.validityFlags()
+	return message
+}
+
+// SetCustomValidity is https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#dom-cva-setcustomvalidity
+func (n *Node) SetCustomValidity(message string) {
+	if n.Element != nil && n.HTMLElement != nil {
+		n.HTMLElement.customValidity = message
+	}
+}
+
+// CheckValidity is https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#dom-cva-checkvalidity.
+// For form elements it's https://html.spec.whatwg.org/multipage/forms.html#dom-form-checkvalidity.
+func (n *Node) CheckValidity() bool {
+	if n.NodeType == ElementNode && n.Element != nil && n.HTMLForm != nil {
+		return n.HTMLForm.CheckValidity()
+	}
+	return n.checkValidity()
+}
+
+// ReportValidity is CheckValidity. There's no user to report the problems to.
+func (n *Node) ReportValidity() bool {
+	return n.CheckValidity()
+}
+
+// https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#check-validity-steps
+func (n *Node) checkValidity() bool {
+	if !n.WillValidate() || n.Validity().Valid {
+		return true
+	}
+	n.DispatchEvent(newTrustedEvent("invalid", false, true))
+	return false
+}
+
+// CheckValidity is https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#statically-validate-the-constraints.
+// It fires invalid at each of the form's invalid controls.
+func (f *HTMLForm) CheckValidity() bool {
+	invalid := []*Node{}
+	f.node.getRoot().walk(func(n *Node) {
+		if n.formOwner() == f.node && n.WillValidate() && !n.Validity().Valid {
+			invalid = append(invalid, n)
+		}
+	})
+	for _, n := range invalid {
+		n.DispatchEvent(newTrustedEvent("invalid", false, true))
+	}
+	return len(invalid) == 0
+}
+
+// ReportValidity is CheckValidity. There's no user to report the problems to.
+func (f *HTMLForm) ReportValidity() bool {
+	return f.CheckValidity()
+}
+
+// validityFlags returns the element's validity and the message for the first
+// of the constraints it suffers from.
+func (n *Node) validityFlags() (ValidityStateFlags, string) {
+	var flags ValidityStateFlags
+	if n.NodeType != ElementNode || n.Element == nil || n.HTMLElement == nil {
+		return flags, ""
+	}
+	if n.isFormAssociatedCustomElement() {
+		if ei := n.HTMLElement.internals; ei != nil {
+			return ei.validityFlags, ei.validationMessage
+		}
+		return flags, ""
+	}
+
+	messages := []string{}
+	add := func(flag *bool, message string) {
+		*flag = true
+		messages = append(messages, message)
+	}
+	if custom := n.HTMLElement.customValidity; custom != "" {
+		add(&flags.CustomError, custom)
+	}
+
+	switch n.NodeName {
+	case "input":
+		n.inputValidity(&flags, add)
+	case "textarea":
+		value := n.ControlValue()
+		if n.hasAttribute("required") && value == "" {
+			add(&flags.ValueMissing, "Please fill out this field.")
+		}
+		n.lengthValidity(value, &flags, add)
+	case "select":
+		if n.hasAttribute("required") && !n.hasSelectedValue() {
+			add(&flags.ValueMissing, "Please select an item in the list.")
+		}
+	}
+
+	if len(messages) == 0 {
+		return flags, ""
+	}
+	// the custom message takes precedence over the others.
+	return flags, messages[0]
+}
+
+// hasSelectedValue reports if a select element has a selected option other
+// than its placeholder label option.
+// https://html.spec.whatwg.org/multipage/form-elements.html#placeholder-label-option
+func (n *Node) hasSelectedValue() bool {
+	for _, option := range n.SelectedOptions() {
+		if option.optionValue() != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *Node) inputValidity(flags *ValidityStateFlags, add func(*bool, string)) {
+	inputType := inputTypeState(n)
+	value := n.ControlValue()
+	required := n.hasAttribute("required")
+
+	switch inputType {
+	case "checkbox":
+		if required && !n.checkedness() {
+			add(&flags.ValueMissing, "Please check this box if you want to proceed.")
+		}
+		return
+	case "radio":
+		if required && !n.radioGroupChecked() {
+			add(&flags.ValueMissing, "Please select one of these options.")
+		}
+		return
+	case "file":
+		if required && len(n.Files()) == 0 {
+			add(&flags.ValueMissing, "Please select a file.")
+		}
+		return
+	case "hidden", "range", "color", "submit", "image", "reset", "button":
+	default:
+		if required && value == "" {
+			add(&flags.ValueMissing, "Please fill out this field.")
+		}
+	}
+	if value == "" {
+		return
+	}
+
+	values := []string{value}
+	if inputType == "email" && n.hasAttribute("multiple") {
+		values = strings.Split(value, ",")
+		for i := range values {
+			values[i] = strings.Trim(values[i], "\t\n\f\r ")
+		}
+	}
+	switch inputType {
+	case "email":
+		for _, v := range values {
+			if !emailPattern.MatchString(v) {
+				add(&flags.TypeMismatch, "Please enter an email address.")
+				break
+			}
+		}
+	case "url":
+		if _, err := ParseURL(value, nil); err != nil {
+			add(&flags.TypeMismatch, "Please enter a URL.")
+		}
+	}
+
+	switch inputType {
+	case "text", "search", "url", "tel", "email", "password":
+		if pattern, err := n.CompiledPattern(); pattern != nil && err == nil {
+			for _, v := range values {
+				if !pattern.MatchString(v) {
+					message := "Please match the requested format."
+					if title := n.attribute("title"); title != "" {
+						message += " " + title
+					}
+					add(&flags.PatternMismatch, message)
+					break
+				}
+			}
+		}
+		n.lengthValidity(value, flags, add)
+	}
+
+	n.rangeValidity(inputType, value, flags, add)
+}
+
+// https://html.spec.whatwg.org/multipage/input.html#valid-e-mail-address
+var emailPattern = regexp.MustCompile("^[a-zA-Z0-9.!#$%&'*+/=?^_`{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$")
+
+// CompiledPattern returns the regular expression the pattern attribute
+// matches whole values against. It's nil when there isn't a pattern
+// attribute. Patterns that don't compile don't constrain the value and return
+// an ErrInvalidPattern.
+// https://html.spec.whatwg.org/multipage/input.html#compiled-pattern-regular-expression
+func (n *Node) CompiledPattern() (*regexp.Regexp, error) {
+	if !n.hasAttribute("pattern") {
+		return nil, nil
+	}
+	pattern := n.attribute("pattern")
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %v", ErrInvalidPattern, pattern, err)
+	}
+	return re, nil
+}
+
+// radioGroupChecked reports if a radio button in the element's group is
+// checked.
+// https://html.spec.whatwg.org/multipage/input.html#radio-button-group
+func (n *Node) radioGroupChecked() bool {
+	name := n.attribute("name")
+	if name == "" {
+		return n.checkedness()
+	}
+	checked := false
+	n.getRoot().walk(func(el *Node) {
+		if !checked && el.inRadioGroupOf(n) && el.checkedness() {
+			checked = true
+		}
+	})
+	return checked
+}
+
+// inRadioGroupOf reports if both elements are radio buttons in the same group.
+func (n *Node) inRadioGroupOf(other *Node) bool {
+	return isHTMLElement(n, "input") && inputTypeState(n) == "radio" &&
+		n.attribute("name") != "" && n.attribute("name") == other.attribute("name") &&
+		n.formOwner() == other.formOwner()
+}
+
+// lengthValidity checks minlength and maxlength. They only apply to values the
+// user changed.
+// https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#setting-minimum-input-length-requirements:-the-minlength-attribute
+func (n *Node) lengthValidity(value string, flags *ValidityStateFlags, add func(*bool, string)) {
+	if !n.controlState().dirtyValue || value == "" {
+		return
+	}
+	length := len(utf16.Encode([]rune(value)))
+	if max, ok := nonNegativeInteger(n.attribute("maxlength")); ok && length > max {
+		add(&flags.TooLong, fmt.Sprintf("Please shorten this text to %d characters or less (you are currently using %d characters).", max, length))
+	}
+	if min, ok := nonNegativeInteger(n.attribute("minlength")); ok && length < min {
+		add(&flags.TooShort, fmt.Sprintf("Please lengthen this text to %d characters or more (you are currently using %d characters).", min, length))
+	}
+}
+
+// https://html.spec.whatwg.org/multipage/common-microsyntaxes.html#rules-for-parsing-non-negative-integers
+func nonNegativeInteger(s string) (int, bool) {
+	s = strings.TrimLeft(s, "\t\n\f\r ")
+	end := 0
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	if end == 0 {
+		return 0, false
+	}
+	i, err := strconv.Atoi(s[:end])
+	return i, err == nil
+}
+
+// rangeTypes are the input types min, max and step apply to with their
+// default step and step scale factor.
+// https://html.spec.whatwg.org/multipage/input.html#concept-input-step-default
+var rangeTypes = map[string]struct{ step, scale float64 }{
+	"number":         {1, 1},
+	"range":          {1, 1},
+	"date":           {1, 86400000},
+	"month":          {1, 1},
+	"time":           {60, 1000},
+	"datetime-local": {60, 1000},
+}
+
+func (n *Node) rangeValidity(inputType, value string, flags *ValidityStateFlags, add func(*bool, string)) {
+	steps, ok := rangeTypes[inputType]
+	if !ok {
+		return
+	}
+	v, ok := parseInputNumber(inputType, value)
+	if !ok {
+		add(&flags.BadInput, "Please enter a valid value.")
+		return
+	}
+
+	minAttr, maxAttr := n.attribute("min"), n.attribute("max")
+	if inputType == "range" {
+		if _, ok := parseInputNumber(inputType, minAttr); !ok {
+			minAttr = "0"
+		}
+		if _, ok := parseInputNumber(inputType, maxAttr); !ok {
+			maxAttr = "100"
+		}
+	}
+	min, hasMin := parseInputNumber(inputType, minAttr)
+	if hasMin && v < min {
+		add(&flags.RangeUnderflow, "Value must be greater than or equal to "+minAttr+".")
+	}
+	if max, ok := parseInputNumber(inputType, maxAttr); ok && v > max {
+		add(&flags.RangeOverflow, "Value must be less than or equal to "+maxAttr+".")
+	}
+
+	// https://html.spec.whatwg.org/multipage/input.html#concept-input-step
+	stepAttr := n.attribute("step")
+	if strings.EqualFold(stepAttr, "any") {
+		return
+	}
+	step, err := strconv.ParseFloat(stepAttr, 64)
+	if !floatingPointNumber.MatchString(stepAttr) || err != nil || step <= 0 {
+		step = steps.step
+	}
+	step *= steps.scale
+
+	// https://html.spec.whatwg.org/multipage/input.html#concept-input-min-zero
+	base := 0.0
+	if hasMin {
+		base = min
+	} else if b, ok := parseInputNumber(inputType, n.attribute("value")); ok {
+		base = b
+	}
+	q := (v - base) / step
+	if math.Abs(q-math.Round(q)) > 1e-9 {
+		add(&flags.StepMismatch, "Please enter a valid value.")
+	}
+}
+
+// https://html.spec.whatwg.org/multipage/common-microsyntaxes.html#valid-floating-point-number
+var floatingPointNumber = regexp.MustCompile(`^-?(?:[0-9]+(?:\.[0-9]+)?|\.[0-9]+)(?:[eE][+-]?[0-9]+)?$`)
+
+// parseInputNumber converts a value of an input type to the number min, max
+// and step work with.
+// https://html.spec.whatwg.org/multipage/input.html#concept-input-value-string-number
+func parseInputNumber(inputType, value string) (float64, bool) {
+	parseTime := func(layouts ...string) (time.Time, bool) {
+		for _, layout := range layouts {
+			if t, err := time.Parse(layout, value); err == nil {
+				return t, true
+			}
+		}
+		return time.Time{}, false
+	}
+	millis := func(t time.Time) float64 {
+		return float64(t.UnixNano()) / float64(time.Millisecond)
+	}
+
+	switch inputType {
+	case "number", "range":
+		if !floatingPointNumber.MatchString(value) {
+			return 0, false
+		}
+		f, err := strconv.ParseFloat(value, 64)
+		return f, err == nil && !math.IsInf(f, 0)
+	case "date":
+		t, ok := parseTime("2006-01-02")
+		return millis(t), ok
+	case "month":
+		t, ok := parseTime("2006-01")
+		return float64((t.Year()-1970)*12 + int(t.Month()) - 1), ok
+	case "time":
+		t, ok := parseTime("15:04", "15:04:05", "15:04:05.999")
+		return millis(t) - millis(time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC)), ok
+	case "datetime-local":
+		t, ok := parseTime("2006-01-02T15:04", "2006-01-02T15:04:05", "2006-01-02T15:04:05.999",
+			"2006-01-02 15:04", "2006-01-02 15:04:05", "2006-01-02 15:04:05.999")
+		return millis(t), ok
+	}
+	return 0, false
+}
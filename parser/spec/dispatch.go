@@ -0,0 +1,36 @@
+package spec
+
+// DispatchEvent is https://dom.spec.whatwg.org/#concept-event-dispatch. The
+// event path is the node and its ancestors. It returns false if the event was
+// canceled.
+func (n *Node) DispatchEvent(e *Event) bool {
+	path := []*Node{}
+	for p := n; p != nil; p = p.ParentNode {
+		path = append(path, p)
+	}
+	e.Target = n
+
+	e.eventPhase = capturingPhase
+	for i := len(path) - 1; i > 0 && !e.stopPropagation; i-- {
+		e.CurrentTarget = path[i]
+		path[i].EventTarget.invoke(e, capturingPhase)
+	}
+	if !e.stopPropagation {
+		e.eventPhase = atTargetPhase
+		e.CurrentTarget = n
+		n.EventTarget.invoke(e, atTargetPhase)
+	}
+	if e.Bubbles {
+		e.eventPhase = bubblingPhase
+		for i := 1; i < len(path) && !e.stopPropagation; i++ {
+			e.CurrentTarget = path[i]
+			path[i].EventTarget.invoke(e, bubblingPhase)
+		}
+	}
+
+	e.eventPhase = noneEventPhase
+	e.CurrentTarget = nil
+	e.stopPropagation = false
+	e.stopImmediatePropagation = false
+	return !e.canceled
+}
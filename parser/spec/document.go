@@ -8,9 +8,40 @@ type Document struct {
 	Doctype                                                       *Node
 	DocumentElement                                               *Element
 
+	// Origin is https://dom.spec.whatwg.org/#concept-document-origin
+	// It's the origin of the URL the document was created with, unless it
+	// inherited another one like about:blank and iframe srcdoc documents.
 	Origin string
 	Mode   string
 	Type   string
+
+	// the HTML document wrapping this document's node.
+	htmlDocument *HTMLDocument
+	url          *URL
+
+	// AboutBaseURL is the base URL of the document that created an
+	// about:blank or iframe srcdoc document.
+	// https://html.spec.whatwg.org/multipage/dom.html#concept-document-about-base-url
+	AboutBaseURL *URL
+}
+
+// URLRecord is https://dom.spec.whatwg.org/#concept-document-url
+// It's about:blank until the URL is set.
+func (d *Document) URLRecord() *URL {
+	if d.url == nil {
+		d.url, _ = ParseURL("about:blank", nil)
+	}
+	return d.url
+}
+
+// SetURL sets the document's URL and the attributes reflecting it.
+func (d *Document) SetURL(u *URL) {
+	d.url = u
+	d.URL = u.Href()
+	d.DocumentURI = d.URL
+	if d.htmlDocument != nil {
+		d.htmlDocument.BaseURI = d.URL
+	}
 }
 
 // GetElementsByTagName is https:domspec.whatwg.org/#dom-document-getelementsbytagname
@@ -0,0 +1,32 @@
+package spec
+
+// DOMException is https://webidl.spec.whatwg.org/#idl-DOMException
+type DOMException struct {
+	Name, Message string
+}
+
+func (e *DOMException) Error() string {
+	if e.Message == "" {
+		return e.Name
+	}
+	return e.Name + ": " + e.Message
+}
+
+// Is matches DOMExceptions by name so errors.Is works with the values below.
+func (e *DOMException) Is(target error) bool {
+	t, ok := target.(*DOMException)
+	return ok && t.Name == e.Name
+}
+
+// https://webidl.spec.whatwg.org/#idl-DOMException-error-names
+var (
+	ErrHierarchyRequest      = &DOMException{Name: "HierarchyRequestError"}
+	ErrIndexSize             = &DOMException{Name: "IndexSizeError"}
+	ErrInvalidCharacter      = &DOMException{Name: "InvalidCharacterError"}
+	ErrInvalidState          = &DOMException{Name: "InvalidStateError"}
+	ErrNoModificationAllowed = &DOMException{Name: "NoModificationAllowedError"}
+	ErrNotFound              = &DOMException{Name: "NotFoundError"}
+	ErrNotSupported          = &DOMException{Name: "NotSupportedError"}
+	ErrSecurity              = &DOMException{Name: "SecurityError"}
+	ErrSyntax                = &DOMException{Name: "SyntaxError"}
+)
@@ -0,0 +1,149 @@
+// Code generated by specgen from idl/dom.webidl. DO NOT EDIT.
+
+package spec
+
+// SlotAssignmentMode is https://dom.spec.whatwg.org/#enumdef-slotassignmentmode
+type SlotAssignmentMode string
+
+const (
+	SlotAssignmentModeManual SlotAssignmentMode = "manual"
+	SlotAssignmentModeNamed  SlotAssignmentMode = "named"
+)
+
+// EventInit is https://dom.spec.whatwg.org/#dictdef-eventinit
+type EventInit struct {
+	Bubbles    bool
+	Cancelable bool
+	Composed   bool
+}
+
+// EventListenerOptions is https://dom.spec.whatwg.org/#dictdef-eventlisteneroptions
+type EventListenerOptions struct {
+	Capture bool
+}
+
+// AddEventListenerOptions is https://dom.spec.whatwg.org/#dictdef-addeventlisteneroptions
+type AddEventListenerOptions struct {
+	EventListenerOptions
+	Passive bool
+	Once    bool
+}
+
+// StaticRangeInit is https://dom.spec.whatwg.org/#dictdef-staticrangeinit
+type StaticRangeInit struct {
+	StartContainer *Node
+	StartOffset    uint
+	EndContainer   *Node
+	EndOffset      uint
+}
+
+// StaticRange is https://dom.spec.whatwg.org/#staticrange
+type StaticRange struct {
+	AbstractRange
+}
+
+// The constants of Range.
+const (
+	RangeStartToStart uint16 = 0
+	RangeStartToEnd   uint16 = 1
+	RangeEndToEnd     uint16 = 2
+	RangeEndToStart   uint16 = 3
+)
+
+// setStart is https://dom.spec.whatwg.org/#dom-range-setstart
+func (r *Range) setStart(node *Node, offset uint) {}
+
+// setEnd is https://dom.spec.whatwg.org/#dom-range-setend
+func (r *Range) setEnd(node *Node, offset uint) {}
+
+// setStartBefore is https://dom.spec.whatwg.org/#dom-range-setstartbefore
+func (r *Range) setStartBefore(node *Node) {}
+
+// setStartAfter is https://dom.spec.whatwg.org/#dom-range-setstartafter
+func (r *Range) setStartAfter(node *Node) {}
+
+// setEndBefore is https://dom.spec.whatwg.org/#dom-range-setendbefore
+func (r *Range) setEndBefore(node *Node) {}
+
+// setEndAfter is https://dom.spec.whatwg.org/#dom-range-setendafter
+func (r *Range) setEndAfter(node *Node) {}
+
+// collapse is https://dom.spec.whatwg.org/#dom-range-collapse
+func (r *Range) collapse(toStart bool) {}
+
+// selectNode is https://dom.spec.whatwg.org/#dom-range-selectnode
+func (r *Range) selectNode(node *Node) {}
+
+// selectNodeContents is https://dom.spec.whatwg.org/#dom-range-selectnodecontents
+func (r *Range) selectNodeContents(node *Node) {}
+
+// compareBoundaryPoints is https://dom.spec.whatwg.org/#dom-range-compareboundarypoints
+func (r *Range) compareBoundaryPoints(how uint16, sourceRange *Range) int16 { return 0 }
+
+// deleteContents is https://dom.spec.whatwg.org/#dom-range-deletecontents
+func (r *Range) deleteContents() {}
+
+// extractContents is https://dom.spec.whatwg.org/#dom-range-extractcontents
+func (r *Range) extractContents() *DocumentFragment { return nil }
+
+// cloneContents is https://dom.spec.whatwg.org/#dom-range-clonecontents
+func (r *Range) cloneContents() *DocumentFragment { return nil }
+
+// insertNode is https://dom.spec.whatwg.org/#dom-range-insertnode
+func (r *Range) insertNode(node *Node) {}
+
+// surroundContents is https://dom.spec.whatwg.org/#dom-range-surroundcontents
+func (r *Range) surroundContents(newParent *Node) {}
+
+// cloneRange is https://dom.spec.whatwg.org/#dom-range-clonerange
+func (r *Range) cloneRange() *Range { return nil }
+
+// detach is https://dom.spec.whatwg.org/#dom-range-detach
+func (r *Range) detach() {}
+
+// isPointInRange is https://dom.spec.whatwg.org/#dom-range-ispointinrange
+func (r *Range) isPointInRange(node *Node, offset uint) bool { return false }
+
+// comparePoint is https://dom.spec.whatwg.org/#dom-range-comparepoint
+func (r *Range) comparePoint(node *Node, offset uint) int16 { return 0 }
+
+// intersectsNode is https://dom.spec.whatwg.org/#dom-range-intersectsnode
+func (r *Range) intersectsNode(node *Node) bool { return false }
+
+// parentNode is https://dom.spec.whatwg.org/#dom-treewalker-parentnode
+func (t *TreeWalker) parentNode() *Node { return nil }
+
+// firstChild is https://dom.spec.whatwg.org/#dom-treewalker-firstchild
+func (t *TreeWalker) firstChild() *Node { return nil }
+
+// lastChild is https://dom.spec.whatwg.org/#dom-treewalker-lastchild
+func (t *TreeWalker) lastChild() *Node { return nil }
+
+// previousSibling is https://dom.spec.whatwg.org/#dom-treewalker-previoussibling
+func (t *TreeWalker) previousSibling() *Node { return nil }
+
+// nextSibling is https://dom.spec.whatwg.org/#dom-treewalker-nextsibling
+func (t *TreeWalker) nextSibling() *Node { return nil }
+
+// previousNode is https://dom.spec.whatwg.org/#dom-treewalker-previousnode
+func (t *TreeWalker) previousNode() *Node { return nil }
+
+// nextNode is https://dom.spec.whatwg.org/#dom-treewalker-nextnode
+func (t *TreeWalker) nextNode() *Node { return nil }
+
+// The constants of NodeFilter.
+const (
+	NodeFilterFilterAccept              uint16 = 1
+	NodeFilterFilterReject              uint16 = 2
+	NodeFilterFilterSkip                uint16 = 3
+	NodeFilterShowAll                   uint   = 0xFFFFFFFF
+	NodeFilterShowElement               uint   = 0x1
+	NodeFilterShowAttribute             uint   = 0x2
+	NodeFilterShowText                  uint   = 0x4
+	NodeFilterShowCdataSection          uint   = 0x8
+	NodeFilterShowProcessingInstruction uint   = 0x40
+	NodeFilterShowComment               uint   = 0x80
+	NodeFilterShowDocument              uint   = 0x100
+	NodeFilterShowDocumentType          uint   = 0x200
+	NodeFilterShowDocumentFragment      uint   = 0x400
+)
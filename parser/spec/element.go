@@ -50,10 +50,6 @@ func (e *Element) GetElementsByTagNameNS(namespace, localName string) HTMLCollec
 	return nil
 }
 func (e *Element) GetElementsByClassName(qualifiedName string) HTMLCollection { return nil }
-func (e *Element) InsertAdjacentElement(where string, element *Element) *Element {
-	return nil
-}
-func (e *Element) InsertAdjacentTExt(where, data string) {}
 
 type ElementType uint
 
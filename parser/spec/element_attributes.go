@@ -0,0 +1,64 @@
+package spec
+
+import "strings"
+
+// qualifiedAttributeName lowercases the name for HTML elements in HTML
+// documents.
+// https://dom.spec.whatwg.org/#concept-element-attributes-get-by-name
+func (n *Node) qualifiedAttributeName(name string) string {
+	if n.NamespaceURI == Htmlns {
+		return strings.ToLower(name)
+	}
+	return name
+}
+
+// GetAttribute is https://dom.spec.whatwg.org/#dom-element-getattribute
+// It's "" for missing attributes, which HasAttribute tells apart.
+func (n *Node) GetAttribute(qualifiedName string) string {
+	return n.attribute(n.qualifiedAttributeName(qualifiedName))
+}
+
+// HasAttribute is https://dom.spec.whatwg.org/#dom-element-hasattribute
+func (n *Node) HasAttribute(qualifiedName string) bool {
+	return n.hasAttribute(n.qualifiedAttributeName(qualifiedName))
+}
+
+// SetAttribute is https://dom.spec.whatwg.org/#dom-element-setattribute
+func (n *Node) SetAttribute(qualifiedName, value string) {
+	if n.NodeType != ElementNode || n.Element == nil {
+		return
+	}
+	name := n.qualifiedAttributeName(qualifiedName)
+	n.setAttributeValue(name, value)
+	n.attributeChanged(name, value, false)
+}
+
+// RemoveAttribute is https://dom.spec.whatwg.org/#dom-element-removeattribute
+func (n *Node) RemoveAttribute(qualifiedName string) {
+	name := n.qualifiedAttributeName(qualifiedName)
+	if !n.hasAttribute(name) {
+		return
+	}
+	delete(n.Attributes.Attrs, name)
+	n.Attributes.Length--
+	n.attributeChanged(name, "", true)
+}
+
+// attributeChanged runs the attribute change steps of the element.
+// https://dom.spec.whatwg.org/#concept-element-attributes-change-ext
+func (n *Node) attributeChanged(name, value string, removed bool) {
+	switch {
+	case isEventHandlerAttribute(n, name):
+		if removed {
+			n.deactivateEventHandler(name)
+			return
+		}
+		n.setEventHandlerAttribute(name, value)
+	case isHTMLElement(n, "iframe") && (name == "src" || name == "srcdoc"):
+		n.HTMLIFrame.processAttributes(false)
+	case name == "checked" || name == "selected":
+		n.formControlAttributeChanged(name, removed)
+	case name == "type":
+		n.formControlsChanged()
+	}
+}
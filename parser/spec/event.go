@@ -1,5 +1,7 @@
 package spec
 
+import "time"
+
 type eventPhase uint
 
 const (
@@ -11,23 +13,68 @@ const (
 
 // https:domspec.whatwg.org/#interface-event
 type Event struct {
-	eventType        string
-	target           EventTarget
-	srcElement       EventTarget
-	currentTarget    EventTarget
-	eventPhase       eventPhase
-	cancelBubble     bool
-	bubbles          bool
-	cancelable       bool
-	returnValue      bool
-	defaultPrevented bool
-	composed         bool
-	isTrusted        bool
-	timeStamp        uint
+	Type                string
+	Target              *Node
+	CurrentTarget       *Node
+	Bubbles, Cancelable bool
+	// IsTrusted is set for events fired by the user agent.
+	IsTrusted bool
+	TimeStamp time.Time
+	// Wrapper is for the ScriptHost to keep the event's JavaScript object,
+	// which goes away with the event.
+	Wrapper interface{}
+
+	eventPhase               eventPhase
+	stopPropagation          bool
+	stopImmediatePropagation bool
+	canceled                 bool
+	inPassiveListener        bool
+}
+
+// NewEvent creates an event that hasn't been dispatched.
+// https://dom.spec.whatwg.org/#dom-event-event
+func NewEvent(eventType string, bubbles, cancelable bool) *Event {
+	return &Event{
+		Type:       eventType,
+		Bubbles:    bubbles,
+		Cancelable: cancelable,
+		TimeStamp:  time.Now(),
+	}
+}
+
+// https://html.spec.whatwg.org/multipage/webappapis.html#concept-event-fire
+func newTrustedEvent(eventType string, bubbles, cancelable bool) *Event {
+	e := NewEvent(eventType, bubbles, cancelable)
+	e.IsTrusted = true
+	return e
+}
+
+func (e *Event) ComposedPath() []EventTarget { return nil }
+
+// https://dom.spec.whatwg.org/#dom-event-stoppropagation
+func (e *Event) StopPropagation() {
+	e.stopPropagation = true
+}
+
+// https://dom.spec.whatwg.org/#dom-event-stopimmediatepropagation
+func (e *Event) StopImmediatePropagation() {
+	e.stopPropagation = true
+	e.stopImmediatePropagation = true
+}
+
+// https://dom.spec.whatwg.org/#dom-event-preventdefault
+func (e *Event) PreventDefault() {
+	if e.Cancelable && !e.inPassiveListener {
+		e.canceled = true
+	}
+}
+
+// EventPhase is https://dom.spec.whatwg.org/#dom-event-eventphase
+func (e *Event) EventPhase() int { return int(e.eventPhase) }
+
+// https://dom.spec.whatwg.org/#dom-event-defaultprevented
+func (e *Event) DefaultPrevented() bool {
+	return e.canceled
 }
 
-func (e *Event) ComposedPath() []EventTarget                 { return nil }
-func (e *Event) StopPropagation()                            {}
-func (e *Event) StopImmediatePropagation()                   {}
-func (e *Event) PreventDefault()                             {}
 func (e *Event) InitEvent(eventType string, options ...bool) {}
@@ -0,0 +1,162 @@
+package spec
+
+// eventHandler is https://html.spec.whatwg.org/multipage/webappapis.html#event-handlers
+type eventHandler struct {
+	value EventHandler
+	// body is the internal raw uncompiled handler of an event handler content
+	// attribute. It's compiled the first time the handler's value is needed.
+	body     string
+	raw      bool
+	listener *EventListener
+}
+
+// EventHandlerNames are the names of the event handlers of
+// https://html.spec.whatwg.org/multipage/webappapis.html#globaleventhandlers
+// and https://html.spec.whatwg.org/multipage/webappapis.html#documentandelementeventhandlers,
+// which HTML elements have content attributes for.
+var EventHandlerNames = []string{
+	"onabort", "onauxclick", "onbeforeinput", "onbeforematch", "onbeforetoggle", "onblur", "oncancel",
+	"oncanplay", "oncanplaythrough", "onchange", "onclick", "onclose", "oncontextlost", "oncontextmenu",
+	"oncontextrestored", "oncopy", "oncuechange", "oncut", "ondblclick", "ondrag", "ondragend",
+	"ondragenter", "ondragleave", "ondragover", "ondragstart", "ondrop", "ondurationchange", "onemptied",
+	"onended", "onerror", "onfocus", "onformdata", "oninput", "oninvalid", "onkeydown", "onkeypress",
+	"onkeyup", "onload", "onloadeddata", "onloadedmetadata", "onloadstart", "onmousedown",
+	"onmouseenter", "onmouseleave", "onmousemove", "onmouseout", "onmouseover", "onmouseup", "onpaste",
+	"onpause", "onplay", "onplaying", "onprogress", "onratechange", "onreset", "onresize", "onscroll",
+	"onscrollend", "onsecuritypolicyviolation", "onseeked", "onseeking", "onselect", "onslotchange",
+	"onstalled", "onsubmit", "onsuspend", "ontimeupdate", "ontoggle", "onvolumechange", "onwaiting",
+	"onwebkitanimationend", "onwebkitanimationiteration", "onwebkitanimationstart",
+	"onwebkittransitionend", "onwheel",
+}
+
+// WindowEventHandlerNames are the names of the event handlers of
+// https://html.spec.whatwg.org/multipage/webappapis.html#windoweventhandlers,
+// which body and frameset elements have content attributes for.
+var WindowEventHandlerNames = []string{
+	"onafterprint", "onbeforeprint", "onbeforeunload", "onhashchange", "onlanguagechange", "onmessage",
+	"onmessageerror", "onoffline", "ononline", "onpagehide", "onpagereveal", "onpageshow", "onpageswap",
+	"onpopstate", "onrejectionhandled", "onstorage", "onunhandledrejection", "onunload",
+}
+
+var (
+	eventHandlerNames       = stringSet(EventHandlerNames)
+	windowEventHandlerNames = stringSet(WindowEventHandlerNames)
+)
+
+// windowReflectingEventHandlers is https://html.spec.whatwg.org/multipage/webappapis.html#window-reflecting-body-element-event-handler-set
+var windowReflectingEventHandlers = stringSet([]string{"onblur", "onerror", "onfocus", "onload", "onresize", "onscroll"})
+
+func stringSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
+
+// isEventHandlerAttribute reports if the attribute is an event handler
+// content attribute of the element.
+// https://html.spec.whatwg.org/multipage/webappapis.html#event-handler-content-attributes
+func isEventHandlerAttribute(n *Node, name string) bool {
+	if isBodyOrFrameset(n) && windowEventHandlerNames[name] {
+		return true
+	}
+	return n.NodeType == ElementNode && n.Element != nil && n.NamespaceURI == Htmlns && eventHandlerNames[name]
+}
+
+func isBodyOrFrameset(n *Node) bool {
+	return isHTMLElement(n, "body") || isHTMLElement(n, "frameset")
+}
+
+// eventHandlerTarget is https://html.spec.whatwg.org/multipage/webappapis.html#determining-the-target-of-an-event-handler
+// The window-reflecting event handlers of body and frameset elements are the
+// handlers of their document's window.
+func (n *Node) eventHandlerTarget(name string) *EventTarget {
+	if isBodyOrFrameset(n) && (windowReflectingEventHandlers[name] || windowEventHandlerNames[name]) {
+		if doc := n.OwnerHTMLDocument(); doc != nil {
+			return doc.Window().EventTarget
+		}
+	}
+	return &n.EventTarget
+}
+
+// EventHandler is https://html.spec.whatwg.org/multipage/webappapis.html#getting-the-current-value-of-the-event-handler
+// name is the name of the event handler, like onclick. Event handler content
+// attributes are compiled by the document's ScriptHost and the handler is nil
+// when there isn't one or the body doesn't compile, which the ScriptHost
+// reports.
+func (n *Node) EventHandler(name string) EventHandler {
+	h := n.eventHandlerTarget(name).handlers[name]
+	if h == nil {
+		return nil
+	}
+	if h.raw {
+		h.raw = false
+		h.value = nil
+		if doc := n.OwnerHTMLDocument(); doc != nil && doc.ScriptHost != nil {
+			compiled, err := doc.ScriptHost.CompileEventHandler(n, name, h.body)
+			if err != nil {
+				doc.ScriptHost.ReportError(doc, err)
+			} else {
+				h.value = compiled
+			}
+		}
+	}
+	return h.value
+}
+
+// SetEventHandler is the setter of event handler IDL attributes like
+// onclick. A nil handler removes the event handler.
+// https://html.spec.whatwg.org/multipage/webappapis.html#event-handler-idl-attributes
+func (n *Node) SetEventHandler(name string, value EventHandler) {
+	if value == nil {
+		n.deactivateEventHandler(name)
+		return
+	}
+	h := n.activateEventHandler(name)
+	h.value, h.raw = value, false
+}
+
+// setEventHandlerAttribute is https://html.spec.whatwg.org/multipage/webappapis.html#event-handler-attributes:concept-element-attributes-change-ext
+func (n *Node) setEventHandlerAttribute(name, value string) {
+	h := n.activateEventHandler(name)
+	h.body, h.raw = value, true
+}
+
+// activateEventHandler is https://html.spec.whatwg.org/multipage/webappapis.html#activate-an-event-handler
+// The event handler's listener is only added once so it keeps its place
+// among the listeners when the handler changes.
+func (n *Node) activateEventHandler(name string) *eventHandler {
+	target := n.eventHandlerTarget(name)
+	if target.handlers == nil {
+		target.handlers = map[string]*eventHandler{}
+	}
+	h := target.handlers[name]
+	if h == nil {
+		h = &eventHandler{}
+		target.handlers[name] = h
+	}
+	if h.listener == nil {
+		// https://html.spec.whatwg.org/multipage/webappapis.html#the-event-handler-processing-algorithm
+		h.listener = &EventListener{Callback: func(e *Event) {
+			if callback := n.EventHandler(name); callback != nil {
+				callback(e)
+			}
+		}}
+		target.AddEventListener(name[len("on"):], h.listener)
+	}
+	return h
+}
+
+// deactivateEventHandler is https://html.spec.whatwg.org/multipage/webappapis.html#deactivate-an-event-handler
+func (n *Node) deactivateEventHandler(name string) {
+	target := n.eventHandlerTarget(name)
+	h := target.handlers[name]
+	if h == nil {
+		return
+	}
+	delete(target.handlers, name)
+	if h.listener != nil {
+		target.RemoveEventListener(name[len("on"):], h.listener)
+	}
+}
@@ -0,0 +1,61 @@
+package spec
+
+// TaskSource is https://html.spec.whatwg.org/multipage/webappapis.html#task-source
+type TaskSource string
+
+// https://html.spec.whatwg.org/multipage/webappapis.html#generic-task-sources
+const (
+	DOMManipulationTaskSource  TaskSource = "DOM manipulation"
+	UserInteractionTaskSource  TaskSource = "user interaction"
+	NetworkingTaskSource       TaskSource = "networking"
+	NavigationTaskSource       TaskSource = "navigation and traversal"
+	RenderingTaskSource        TaskSource = "rendering"
+	TimerTaskSource            TaskSource = "timer"
+	PostedMessageTaskSource    TaskSource = "posted message"
+	HistoryTraversalTaskSource TaskSource = "history traversal"
+)
+
+// EventLoop is https://html.spec.whatwg.org/multipage/webappapis.html#event-loop
+// The spec package can't depend on the event loop's implementation so it's
+// reached through this interface.
+type EventLoop interface {
+	// QueueTask is https://html.spec.whatwg.org/multipage/webappapis.html#queue-a-task
+	// It's safe to call from any goroutine.
+	QueueTask(source TaskSource, task func())
+	// QueueMicrotask is https://html.spec.whatwg.org/multipage/webappapis.html#queue-a-microtask
+	QueueMicrotask(microtask func())
+	// PerformMicrotaskCheckpoint is https://html.spec.whatwg.org/multipage/webappapis.html#perform-a-microtask-checkpoint
+	PerformMicrotaskCheckpoint()
+	// InParallel runs the steps on another goroutine. The event loop isn't
+	// idle until they're done, so they can queue tasks with their results.
+	// https://html.spec.whatwg.org/multipage/infrastructure.html#in-parallel
+	InParallel(steps func())
+}
+
+// QueueTask is https://html.spec.whatwg.org/multipage/webappapis.html#queue-a-global-task
+// The task runs right away for documents without an event loop.
+func (d *HTMLDocument) QueueTask(source TaskSource, task func()) {
+	if d.EventLoop == nil {
+		task()
+		return
+	}
+	d.EventLoop.QueueTask(source, task)
+}
+
+// QueueMicrotask is https://html.spec.whatwg.org/multipage/timers-and-user-prompts.html#dom-queuemicrotask
+// The microtask runs right away for documents without an event loop.
+func (d *HTMLDocument) QueueMicrotask(microtask func()) {
+	if d.EventLoop == nil {
+		microtask()
+		return
+	}
+	d.EventLoop.QueueMicrotask(microtask)
+}
+
+// PerformMicrotaskCheckpoint is https://html.spec.whatwg.org/multipage/webappapis.html#perform-a-microtask-checkpoint
+// It does nothing for documents without an event loop.
+func (d *HTMLDocument) PerformMicrotaskCheckpoint() {
+	if d.EventLoop != nil {
+		d.EventLoop.PerformMicrotaskCheckpoint()
+	}
+}
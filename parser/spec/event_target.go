@@ -1,9 +1,68 @@
 package spec
 
+// EventListener is https://dom.spec.whatwg.org/#concept-event-listener. The
+// same *EventListener has to be passed to RemoveEventListener to remove it.
+type EventListener struct {
+	Callback               func(e *Event)
+	Capture, Once, Passive bool
+
+	removed bool
+}
+
 //https:domspec.whatwg.org/#eventtarget
 type EventTarget struct {
+	listeners map[string][]*EventListener
+	// handlers are the event handlers of the target by name, like onclick.
+	handlers map[string]*eventHandler
+}
+
+// AddEventListener is https://dom.spec.whatwg.org/#dom-eventtarget-addeventlistener
+func (et *EventTarget) AddEventListener(eventType string, listener *EventListener) {
+	if listener == nil || listener.Callback == nil {
+		return
+	}
+	for _, l := range et.listeners[eventType] {
+		if l == listener {
+			return
+		}
+	}
+	if et.listeners == nil {
+		et.listeners = map[string][]*EventListener{}
+	}
+	listener.removed = false
+	et.listeners[eventType] = append(et.listeners[eventType], listener)
+}
+
+// RemoveEventListener is https://dom.spec.whatwg.org/#dom-eventtarget-removeeventlistener
+func (et *EventTarget) RemoveEventListener(eventType string, listener *EventListener) {
+	listeners := et.listeners[eventType]
+	for i, l := range listeners {
+		if l == listener {
+			l.removed = true
+			et.listeners[eventType] = append(listeners[:i:i], listeners[i+1:]...)
+			return
+		}
+	}
 }
 
-func (et *EventTarget) AddEventListener()    {}
-func (et *EventTarget) RemoveEventListener() {}
-func (et *EventTarget) DispatchEvent()       {}
+// https://dom.spec.whatwg.org/#concept-event-listener-inner-invoke
+func (et *EventTarget) invoke(e *Event, phase eventPhase) {
+	// listeners added while the event is being dispatched aren't called.
+	for _, l := range append([]*EventListener(nil), et.listeners[e.Type]...) {
+		if l.removed {
+			continue
+		}
+		if (phase == capturingPhase && !l.Capture) || (phase == bubblingPhase && l.Capture) {
+			continue
+		}
+		if l.Once {
+			et.RemoveEventListener(e.Type, l)
+		}
+		e.inPassiveListener = l.Passive
+		l.Callback(e)
+		e.inPassiveListener = false
+		if e.stopImmediatePropagation {
+			return
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package spec
+
+// FormAssociatedCustomElement is implemented by the definition of a custom
+// element that is form-associated. The callbacks are its custom element
+// reactions.
+// https://html.spec.whatwg.org/multipage/custom-elements.html#form-associated-custom-elements
+type FormAssociatedCustomElement interface {
+	// FormAssociatedCallback is called when the element's form owner changes.
+	// form is nil when the element no longer has one.
+	FormAssociatedCallback(element, form *Node)
+	// FormResetCallback is called when the element's form owner is reset.
+	FormResetCallback(element *Node)
+}
+
+// https://html.spec.whatwg.org/multipage/forms.html#form-associated-element
+func (n *Node) IsFormAssociated() bool {
+	if n.NodeType != ElementNode || n.Element == nil || n.NamespaceURI != Htmlns {
+		return false
+	}
+	switch n.NodeName {
+	case "button", "fieldset", "input", "object", "output", "select", "textarea", "img":
+		return true
+	}
+	return n.isFormAssociatedCustomElement()
+}
+
+func (n *Node) isFormAssociatedCustomElement() bool {
+	return n.HTMLElement != nil && n.HTMLElement.FormAssociatedCustomElement != nil
+}
+
+// https://html.spec.whatwg.org/multipage/forms.html#category-listed
+func (n *Node) IsListed() bool {
+	return n.IsFormAssociated() && n.NodeName != "img"
+}
+
+// https://html.spec.whatwg.org/multipage/forms.html#category-submit
+func (n *Node) IsSubmittable() bool {
+	if !n.IsFormAssociated() {
+		return false
+	}
+	switch n.NodeName {
+	case "button", "input", "select", "textarea":
+		return true
+	}
+	return n.isFormAssociatedCustomElement()
+}
+
+// https://html.spec.whatwg.org/multipage/forms.html#category-reset
+func (n *Node) IsResettable() bool {
+	if !n.IsFormAssociated() {
+		return false
+	}
+	switch n.NodeName {
+	case "input", "output", "select", "textarea":
+		return true
+	}
+	return n.isFormAssociatedCustomElement()
+}
+
+// Form returns the form owner of a form-associated element.
+// https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#form-owner
+func (n *Node) Form() *Node {
+	return n.formOwner()
+}
+
+func (n *Node) formOwner() *Node {
+	if n.NodeType != ElementNode || n.Element == nil || n.HTMLElement == nil {
+		return nil
+	}
+	return n.HTMLElement.formOwner
+}
+
+// https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#concept-form-association
+func (n *Node) setFormOwner(form *Node) {
+	old := n.HTMLElement.formOwner
+	if old == form {
+		return
+	}
+	if old != nil {
+		old.HTMLForm.dissociate(n)
+	}
+	n.HTMLElement.formOwner = form
+	if form != nil {
+		form.HTMLForm.associate(n)
+	}
+	if isHTMLElement(n, "input") {
+		n.uncheckRadioGroup()
+	}
+	if n.isFormAssociatedCustomElement() {
+		n.HTMLElement.FormAssociatedCustomElement.FormAssociatedCallback(n, form)
+	}
+}
+
+// SetParserInsertedFormOwner associates an element created by the parser with
+// the form element pointer and sets its parser inserted flag so inserting it
+// doesn't reset its form owner.
+// https://html.spec.whatwg.org/multipage/parsing.html#create-an-element-for-the-token
+func (n *Node) SetParserInsertedFormOwner(form *Node) {
+	n.setFormOwner(form)
+	n.HTMLElement.parserInsertedForm = true
+}
+
+// ResetFormOwner is https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#reset-the-form-owner.
+// It has to be called when the form or id attributes involved change.
+func (n *Node) ResetFormOwner() {
+	if !n.IsFormAssociated() {
+		return
+	}
+	n.HTMLElement.parserInsertedForm = false
+	_, hasFormAttr := n.Attributes.Attrs["form"]
+	owner := n.formOwner()
+	if owner != nil && !hasFormAttr && n.isInclusiveAncestor(owner) {
+		return
+	}
+
+	if n.IsListed() && hasFormAttr {
+		var form *Node
+		root := n.getRoot()
+		if root.NodeType == DocumentNode {
+			if el := root.elementByID(n.attribute("form")); el != nil && isHTMLElement(el, "form") {
+				form = el
+			}
+		}
+		n.setFormOwner(form)
+		return
+	}
+
+	for a := n.ParentNode; a != nil; a = a.ParentNode {
+		// template contents are kept as the template's children rather than in
+		// a separate document fragment, so they mustn't see forms outside it.
+		if isHTMLElement(a, "template") {
+			break
+		}
+		if isHTMLElement(a, "form") {
+			n.setFormOwner(a)
+			return
+		}
+	}
+	n.setFormOwner(nil)
+}
+
+// formAssociatedInserted runs the insertion steps of the form-associated
+// elements in the inserted subtree.
+// https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#association-of-controls-and-forms
+func (n *Node) formAssociatedInserted() {
+	n.walk(func(el *Node) {
+		if !el.IsFormAssociated() {
+			return
+		}
+		if !el.HTMLElement.parserInsertedForm {
+			el.ResetFormOwner()
+		}
+		el.formControlsChanged()
+	})
+}
+
+// formAssociatedRemoved runs the removing steps of the form-associated
+// elements in the removed subtree and of the elements left in oldRoot whose
+// form owner was removed.
+func (n *Node) formAssociatedRemoved(oldRoot *Node) {
+	forms := []*Node{}
+	n.walk(func(el *Node) {
+		if isHTMLElement(el, "form") {
+			forms = append(forms, el)
+		}
+		if !el.IsFormAssociated() {
+			return
+		}
+		if owner := el.formOwner(); owner != nil && owner.getRoot() != n {
+			el.ResetFormOwner()
+		}
+		el.formControlsChanged()
+	})
+	for _, form := range forms {
+		for _, el := range form.HTMLForm.associatedIn(oldRoot) {
+			el.ResetFormOwner()
+		}
+	}
+}
+
+// formControlsChanged drops the cached controls of the form owner of n since
+// their tree order or which of them are listed may have changed.
+func (n *Node) formControlsChanged() {
+	if owner := n.formOwner(); owner != nil {
+		owner.HTMLForm.elements = nil
+	}
+}
+
+// precedes reports if n comes before other in tree order. They have to share
+// a root.
+func (n *Node) precedes(other *Node) bool {
+	if n == other {
+		return false
+	}
+	a, b := n.inclusiveAncestors(), other.inclusiveAncestors()
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	switch {
+	case i == len(a):
+		return true
+	case i == len(b) || i == 0:
+		return false
+	}
+	parent := a[i-1]
+	return parent.ChildNodes.Contains(a[i]) < parent.ChildNodes.Contains(b[i])
+}
+
+// inclusiveAncestors returns n and its ancestors starting from the root.
+func (n *Node) inclusiveAncestors() []*Node {
+	ancestors := []*Node{}
+	for a := n; a != nil; a = a.ParentNode {
+		ancestors = append(ancestors, a)
+	}
+	for i, j := 0, len(ancestors)-1; i < j; i, j = i+1, j-1 {
+		ancestors[i], ancestors[j] = ancestors[j], ancestors[i]
+	}
+	return ancestors
+}
+
+// isInclusiveAncestor reports if ancestor is n or one of its ancestors.
+func (n *Node) isInclusiveAncestor(ancestor *Node) bool {
+	for a := n; a != nil; a = a.ParentNode {
+		if a == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// walk calls f for n and its descendants in tree order.
+func (n *Node) walk(f func(*Node)) {
+	f(n)
+	for _, child := range n.ChildNodes {
+		child.walk(f)
+	}
+}
+
+// elementByID returns the first element in tree order with the ID.
+func (n *Node) elementByID(id string) *Node {
+	if id == "" {
+		return nil
+	}
+	var found *Node
+	n.walk(func(el *Node) {
+		if found == nil && el.NodeType == ElementNode && el.attribute("id") == id {
+			found = el
+		}
+	})
+	return found
+}
+
+// attribute returns the value of an attribute or the empty string if it's not
+// set.
+func (n *Node) attribute(name string) string {
+	if n.NodeType != ElementNode || n.Element == nil || n.Attributes == nil {
+		return ""
+	}
+	if attr, ok := n.Attributes.Attrs[name]; ok {
+		return attr.Value
+	}
+	return ""
+}
+
+func isHTMLElement(n *Node, name string) bool {
+	return n.NodeType == ElementNode && n.Element != nil && n.NamespaceURI == Htmlns && n.NodeName == name
+}
+
+// AttachInternals is https://html.spec.whatwg.org/multipage/custom-elements.html#dom-attachinternals
+func (n *Node) AttachInternals() (*ElementInternals, error) {
+	if n.HTMLElement == nil || !n.isFormAssociatedCustomElement() {
+		return nil, ErrNotSupported
+	}
+	if n.HTMLElement.internals != nil {
+		return nil, ErrNotSupported
+	}
+	n.HTMLElement.internals = &ElementInternals{target: n}
+	return n.HTMLElement.internals, nil
+}
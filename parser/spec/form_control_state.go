@@ -0,0 +1,635 @@
+package spec
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// formControlState is the state of form controls that isn't kept in their
+// attributes.
+type formControlState struct {
+	// value is the value of inputs in the value mode and the raw value of
+	// textareas. Until dirtyValue is set it follows the default value.
+	value      string
+	dirtyValue bool
+
+	// checkedness of inputs and selectedness of options. They follow the
+	// checked and selected attributes until they're dirty.
+	checkedness, dirtyCheckedness   bool
+	selectedness, dirtySelectedness bool
+	// selectednessSet is set on select elements once the selectedness of
+	// their options was set rather than following the display rules.
+	selectednessSet bool
+
+	// files are the selected files of file inputs.
+	files []FormFile
+}
+
+// https://html.spec.whatwg.org/multipage/input.html#dom-input-value
+const (
+	valueModeValue     = "value"
+	valueModeDefault   = "default"
+	valueModeDefaultOn = "default/on"
+	valueModeFilename  = "filename"
+)
+
+func valueMode(inputType string) string {
+	switch inputType {
+	case "hidden", "submit", "image", "reset", "button":
+		return valueModeDefault
+	case "checkbox", "radio":
+		return valueModeDefaultOn
+	case "file":
+		return valueModeFilename
+	}
+	return valueModeValue
+}
+
+func (n *Node) controlState() *formControlState {
+	return &n.HTMLElement.control
+}
+
+// ControlValue is the value IDL attribute of input, textarea, select, option,
+// button and output elements.
+// https://html.spec.whatwg.org/multipage/input.html#dom-input-value
+func (n *Node) ControlValue() string {
+	if n.NodeType != ElementNode || n.Element == nil || n.HTMLElement == nil {
+		return ""
+	}
+	switch n.NodeName {
+	case "input":
+		switch valueMode(inputTypeState(n)) {
+		case valueModeValue:
+			if n.controlState().dirtyValue {
+				return n.controlState().value
+			}
+			return n.sanitizeValue(n.attribute("value"))
+		case valueModeDefaultOn:
+			if !n.hasAttribute("value") {
+				return "on"
+			}
+		case valueModeFilename:
+			if files := n.controlState().files; len(files) > 0 {
+				return `C:\fakepath\` + files[0].Name
+			}
+			return ""
+		}
+	case "textarea":
+		// https://html.spec.whatwg.org/multipage/form-elements.html#concept-textarea-api-value
+		raw := n.DefaultValue()
+		if n.controlState().dirtyValue {
+			raw = n.controlState().value
+		}
+		raw = strings.ReplaceAll(raw, "\r\n", "\n")
+		return strings.ReplaceAll(raw, "\r", "\n")
+	case "select":
+		if selected := n.SelectedOptions(); len(selected) > 0 {
+			return selected[0].optionValue()
+		}
+		return ""
+	case "option":
+		return n.optionValue()
+	case "output":
+		text, _ := n.TextContent()
+		return text
+	}
+	return n.attribute("value")
+}
+
+// SetControlValue sets the value IDL attribute. It's also how user input is
+// simulated since either way the dirty value flag is set. File inputs can
+// only be cleared.
+func (n *Node) SetControlValue(value string) error {
+	if n.NodeType != ElementNode || n.Element == nil || n.HTMLElement == nil {
+		return nil
+	}
+	switch n.NodeName {
+	case "input":
+		switch valueMode(inputTypeState(n)) {
+		case valueModeValue:
+			n.controlState().value = n.sanitizeValue(value)
+			n.controlState().dirtyValue = true
+			return nil
+		case valueModeFilename:
+			if value != "" {
+				return ErrInvalidState
+			}
+			n.controlState().files = nil
+			return nil
+		}
+	case "textarea":
+		n.controlState().value = value
+		n.controlState().dirtyValue = true
+		return nil
+	case "select":
+		// https://html.spec.whatwg.org/multipage/form-elements.html#dom-select-value
+		n.controlState().selectednessSet = true
+		found := false
+		for _, option := range n.options() {
+			selected := !found && option.optionValue() == value
+			option.controlState().selectedness = selected
+			if selected {
+				option.controlState().dirtySelectedness = true
+				found = true
+			}
+		}
+		return nil
+	case "output":
+		n.SetTextContent(value)
+		return nil
+	}
+	n.setAttributeValue("value", value)
+	return nil
+}
+
+// DefaultValue is the defaultValue IDL attribute of input and textarea
+// elements.
+// https://html.spec.whatwg.org/multipage/form-elements.html#dom-textarea-defaultvalue
+func (n *Node) DefaultValue() string {
+	if n.NodeName == "textarea" {
+		text, _ := n.TextContent()
+		return text
+	}
+	return n.attribute("value")
+}
+
+// SetDefaultValue sets the defaultValue IDL attribute.
+func (n *Node) SetDefaultValue(value string) {
+	if n.NodeName == "textarea" {
+		n.SetTextContent(value)
+		return
+	}
+	n.setAttributeValue("value", value)
+}
+
+// setAttributeValue sets an attribute without a namespace.
+func (n *Node) setAttributeValue(name, value string) {
+	if n.Attributes == nil {
+		n.Attributes = NewNamedNodeMap(nil, n)
+	}
+	if attr, ok := n.Attributes.Attrs[name]; ok {
+		attr.Value = value
+		return
+	}
+	n.Attributes.Attrs[name] = &Attr{LocalName: name, Name: name, Value: value, OwnerElement: n}
+	n.Attributes.Length++
+}
+
+// Files returns the selected files of a file input.
+// https://html.spec.whatwg.org/multipage/input.html#dom-input-files
+func (n *Node) Files() []FormFile {
+	if !isHTMLElement(n, "input") || inputTypeState(n) != "file" {
+		return nil
+	}
+	return n.controlState().files
+}
+
+// SetFiles selects files in a file input the way a user would. Only the first
+// file is kept unless the input has the multiple attribute.
+func (n *Node) SetFiles(files []FormFile) {
+	if !isHTMLElement(n, "input") || inputTypeState(n) != "file" {
+		return
+	}
+	if len(files) > 1 && !n.hasAttribute("multiple") {
+		files = files[:1]
+	}
+	n.controlState().files = files
+}
+
+// Checked is https://html.spec.whatwg.org/multipage/input.html#dom-input-checked
+func (n *Node) Checked() bool {
+	return n.checkedness()
+}
+
+// SetChecked sets the checkedness of checkbox and radio inputs and its dirty
+// flag. Checking a radio button unchecks the others in its group.
+func (n *Node) SetChecked(checked bool) {
+	if !isHTMLElement(n, "input") {
+		return
+	}
+	n.controlState().dirtyCheckedness = true
+	n.setCheckedness(checked)
+}
+
+// checkedness is the checkedness of checkbox and radio inputs.
+// https://html.spec.whatwg.org/multipage/input.html#concept-fe-checked
+func (n *Node) checkedness() bool {
+	if n.NodeType != ElementNode || n.Element == nil || n.HTMLElement == nil {
+		return false
+	}
+	return n.controlState().checkedness
+}
+
+func (n *Node) setCheckedness(checked bool) {
+	n.controlState().checkedness = checked
+	if checked {
+		n.uncheckRadioGroup()
+	}
+}
+
+// uncheckRadioGroup sets the checkedness of the other elements in a checked
+// radio button's group to false.
+// https://html.spec.whatwg.org/multipage/input.html#radio-button-state-(type=radio)
+func (n *Node) uncheckRadioGroup() {
+	if inputTypeState(n) != "radio" || !n.checkedness() {
+		return
+	}
+	n.getRoot().walk(func(el *Node) {
+		if el != n && el.inRadioGroupOf(n) {
+			el.controlState().checkedness = false
+		}
+	})
+}
+
+// Selected is https://html.spec.whatwg.org/multipage/form-elements.html#dom-option-selected
+func (n *Node) Selected() bool {
+	if !isHTMLElement(n, "option") {
+		return false
+	}
+	if sel := n.optionSelect(); sel != nil {
+		for _, option := range sel.SelectedOptions() {
+			if option == n {
+				return true
+			}
+		}
+		return false
+	}
+	return n.selectedness()
+}
+
+// SetSelected sets the selectedness of an option and its dirtiness. It's how
+// users pick options too.
+func (n *Node) SetSelected(selected bool) {
+	if !isHTMLElement(n, "option") {
+		return
+	}
+	sel := n.optionSelect()
+	if sel != nil {
+		sel.applySelectedness()
+	}
+	n.controlState().selectedness = selected
+	n.controlState().dirtySelectedness = true
+	if sel == nil {
+		return
+	}
+	if selected && !sel.hasAttribute("multiple") {
+		for _, option := range sel.options() {
+			if option != n {
+				option.controlState().selectedness = false
+			}
+		}
+	}
+	sel.selectednessSetting()
+}
+
+// SelectedIndex is https://html.spec.whatwg.org/multipage/form-elements.html#dom-select-selectedindex
+func (n *Node) SelectedIndex() int {
+	selected := n.SelectedOptions()
+	if len(selected) == 0 {
+		return -1
+	}
+	for i, option := range n.options() {
+		if option == selected[0] {
+			return i
+		}
+	}
+	return -1
+}
+
+// SetSelectedIndex selects the option at the index and deselects the others.
+// An index out of range deselects all of them.
+func (n *Node) SetSelectedIndex(index int) {
+	if !isHTMLElement(n, "select") {
+		return
+	}
+	n.controlState().selectednessSet = true
+	for i, option := range n.options() {
+		option.controlState().selectedness = i == index
+		if i == index {
+			option.controlState().dirtySelectedness = true
+		}
+	}
+}
+
+// selectedness is the selectedness of an option without the display rules of
+// its select element.
+// https://html.spec.whatwg.org/multipage/form-elements.html#concept-option-selectedness
+func (n *Node) selectedness() bool {
+	if n.NodeType != ElementNode || n.Element == nil || n.HTMLElement == nil {
+		return false
+	}
+	return n.controlState().selectedness
+}
+
+// optionSelect returns the select element whose list of options the option is
+// in.
+func (n *Node) optionSelect() *Node {
+	p := n.ParentNode
+	if p != nil && isHTMLElement(p, "optgroup") {
+		p = p.ParentNode
+	}
+	if p != nil && isHTMLElement(p, "select") {
+		return p
+	}
+	return nil
+}
+
+// applySelectedness stores the selectedness the display rules give the options
+// of a select element so that it can be changed.
+func (n *Node) applySelectedness() {
+	if n.controlState().selectednessSet {
+		return
+	}
+	selected := map[*Node]bool{}
+	for _, option := range n.SelectedOptions() {
+		selected[option] = true
+	}
+	for _, option := range n.options() {
+		option.controlState().selectedness = selected[option]
+	}
+	n.controlState().selectednessSet = true
+}
+
+// selectednessSetting is the selectedness setting algorithm run on the stored
+// selectedness.
+// https://html.spec.whatwg.org/multipage/form-elements.html#selectedness-setting-algorithm
+func (n *Node) selectednessSetting() {
+	if n.hasAttribute("multiple") || n.displaySize() > 1 {
+		return
+	}
+	var selected []*Node
+	options := n.options()
+	for _, option := range options {
+		if option.selectedness() {
+			selected = append(selected, option)
+		}
+	}
+	if len(selected) > 1 {
+		for _, option := range selected[:len(selected)-1] {
+			option.controlState().selectedness = false
+		}
+		return
+	}
+	if len(selected) == 0 {
+		for _, option := range options {
+			if !option.hasAttribute("disabled") {
+				option.controlState().selectedness = true
+				return
+			}
+		}
+	}
+}
+
+// SelectedOptions returns the selected options of a select element. Until the
+// selectedness of its options is changed, a select element that shows a
+// single option has the last one with the selected attribute selected, or the
+// first that isn't disabled.
+// https://html.spec.whatwg.org/multipage/form-elements.html#dom-select-selectedoptions
+func (n *Node) SelectedOptions() []*Node {
+	selected, options := []*Node{}, n.options()
+	for _, option := range options {
+		if option.selectedness() {
+			selected = append(selected, option)
+		}
+	}
+	if n.NodeType != ElementNode || n.Element == nil || n.HTMLElement == nil || n.controlState().selectednessSet {
+		return selected
+	}
+	if n.hasAttribute("multiple") || n.displaySize() > 1 {
+		return selected
+	}
+	if len(selected) > 1 {
+		return selected[len(selected)-1:]
+	}
+	if len(selected) == 0 {
+		for _, option := range options {
+			if !option.hasAttribute("disabled") {
+				return []*Node{option}
+			}
+		}
+	}
+	return selected
+}
+
+// Reset is https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#concept-form-reset
+func (f *HTMLForm) Reset() {
+	if !f.node.DispatchEvent(newTrustedEvent("reset", true, true)) {
+		return
+	}
+	f.node.getRoot().walk(func(el *Node) {
+		if el.formOwner() == f.node && el.IsResettable() {
+			el.resetControl()
+		}
+	})
+}
+
+// resetControl runs the reset algorithm of a resettable element.
+// https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#concept-form-reset-control
+func (n *Node) resetControl() {
+	if n.isFormAssociatedCustomElement() {
+		n.HTMLElement.FormAssociatedCustomElement.FormResetCallback(n)
+		return
+	}
+	state := n.controlState()
+	switch n.NodeName {
+	case "input":
+		state.dirtyValue, state.value = false, ""
+		state.dirtyCheckedness = false
+		state.files = nil
+		n.setCheckedness(n.hasAttribute("checked"))
+	case "textarea":
+		state.dirtyValue, state.value = false, ""
+	case "select":
+		for _, option := range n.options() {
+			option.controlState().selectedness = option.hasAttribute("selected")
+			option.controlState().dirtySelectedness = false
+		}
+		state.selectednessSet = true
+		n.selectednessSetting()
+	}
+}
+
+// formControlAttributeChanged makes the checkedness and selectedness follow
+// the checked and selected attributes while they aren't dirty.
+// https://html.spec.whatwg.org/multipage/input.html#the-input-element:concept-fe-checked
+// https://html.spec.whatwg.org/multipage/form-elements.html#the-option-element:concept-option-selectedness-3
+func (n *Node) formControlAttributeChanged(name string, removed bool) {
+	switch {
+	case name == "checked" && isHTMLElement(n, "input"):
+		if !n.controlState().dirtyCheckedness {
+			n.setCheckedness(!removed)
+		}
+	case name == "selected" && isHTMLElement(n, "option"):
+		if n.controlState().dirtySelectedness {
+			return
+		}
+		n.controlState().selectedness = !removed
+		sel := n.optionSelect()
+		if sel == nil || !sel.controlState().selectednessSet {
+			return
+		}
+		if !removed && !sel.hasAttribute("multiple") {
+			for _, option := range sel.options() {
+				if option != n {
+					option.controlState().selectedness = false
+				}
+			}
+		}
+		sel.selectednessSetting()
+	}
+}
+
+// formControlInserted runs the insertion steps of radio buttons and options
+// in the inserted subtree.
+func (n *Node) formControlInserted() {
+	n.walk(func(el *Node) {
+		switch {
+		case isHTMLElement(el, "input"):
+			if el.checkedness() {
+				el.uncheckRadioGroup()
+			}
+		case isHTMLElement(el, "option"):
+			if sel := el.optionSelect(); sel != nil && sel.controlState().selectednessSet {
+				if el.selectedness() && !sel.hasAttribute("multiple") {
+					for _, option := range sel.options() {
+						if option != el {
+							option.controlState().selectedness = false
+						}
+					}
+				}
+				sel.selectednessSetting()
+			}
+		}
+	})
+}
+
+// sanitizeValue is the value sanitization algorithm of the input's type.
+// https://html.spec.whatwg.org/multipage/input.html#value-sanitization-algorithm
+func (n *Node) sanitizeValue(value string) string {
+	inputType := inputTypeState(n)
+	switch inputType {
+	case "text", "search", "tel", "password":
+		return stripNewlines(value)
+	case "url":
+		return strings.Trim(stripNewlines(value), asciiWhitespace)
+	case "email":
+		if !n.hasAttribute("multiple") {
+			return strings.Trim(stripNewlines(value), asciiWhitespace)
+		}
+		values := strings.Split(value, ",")
+		for i := range values {
+			values[i] = strings.Trim(stripNewlines(values[i]), asciiWhitespace)
+		}
+		return strings.Join(values, ",")
+	case "number":
+		if _, ok := parseInputNumber(inputType, value); !ok {
+			return ""
+		}
+	case "range":
+		return n.sanitizeRange(value)
+	case "date", "month", "time":
+		if _, ok := parseInputNumber(inputType, value); !ok {
+			return ""
+		}
+	case "week":
+		if !validWeek(value) {
+			return ""
+		}
+	case "datetime-local":
+		return normalizeLocalDateTime(value)
+	case "color":
+		if !simpleColor.MatchString(value) {
+			return "#000000"
+		}
+		return strings.ToLower(value)
+	}
+	return value
+}
+
+const asciiWhitespace = "\t\n\f\r "
+
+func stripNewlines(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
+
+// sanitizeRange returns the default value for values that aren't numbers and
+// clamps the others to the range and step.
+// https://html.spec.whatwg.org/multipage/input.html#range-state-(type=range)
+func (n *Node) sanitizeRange(value string) string {
+	min, ok := parseInputNumber("range", n.attribute("min"))
+	if !ok {
+		min = 0
+	}
+	max, ok := parseInputNumber("range", n.attribute("max"))
+	if !ok {
+		max = 100
+	}
+	if max < min {
+		max = min
+	}
+	v, ok := parseInputNumber("range", value)
+	if !ok {
+		v = min + (max-min)/2
+	}
+	v = math.Max(min, math.Min(max, v))
+
+	if stepAttr := n.attribute("step"); !strings.EqualFold(stepAttr, "any") {
+		step, err := strconv.ParseFloat(stepAttr, 64)
+		if !floatingPointNumber.MatchString(stepAttr) || err != nil || step <= 0 {
+			step = rangeTypes["range"].step
+		}
+		v = min + math.Round((v-min)/step)*step
+		if v > max {
+			v -= step
+		}
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// https://html.spec.whatwg.org/multipage/common-microsyntaxes.html#valid-simple-colour
+var simpleColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+var weekString = regexp.MustCompile(`^([0-9]{4,})-W([0-9]{2})$`)
+
+// https://html.spec.whatwg.org/multipage/common-microsyntaxes.html#valid-week-string
+func validWeek(s string) bool {
+	m := weekString.FindStringSubmatch(s)
+	if m == nil {
+		return false
+	}
+	year, err := strconv.Atoi(m[1])
+	if err != nil || year == 0 {
+		return false
+	}
+	week, _ := strconv.Atoi(m[2])
+	// years that start on a Thursday, or a Wednesday in leap years, have 53
+	// weeks.
+	maxWeek := 52
+	jan1 := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC).Weekday()
+	leap := year%4 == 0 && (year%100 != 0 || year%400 == 0)
+	if jan1 == time.Thursday || (leap && jan1 == time.Wednesday) {
+		maxWeek = 53
+	}
+	return week >= 1 && week <= maxWeek
+}
+
+// normalizeLocalDateTime returns the valid normalized local date and time
+// string of a local date and time string or the empty string.
+// https://html.spec.whatwg.org/multipage/common-microsyntaxes.html#valid-normalised-local-date-and-time-string
+func normalizeLocalDateTime(s string) string {
+	if _, ok := parseInputNumber("datetime-local", s); !ok {
+		return ""
+	}
+	s = strings.Replace(s, " ", "T", 1)
+	t, err := time.Parse("2006-01-02T15:04:05", s)
+	if err != nil {
+		t, _ = time.Parse("2006-01-02T15:04", s)
+	}
+	if t.Second() == 0 && t.Nanosecond() == 0 {
+		return t.Format("2006-01-02T15:04")
+	}
+	return t.Format("2006-01-02T15:04:05.999")
+}
@@ -0,0 +1,440 @@
+package spec
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// FormFile is a file in a form data set. The files chosen for a file input
+// aren't modeled so they're always empty placeholders.
+type FormFile struct {
+	Name, Type string
+	Body       []byte
+}
+
+// FormDataEntry is an entry of a form data set. File is set for entries from
+// file inputs and Value is empty for them.
+// https://xhr.spec.whatwg.org/#concept-formdata-entry
+type FormDataEntry struct {
+	Name, Value string
+	File        *FormFile
+}
+
+// formCharset is the encoding used to submit forms. UTF-8 is the only encoding
+// supported so accept-charset has no effect.
+// https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#picking-an-encoding-for-the-form
+const formCharset = "UTF-8"
+
+// EntryList is https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#constructing-the-form-data-set.
+// submitter is the button that submitted the form, if any.
+func (f *HTMLForm) EntryList(submitter *Node) []FormDataEntry {
+	entries := []FormDataEntry{}
+	f.node.getRoot().walk(func(field *Node) {
+		if field.formOwner() != f.node || !field.IsSubmittable() {
+			return
+		}
+		entries = appendEntries(entries, field, submitter)
+	})
+	return entries
+}
+
+func appendEntries(entries []FormDataEntry, field, submitter *Node) []FormDataEntry {
+	if hasAncestor(field, "datalist") || field.IsDisabledFormControl() {
+		return entries
+	}
+	if isButton(field) && field != submitter {
+		return entries
+	}
+
+	inputType := ""
+	if field.NodeName == "input" {
+		inputType = inputTypeState(field)
+	}
+	if (inputType == "checkbox" || inputType == "radio") && !field.checkedness() {
+		return entries
+	}
+
+	name := field.attribute("name")
+	if inputType == "image" {
+		// the coordinate is where the image was clicked, which is the origin
+		// since there's no pointer.
+		prefix := ""
+		if name != "" {
+			prefix = name + "."
+		}
+		return append(entries,
+			FormDataEntry{Name: prefix + "x", Value: "0"},
+			FormDataEntry{Name: prefix + "y", Value: "0"})
+	}
+	if name == "" {
+		return entries
+	}
+
+	switch {
+	case field.NodeName == "select":
+		for _, option := range field.SelectedOptions() {
+			if !option.hasAttribute("disabled") {
+				entries = append(entries, FormDataEntry{Name: name, Value: option.optionValue()})
+			}
+		}
+	case inputType == "checkbox" || inputType == "radio":
+		entries = append(entries, FormDataEntry{Name: name, Value: field.ControlValue()})
+	case inputType == "file":
+		files := field.Files()
+		if len(files) == 0 {
+			entries = append(entries, FormDataEntry{Name: name, File: &FormFile{Type: "application/octet-stream"}})
+		}
+		for i := range files {
+			entries = append(entries, FormDataEntry{Name: name, File: &files[i]})
+		}
+	case inputType == "hidden" && strings.EqualFold(name, "_charset_"):
+		entries = append(entries, FormDataEntry{Name: name, Value: formCharset})
+	default:
+		entries = append(entries, FormDataEntry{Name: name, Value: field.ControlValue()})
+	}
+
+	// https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#submitting-element-directionality:-the-dirname-attribute
+	if dirname := field.attribute("dirname"); dirname != "" && (field.NodeName == "textarea" || field.NodeName == "input") {
+		entries = append(entries, FormDataEntry{Name: dirname, Value: field.directionality()})
+	}
+	return entries
+}
+
+func hasAncestor(n *Node, name string) bool {
+	for a := n.ParentNode; a != nil; a = a.ParentNode {
+		if isHTMLElement(a, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *Node) hasAttribute(name string) bool {
+	if n.NodeType != ElementNode || n.Element == nil || n.Attributes == nil {
+		return false
+	}
+	_, ok := n.Attributes.Attrs[name]
+	return ok
+}
+
+// isButton reports if the element is a button that submits or resets forms or
+// does nothing.
+// https://html.spec.whatwg.org/multipage/forms.html#concept-button
+func isButton(n *Node) bool {
+	if n.NodeName == "button" {
+		return true
+	}
+	if n.NodeName != "input" {
+		return false
+	}
+	switch inputTypeState(n) {
+	case "submit", "reset", "button", "image":
+		return true
+	}
+	return false
+}
+
+// IsSubmitButton is https://html.spec.whatwg.org/multipage/forms.html#concept-submit-button
+func (n *Node) IsSubmitButton() bool {
+	if isHTMLElement(n, "button") {
+		switch strings.ToLower(n.attribute("type")) {
+		case "reset", "button":
+			return false
+		}
+		return true
+	}
+	if !isHTMLElement(n, "input") {
+		return false
+	}
+	inputType := inputTypeState(n)
+	return inputType == "submit" || inputType == "image"
+}
+
+// inputTypeState returns the type attribute's state, which is text for
+// unknown values.
+// https://html.spec.whatwg.org/multipage/input.html#attr-input-type
+func inputTypeState(n *Node) string {
+	t := strings.ToLower(n.attribute("type"))
+	switch t {
+	case "hidden", "text", "search", "tel", "url", "email", "password", "date",
+		"month", "week", "time", "datetime-local", "number", "range", "color",
+		"checkbox", "radio", "file", "submit", "image", "reset", "button":
+		return t
+	}
+	return "text"
+}
+
+// IsDisabledFormControl reports if the element is a disabled button, input,
+// select, textarea, fieldset or form-associated custom element.
+// https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#concept-fe-disabled
+func (n *Node) IsDisabledFormControl() bool {
+	switch {
+	case isHTMLElement(n, "button"), isHTMLElement(n, "input"), isHTMLElement(n, "select"),
+		isHTMLElement(n, "textarea"), isHTMLElement(n, "fieldset"), n.isFormAssociatedCustomElement():
+	default:
+		return false
+	}
+	if n.hasAttribute("disabled") {
+		return true
+	}
+	for child, a := n, n.ParentNode; a != nil; child, a = a, a.ParentNode {
+		if !isHTMLElement(a, "fieldset") || !a.hasAttribute("disabled") {
+			continue
+		}
+		// descendants of the first legend aren't disabled by the fieldset.
+		if isHTMLElement(child, "legend") && child == firstChildElement(a, "legend") {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+func firstChildElement(n *Node, name string) *Node {
+	for _, child := range n.ChildNodes {
+		if isHTMLElement(child, name) {
+			return child
+		}
+	}
+	return nil
+}
+
+// https://html.spec.whatwg.org/multipage/form-elements.html#concept-select-size
+func (n *Node) displaySize() int {
+	size := 1
+	fmt.Sscanf(n.attribute("size"), "%d", &size)
+	return size
+}
+
+// options returns the list of options of a select element.
+// https://html.spec.whatwg.org/multipage/form-elements.html#concept-select-option-list
+func (n *Node) options() []*Node {
+	options := []*Node{}
+	for _, child := range n.ChildNodes {
+		switch {
+		case isHTMLElement(child, "option"):
+			options = append(options, child)
+		case isHTMLElement(child, "optgroup"):
+			for _, grandchild := range child.ChildNodes {
+				if isHTMLElement(grandchild, "option") {
+					options = append(options, grandchild)
+				}
+			}
+		}
+	}
+	return options
+}
+
+// https://html.spec.whatwg.org/multipage/form-elements.html#concept-option-value
+func (n *Node) optionValue() string {
+	if n.hasAttribute("value") {
+		return n.attribute("value")
+	}
+	text, _ := n.TextContent()
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// directionality is ltr or rtl from the nearest dir attribute. The auto value
+// isn't supported and is treated as ltr.
+// https://html.spec.whatwg.org/multipage/dom.html#the-directionality
+func (n *Node) directionality() string {
+	for a := n; a != nil; a = a.ParentNode {
+		switch strings.ToLower(a.attribute("dir")) {
+		case "rtl":
+			return "rtl"
+		case "ltr":
+			return "ltr"
+		}
+	}
+	return "ltr"
+}
+
+// normalizeLineBreaks replaces CR and LF that aren't part of a CRLF pair with
+// CRLF.
+func normalizeLineBreaks(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	return strings.ReplaceAll(s, "\n", "\r\n")
+}
+
+// EncodeURLEncoded is https://url.spec.whatwg.org/#concept-urlencoded-serializer
+// applied to the entry list converted to name-value pairs.
+func EncodeURLEncoded(entries []FormDataEntry) string {
+	var b strings.Builder
+	for i, entry := range entries {
+		if i > 0 {
+			b.WriteByte('&')
+		}
+		value := entry.Value
+		if entry.File != nil {
+			value = entry.File.Name
+		}
+		b.WriteString(urlencode(normalizeLineBreaks(entry.Name)))
+		b.WriteByte('=')
+		b.WriteString(urlencode(normalizeLineBreaks(value)))
+	}
+	return b.String()
+}
+
+// https://url.spec.whatwg.org/#application-x-www-form-urlencoded-percent-encode-set
+func urlencode(s string) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9',
+			c == '*', c == '-', c == '.', c == '_':
+			b.WriteByte(c)
+		case c == ' ':
+			b.WriteByte('+')
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&0xF])
+		}
+	}
+	return b.String()
+}
+
+// EncodeMultipart is https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#multipart/form-data-encoding-algorithm
+func EncodeMultipart(entries []FormDataEntry, boundary string) []byte {
+	escape := strings.NewReplacer("\n", "%0A", "\r", "%0D", "\"", "%22").Replace
+	var b bytes.Buffer
+	for _, entry := range entries {
+		b.WriteString("--" + boundary + "\r\n")
+		name := escape(normalizeLineBreaks(entry.Name))
+		if entry.File == nil {
+			b.WriteString("Content-Disposition: form-data; name=\"" + name + "\"\r\n\r\n")
+			b.WriteString(normalizeLineBreaks(entry.Value))
+		} else {
+			b.WriteString("Content-Disposition: form-data; name=\"" + name + "\"; filename=\"" + escape(entry.File.Name) + "\"\r\n")
+			b.WriteString("Content-Type: " + entry.File.Type + "\r\n\r\n")
+			b.Write(entry.File.Body)
+		}
+		b.WriteString("\r\n")
+	}
+	b.WriteString("--" + boundary + "--\r\n")
+	return b.Bytes()
+}
+
+// EncodeTextPlain is https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#text/plain-encoding-algorithm
+func EncodeTextPlain(entries []FormDataEntry) string {
+	var b strings.Builder
+	for _, entry := range entries {
+		value := entry.Value
+		if entry.File != nil {
+			value = entry.File.Name
+		}
+		b.WriteString(normalizeLineBreaks(entry.Name) + "=" + normalizeLineBreaks(value) + "\r\n")
+	}
+	return b.String()
+}
+
+// FormSubmission is the request a form submission navigates to.
+type FormSubmission struct {
+	// Method is get, post or dialog. Dialog submissions close the dialog the
+	// form is in instead of making a request.
+	Method string
+	Action *url.URL
+	// ContentType and Body are set for post submissions.
+	ContentType string
+	Body        []byte
+}
+
+// ErrDialogSubmission is returned when a request is made for a form submitted
+// with the dialog method.
+var ErrDialogSubmission = errors.New("dialog form submissions don't make requests")
+
+// Submission builds the request for submitting the form. submitter is the
+// button that submitted the form, if any. Its formaction, formmethod and
+// formenctype attributes override the form's.
+// https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#form-submission-algorithm
+func (f *HTMLForm) Submission(submitter *Node) (*FormSubmission, error) {
+	attr := func(name string) string {
+		if submitter != nil && submitter.hasAttribute("form"+name) {
+			return submitter.attribute("form" + name)
+		}
+		return f.node.attribute(name)
+	}
+
+	// https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#attr-fs-method
+	method := strings.ToLower(attr("method"))
+	if method != "post" && method != "dialog" {
+		method = "get"
+	}
+	submission := &FormSubmission{Method: method}
+	if method == "dialog" {
+		return submission, nil
+	}
+
+	action := f.node.Action()
+	if submitter != nil && submitter.hasAttribute("formaction") {
+		action = submitter.FormAction()
+	}
+	parsedAction, err := ParseURL(action, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := f.EntryList(submitter)
+	if method == "get" {
+		// https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#submit-mutate-action
+		query := EncodeURLEncoded(entries)
+		parsedAction.query = &query
+	}
+	if submission.Action, err = parsedAction.NetURL(); err != nil {
+		return nil, err
+	}
+	if method == "get" {
+		return submission, nil
+	}
+
+	// https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#attr-fs-enctype
+	switch strings.ToLower(attr("enctype")) {
+	case "multipart/form-data":
+		boundary, err := multipartBoundary()
+		if err != nil {
+			return nil, err
+		}
+		submission.ContentType = "multipart/form-data; boundary=" + boundary
+		submission.Body = EncodeMultipart(entries, boundary)
+	case "text/plain":
+		submission.ContentType = "text/plain"
+		submission.Body = []byte(EncodeTextPlain(entries))
+	default:
+		submission.ContentType = "application/x-www-form-urlencoded"
+		submission.Body = []byte(EncodeURLEncoded(entries))
+	}
+	return submission, nil
+}
+
+func multipartBoundary() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return "----gobrowseFormBoundary" + hex.EncodeToString(b[:]), nil
+}
+
+// NewRequest returns the HTTP request for the submission.
+func (s *FormSubmission) NewRequest() (*http.Request, error) {
+	if s.Method == "dialog" {
+		return nil, ErrDialogSubmission
+	}
+	if s.Method == "get" {
+		return http.NewRequest(http.MethodGet, s.Action.String(), nil)
+	}
+	req, err := http.NewRequest(http.MethodPost, s.Action.String(), bytes.NewReader(s.Body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", s.ContentType)
+	return req, nil
+}
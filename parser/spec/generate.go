@@ -0,0 +1,7 @@
+package spec
+
+// The *_idl.go files are generated from the IDL fragments in idl. Hand-written
+// declarations take precedence over generated ones, so implementing a stub
+// means writing the exported method in another file and generating again,
+// which drops the unexported stub.
+//go:generate go run ../../webidl/specgen idl/dom.webidl idl/html.webidl
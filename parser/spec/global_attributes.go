@@ -0,0 +1,127 @@
+package spec
+
+import "strings"
+
+// Translate is https://html.spec.whatwg.org/multipage/dom.html#dom-translate
+// It's the element's translation mode, which it inherits from its parent
+// unless its translate attribute is yes or no.
+func (n *Node) Translate() bool {
+	for e := n; e != nil && e.NodeType == ElementNode; e = e.ParentNode {
+		if !e.hasAttribute("translate") {
+			continue
+		}
+		switch strings.ToLower(e.attribute("translate")) {
+		case "", "yes":
+			return true
+		case "no":
+			return false
+		}
+	}
+	return true
+}
+
+// SetTranslate is https://html.spec.whatwg.org/multipage/dom.html#dom-translate
+func (n *Node) SetTranslate(v bool) {
+	if v {
+		n.SetAttribute("translate", "yes")
+		return
+	}
+	n.SetAttribute("translate", "no")
+}
+
+// Dir is https://html.spec.whatwg.org/multipage/dom.html#dom-dir
+// It reflects the dir content attribute limited to ltr, rtl and auto.
+func (n *Node) Dir() string {
+	switch dir := strings.ToLower(n.attribute("dir")); dir {
+	case "ltr", "rtl", "auto":
+		return dir
+	}
+	return ""
+}
+
+// SetDir sets the dir content attribute.
+func (n *Node) SetDir(v string) { n.SetAttribute("dir", v) }
+
+// AccessKeyLabel is https://html.spec.whatwg.org/multipage/interaction.html#dom-accesskeylabel
+// It's empty since no access keys are assigned without a user interface.
+func (n *Node) AccessKeyLabel() string { return "" }
+
+// Draggable is https://html.spec.whatwg.org/multipage/dnd.html#dom-draggable
+// Images and links with an href are draggable unless the draggable attribute
+// is false, and other elements only when it's true.
+func (n *Node) Draggable() bool {
+	switch strings.ToLower(n.attribute("draggable")) {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	return isHTMLElement(n, "img") || isHTMLElement(n, "a") && n.hasAttribute("href")
+}
+
+// SetDraggable is https://html.spec.whatwg.org/multipage/dnd.html#dom-draggable
+func (n *Node) SetDraggable(v bool) {
+	if v {
+		n.SetAttribute("draggable", "true")
+		return
+	}
+	n.SetAttribute("draggable", "false")
+}
+
+// Spellcheck is https://html.spec.whatwg.org/multipage/interaction.html#dom-spellcheck
+// Elements inherit it from the nearest ancestor with a spellcheck attribute
+// of true or false and are checked by default.
+func (n *Node) Spellcheck() bool {
+	for e := n; e != nil && e.NodeType == ElementNode; e = e.ParentNode {
+		if !e.hasAttribute("spellcheck") {
+			continue
+		}
+		switch strings.ToLower(e.attribute("spellcheck")) {
+		case "", "true":
+			return true
+		case "false":
+			return false
+		}
+	}
+	return true
+}
+
+// SetSpellcheck is https://html.spec.whatwg.org/multipage/interaction.html#dom-spellcheck
+func (n *Node) SetSpellcheck(v bool) {
+	if v {
+		n.SetAttribute("spellcheck", "true")
+		return
+	}
+	n.SetAttribute("spellcheck", "false")
+}
+
+// autocapitalizeInheritingElements are https://html.spec.whatwg.org/multipage/forms.html#category-autocapitalize
+var autocapitalizeInheritingElements = map[string]bool{
+	"button": true, "fieldset": true, "input": true, "output": true, "select": true, "textarea": true,
+}
+
+// Autocapitalize is https://html.spec.whatwg.org/multipage/interaction.html#dom-autocapitalize
+// It's the keyword of the element's own autocapitalization hint, which form
+// controls inherit from their form owner, or empty for the default.
+// https://html.spec.whatwg.org/multipage/interaction.html#own-autocapitalization-hint
+func (n *Node) Autocapitalize() string {
+	switch strings.ToLower(n.attribute("autocapitalize")) {
+	case "off", "none":
+		return "none"
+	case "on", "sentences":
+		return "sentences"
+	case "words":
+		return "words"
+	case "characters":
+		return "characters"
+	}
+	if autocapitalizeInheritingElements[n.NodeName] && isHTMLElement(n, n.NodeName) {
+		if form := n.Form(); form != nil {
+			return form.Autocapitalize()
+		}
+	}
+	return ""
+}
+
+// SetAutocapitalize sets the autocapitalize content attribute.
+func (n *Node) SetAutocapitalize(v string) { n.SetAttribute("autocapitalize", v) }
@@ -0,0 +1,95 @@
+package spec
+
+// HTMLBase is https://html.spec.whatwg.org/multipage/semantics.html#htmlbaseelement
+type HTMLBase struct {
+	// node is the base element.
+	node *Node
+	// frozen is the frozen base URL and frozenHref the href it was set for.
+	frozen     *URL
+	frozenHref string
+}
+
+// setFrozenBaseURL is https://html.spec.whatwg.org/multipage/semantics.html#set-the-frozen-base-url
+func (b *HTMLBase) setFrozenBaseURL() {
+	fallback := b.node.document().FallbackBaseURL()
+	href := b.node.attribute("href")
+	u, err := ParseURL(href, fallback)
+	// browsers don't let data: and javascript: URLs be base URLs.
+	if err != nil || u.scheme == "data" || u.scheme == "javascript" {
+		u = fallback
+	}
+	b.frozen, b.frozenHref = u, href
+}
+
+// FrozenBaseURL is https://html.spec.whatwg.org/multipage/semantics.html#frozen-base-url
+// It's set again when the href attribute has changed since it was frozen.
+func (b *HTMLBase) FrozenBaseURL() *URL {
+	if b.frozen == nil || b.frozenHref != b.node.attribute("href") {
+		b.setFrozenBaseURL()
+	}
+	return b.frozen
+}
+
+// baseInserted freezes the base URL when the node is inserted as the first
+// base element with an href attribute in its document.
+func (n *Node) baseInserted() {
+	if !isHTMLElement(n, "base") || !n.hasAttribute("href") || n.OwnerDocument == nil {
+		return
+	}
+	if n.getRoot() == n.OwnerDocument && n.document().firstBaseWithHref() == n {
+		n.HTMLBase.setFrozenBaseURL()
+	}
+}
+
+// document returns the node document. Documents without one get an empty
+// document so callers don't have to check.
+func (n *Node) document() *Document {
+	if n.NodeType == DocumentNode && n.Document != nil {
+		return n.Document
+	}
+	if n.OwnerDocument == nil || n.OwnerDocument.Document == nil {
+		return &Document{}
+	}
+	return n.OwnerDocument.Document
+}
+
+// firstBaseWithHref returns the first base element with an href attribute in
+// tree order.
+func (d *Document) firstBaseWithHref() *Node {
+	if d.htmlDocument == nil {
+		return nil
+	}
+	var found *Node
+	d.htmlDocument.Node.walk(func(n *Node) {
+		if found == nil && isHTMLElement(n, "base") && n.hasAttribute("href") {
+			found = n
+		}
+	})
+	return found
+}
+
+// matchesAbout reports if the URL is about:name ignoring its query and
+// fragment.
+// https://html.spec.whatwg.org/multipage/urls-and-fetching.html#matches-about:blank
+func (u *URL) matchesAbout(name string) bool {
+	return u.scheme == "about" && u.opaque && u.path[0] == name &&
+		u.username == "" && u.password == "" && u.host == nil
+}
+
+// FallbackBaseURL is https://html.spec.whatwg.org/multipage/urls-and-fetching.html#fallback-base-url
+// iframe srcdoc documents are the ones with the URL about:srcdoc.
+func (d *Document) FallbackBaseURL() *URL {
+	u := d.URLRecord()
+	if d.AboutBaseURL != nil && (u.matchesAbout("srcdoc") || u.matchesAbout("blank")) {
+		return d.AboutBaseURL
+	}
+	return u
+}
+
+// BaseURL is https://html.spec.whatwg.org/multipage/urls-and-fetching.html#document-base-url
+func (d *Document) BaseURL() *URL {
+	if base := d.firstBaseWithHref(); base != nil {
+		return base.HTMLBase.FrozenBaseURL()
+	}
+	return d.FallbackBaseURL()
+}
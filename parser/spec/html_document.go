@@ -3,38 +3,241 @@ package spec
 type DocumentReadyState string
 
 const (
-	loading     DocumentReadyState = "loading"
-	interactive DocumentReadyState = "interactive"
-	complete    DocumentReadyState = "complete"
+	Loading     DocumentReadyState = "loading"
+	Interactive DocumentReadyState = "interactive"
+	Complete    DocumentReadyState = "complete"
 )
 
-//https://html.spec.whatwg.org/#eventhandler
+// https://html.spec.whatwg.org/#eventhandler
 type EventHandler func(e *Event)
 
 // https://html.spec.whatwg.org/#the-document-object
 type HTMLDocument struct {
-	Location                                                       *HTMLLocation
-	Domain, Referrer, Cookie, LastModified, Title, Dir, DesignMode string
-	ReadyState                                                     DocumentReadyState
-	Body                                                           *HTMLElement
-	Head                                                           *HTMLHead
-	Images, Embeds, Plugins, Links, Forms, Scripts                 HTMLCollection
-	CurrentScript                                                  HTMLOrSVGScript
-	DefaultView                                                    *WindowProxy
-	Onreadystatechange                                             EventHandler
+	Location                                               *HTMLLocation
+	History                                                *HTMLHistory
+	Domain, Referrer, LastModified, Title, Dir, DesignMode string
+	ReadyState                                             DocumentReadyState
+	Body                                                   *HTMLElement
+	Head                                                   *HTMLHead
+	Images, Embeds, Plugins, Links, Forms, Scripts         HTMLCollection
+	CurrentScript                                          *Node
+	DefaultView                                            *WindowProxy
+	Onreadystatechange                                     EventHandler
+
+	// BrowsingContext is the browsing context the document is in, if any.
+	BrowsingContext BrowsingContext
+	// CookieStore backs document.cookie. Documents without one are cookie-averse.
+	CookieStore CookieStore
+	// EventLoop runs the tasks queued for the document. Documents without one
+	// run them right away.
+	EventLoop EventLoop
+	// ScriptHost runs the document's scripts and compiles its event handler
+	// content attributes.
+	ScriptHost ScriptHost
+	// Realm is for the ScriptHost to keep the document's global object and
+	// the wrappers of its platform objects, which go away with the document.
+	Realm interface{}
+
+	window *HTMLWindow
+
+	// Parser is the HTML parser that was last associated with the document.
+	Parser DocumentParser
+	// https://html.spec.whatwg.org/multipage/dynamic-markup-insertion.html
+	ActiveParserWasAborted                                                              bool
+	IgnoreDestructiveWritesCounter, ThrowOnDynamicMarkupInsertionCounter, UnloadCounter int
 
 	*Node
 }
 
+// DocumentParser is the HTML parser associated with a document. The spec package
+// can't depend on the parser so the parser hands itself to the document through
+// this interface.
+type DocumentParser interface {
+	// Active reports if the parser hasn't yet been stopped or aborted.
+	Active() bool
+	// ScriptCreated reports if the parser was created by document.open().
+	ScriptCreated() bool
+	ScriptNestingLevel() int
+	HasPendingParsingBlockingScript() bool
+	InsertionPointDefined() bool
+	// Write inserts the input just before the insertion point and runs the
+	// tokenizer until it reaches the insertion point. It returns the error
+	// that stopped the parser, if any.
+	Write(input string) error
+	// Close inserts an explicit EOF at the end of the input and runs the
+	// tokenizer until it reaches it. It returns the error that stopped the
+	// parser, if any.
+	Close() error
+	// Abort discards any pending input and stops the parser.
+	Abort()
+}
+
+// CookieStore is the cookie store shared by a document and the requests made
+// for it. The spec package can't depend on the store's implementation so it's
+// reached through this interface.
+type CookieStore interface {
+	// DocumentCookie returns the cookie-string for a document at the URL
+	// without the HttpOnly cookies.
+	DocumentCookie(u *URL) string
+	// SetDocumentCookie stores a cookie set by a document at the URL from a
+	// non-HTTP API.
+	SetDocumentCookie(u *URL, cookie string)
+}
+
+// NewScriptCreatedParser creates a script-created parser for the document,
+// carrying over the settings of the previous parser if there was one. It is
+// registered by the parser package.
+var NewScriptCreatedParser func(doc *HTMLDocument, previous DocumentParser) DocumentParser
+
+// ActiveParser returns the document's active parser or nil if it doesn't have one.
+// https://html.spec.whatwg.org/multipage/dom.html#active-parser
+func (d *HTMLDocument) ActiveParser() DocumentParser {
+	if d.Parser == nil || !d.Parser.Active() {
+		return nil
+	}
+	return d.Parser
+}
+
+// https://html.spec.whatwg.org/multipage/dom.html#cookie-averse-document-object
+func (d *HTMLDocument) cookieAverse() bool {
+	scheme := d.URLRecord().Scheme()
+	return d.CookieStore == nil || (scheme != "http" && scheme != "https")
+}
+
+// Cookie is https://html.spec.whatwg.org/multipage/dom.html#dom-document-cookie
+func (d *HTMLDocument) Cookie() (string, error) {
+	if d.cookieAverse() {
+		return "", nil
+	}
+	if d.URLRecord().Origin() == "null" {
+		return "", ErrSecurity
+	}
+	return d.CookieStore.DocumentCookie(d.URLRecord()), nil
+}
+
+// SetCookie is https://html.spec.whatwg.org/multipage/dom.html#dom-document-cookie
+func (d *HTMLDocument) SetCookie(cookie string) error {
+	if d.cookieAverse() {
+		return nil
+	}
+	if d.URLRecord().Origin() == "null" {
+		return ErrSecurity
+	}
+	d.CookieStore.SetDocumentCookie(d.URLRecord(), cookie)
+	return nil
+}
+
+// removeAllChildren replaces all with null within the document.
+func (d *HTMLDocument) removeAllChildren() {
+	for _, child := range d.ChildNodes {
+		child.ParentNode = nil
+		child.PreviousSibling = nil
+		child.NextSibling = nil
+	}
+	d.ChildNodes = nil
+	d.FirstChild = nil
+	d.LastChild = nil
+	d.Doctype = nil
+	d.DocumentElement = nil
+}
+
 func (d *HTMLDocument) GetElementsByName(elementName string) NodeList { return nil }
-func (d *HTMLDocument) Open(u1, u2 string) *HTMLDocument              { return nil }
+
+// Open is https://html.spec.whatwg.org/multipage/dynamic-markup-insertion.html#document-open-steps
+func (d *HTMLDocument) Open(u1, u2 string) (*HTMLDocument, error) {
+	if d.Document.Type == "xml" {
+		return nil, ErrInvalidState
+	}
+	if d.ThrowOnDynamicMarkupInsertionCounter > 0 {
+		return nil, ErrInvalidState
+	}
+
+	active := d.ActiveParser()
+	if active != nil && active.ScriptNestingLevel() > 0 {
+		return d, nil
+	}
+	if d.UnloadCounter > 0 {
+		return d, nil
+	}
+	if d.ActiveParserWasAborted {
+		return d, nil
+	}
+	if active != nil {
+		// the old parser won't be associated with the document anymore
+		active.Abort()
+	}
+
+	d.removeAllChildren()
+	d.Mode = "no-quirks"
+	d.CompatMode = "CSS1Compat"
+	if NewScriptCreatedParser == nil {
+		return nil, ErrInvalidState
+	}
+	d.Parser = NewScriptCreatedParser(d, d.Parser)
+	d.ReadyState = Loading
+	return d, nil
+}
+
 func (d *HTMLDocument) OpenW(url string, name, features string) *WindowProxy {
 	return nil
 }
-func (d *HTMLDocument) Close()                 {}
-func (d *HTMLDocument) Write(text ...string)   {}
-func (d *HTMLDocument) Writeln(text ...string) {}
-func (d *HTMLDocument) HasFocus() bool         { return false }
+
+// Close is https://html.spec.whatwg.org/multipage/dynamic-markup-insertion.html#dom-document-close
+func (d *HTMLDocument) Close() error {
+	if d.Document.Type == "xml" {
+		return ErrInvalidState
+	}
+	if d.ThrowOnDynamicMarkupInsertionCounter > 0 {
+		return ErrInvalidState
+	}
+
+	active := d.ActiveParser()
+	if active == nil || !active.ScriptCreated() {
+		return nil
+	}
+	return active.Close()
+}
+
+// Write is https://html.spec.whatwg.org/multipage/dynamic-markup-insertion.html#document-write-steps
+func (d *HTMLDocument) Write(text ...string) error {
+	input := ""
+	for _, t := range text {
+		input += t
+	}
+
+	if d.Document.Type == "xml" {
+		return ErrInvalidState
+	}
+	if d.ThrowOnDynamicMarkupInsertionCounter > 0 {
+		return ErrInvalidState
+	}
+	if d.ActiveParserWasAborted {
+		return nil
+	}
+
+	active := d.ActiveParser()
+	if active == nil || !active.InsertionPointDefined() {
+		if d.UnloadCounter > 0 || d.IgnoreDestructiveWritesCounter > 0 {
+			return nil
+		}
+		if _, err := d.Open("", ""); err != nil {
+			return err
+		}
+		active = d.ActiveParser()
+		if active == nil {
+			return nil
+		}
+	}
+
+	return active.Write(input)
+}
+
+// Writeln is https://html.spec.whatwg.org/multipage/dynamic-markup-insertion.html#dom-document-writeln
+func (d *HTMLDocument) Writeln(text ...string) error {
+	return d.Write(append(text, "\n")...)
+}
+
+func (d *HTMLDocument) HasFocus() bool { return false }
 func (d *HTMLDocument) ExecCommand(commandID string, showUI bool, value string) bool {
 	return false
 }
@@ -3,6 +3,8 @@ package spec
 func NewHTMLElement(name string) *HTMLElement {
 	elem := &HTMLElement{}
 	switch name {
+	case "base":
+		elem.HTMLBase = &HTMLBase{}
 	case "script":
 		elem.HTMLScript = &HTMLScript{}
 	case "document":
@@ -11,6 +13,8 @@ func NewHTMLElement(name string) *HTMLElement {
 		elem.HTMLForm = &HTMLForm{}
 	case "head":
 		elem.HTMLHead = &HTMLHead{}
+	case "iframe":
+		elem.HTMLIFrame = &HTMLIFrame{}
 	case "location":
 		elem.HTMLLocation = &HTMLLocation{}
 	case "table":
@@ -31,13 +35,24 @@ func NewHTMLElement(name string) *HTMLElement {
 }
 
 type HTMLElement struct {
-	Title, Lang, Dir, AccessKey, AccessKeyLabel, Autocapitalize, InnerText string
-	Translate, Hidden, Draggable, Spellcheck                               bool
+	// FormAssociatedCustomElement is set for custom elements whose definition
+	// is form-associated.
+	FormAssociatedCustomElement FormAssociatedCustomElement
+	// the form owner of form-associated elements and whether it was set by the
+	// parser.
+	formOwner          *Node
+	parserInsertedForm bool
+	internals          *ElementInternals
 
+	customValidity string
+	control        formControlState
+
+	*HTMLBase
 	*HTMLScript
 	*HTMLDocument
 	*HTMLForm
 	*HTMLHead
+	*HTMLIFrame
 	*HTMLLocation
 	*HTMLTable
 	*HTMLTBody
@@ -48,5 +63,4 @@ type HTMLElement struct {
 	*HTMLWindow
 }
 
-func (e *HTMLElement) attachInternals() *ElementInternals { return nil }
-func (e *HTMLElement) click()                             {}
+func (e *HTMLElement) click() {}
@@ -1,16 +1,46 @@
 package spec
 
+// ElementInternals is https://html.spec.whatwg.org/multipage/custom-elements.html#elementinternals
 type ElementInternals struct {
-	form              *HTMLForm
-	willValidate      bool
-	validity          ValidityState
+	// target is the element the internals were attached to.
+	target            *Node
+	validityFlags     ValidityStateFlags
 	validationMessage string
 	labels            NodeList
 }
 
+// Form is https://html.spec.whatwg.org/multipage/custom-elements.html#dom-elementinternals-form
+func (ei *ElementInternals) Form() *Node {
+	return ei.target.formOwner()
+}
+
 func (ei *ElementInternals) setFormValue(value, state string) {
 }
-func (ei *ElementInternals) setValidity(flags ValidityStateFlags, message string, anchor *HTMLElement) {
+
+// SetValidity is https://html.spec.whatwg.org/multipage/custom-elements.html#dom-elementinternals-setvalidity
+func (ei *ElementInternals) SetValidity(flags ValidityStateFlags, message string) error {
+	if flags.any() && message == "" {
+		return ErrValidityMessageRequired
+	}
+	ei.validityFlags = flags
+	ei.validationMessage = message
+	if !flags.any() {
+		ei.validationMessage = ""
+	}
+	return nil
 }
-func (ei *ElementInternals) checkValidity() bool  { return false }
-func (ei *ElementInternals) reportValidity() bool { return false }
+
+// WillValidate is https://html.spec.whatwg.org/multipage/custom-elements.html#dom-elementinternals-willvalidate
+func (ei *ElementInternals) WillValidate() bool { return ei.target.WillValidate() }
+
+// Validity is https://html.spec.whatwg.org/multipage/custom-elements.html#dom-elementinternals-validity
+func (ei *ElementInternals) Validity() ValidityState { return ei.target.Validity() }
+
+// ValidationMessage is https://html.spec.whatwg.org/multipage/custom-elements.html#dom-elementinternals-validationmessage
+func (ei *ElementInternals) ValidationMessage() string { return ei.target.ValidationMessage() }
+
+// CheckValidity is https://html.spec.whatwg.org/multipage/custom-elements.html#dom-elementinternals-checkvalidity
+func (ei *ElementInternals) CheckValidity() bool { return ei.target.CheckValidity() }
+
+// ReportValidity is https://html.spec.whatwg.org/multipage/custom-elements.html#dom-elementinternals-reportvalidity
+func (ei *ElementInternals) ReportValidity() bool { return ei.target.ReportValidity() }
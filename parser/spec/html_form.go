@@ -1,3 +1,109 @@
 package spec
 
-type HTMLForm struct{}
+import (
+	"sort"
+	"strings"
+)
+
+// HTMLForm is https://html.spec.whatwg.org/multipage/forms.html#htmlformelement
+type HTMLForm struct {
+	// node is the form element.
+	node *Node
+	// associated is the set of elements whose form owner is the form.
+	associated map[*Node]struct{}
+	// elements caches the controls of the form in tree order. It's nil once
+	// one of them moves or changes so it has to be worked out again.
+	elements []*Node
+}
+
+// associate adds an element whose form owner became the form.
+func (f *HTMLForm) associate(n *Node) {
+	if f.associated == nil {
+		f.associated = map[*Node]struct{}{}
+	}
+	f.associated[n] = struct{}{}
+	f.elements = nil
+}
+
+// dissociate removes an element whose form owner isn't the form anymore.
+func (f *HTMLForm) dissociate(n *Node) {
+	delete(f.associated, n)
+	f.elements = nil
+}
+
+// associatedIn returns the elements associated with the form whose root is
+// root in tree order.
+func (f *HTMLForm) associatedIn(root *Node) []*Node {
+	nodes := []*Node{}
+	for n := range f.associated {
+		if n.getRoot() == root {
+			nodes = append(nodes, n)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].precedes(nodes[j]) })
+	return nodes
+}
+
+// Elements is https://html.spec.whatwg.org/multipage/forms.html#dom-form-elements
+func (f *HTMLForm) Elements() *HTMLFormControlsCollection {
+	return &HTMLFormControlsCollection{form: f.node}
+}
+
+// Length is https://html.spec.whatwg.org/multipage/forms.html#dom-form-length
+func (f *HTMLForm) Length() int {
+	return f.Elements().Length()
+}
+
+// HTMLFormControlsCollection is a live collection of the listed elements
+// whose form owner is a form, in tree order. Image buttons are left out for
+// historical reasons.
+// https://html.spec.whatwg.org/multipage/common-dom-interfaces.html#htmlformcontrolscollection
+type HTMLFormControlsCollection struct {
+	form *Node
+}
+
+// nodes returns the elements currently in the collection.
+func (c *HTMLFormControlsCollection) nodes() []*Node {
+	f := c.form.HTMLForm
+	if f.elements != nil {
+		return f.elements
+	}
+	f.elements = []*Node{}
+	for _, n := range f.associatedIn(c.form.getRoot()) {
+		if n.IsListed() && !isImageButton(n) {
+			f.elements = append(f.elements, n)
+		}
+	}
+	return f.elements
+}
+
+func (c *HTMLFormControlsCollection) Length() int {
+	return len(c.nodes())
+}
+
+// Item returns the element at index or nil if there isn't one.
+func (c *HTMLFormControlsCollection) Item(index int) *Node {
+	nodes := c.nodes()
+	if index < 0 || index >= len(nodes) {
+		return nil
+	}
+	return nodes[index]
+}
+
+// NamedItem returns the first element whose id or name is name.
+// https://html.spec.whatwg.org/multipage/common-dom-interfaces.html#dom-htmlformcontrolscollection-nameditem
+func (c *HTMLFormControlsCollection) NamedItem(name string) *Node {
+	if name == "" {
+		return nil
+	}
+	for _, n := range c.nodes() {
+		if n.attribute("id") == name || n.attribute("name") == name {
+			return n
+		}
+	}
+	return nil
+}
+
+func isImageButton(n *Node) bool {
+	return n.NodeName == "input" && strings.ToLower(n.attribute("type")) == "image"
+}
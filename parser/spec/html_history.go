@@ -0,0 +1,97 @@
+package spec
+
+// HTMLHistory is https://html.spec.whatwg.org/multipage/nav-history-apis.html#the-history-interface
+// Its methods fail with ErrSecurity when the document isn't the active
+// document of a browsing context.
+type HTMLHistory struct {
+	document *HTMLDocument
+}
+
+func (h *HTMLHistory) browsingContext() (BrowsingContext, error) {
+	if h.document == nil || !h.document.fullyActive() {
+		return nil, ErrSecurity
+	}
+	return h.document.BrowsingContext, nil
+}
+
+// Length is https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-history-length
+func (h *HTMLHistory) Length() (int, error) {
+	bc, err := h.browsingContext()
+	if err != nil {
+		return 0, err
+	}
+	return bc.SessionHistoryLength(), nil
+}
+
+// State is https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-history-state
+func (h *HTMLHistory) State() (interface{}, error) {
+	bc, err := h.browsingContext()
+	if err != nil {
+		return nil, err
+	}
+	return bc.State(), nil
+}
+
+// Go is https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-history-go
+func (h *HTMLHistory) Go(delta int) error {
+	bc, err := h.browsingContext()
+	if err != nil {
+		return err
+	}
+	if delta == 0 {
+		return bc.Reload()
+	}
+	return bc.Traverse(delta)
+}
+
+// Back is https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-history-back
+func (h *HTMLHistory) Back() error { return h.Go(-1) }
+
+// Forward is https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-history-forward
+func (h *HTMLHistory) Forward() error { return h.Go(1) }
+
+// PushState is https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-history-pushstate
+// The unused argument is the title browsers ignore.
+func (h *HTMLHistory) PushState(data interface{}, unused string, url ...string) error {
+	return h.pushOrReplaceState(data, url, false)
+}
+
+// ReplaceState is https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-history-replacestate
+func (h *HTMLHistory) ReplaceState(data interface{}, unused string, url ...string) error {
+	return h.pushOrReplaceState(data, url, true)
+}
+
+// pushOrReplaceState is https://html.spec.whatwg.org/multipage/nav-history-apis.html#shared-history-push/replace-state-steps
+// The state is stored as it is instead of being serialized.
+func (h *HTMLHistory) pushOrReplaceState(data interface{}, url []string, replace bool) error {
+	bc, err := h.browsingContext()
+	if err != nil {
+		return err
+	}
+	newURL := h.document.URLRecord()
+	if len(url) > 0 {
+		if newURL, err = ParseURL(url[0], h.document.BaseURL()); err != nil {
+			return ErrSecurity
+		}
+		if !h.document.URLRecord().canBeRewrittenTo(newURL) {
+			return ErrSecurity
+		}
+	}
+	bc.UpdateHistory(newURL, data, replace)
+	return nil
+}
+
+// canBeRewrittenTo is https://html.spec.whatwg.org/multipage/nav-history-apis.html#can-have-its-url-rewritten
+func (u *URL) canBeRewrittenTo(target *URL) bool {
+	if u.scheme != target.scheme || u.username != target.username || u.password != target.password ||
+		u.Host() != target.Host() || (u.host == nil) != (target.host == nil) {
+		return false
+	}
+	if target.scheme == "http" || target.scheme == "https" {
+		return true
+	}
+	if target.scheme == "file" {
+		return u.pathString() == target.pathString()
+	}
+	return u.pathString() == target.pathString() && u.Search() == target.Search()
+}
@@ -0,0 +1,129 @@
+// Code generated by specgen from idl/html.webidl. DO NOT EDIT.
+
+package spec
+
+// Title reflects the title content attribute.
+func (n *Node) Title() string { return n.GetAttribute("title") }
+
+// SetTitle sets the title content attribute.
+func (n *Node) SetTitle(v string) { n.SetAttribute("title", v) }
+
+// Lang reflects the lang content attribute.
+func (n *Node) Lang() string { return n.GetAttribute("lang") }
+
+// SetLang sets the lang content attribute.
+func (n *Node) SetLang(v string) { n.SetAttribute("lang", v) }
+
+// Hidden reflects the hidden content attribute.
+func (n *Node) Hidden() bool { return n.HasAttribute("hidden") }
+
+// SetHidden sets the hidden content attribute.
+func (n *Node) SetHidden(v bool) { n.setBooleanAttribute("hidden", v) }
+
+// Inert reflects the inert content attribute.
+func (n *Node) Inert() bool { return n.HasAttribute("inert") }
+
+// SetInert sets the inert content attribute.
+func (n *Node) SetInert(v bool) { n.setBooleanAttribute("inert", v) }
+
+// AccessKey reflects the accesskey content attribute.
+func (n *Node) AccessKey() string { return n.GetAttribute("accesskey") }
+
+// SetAccessKey sets the accesskey content attribute.
+func (n *Node) SetAccessKey(v string) { n.SetAttribute("accesskey", v) }
+
+// Target reflects the target content attribute.
+func (n *Node) Target() string { return n.GetAttribute("target") }
+
+// SetTarget sets the target content attribute.
+func (n *Node) SetTarget(v string) { n.SetAttribute("target", v) }
+
+// Download reflects the download content attribute.
+func (n *Node) Download() string { return n.GetAttribute("download") }
+
+// SetDownload sets the download content attribute.
+func (n *Node) SetDownload(v string) { n.SetAttribute("download", v) }
+
+// Ping reflects the ping content attribute.
+func (n *Node) Ping() string { return n.GetAttribute("ping") }
+
+// SetPing sets the ping content attribute.
+func (n *Node) SetPing(v string) { n.SetAttribute("ping", v) }
+
+// Rel reflects the rel content attribute.
+func (n *Node) Rel() string { return n.GetAttribute("rel") }
+
+// SetRel sets the rel content attribute.
+func (n *Node) SetRel(v string) { n.SetAttribute("rel", v) }
+
+// Hreflang reflects the hreflang content attribute.
+func (n *Node) Hreflang() string { return n.GetAttribute("hreflang") }
+
+// SetHreflang sets the hreflang content attribute.
+func (n *Node) SetHreflang(v string) { n.SetAttribute("hreflang", v) }
+
+// Type reflects the type content attribute.
+func (n *Node) Type() string { return n.GetAttribute("type") }
+
+// SetType sets the type content attribute.
+func (n *Node) SetType(v string) { n.SetAttribute("type", v) }
+
+// Reversed reflects the reversed content attribute.
+func (n *Node) Reversed() bool { return n.HasAttribute("reversed") }
+
+// SetReversed sets the reversed content attribute.
+func (n *Node) SetReversed(v bool) { n.setBooleanAttribute("reversed", v) }
+
+// Start reflects the start content attribute.
+func (n *Node) Start() int { return n.reflectLong("start") }
+
+// SetStart sets the start content attribute.
+func (n *Node) SetStart(v int) { n.setLongAttribute("start", v) }
+
+// Name reflects the name content attribute.
+func (n *Node) Name() string { return n.GetAttribute("name") }
+
+// SetName sets the name content attribute.
+func (n *Node) SetName(v string) { n.SetAttribute("name", v) }
+
+// Allow reflects the allow content attribute.
+func (n *Node) Allow() string { return n.GetAttribute("allow") }
+
+// SetAllow sets the allow content attribute.
+func (n *Node) SetAllow(v string) { n.SetAttribute("allow", v) }
+
+// AllowFullscreen reflects the allowfullscreen content attribute.
+func (n *Node) AllowFullscreen() bool { return n.HasAttribute("allowfullscreen") }
+
+// SetAllowFullscreen sets the allowfullscreen content attribute.
+func (n *Node) SetAllowFullscreen(v bool) { n.setBooleanAttribute("allowfullscreen", v) }
+
+// Width reflects the width content attribute.
+func (n *Node) Width() string { return n.GetAttribute("width") }
+
+// SetWidth sets the width content attribute.
+func (n *Node) SetWidth(v string) { n.SetAttribute("width", v) }
+
+// Height reflects the height content attribute.
+func (n *Node) Height() string { return n.GetAttribute("height") }
+
+// SetHeight sets the height content attribute.
+func (n *Node) SetHeight(v string) { n.SetAttribute("height", v) }
+
+// HtmlFor reflects the for content attribute.
+func (n *Node) HtmlFor() string { return n.GetAttribute("for") }
+
+// SetHtmlFor sets the for content attribute.
+func (n *Node) SetHtmlFor(v string) { n.SetAttribute("for", v) }
+
+// AcceptCharset reflects the accept-charset content attribute.
+func (n *Node) AcceptCharset() string { return n.GetAttribute("accept-charset") }
+
+// SetAcceptCharset sets the accept-charset content attribute.
+func (n *Node) SetAcceptCharset(v string) { n.SetAttribute("accept-charset", v) }
+
+// NoValidate reflects the novalidate content attribute.
+func (n *Node) NoValidate() bool { return n.HasAttribute("novalidate") }
+
+// SetNoValidate sets the novalidate content attribute.
+func (n *Node) SetNoValidate(v bool) { n.setBooleanAttribute("novalidate", v) }
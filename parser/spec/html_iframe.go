@@ -0,0 +1,140 @@
+package spec
+
+// HTMLIFrame is https://html.spec.whatwg.org/multipage/iframe-embed-object.html#htmliframeelement
+type HTMLIFrame struct {
+	node *Node
+	// content is the iframe's content navigable, which exists while the
+	// iframe is in a document with a browsing context.
+	content BrowsingContext
+}
+
+// ContentBrowsingContext is the browsing context nested in the iframe, if any.
+// https://html.spec.whatwg.org/multipage/document-sequences.html#content-navigable
+func (f *HTMLIFrame) ContentBrowsingContext() BrowsingContext { return f.content }
+
+// ContentDocument is https://html.spec.whatwg.org/multipage/iframe-embed-object.html#dom-iframe-contentdocument
+// It's nil unless the document has the same origin as the iframe's document.
+func (f *HTMLIFrame) ContentDocument() *HTMLDocument {
+	container := f.node.OwnerHTMLDocument()
+	if f.content == nil || container == nil {
+		return nil
+	}
+	doc := f.content.ActiveDocument()
+	if !sameOrigin(container, doc) {
+		return nil
+	}
+	return doc
+}
+
+// ContentWindow is https://html.spec.whatwg.org/multipage/iframe-embed-object.html#dom-iframe-contentwindow
+func (f *HTMLIFrame) ContentWindow() *WindowProxy {
+	if f.content == nil {
+		return nil
+	}
+	return NewWindowProxy(f.content, f.node.OwnerHTMLDocument())
+}
+
+// iframeInserted is https://html.spec.whatwg.org/multipage/iframe-embed-object.html#the-iframe-element:html-element-post-connection-steps
+// The iframes inserted with the node get a nested browsing context when
+// they're connected to a document with a browsing context.
+func (n *Node) iframeInserted() {
+	doc := n.OwnerHTMLDocument()
+	if doc == nil || doc.BrowsingContext == nil || n.getRoot() != doc.Node {
+		return
+	}
+	n.walk(func(d *Node) {
+		if !isHTMLElement(d, "iframe") || d.HTMLIFrame.content != nil {
+			return
+		}
+		d.HTMLIFrame.content = doc.BrowsingContext.CreateChild(d)
+		d.HTMLIFrame.processAttributes(true)
+	})
+}
+
+// iframeRemoved is https://html.spec.whatwg.org/multipage/iframe-embed-object.html#the-iframe-element:html-element-removing-steps
+// The nested browsing contexts of the removed iframes are discarded.
+func (n *Node) iframeRemoved() {
+	n.walk(func(d *Node) {
+		if isHTMLElement(d, "iframe") {
+			d.HTMLIFrame.content = nil
+		}
+	})
+}
+
+// maxNestingDepth is the number of browsing contexts an iframe's nested
+// browsing context can have above it before it stops navigating, which
+// bounds documents nesting themselves through URLs that keep changing.
+const maxNestingDepth = 10
+
+// processAttributes is https://html.spec.whatwg.org/multipage/iframe-embed-object.html#process-the-iframe-attributes
+// The nested browsing context navigates to about:srcdoc for iframes with a
+// srcdoc attribute and reads the document from the iframe.
+func (f *HTMLIFrame) processAttributes(initialInsertion bool) {
+	if f.content == nil {
+		return
+	}
+	if f.node.hasAttribute("srcdoc") {
+		u, _ := ParseURL("about:srcdoc", nil)
+		f.navigate(u)
+		return
+	}
+
+	// https://html.spec.whatwg.org/multipage/iframe-embed-object.html#shared-attribute-processing-steps-for-iframe-and-frame-elements
+	u, _ := ParseURL("about:blank", nil)
+	if src := f.node.attribute("src"); src != "" {
+		if parsed, err := f.node.ResolveURL(src); err == nil {
+			u = parsed
+		}
+	}
+	if u.matchesAbout("blank") && initialInsertion {
+		return
+	}
+	// iframes can't nest the documents they're in. The container documents
+	// are checked rather than the active documents of the ancestor browsing
+	// contexts since they aren't active yet while they're being parsed.
+	for doc := f.node.OwnerHTMLDocument(); doc != nil; {
+		if equalExcludingFragments(doc.URLRecord(), u) {
+			return
+		}
+		container := doc.BrowsingContext.Container()
+		if container == nil {
+			break
+		}
+		doc = container.OwnerHTMLDocument()
+	}
+	f.navigate(u)
+}
+
+// navigate navigates the nested browsing context from a task so the document
+// isn't fetched and parsed while its container's document is being parsed.
+// Browsing contexts nested deeper than maxNestingDepth aren't navigated.
+func (f *HTMLIFrame) navigate(u *URL) {
+	content := f.content
+	depth := 0
+	for parent := content.Parent(); parent != nil; parent = parent.Parent() {
+		depth++
+	}
+	if depth > maxNestingDepth {
+		return
+	}
+	f.node.OwnerHTMLDocument().QueueTask(NavigationTaskSource, func() {
+		if f.content == content {
+			content.Navigate(u, false)
+		}
+	})
+}
+
+// equalExcludingFragments is https://url.spec.whatwg.org/#concept-url-equals
+// with exclude fragments set.
+func equalExcludingFragments(a, b *URL) bool {
+	a, b = a.Clone(), b.Clone()
+	a.fragment, b.fragment = nil, nil
+	return a.Href() == b.Href()
+}
+
+// Srcdoc is https://html.spec.whatwg.org/multipage/iframe-embed-object.html#dom-iframe-srcdoc
+func (n *Node) Srcdoc() string { return n.attribute("srcdoc") }
+
+// SetSrcdoc sets the srcdoc attribute, which navigates the iframe's nested
+// browsing context to the new document.
+func (n *Node) SetSrcdoc(srcdoc string) { n.SetAttribute("srcdoc", srcdoc) }
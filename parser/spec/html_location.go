@@ -1,11 +1,179 @@
 package spec
 
 type DOMStringList []string
+
+// HTMLLocation is https://html.spec.whatwg.org/multipage/nav-history-apis.html#the-location-interface
+// It reflects the URL of its document.
 type HTMLLocation struct {
-	href, origin, protocol, host, hostname, port, pathname, search, hash string
-	ancestorOrigins                                                      DOMStringList
+	document        *HTMLDocument
+	ancestorOrigins DOMStringList
+}
+
+// Document is the document the location belongs to.
+func (l *HTMLLocation) Document() *HTMLDocument { return l.document }
+
+// url is https://html.spec.whatwg.org/multipage/nav-history-apis.html#concept-location-url
+func (l *HTMLLocation) url() *URL {
+	if l.document == nil {
+		u, _ := ParseURL("about:blank", nil)
+		return u
+	}
+	return l.document.URLRecord()
+}
+
+// copyURL returns a copy of the location's URL for the setters to modify.
+func (l *HTMLLocation) copyURL() *URL { return l.url().Clone() }
+
+// navigate is https://html.spec.whatwg.org/multipage/nav-history-apis.html#location-object-navigate
+// Without a browsing context to navigate, the document's URL is changed.
+// Navigations that fail leave the document as it is.
+func (l *HTMLLocation) navigate(u *URL, replace bool) {
+	if l.document == nil {
+		return
+	}
+	if bc := l.document.BrowsingContext; bc != nil {
+		if l.document.fullyActive() {
+			bc.Navigate(u, replace)
+		}
+		return
+	}
+	l.document.Document.SetURL(u)
+}
+
+// Href is https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-location-href
+func (l *HTMLLocation) Href() string { return l.url().Href() }
+
+// SetHref is https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-location-href
+func (l *HTMLLocation) SetHref(href string) error {
+	return l.Assign(href)
+}
+
+// Origin is https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-location-origin
+func (l *HTMLLocation) Origin() string { return l.url().Origin() }
+
+// Protocol is https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-location-protocol
+func (l *HTMLLocation) Protocol() string { return l.url().Protocol() }
+
+// SetProtocol is https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-location-protocol
+func (l *HTMLLocation) SetProtocol(protocol string) error {
+	u := l.copyURL()
+	if _, err := basicURLParse(protocol+":", nil, u, schemeStartState); err != nil {
+		return ErrSyntax
+	}
+	if u.scheme != "http" && u.scheme != "https" {
+		return nil
+	}
+	l.navigate(u, false)
+	return nil
+}
+
+// Host is https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-location-host
+func (l *HTMLLocation) Host() string { return l.url().Host() }
+
+// SetHost is https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-location-host
+func (l *HTMLLocation) SetHost(host string) {
+	u := l.copyURL()
+	if u.opaque {
+		return
+	}
+	basicURLParse(host, nil, u, hostState)
+	l.navigate(u, false)
+}
+
+// Hostname is https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-location-hostname
+func (l *HTMLLocation) Hostname() string { return l.url().Hostname() }
+
+// SetHostname is https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-location-hostname
+func (l *HTMLLocation) SetHostname(hostname string) {
+	u := l.copyURL()
+	if u.opaque {
+		return
+	}
+	basicURLParse(hostname, nil, u, hostnameState)
+	l.navigate(u, false)
+}
+
+// Port is https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-location-port
+func (l *HTMLLocation) Port() string { return l.url().Port() }
+
+// SetPort is https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-location-port
+func (l *HTMLLocation) SetPort(port string) {
+	u := l.copyURL()
+	if u.cannotHaveUsernamePasswordPort() {
+		return
+	}
+	if port == "" {
+		u.port = -1
+	} else {
+		basicURLParse(port, nil, u, portState)
+	}
+	l.navigate(u, false)
+}
+
+// Pathname is https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-location-pathname
+func (l *HTMLLocation) Pathname() string { return l.url().Pathname() }
+
+// SetPathname is https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-location-pathname
+func (l *HTMLLocation) SetPathname(pathname string) {
+	u := l.copyURL()
+	if u.opaque {
+		return
+	}
+	u.path = nil
+	basicURLParse(pathname, nil, u, pathStartState)
+	l.navigate(u, false)
+}
+
+// Search is https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-location-search
+func (l *HTMLLocation) Search() string { return l.url().Search() }
+
+// SetSearch is https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-location-search
+func (l *HTMLLocation) SetSearch(search string) {
+	u := l.copyURL()
+	u.SetSearch(search)
+	l.navigate(u, false)
 }
 
-func (l *HTMLLocation) assign(url string)  {}
-func (l *HTMLLocation) replace(url string) {}
-func (l *HTMLLocation) reload()            {}
+// Hash is https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-location-hash
+func (l *HTMLLocation) Hash() string { return l.url().Hash() }
+
+// SetHash is https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-location-hash
+func (l *HTMLLocation) SetHash(hash string) {
+	u := l.copyURL()
+	u.SetHash(hash)
+	if current := l.url().fragment; (u.fragment == nil) == (current == nil) && (current == nil || *u.fragment == *current) {
+		return
+	}
+	l.navigate(u, false)
+}
+
+// AncestorOrigins is https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-location-ancestororigins
+func (l *HTMLLocation) AncestorOrigins() DOMStringList { return l.ancestorOrigins }
+
+// Assign is https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-location-assign
+func (l *HTMLLocation) Assign(url string) error {
+	u, err := ParseURL(url, l.url())
+	if err != nil {
+		return ErrSyntax
+	}
+	l.navigate(u, false)
+	return nil
+}
+
+// Replace is https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-location-replace
+func (l *HTMLLocation) Replace(url string) error {
+	u, err := ParseURL(url, l.url())
+	if err != nil {
+		return ErrSyntax
+	}
+	l.navigate(u, true)
+	return nil
+}
+
+// Reload is https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-location-reload
+// It does nothing without a browsing context to reload.
+func (l *HTMLLocation) Reload() {
+	if l.document != nil && l.document.fullyActive() {
+		l.document.BrowsingContext.Reload()
+	}
+}
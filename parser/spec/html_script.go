@@ -1,8 +1,37 @@
 package spec
 
+// ScriptType is the type of the script block.
+// https://html.spec.whatwg.org/multipage/scripting.html#concept-script-type
+type ScriptType uint
+
+const (
+	ClassicScript ScriptType = iota
+	ModuleScript
+	ImportMapScript
+)
+
+// Script is https://html.spec.whatwg.org/multipage/webappapis.html#concept-script
+type Script struct {
+	Type    ScriptType
+	Source  string
+	BaseURL string
+	Element *Node
+	// MutedErrors is https://html.spec.whatwg.org/multipage/webappapis.html#muted-errors
+	// It's set for classic scripts fetched from other origins without CORS,
+	// whose errors mustn't reveal anything about them.
+	MutedErrors bool
+}
+
 type HTMLScript struct {
 	Src                                                             string
 	ScriptElementType, CrossOrigin, Text, Integrity, ReferrerPolicy string
 	NoModule, Async, DeferScript, NonBlocking, AlreadyStated        bool
 	ParserDocument                                                  *HTMLDocument
+
+	// internal slots used by the script processing model
+	// https://html.spec.whatwg.org/multipage/scripting.html#script-processing-model
+	ScriptType                                       ScriptType
+	Result                                           *Script
+	PreparationTimeDocument                          *Node
+	FromExternalFile, Ready, ReadyToBeParserExecuted bool
 }
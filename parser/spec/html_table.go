@@ -1,4 +1,212 @@
 package spec
 
+// HTMLTable is https://html.spec.whatwg.org/multipage/tables.html#htmltableelement
 type HTMLTable struct {
+	// node is the table element.
+	node *Node
+}
+
+// Caption is https://html.spec.whatwg.org/multipage/tables.html#dom-table-caption
+func (t *HTMLTable) Caption() *Node {
+	return firstChildElement(t.node, "caption")
+}
+
+// SetCaption removes the first caption and inserts the new one as the first
+// child.
+func (t *HTMLTable) SetCaption(caption *Node) error {
+	if caption != nil && !isHTMLElement(caption, "caption") {
+		return ErrHierarchyRequest
+	}
+	t.DeleteCaption()
+	if caption != nil {
+		t.node.InsertBefore(caption, t.node.FirstChild)
+	}
+	return nil
+}
+
+// CreateCaption is https://html.spec.whatwg.org/multipage/tables.html#dom-table-createcaption
+func (t *HTMLTable) CreateCaption() *Node {
+	if caption := t.Caption(); caption != nil {
+		return caption
+	}
+	caption := NewDOMElement(t.node.OwnerDocument, "caption", Htmlns)
+	t.node.InsertBefore(caption, t.node.FirstChild)
+	return caption
+}
+
+// DeleteCaption is https://html.spec.whatwg.org/multipage/tables.html#dom-table-deletecaption
+func (t *HTMLTable) DeleteCaption() {
+	if caption := t.Caption(); caption != nil {
+		t.node.RemoveChild(caption)
+	}
+}
+
+// THead is https://html.spec.whatwg.org/multipage/tables.html#dom-table-thead
+func (t *HTMLTable) THead() *Node {
+	return firstChildElement(t.node, "thead")
+}
+
+// SetTHead replaces the first thead and inserts the new one before the first
+// child that isn't a caption or colgroup.
+func (t *HTMLTable) SetTHead(thead *Node) error {
+	if thead != nil && !isHTMLElement(thead, "thead") {
+		return ErrHierarchyRequest
+	}
+	t.DeleteTHead()
+	if thead != nil {
+		t.node.InsertBefore(thead, t.firstChildAfterCaptions())
+	}
+	return nil
+}
+
+// CreateTHead is https://html.spec.whatwg.org/multipage/tables.html#dom-table-createthead
+func (t *HTMLTable) CreateTHead() *Node {
+	if thead := t.THead(); thead != nil {
+		return thead
+	}
+	thead := NewDOMElement(t.node.OwnerDocument, "thead", Htmlns)
+	t.node.InsertBefore(thead, t.firstChildAfterCaptions())
+	return thead
+}
+
+// DeleteTHead is https://html.spec.whatwg.org/multipage/tables.html#dom-table-deletethead
+func (t *HTMLTable) DeleteTHead() {
+	if thead := t.THead(); thead != nil {
+		t.node.RemoveChild(thead)
+	}
+}
+
+// firstChildAfterCaptions returns the first child element that isn't a caption
+// or colgroup element.
+func (t *HTMLTable) firstChildAfterCaptions() *Node {
+	for _, child := range t.node.ChildNodes {
+		if child.NodeType == ElementNode && !isHTMLElement(child, "caption") && !isHTMLElement(child, "colgroup") {
+			return child
+		}
+	}
+	return nil
+}
+
+// TFoot is https://html.spec.whatwg.org/multipage/tables.html#dom-table-tfoot
+func (t *HTMLTable) TFoot() *Node {
+	return firstChildElement(t.node, "tfoot")
+}
+
+// SetTFoot replaces the first tfoot and appends the new one.
+func (t *HTMLTable) SetTFoot(tfoot *Node) error {
+	if tfoot != nil && !isHTMLElement(tfoot, "tfoot") {
+		return ErrHierarchyRequest
+	}
+	t.DeleteTFoot()
+	if tfoot != nil {
+		t.node.AppendChild(tfoot)
+	}
+	return nil
+}
+
+// CreateTFoot is https://html.spec.whatwg.org/multipage/tables.html#dom-table-createtfoot
+func (t *HTMLTable) CreateTFoot() *Node {
+	if tfoot := t.TFoot(); tfoot != nil {
+		return tfoot
+	}
+	tfoot := NewDOMElement(t.node.OwnerDocument, "tfoot", Htmlns)
+	t.node.AppendChild(tfoot)
+	return tfoot
+}
+
+// DeleteTFoot is https://html.spec.whatwg.org/multipage/tables.html#dom-table-deletetfoot
+func (t *HTMLTable) DeleteTFoot() {
+	if tfoot := t.TFoot(); tfoot != nil {
+		t.node.RemoveChild(tfoot)
+	}
+}
+
+// TBodies is https://html.spec.whatwg.org/multipage/tables.html#dom-table-tbodies
+func (t *HTMLTable) TBodies() []*Node {
+	return childElements(t.node, "tbody")
+}
+
+// CreateTBody is https://html.spec.whatwg.org/multipage/tables.html#dom-table-createtbody
+func (t *HTMLTable) CreateTBody() *Node {
+	tbody := NewDOMElement(t.node.OwnerDocument, "tbody", Htmlns)
+	var after *Node
+	if tbodies := t.TBodies(); len(tbodies) > 0 {
+		after = tbodies[len(tbodies)-1].NextSibling
+	}
+	t.node.InsertBefore(tbody, after)
+	return tbody
+}
+
+// Rows returns the rows of the thead elements, then those of the tbody
+// elements and the table's own rows in tree order, then those of the tfoot
+// elements.
+// https://html.spec.whatwg.org/multipage/tables.html#dom-table-rows
+func (t *HTMLTable) Rows() []*Node {
+	var head, body, foot []*Node
+	for _, child := range t.node.ChildNodes {
+		switch {
+		case isHTMLElement(child, "thead"):
+			head = append(head, childElements(child, "tr")...)
+		case isHTMLElement(child, "tbody"):
+			body = append(body, childElements(child, "tr")...)
+		case isHTMLElement(child, "tr"):
+			body = append(body, child)
+		case isHTMLElement(child, "tfoot"):
+			foot = append(foot, childElements(child, "tr")...)
+		}
+	}
+	return append(append(append([]*Node{}, head...), body...), foot...)
+}
+
+// InsertRow is https://html.spec.whatwg.org/multipage/tables.html#dom-table-insertrow
+func (t *HTMLTable) InsertRow(index int) (*Node, error) {
+	rows := t.Rows()
+	if index < -1 || index > len(rows) {
+		return nil, ErrIndexSize
+	}
+	tr := NewDOMElement(t.node.OwnerDocument, "tr", Htmlns)
+	switch {
+	case len(rows) == 0:
+		tbodies := t.TBodies()
+		if len(tbodies) == 0 {
+			tbody := NewDOMElement(t.node.OwnerDocument, "tbody", Htmlns)
+			tbody.AppendChild(tr)
+			t.node.AppendChild(tbody)
+			return tr, nil
+		}
+		tbodies[len(tbodies)-1].AppendChild(tr)
+	case index == -1 || index == len(rows):
+		rows[len(rows)-1].ParentNode.AppendChild(tr)
+	default:
+		rows[index].ParentNode.InsertBefore(tr, rows[index])
+	}
+	return tr, nil
+}
+
+// DeleteRow is https://html.spec.whatwg.org/multipage/tables.html#dom-table-deleterow
+func (t *HTMLTable) DeleteRow(index int) error {
+	rows := t.Rows()
+	if index == -1 {
+		if len(rows) > 0 {
+			last := rows[len(rows)-1]
+			last.ParentNode.RemoveChild(last)
+		}
+		return nil
+	}
+	if index < 0 || index >= len(rows) {
+		return ErrIndexSize
+	}
+	rows[index].ParentNode.RemoveChild(rows[index])
+	return nil
+}
+
+// childElements returns the HTML element children with the name.
+func childElements(n *Node, name string) []*Node {
+	children := []*Node{}
+	for _, child := range n.ChildNodes {
+		if isHTMLElement(child, name) {
+			children = append(children, child)
+		}
+	}
+	return children
 }
@@ -0,0 +1,43 @@
+package spec
+
+// tableSection is https://html.spec.whatwg.org/multipage/tables.html#htmltablesectionelement
+type tableSection struct {
+	// node is the thead, tbody or tfoot element.
+	node *Node
+}
+
+// Rows is https://html.spec.whatwg.org/multipage/tables.html#dom-tbody-rows
+func (s *tableSection) Rows() []*Node {
+	return childElements(s.node, "tr")
+}
+
+// InsertRow is https://html.spec.whatwg.org/multipage/tables.html#dom-tbody-insertrow
+func (s *tableSection) InsertRow(index int) (*Node, error) {
+	rows := s.Rows()
+	if index < -1 || index > len(rows) {
+		return nil, ErrIndexSize
+	}
+	tr := NewDOMElement(s.node.OwnerDocument, "tr", Htmlns)
+	if index == -1 || index == len(rows) {
+		s.node.AppendChild(tr)
+	} else {
+		s.node.InsertBefore(tr, rows[index])
+	}
+	return tr, nil
+}
+
+// DeleteRow is https://html.spec.whatwg.org/multipage/tables.html#dom-tbody-deleterow
+func (s *tableSection) DeleteRow(index int) error {
+	rows := s.Rows()
+	if index == -1 {
+		if len(rows) > 0 {
+			s.node.RemoveChild(rows[len(rows)-1])
+		}
+		return nil
+	}
+	if index < 0 || index >= len(rows) {
+		return ErrIndexSize
+	}
+	s.node.RemoveChild(rows[index])
+	return nil
+}
@@ -1,4 +1,6 @@
 package spec
 
+// HTMLTBody is https://html.spec.whatwg.org/multipage/tables.html#htmltablesectionelement
 type HTMLTBody struct {
+	tableSection
 }
@@ -1,4 +1,6 @@
 package spec
 
+// HTMLTFoot is https://html.spec.whatwg.org/multipage/tables.html#htmltablesectionelement
 type HTMLTFoot struct {
+	tableSection
 }
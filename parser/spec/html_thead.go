@@ -1,4 +1,6 @@
 package spec
 
+// HTMLTHead is https://html.spec.whatwg.org/multipage/tables.html#htmltablesectionelement
 type HTMLTHead struct {
+	tableSection
 }
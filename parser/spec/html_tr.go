@@ -1,4 +1,80 @@
 package spec
 
+// HTMLTr is https://html.spec.whatwg.org/multipage/tables.html#htmltablerowelement
 type HTMLTr struct {
+	// node is the tr element.
+	node *Node
+}
+
+// RowIndex is https://html.spec.whatwg.org/multipage/tables.html#dom-tr-rowindex
+func (r *HTMLTr) RowIndex() int {
+	table := r.node.ParentNode
+	if table != nil && !isHTMLElement(table, "table") {
+		table = table.ParentNode
+	}
+	if table == nil || !isHTMLElement(table, "table") {
+		return -1
+	}
+	for i, row := range table.HTMLTable.Rows() {
+		if row == r.node {
+			return i
+		}
+	}
+	return -1
+}
+
+// SectionRowIndex is https://html.spec.whatwg.org/multipage/tables.html#dom-tr-sectionrowindex
+func (r *HTMLTr) SectionRowIndex() int {
+	parent := r.node.ParentNode
+	if parent == nil {
+		return -1
+	}
+	for i, row := range childElements(parent, "tr") {
+		if row == r.node {
+			return i
+		}
+	}
+	return -1
+}
+
+// Cells is https://html.spec.whatwg.org/multipage/tables.html#dom-tr-cells
+func (r *HTMLTr) Cells() []*Node {
+	cells := []*Node{}
+	for _, child := range r.node.ChildNodes {
+		if isHTMLElement(child, "td") || isHTMLElement(child, "th") {
+			cells = append(cells, child)
+		}
+	}
+	return cells
+}
+
+// InsertCell is https://html.spec.whatwg.org/multipage/tables.html#dom-tr-insertcell
+func (r *HTMLTr) InsertCell(index int) (*Node, error) {
+	cells := r.Cells()
+	if index < -1 || index > len(cells) {
+		return nil, ErrIndexSize
+	}
+	td := NewDOMElement(r.node.OwnerDocument, "td", Htmlns)
+	if index == -1 || index == len(cells) {
+		r.node.AppendChild(td)
+	} else {
+		r.node.InsertBefore(td, cells[index])
+	}
+	return td, nil
+}
+
+// DeleteCell is https://html.spec.whatwg.org/multipage/tables.html#dom-tr-deletecell
+func (r *HTMLTr) DeleteCell(index int) error {
+	cells := r.Cells()
+	if index == -1 {
+		if len(cells) > 0 {
+			r.node.RemoveChild(cells[len(cells)-1])
+		}
+		return nil
+	}
+	if index < 0 || index >= len(cells) {
+		return ErrIndexSize
+	}
+	r.node.RemoveChild(cells[index])
+	return nil
 }
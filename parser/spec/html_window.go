@@ -1,5 +1,33 @@
 package spec
 
+// HTMLWindow is https://html.spec.whatwg.org/multipage/nav-history-apis.html#window
+// Only the window's event target is kept here. It has the listeners of the
+// window-reflecting event handlers of body and frameset elements.
 type HTMLWindow struct {
 	*EventTarget
+	document *HTMLDocument
+}
+
+// Window is the window of the document, created the first time it's needed.
+func (d *HTMLDocument) Window() *HTMLWindow {
+	if d.window == nil {
+		d.window = &HTMLWindow{EventTarget: &EventTarget{}, document: d}
+	}
+	return d.window
+}
+
+// DispatchEvent is https://dom.spec.whatwg.org/#concept-event-dispatch
+// with the window as the target. Event targets are nodes so the event's
+// target is the window's document, like the legacy target override flag does
+// for load events, and its current target is nil while the listeners run.
+func (w *HTMLWindow) DispatchEvent(e *Event) bool {
+	e.Target = w.document.Node
+	e.CurrentTarget = nil
+	e.eventPhase = atTargetPhase
+	w.EventTarget.invoke(e, atTargetPhase)
+
+	e.eventPhase = noneEventPhase
+	e.stopPropagation = false
+	e.stopImmediatePropagation = false
+	return !e.canceled
 }
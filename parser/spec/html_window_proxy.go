@@ -1,4 +1,91 @@
 package spec
 
+// WindowProxy is https://html.spec.whatwg.org/multipage/nav-history-apis.html#the-windowproxy-exotic-object
+// It's the window of a browsing context as seen by a document, which can only
+// reach the window's document and location when it has the same origin.
 type WindowProxy struct {
+	browsingContext BrowsingContext
+	// accessor is the document the window is seen from.
+	accessor *HTMLDocument
+}
+
+// NewWindowProxy creates the window of a browsing context seen by the
+// accessor document.
+func NewWindowProxy(bc BrowsingContext, accessor *HTMLDocument) *WindowProxy {
+	return &WindowProxy{browsingContext: bc, accessor: accessor}
+}
+
+// BrowsingContext is the browsing context of the window.
+func (w *WindowProxy) BrowsingContext() BrowsingContext { return w.browsingContext }
+
+// Accessor is the document the window is seen from.
+func (w *WindowProxy) Accessor() *HTMLDocument { return w.accessor }
+
+// https://html.spec.whatwg.org/multipage/nav-history-apis.html#isplatformobjectsameorigin-(-o-)
+func (w *WindowProxy) sameOrigin() bool {
+	return w.accessor != nil && sameOrigin(w.accessor, w.browsingContext.ActiveDocument())
+}
+
+// Document is https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-document-2
+// It fails with ErrSecurity for cross-origin windows.
+func (w *WindowProxy) Document() (*HTMLDocument, error) {
+	if !w.sameOrigin() {
+		return nil, ErrSecurity
+	}
+	return w.browsingContext.ActiveDocument(), nil
+}
+
+// Location is https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-location
+// It fails with ErrSecurity for cross-origin windows.
+func (w *WindowProxy) Location() (*HTMLLocation, error) {
+	if !w.sameOrigin() {
+		return nil, ErrSecurity
+	}
+	return w.browsingContext.ActiveDocument().Location, nil
+}
+
+// FrameElement is https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-frameelement
+// It's nil for top-level windows and windows nested in a cross-origin document.
+func (w *WindowProxy) FrameElement() *Node {
+	container := w.browsingContext.Container()
+	if container == nil || w.accessor == nil {
+		return nil
+	}
+	if doc := container.OwnerHTMLDocument(); doc == nil || !sameOrigin(w.accessor, doc) {
+		return nil
+	}
+	return container
+}
+
+// Parent is https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-parent
+// Top-level windows are their own parent.
+func (w *WindowProxy) Parent() *WindowProxy {
+	parent := w.browsingContext.Parent()
+	if parent == nil {
+		return w
+	}
+	return NewWindowProxy(parent, w.accessor)
+}
+
+// Top is https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-top
+func (w *WindowProxy) Top() *WindowProxy {
+	top := w.browsingContext
+	for top.Parent() != nil {
+		top = top.Parent()
+	}
+	return NewWindowProxy(top, w.accessor)
+}
+
+// Length is https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-length
+func (w *WindowProxy) Length() int { return len(w.browsingContext.Children()) }
+
+// Frame is the window of the browsing context nested in the index-th iframe
+// of the document, or nil if there isn't one.
+// https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-window-item
+func (w *WindowProxy) Frame(index int) *WindowProxy {
+	children := w.browsingContext.Children()
+	if index < 0 || index >= len(children) {
+		return nil
+	}
+	return NewWindowProxy(children[index], w.accessor)
 }
@@ -0,0 +1,178 @@
+package spec
+
+import "strings"
+
+// ParseHTMLFragment is the HTML fragment parsing algorithm. It returns the
+// nodes parsed from markup as if it were the contents of the context element.
+// It is registered by the parser package.
+// https://html.spec.whatwg.org/multipage/parsing.html#parsing-html-fragments
+var ParseHTMLFragment func(context *Node, markup string) []*Node
+
+// SerializeHTMLFragment is the HTML fragment serialization algorithm. It is
+// registered by the parser package.
+// https://html.spec.whatwg.org/multipage/parsing.html#serialising-html-fragments
+var SerializeHTMLFragment func(node *Node) string
+
+// InnerHTML is https://html.spec.whatwg.org/multipage/dynamic-markup-insertion.html#dom-element-innerhtml
+func (n *Node) InnerHTML() string {
+	if SerializeHTMLFragment == nil {
+		return ""
+	}
+	return SerializeHTMLFragment(n)
+}
+
+// SetInnerHTML is https://html.spec.whatwg.org/multipage/dynamic-markup-insertion.html#dom-element-innerhtml
+func (n *Node) SetInnerHTML(markup string) error {
+	nodes, err := parseFragment(n, markup)
+	if err != nil {
+		return err
+	}
+	n.replaceAll(nodes)
+	return nil
+}
+
+// OuterHTML is https://html.spec.whatwg.org/multipage/dynamic-markup-insertion.html#dom-element-outerhtml
+func (n *Node) OuterHTML() string {
+	if SerializeHTMLFragment == nil {
+		return ""
+	}
+	// serialize a fictional node whose only child is this one.
+	return SerializeHTMLFragment(&Node{ChildNodes: NodeList{n}})
+}
+
+// SetOuterHTML is https://html.spec.whatwg.org/multipage/dynamic-markup-insertion.html#dom-element-outerhtml
+func (n *Node) SetOuterHTML(markup string) error {
+	parent := n.ParentNode
+	if parent == nil {
+		return nil
+	}
+	if parent.NodeType == DocumentNode {
+		return ErrNoModificationAllowed
+	}
+	if parent.NodeType == DocumentFragmentNode {
+		parent = NewDOMElement(n.OwnerDocument, "body", Htmlns)
+	}
+
+	nodes, err := parseFragment(parent, markup)
+	if err != nil {
+		return err
+	}
+	n.ParentNode.insertNodesBefore(nodes, n)
+	n.ParentNode.RemoveChild(n)
+	return nil
+}
+
+// InsertAdjacentHTML is https://html.spec.whatwg.org/multipage/dynamic-markup-insertion.html#dom-element-insertadjacenthtml
+func (n *Node) InsertAdjacentHTML(position, markup string) error {
+	var context *Node
+	switch strings.ToLower(position) {
+	case "beforebegin", "afterend":
+		context = n.ParentNode
+		if context == nil || context.NodeType == DocumentNode {
+			return ErrNoModificationAllowed
+		}
+	case "afterbegin", "beforeend":
+		context = n
+	default:
+		return ErrSyntax
+	}
+
+	if context.NodeType != ElementNode ||
+		(context.NodeName == "html" && context.NamespaceURI == Htmlns) {
+		context = NewDOMElement(n.OwnerDocument, "body", Htmlns)
+	}
+
+	nodes, err := parseFragment(context, markup)
+	if err != nil {
+		return err
+	}
+	n.insertAdjacent(position, nodes)
+	return nil
+}
+
+// InsertAdjacentElement is https://dom.spec.whatwg.org/#dom-element-insertadjacentelement
+func (n *Node) InsertAdjacentElement(where string, element *Node) (*Node, error) {
+	if !isInsertAdjacentPosition(where) {
+		return nil, ErrSyntax
+	}
+	parent := n
+	switch strings.ToLower(where) {
+	case "beforebegin", "afterend":
+		parent = n.ParentNode
+	}
+	if parent == nil {
+		return nil, nil
+	}
+	if parent.isInclusiveAncestor(element) {
+		return nil, ErrHierarchyRequest
+	}
+	if element.ParentNode != nil {
+		element.ParentNode.RemoveChild(element)
+	}
+	n.insertAdjacent(where, []*Node{element})
+	return element, nil
+}
+
+// InsertAdjacentText is https://dom.spec.whatwg.org/#dom-element-insertadjacenttext
+func (n *Node) InsertAdjacentText(where, data string) error {
+	if !isInsertAdjacentPosition(where) {
+		return ErrSyntax
+	}
+	n.insertAdjacent(where, []*Node{NewTextNode(n.OwnerDocument, data)})
+	return nil
+}
+
+func isInsertAdjacentPosition(where string) bool {
+	switch strings.ToLower(where) {
+	case "beforebegin", "afterbegin", "beforeend", "afterend":
+		return true
+	}
+	return false
+}
+
+// insertAdjacent inserts nodes relative to n. It returns false if nothing was
+// inserted because n doesn't have a parent.
+// https://dom.spec.whatwg.org/#insert-adjacent
+func (n *Node) insertAdjacent(where string, nodes []*Node) bool {
+	switch strings.ToLower(where) {
+	case "beforebegin":
+		if n.ParentNode == nil {
+			return false
+		}
+		n.ParentNode.insertNodesBefore(nodes, n)
+	case "afterbegin":
+		n.insertNodesBefore(nodes, n.FirstChild)
+	case "beforeend":
+		n.insertNodesBefore(nodes, nil)
+	case "afterend":
+		if n.ParentNode == nil {
+			return false
+		}
+		n.ParentNode.insertNodesBefore(nodes, n.NextSibling)
+	}
+	return true
+}
+
+// parseFragment runs the fragment parsing algorithm with context as the
+// context element.
+// https://html.spec.whatwg.org/multipage/dynamic-markup-insertion.html#fragment-parsing-algorithm-steps
+func parseFragment(context *Node, markup string) ([]*Node, error) {
+	if ParseHTMLFragment == nil {
+		return nil, ErrInvalidState
+	}
+	return ParseHTMLFragment(context, markup), nil
+}
+
+func (n *Node) insertNodesBefore(nodes []*Node, child *Node) {
+	for _, node := range nodes {
+		n.InsertBefore(node, child)
+	}
+}
+
+// https://dom.spec.whatwg.org/#concept-node-replace-all
+func (n *Node) replaceAll(nodes []*Node) {
+	for len(n.ChildNodes) > 0 {
+		n.RemoveChild(n.ChildNodes[0])
+	}
+	n.insertNodesBefore(nodes, nil)
+}
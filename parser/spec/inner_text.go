@@ -0,0 +1,249 @@
+package spec
+
+import "strings"
+
+// displayNoneElements are hidden by the user agent style sheet.
+// https://html.spec.whatwg.org/multipage/rendering.html#hidden-elements
+var displayNoneElements = map[string]bool{
+	"area": true, "base": true, "basefont": true, "datalist": true,
+	"head": true, "link": true, "meta": true, "noembed": true,
+	"noframes": true, "param": true, "rp": true, "script": true,
+	"style": true, "template": true, "title": true, "source": true,
+	"track": true,
+}
+
+// blockElements are block-level or table captions in the user agent style
+// sheet.
+// https://html.spec.whatwg.org/multipage/rendering.html#the-css-user-agent-style-sheet-and-presentational-hints
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true,
+	"body": true, "caption": true, "center": true, "dd": true, "details": true,
+	"dialog": true, "dir": true, "div": true, "dl": true, "dt": true,
+	"fieldset": true, "figcaption": true, "figure": true, "footer": true,
+	"form": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true,
+	"h6": true, "header": true, "hgroup": true, "hr": true, "html": true,
+	"legend": true, "li": true, "listing": true, "main": true, "menu": true,
+	"nav": true, "ol": true, "plaintext": true, "pre": true, "search": true,
+	"section": true, "summary": true, "table": true, "ul": true, "xmp": true,
+}
+
+// preformattedElements have white-space: pre in the user agent style sheet.
+var preformattedElements = map[string]bool{
+	"listing": true, "plaintext": true, "pre": true, "textarea": true, "xmp": true,
+}
+
+// innerTextItem is an item of the rendered text collection. It's either a
+// string or a required line break count.
+type innerTextItem struct {
+	text       string
+	lineBreaks int
+	pre        bool
+}
+
+// InnerText is https://html.spec.whatwg.org/multipage/dom.html#dom-innertext.
+// There's no CSS so it's an approximation that uses the user agent style
+// sheet's display and white-space values and skips elements with the hidden
+// attribute or an inline display: none style.
+func (n *Node) InnerText() string {
+	var items []innerTextItem
+	for _, child := range n.ChildNodes {
+		items = child.renderedTextCollection(items, preformattedElements[n.NodeName])
+	}
+	return joinInnerTextItems(items)
+}
+
+// https://html.spec.whatwg.org/multipage/dom.html#rendered-text-collection-steps
+func (n *Node) renderedTextCollection(items []innerTextItem, pre bool) []innerTextItem {
+	switch n.NodeType {
+	case TextNode:
+		return append(items, innerTextItem{text: n.Text.Data, pre: pre})
+	case ElementNode:
+	default:
+		return items
+	}
+	if !n.isRendered() {
+		return items
+	}
+
+	pre = pre || preformattedElements[n.NodeName]
+	switch {
+	case n.NodeName == "p":
+		items = append(items, innerTextItem{lineBreaks: 2})
+	case blockElements[n.NodeName]:
+		items = append(items, innerTextItem{lineBreaks: 1})
+	}
+	for _, child := range n.ChildNodes {
+		items = child.renderedTextCollection(items, pre)
+	}
+
+	switch {
+	case n.NodeName == "br":
+		items = append(items, innerTextItem{text: "\n", pre: true})
+	case (n.NodeName == "td" || n.NodeName == "th") && n.nextSiblingElement() != nil:
+		items = append(items, innerTextItem{text: "\t", pre: true})
+	case n.NodeName == "tr" && n.nextTableRow() != nil:
+		items = append(items, innerTextItem{lineBreaks: 1})
+	case n.NodeName == "p":
+		items = append(items, innerTextItem{lineBreaks: 2})
+	case blockElements[n.NodeName]:
+		items = append(items, innerTextItem{lineBreaks: 1})
+	}
+	return items
+}
+
+// isRendered reports if the element would have a box.
+func (n *Node) isRendered() bool {
+	if n.Element == nil || n.NamespaceURI != Htmlns {
+		return true
+	}
+	if displayNoneElements[n.NodeName] {
+		return false
+	}
+	if n.Attributes == nil {
+		return true
+	}
+	if _, hidden := n.Attributes.Attrs["hidden"]; hidden {
+		return false
+	}
+	if t, ok := n.Attributes.Attrs["type"]; ok && n.NodeName == "input" && strings.EqualFold(t.Value, "hidden") {
+		return false
+	}
+	if style, ok := n.Attributes.Attrs["style"]; ok {
+		css := strings.ToLower(strings.Join(strings.Fields(style.Value), ""))
+		if strings.Contains(css, "display:none") || strings.Contains(css, "visibility:hidden") {
+			return false
+		}
+	}
+	return true
+}
+
+func (n *Node) nextSiblingElement() *Node {
+	for s := n.NextSibling; s != nil; s = s.NextSibling {
+		if s.NodeType == ElementNode {
+			return s
+		}
+	}
+	return nil
+}
+
+// nextTableRow returns the row after the one in the same table, looking in the
+// following table sections if it's the last in its own.
+func (n *Node) nextTableRow() *Node {
+	for s := n.nextSiblingElement(); s != nil; s = s.nextSiblingElement() {
+		if s.NodeName == "tr" {
+			return s
+		}
+	}
+	section := n.ParentNode
+	if section == nil {
+		return nil
+	}
+	switch section.NodeName {
+	case "tbody", "thead", "tfoot":
+	default:
+		return nil
+	}
+	for s := section.nextSiblingElement(); s != nil; s = s.nextSiblingElement() {
+		for _, row := range s.ChildNodes {
+			if row.NodeName == "tr" {
+				return row
+			}
+		}
+	}
+	return nil
+}
+
+// joinInnerTextItems collapses white space, removes the required line break
+// counts at the start and end and replaces the rest with that many line feeds.
+func joinInnerTextItems(items []innerTextItem) string {
+	var b strings.Builder
+	lineBreaks := 0
+	// lineStart is set when collapsible spaces at the start of a line are
+	// removed, spaceSeen when a collapsed space is waiting to be written.
+	lineStart, spaceSeen := true, false
+	for _, item := range items {
+		if item.lineBreaks > 0 {
+			if b.Len() > 0 && item.lineBreaks > lineBreaks {
+				lineBreaks = item.lineBreaks
+			}
+			spaceSeen = false
+			continue
+		}
+
+		text := item.text
+		if !item.pre {
+			text = collapseWhiteSpace(text)
+			if text == "" {
+				continue
+			}
+			if text == " " {
+				spaceSeen = !lineStart && lineBreaks == 0
+				continue
+			}
+		}
+
+		if lineBreaks > 0 {
+			b.WriteString(strings.Repeat("\n", lineBreaks))
+			lineBreaks = 0
+			lineStart = true
+		}
+		if !item.pre {
+			if strings.HasPrefix(text, " ") {
+				if !lineStart {
+					spaceSeen = true
+				}
+				text = text[1:]
+			}
+			if spaceSeen {
+				b.WriteByte(' ')
+			}
+			spaceSeen = strings.HasSuffix(text, " ")
+			text = strings.TrimSuffix(text, " ")
+		} else if spaceSeen {
+			b.WriteByte(' ')
+			spaceSeen = false
+		}
+		b.WriteString(text)
+		lineStart = strings.HasSuffix(text, "\n")
+	}
+	return b.String()
+}
+
+// collapseWhiteSpace replaces runs of ASCII white space with a single space.
+// https://drafts.csswg.org/css-text/#white-space-phase-1
+func collapseWhiteSpace(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		switch r {
+		case '\t', '\n', '\f', '\r', ' ':
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	if space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+// SetInnerText is https://html.spec.whatwg.org/multipage/dom.html#set-the-inner-text-steps
+func (n *Node) SetInnerText(value string) {
+	var nodes []*Node
+	value = strings.ReplaceAll(value, "\r\n", "\n")
+	value = strings.ReplaceAll(value, "\r", "\n")
+	for i, line := range strings.Split(value, "\n") {
+		if i > 0 {
+			nodes = append(nodes, NewDOMElement(n.OwnerDocument, "br", Htmlns))
+		}
+		if line != "" {
+			nodes = append(nodes, NewTextNode(n.OwnerDocument, line))
+		}
+	}
+	n.replaceAll(nodes)
+}
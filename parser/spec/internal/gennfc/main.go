@@ -0,0 +1,203 @@
+// Command gennfc compiles the canonical decompositions, combining classes and
+// composition exclusions of the Unicode Character Database into the tables
+// in nfc_tables.go that NFC normalization uses.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const tablesFile = "nfc_tables.go"
+
+var (
+	version = flag.String("version", "14.0.0", "Unicode version of the tables")
+	ucd     = flag.String("ucd", "", "directory with the UCD files, downloaded from unicode.org if it's empty")
+)
+
+// open opens a file of the Unicode Character Database.
+func open(name string) io.ReadCloser {
+	if *ucd != "" {
+		f, err := os.Open(path.Join(*ucd, name))
+		if err != nil {
+			log.Fatal(err)
+		}
+		return f
+	}
+	url := "https://www.unicode.org/Public/" + *version + "/ucd/" + name
+	resp, err := http.Get(url)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		log.Fatalf("%s: %s", url, resp.Status)
+	}
+	return resp.Body
+}
+
+// fields calls f with the fields of every line of a UCD file that isn't a
+// comment.
+func fields(name string, f func([]string)) {
+	r := open(name)
+	defer r.Close()
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := s.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		parts := strings.Split(line, ";")
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		f(parts)
+	}
+	if err := s.Err(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func codePoint(s string) rune {
+	r, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return rune(r)
+}
+
+func sortedRunes(m map[rune]bool) []rune {
+	runes := make([]rune, 0, len(m))
+	for r := range m {
+		runes = append(runes, r)
+	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+	return runes
+}
+
+// rangeTable builds a unicode.RangeTable of the runes.
+func rangeTable(runes []rune) *unicode.RangeTable {
+	table := &unicode.RangeTable{}
+	for i := 0; i < len(runes); {
+		j := i + 1
+		for j < len(runes) && runes[j] == runes[j-1]+1 {
+			j++
+		}
+		lo, hi := runes[i], runes[j-1]
+		switch {
+		case hi <= 0xFFFF:
+			table.R16 = append(table.R16, unicode.Range16{Lo: uint16(lo), Hi: uint16(hi), Stride: 1})
+		case lo > 0xFFFF:
+			table.R32 = append(table.R32, unicode.Range32{Lo: uint32(lo), Hi: uint32(hi), Stride: 1})
+		default:
+			table.R16 = append(table.R16, unicode.Range16{Lo: uint16(lo), Hi: 0xFFFF, Stride: 1})
+			table.R32 = append(table.R32, unicode.Range32{Lo: 0x10000, Hi: uint32(hi), Stride: 1})
+		}
+		i = j
+	}
+	return table
+}
+
+func main() {
+	flag.Parse()
+
+	decompositions := map[rune][]rune{}
+	classes := map[rune]uint8{}
+	compatibility := map[rune]bool{}
+	// https://www.unicode.org/reports/tr44/#UnicodeData.txt
+	fields("UnicodeData.txt", func(f []string) {
+		r := codePoint(f[0])
+		class, err := strconv.ParseUint(f[3], 10, 8)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if class != 0 {
+			classes[r] = uint8(class)
+		}
+		if f[5] == "" {
+			return
+		}
+		if strings.HasPrefix(f[5], "<") {
+			compatibility[r] = true
+			return
+		}
+		for _, d := range strings.Fields(f[5]) {
+			decompositions[r] = append(decompositions[r], codePoint(d))
+		}
+	})
+	exclusions := map[rune]bool{}
+	fields("CompositionExclusions.txt", func(f []string) {
+		exclusions[codePoint(f[0])] = true
+	})
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by \"go run ./internal/gennfc\" from Unicode %s; DO NOT EDIT.\n\n", *version)
+	fmt.Fprintf(&buf, "package spec\n\n")
+	fmt.Fprintf(&buf, "import \"unicode\"\n\n")
+
+	fmt.Fprintf(&buf, "// nfcDecompositions are the canonical decompositions, one level deep.\n")
+	fmt.Fprintf(&buf, "var nfcDecompositions = map[rune]string{\n")
+	keys := map[rune]bool{}
+	for r := range decompositions {
+		keys[r] = true
+	}
+	for _, r := range sortedRunes(keys) {
+		fmt.Fprintf(&buf, "0x%04X: %+q,\n", r, string(decompositions[r]))
+	}
+	fmt.Fprintf(&buf, "}\n\n")
+
+	fmt.Fprintf(&buf, "// nfcCombiningClasses are the canonical combining classes that aren't 0.\n")
+	fmt.Fprintf(&buf, "var nfcCombiningClasses = map[rune]uint8{\n")
+	keys = map[rune]bool{}
+	for r := range classes {
+		keys[r] = true
+	}
+	for _, r := range sortedRunes(keys) {
+		fmt.Fprintf(&buf, "0x%04X: %d,\n", r, classes[r])
+	}
+	fmt.Fprintf(&buf, "}\n\n")
+
+	fmt.Fprintf(&buf, "// nfcCompositionExclusions are the canonical decompositions that aren't\n")
+	fmt.Fprintf(&buf, "// composed again by NFC for being specific to a script or added late.\n")
+	fmt.Fprintf(&buf, "var nfcCompositionExclusions = map[rune]bool{\n")
+	for _, r := range sortedRunes(exclusions) {
+		fmt.Fprintf(&buf, "0x%04X: true,\n", r)
+	}
+	fmt.Fprintf(&buf, "}\n\n")
+
+	fmt.Fprintf(&buf, "// compatibilityMapped are the code points with a compatibility decomposition.\n")
+	fmt.Fprintf(&buf, "var compatibilityMapped = &unicode.RangeTable{\n")
+	table := rangeTable(sortedRunes(compatibility))
+	fmt.Fprintf(&buf, "R16: []unicode.Range16{\n")
+	for _, r := range table.R16 {
+		fmt.Fprintf(&buf, "{0x%04X, 0x%04X, 1},\n", r.Lo, r.Hi)
+	}
+	fmt.Fprintf(&buf, "},\nR32: []unicode.Range32{\n")
+	for _, r := range table.R32 {
+		fmt.Fprintf(&buf, "{0x%04X, 0x%04X, 1},\n", r.Lo, r.Hi)
+	}
+	fmt.Fprintf(&buf, "},\n}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(tablesFile, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -9,6 +9,17 @@ func NewNamedNodeMap(attrs map[string]*Attr, oe *Node) *NamedNodeMap {
 	for k, v := range attrs {
 		a[k] = NewAttr(k, v, oe)
 	}
+	// the attribute change steps of the appended attributes
+	if oe != nil {
+		for k, v := range a {
+			switch {
+			case isEventHandlerAttribute(oe, k):
+				oe.setEventHandlerAttribute(k, v.Value)
+			case k == "checked" || k == "selected":
+				oe.formControlAttributeChanged(k, false)
+			}
+		}
+	}
 	return &NamedNodeMap{
 		Length:            len(a),
 		Attrs:             a,
@@ -31,7 +42,7 @@ func (n *NamedNodeMap) getAttributeByName(qn string) *Attr {
 	if n.AssociatedElement.OwnerDocument != nil &&
 		n.AssociatedElement.Element.NamespaceURI == Htmlns &&
 		n.AssociatedElement.OwnerDocument.NodeType == DocumentNode &&
-		n.AssociatedElement.OwnerDocument.Type == "html" {
+		n.AssociatedElement.OwnerDocument.Document.Type == "html" {
 		qn = strings.ToLower(string(qn))
 	}
 
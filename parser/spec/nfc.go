@@ -0,0 +1,134 @@
+package spec
+
+//go:generate go run ./internal/gennfc
+
+import "sort"
+
+// https://www.unicode.org/versions/Unicode14.0.0/ch03.pdf#G56669
+const (
+	hangulSBase  = 0xAC00
+	hangulLBase  = 0x1100
+	hangulVBase  = 0x1161
+	hangulTBase  = 0x11A7
+	hangulLCount = 19
+	hangulVCount = 21
+	hangulTCount = 28
+	hangulNCount = hangulVCount * hangulTCount
+	hangulSCount = hangulLCount * hangulNCount
+)
+
+// nfcCompositions are the primary composites by the pair of code points they
+// canonically decompose to.
+var nfcCompositions = func() map[[2]rune]rune {
+	compositions := map[[2]rune]rune{}
+	for r, d := range nfcDecompositions {
+		pair := []rune(d)
+		// singletons, non-starter decompositions and the exclusions are the
+		// full composition exclusions.
+		if len(pair) != 2 || nfcCombiningClasses[r] != 0 || nfcCombiningClasses[pair[0]] != 0 || nfcCompositionExclusions[r] {
+			continue
+		}
+		compositions[[2]rune{pair[0], pair[1]}] = r
+	}
+	return compositions
+}()
+
+// nfc normalizes s to Normalization Form C.
+// https://www.unicode.org/reports/tr15/#Norm_Forms
+func nfc(s string) string {
+	ascii := true
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return s
+	}
+	runes := []rune{}
+	for _, r := range s {
+		runes = decompose(runes, r)
+	}
+	reorder(runes)
+	return string(compose(runes))
+}
+
+// decompose appends the full canonical decomposition of r.
+func decompose(runes []rune, r rune) []rune {
+	if r >= hangulSBase && r < hangulSBase+hangulSCount {
+		s := r - hangulSBase
+		runes = append(runes, hangulLBase+s/hangulNCount, hangulVBase+(s%hangulNCount)/hangulTCount)
+		if t := s % hangulTCount; t != 0 {
+			runes = append(runes, hangulTBase+t)
+		}
+		return runes
+	}
+	d, ok := nfcDecompositions[r]
+	if !ok {
+		return append(runes, r)
+	}
+	for _, r := range d {
+		runes = decompose(runes, r)
+	}
+	return runes
+}
+
+// reorder puts each run of non-starters in canonical order.
+// https://www.unicode.org/versions/Unicode14.0.0/ch03.pdf#G49591
+func reorder(runes []rune) {
+	for i := 0; i < len(runes); {
+		if nfcCombiningClasses[runes[i]] == 0 {
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && nfcCombiningClasses[runes[j]] != 0 {
+			j++
+		}
+		run := runes[i:j]
+		sort.SliceStable(run, func(a, b int) bool {
+			return nfcCombiningClasses[run[a]] < nfcCombiningClasses[run[b]]
+		})
+		i = j
+	}
+}
+
+// compose is the canonical composition algorithm.
+// https://www.unicode.org/versions/Unicode14.0.0/ch03.pdf#G50628
+func compose(runes []rune) []rune {
+	out := runes[:0]
+	starter := -1
+	for _, r := range runes {
+		class := nfcCombiningClasses[r]
+		if starter >= 0 {
+			// the last character isn't blocked from the starter when it's
+			// the starter or a non-starter of a lower class.
+			last := out[len(out)-1]
+			lastClass := nfcCombiningClasses[last]
+			if len(out)-1 == starter || (lastClass != 0 && lastClass < class) {
+				if composed, ok := composePair(out[starter], r); ok {
+					out[starter] = composed
+					continue
+				}
+			}
+		}
+		if class == 0 {
+			starter = len(out)
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+func composePair(a, b rune) (rune, bool) {
+	switch {
+	case a >= hangulLBase && a < hangulLBase+hangulLCount && b >= hangulVBase && b < hangulVBase+hangulVCount:
+		return hangulSBase + ((a-hangulLBase)*hangulVCount+b-hangulVBase)*hangulTCount, true
+	case a >= hangulSBase && a < hangulSBase+hangulSCount && (a-hangulSBase)%hangulTCount == 0 &&
+		b > hangulTBase && b < hangulTBase+hangulTCount:
+		return a + b - hangulTBase, true
+	}
+	r, ok := nfcCompositions[[2]rune{a, b}]
+	return r, ok
+}
@@ -143,12 +143,31 @@ func NewComment(data string, od *Node) *Node {
 }
 
 func NewHTMLDocumentNode() *HTMLDocument {
-	return &HTMLDocument{
+	d := &HTMLDocument{
 		Node: &Node{
 			NodeType: DocumentNode,
 			Document: &Document{Type: "html"},
 		},
 	}
+	d.Document.htmlDocument = d
+	d.Location = &HTMLLocation{document: d}
+	d.History = &HTMLHistory{document: d}
+	// a document's node document is the document itself
+	d.OwnerDocument = d.Node
+	return d
+}
+
+// OwnerHTMLDocument returns the HTML document that is the node's node document.
+// It's nil if the node isn't in an HTML document.
+func (n *Node) OwnerHTMLDocument() *HTMLDocument {
+	doc := n
+	if n.NodeType != DocumentNode {
+		doc = n.OwnerDocument
+	}
+	if doc == nil || doc.Document == nil {
+		return nil
+	}
+	return doc.Document.htmlDocument
 }
 
 func NewTextNode(od *Node, text string) *Node {
@@ -197,6 +216,24 @@ func NewDOMElement(od *Node, name string, namespace Namespace, optionals ...stri
 	}
 
 	n.Attributes.AssociatedElement = n
+	switch {
+	case n.HTMLBase != nil:
+		n.HTMLBase.node = n
+	case n.HTMLForm != nil:
+		n.HTMLForm.node = n
+	case n.HTMLIFrame != nil:
+		n.HTMLIFrame.node = n
+	case n.HTMLTable != nil:
+		n.HTMLTable.node = n
+	case n.HTMLTBody != nil:
+		n.HTMLTBody.node = n
+	case n.HTMLTHead != nil:
+		n.HTMLTHead.node = n
+	case n.HTMLTFoot != nil:
+		n.HTMLTFoot.node = n
+	case n.HTMLTr != nil:
+		n.HTMLTr.node = n
+	}
 	return n
 }
 
@@ -210,7 +247,7 @@ type Node struct {
 	ParentNode, FirstChild, LastChild, PreviousSibling, NextSibling *Node
 	ParentElement                                                   *Element
 	ChildNodes                                                      NodeList
-	NodeValue, TextContent                                          string
+	EventTarget
 
 	// Node types
 	*Element
@@ -351,7 +388,9 @@ func (n *Node) CloneNode(deep bool) *Node {
 			copy.InputEncoding = n.InputEncoding
 			copy.ContentType = n.ContentType
 			copy.URL = n.URL
-			// origin
+			copy.DocumentURI = n.DocumentURI
+			copy.Document.url = n.Document.url
+			copy.Origin = n.Origin
 			// type
 			copy.CompatMode = n.CompatMode
 		case DocumentTypeNode:
@@ -423,60 +462,76 @@ func (n *Node) Contains(on *Node) bool                            { return false
 func (n *Node) LookupPrefix(namespace string) string              { return "" }
 func (n *Node) LookupNamespaceURI(prefix string) string           { return "" }
 func (n *Node) IsDefaultNamespace() bool                          { return false }
-func (n *Node) InsertBefore(on, child *Node) *Node {
 
-	//old := n.getRoot().String()
-	for i := range n.ChildNodes {
-		if n.ChildNodes[i] == child {
-			n.ChildNodes = append(n.ChildNodes[:i+1], n.ChildNodes[i:]...)
-			n.ChildNodes[i] = on
-			on.ParentNode = n
-			on.NextSibling = child
-			if i == 0 {
-				n.FirstChild = on
-			} else {
-				on.PreviousSibling = n.ChildNodes[i-1]
-			}
-		}
+// https://dom.spec.whatwg.org/#concept-node-insert
+func (n *Node) InsertBefore(on, child *Node) *Node {
+	if child == nil {
+		return n.AppendChild(on)
 	}
 
-	//new := n.getRoot().String()
-	//PrintDiff(old, new, "InsertBefore")
+	i := n.ChildNodes.Contains(child)
+	if i == -1 {
+		return on
+	}
+	n.ChildNodes.WedgeIn(i, on)
+	on.ParentNode = n
+	on.PreviousSibling = child.PreviousSibling
+	if on.PreviousSibling != nil {
+		on.PreviousSibling.NextSibling = on
+	}
+	on.NextSibling = child
+	child.PreviousSibling = on
+	if i == 0 {
+		n.FirstChild = on
+	}
+	on.formAssociatedInserted()
+	on.formControlInserted()
+	on.baseInserted()
+	on.iframeInserted()
 	return on
 }
 
 // didn't really follow the steps here because they seem complicated :/
 // https://dom.whatwg.org/#concept-node-append
 func (n *Node) AppendChild(on *Node) *Node {
-	//old := n.getRoot().String()
 	if n.LastChild != nil {
 		on.PreviousSibling = n.LastChild
 		n.LastChild.NextSibling = on
+	} else {
+		n.FirstChild = on
 	}
 	on.ParentNode = n
 	n.LastChild = on
 	n.ChildNodes = append(n.ChildNodes, on)
-
-	//new := n.getRoot().String()
-	//PrintDiff(old, new, "AppendChild")
+	on.formAssociatedInserted()
+	on.formControlInserted()
+	on.baseInserted()
+	on.iframeInserted()
 	return on
 }
 func (n *Node) ReplaceChild(on, child *Node) *Node { return nil }
 
-// TODO: not to yet, for some reason remove is like 50 steps. I'll come back to it
+// https://dom.spec.whatwg.org/#concept-node-remove
 func (n *Node) RemoveChild(child *Node) *Node {
-	//old := n.getRoot().String()
 	node := n.ChildNodes.Remove(n.ChildNodes.Contains(child))
-	if n.LastChild != nil {
-		if len(n.ChildNodes) == 0 {
-			n.LastChild = nil
-		} else {
-			n.LastChild = n.ChildNodes[len(n.ChildNodes)-1]
-		}
+	if node == nil {
+		return nil
 	}
-
-	//new := n.getRoot().String()
-	//PrintDiff(old, new, "RemoveChild")
+	if node.PreviousSibling != nil {
+		node.PreviousSibling.NextSibling = node.NextSibling
+	} else {
+		n.FirstChild = node.NextSibling
+	}
+	if node.NextSibling != nil {
+		node.NextSibling.PreviousSibling = node.PreviousSibling
+	} else {
+		n.LastChild = node.PreviousSibling
+	}
+	node.ParentNode = nil
+	node.PreviousSibling = nil
+	node.NextSibling = nil
+	node.formAssociatedRemoved(n.getRoot())
+	node.iframeRemoved()
 	return node
 }
 
@@ -0,0 +1,57 @@
+package spec
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// setBooleanAttribute is the setter of an IDL attribute reflecting a boolean
+// content attribute.
+// https://html.spec.whatwg.org/multipage/common-dom-interfaces.html#reflecting-content-attributes-in-idl-attributes
+func (n *Node) setBooleanAttribute(name string, v bool) {
+	if v {
+		n.SetAttribute(name, "")
+		return
+	}
+	n.RemoveAttribute(name)
+}
+
+// reflectLong is the getter of an IDL attribute reflecting a long content
+// attribute. It's 0 when the attribute is missing or isn't an integer.
+// https://html.spec.whatwg.org/multipage/common-dom-interfaces.html#reflecting-content-attributes-in-idl-attributes
+func (n *Node) reflectLong(name string) int {
+	v, ok := parseInteger(n.GetAttribute(name))
+	if !ok || v < math.MinInt32 || v > math.MaxInt32 {
+		return 0
+	}
+	return int(v)
+}
+
+// setLongAttribute is the setter of an IDL attribute reflecting a long
+// content attribute.
+func (n *Node) setLongAttribute(name string, v int) {
+	n.SetAttribute(name, strconv.Itoa(v))
+}
+
+// parseInteger is https://html.spec.whatwg.org/multipage/common-microsyntaxes.html#rules-for-parsing-integers
+func parseInteger(s string) (int64, bool) {
+	s = strings.TrimLeft(s, asciiWhitespace)
+	i := 0
+	sign := int64(1)
+	if i < len(s) && (s[i] == '-' || s[i] == '+') {
+		if s[i] == '-' {
+			sign = -1
+		}
+		i++
+	}
+	start := i
+	var v int64
+	for ; i < len(s) && s[i] >= '0' && s[i] <= '9'; i++ {
+		if v > math.MaxInt64/10 {
+			return 0, false
+		}
+		v = v*10 + int64(s[i]-'0')
+	}
+	return sign * v, i > start
+}
@@ -0,0 +1,21 @@
+package spec
+
+// ScriptHost runs the scripts of documents with a JavaScript engine. The spec
+// package can't depend on an engine so embedders plug one in through this
+// interface.
+type ScriptHost interface {
+	// RunScript is https://html.spec.whatwg.org/multipage/webappapis.html#run-a-classic-script
+	// and https://html.spec.whatwg.org/multipage/webappapis.html#run-a-module-script
+	// with the document's window as the global object. It returns the
+	// exception the script threw, which the parser reports with ReportError.
+	RunScript(doc *HTMLDocument, script *Script) error
+	// CompileEventHandler compiles the body of the element's event handler
+	// content attribute, like onclick, into a function. The error is
+	// reported with ReportError when the body doesn't compile.
+	// https://html.spec.whatwg.org/multipage/webappapis.html#getting-the-current-value-of-the-event-handler
+	CompileEventHandler(element *Node, name, body string) (EventHandler, error)
+	// ReportError is https://html.spec.whatwg.org/multipage/webappapis.html#report-the-exception
+	// for errors that aren't thrown to a script, with the document's window
+	// as the global object.
+	ReportError(doc *HTMLDocument, err error)
+}
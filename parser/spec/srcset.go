@@ -0,0 +1,149 @@
+package spec
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ImageCandidate is https://html.spec.whatwg.org/multipage/images.html#image-candidate-string
+type ImageCandidate struct {
+	URL string
+	// Width is the width descriptor or 0 when there isn't one.
+	Width int
+	// Density is the pixel density descriptor. It's 1 when there are no
+	// descriptors and 0 when there's a width descriptor.
+	Density float64
+}
+
+var (
+	validNonNegativeInteger = regexp.MustCompile(`^[0-9]+$`)
+	validFloatingPoint      = regexp.MustCompile(`^-?([0-9]+|[0-9]*\.[0-9]+)([eE][-+]?[0-9]+)?$`)
+)
+
+// ParseSrcset is https://html.spec.whatwg.org/multipage/images.html#parse-a-srcset-attribute
+func ParseSrcset(input string) []ImageCandidate {
+	candidates := []ImageCandidate{}
+	isSpace := func(c byte) bool { return strings.IndexByte(asciiWhitespace, c) != -1 }
+	pos := 0
+	for {
+		for pos < len(input) && (isSpace(input[pos]) || input[pos] == ',') {
+			pos++
+		}
+		if pos >= len(input) {
+			return candidates
+		}
+		start := pos
+		for pos < len(input) && !isSpace(input[pos]) {
+			pos++
+		}
+		url := input[start:pos]
+
+		descriptors := []string{}
+		if strings.HasSuffix(url, ",") {
+			url = strings.TrimRight(url, ",")
+		} else {
+			descriptors, pos = tokenizeSrcsetDescriptors(input, pos)
+		}
+		if candidate, ok := parseSrcsetDescriptors(url, descriptors); ok {
+			candidates = append(candidates, candidate)
+		}
+	}
+}
+
+// tokenizeSrcsetDescriptors returns the descriptors at pos and the position
+// after the comma ending them.
+func tokenizeSrcsetDescriptors(input string, pos int) ([]string, int) {
+	const (
+		inDescriptor = iota
+		inParens
+		afterDescriptor
+	)
+	isSpace := func(c byte) bool { return strings.IndexByte(asciiWhitespace, c) != -1 }
+	for pos < len(input) && isSpace(input[pos]) {
+		pos++
+	}
+	descriptors := []string{}
+	current := ""
+	state := inDescriptor
+	for ; ; pos++ {
+		if pos >= len(input) {
+			if current != "" {
+				descriptors = append(descriptors, current)
+			}
+			return descriptors, pos
+		}
+		c := input[pos]
+		switch state {
+		case inDescriptor:
+			switch {
+			case isSpace(c):
+				if current != "" {
+					descriptors = append(descriptors, current)
+					current = ""
+				}
+				state = afterDescriptor
+			case c == ',':
+				if current != "" {
+					descriptors = append(descriptors, current)
+				}
+				return descriptors, pos + 1
+			case c == '(':
+				current += "("
+				state = inParens
+			default:
+				current += string(c)
+			}
+		case inParens:
+			current += string(c)
+			if c == ')' {
+				state = inDescriptor
+			}
+		case afterDescriptor:
+			if !isSpace(c) {
+				state = inDescriptor
+				pos--
+			}
+		}
+	}
+}
+
+// parseSrcsetDescriptors turns the descriptors of a candidate into its width
+// or density and reports if they're valid.
+func parseSrcsetDescriptors(url string, descriptors []string) (ImageCandidate, bool) {
+	candidate := ImageCandidate{URL: url}
+	hasWidth, hasDensity, hasHeight := false, false, false
+	for _, descriptor := range descriptors {
+		value, last := descriptor[:len(descriptor)-1], descriptor[len(descriptor)-1]
+		switch {
+		case last == 'w' && validNonNegativeInteger.MatchString(value):
+			width, err := strconv.Atoi(value)
+			if hasWidth || hasDensity || err != nil || width == 0 {
+				return candidate, false
+			}
+			candidate.Width, hasWidth = width, true
+		case last == 'x' && validFloatingPoint.MatchString(value):
+			density, err := strconv.ParseFloat(value, 64)
+			if hasWidth || hasDensity || hasHeight || err != nil || density < 0 {
+				return candidate, false
+			}
+			candidate.Density, hasDensity = density, true
+		case last == 'h' && validNonNegativeInteger.MatchString(value):
+			// future-compat height descriptors are only allowed with widths.
+			height, err := strconv.Atoi(value)
+			if hasHeight || hasDensity || err != nil || height == 0 {
+				return candidate, false
+			}
+			hasHeight = true
+		default:
+			return candidate, false
+		}
+	}
+	if hasHeight && !hasWidth {
+		return candidate, false
+	}
+	if !hasWidth && !hasDensity {
+		candidate.Density = 1
+	}
+	return candidate, true
+}
@@ -0,0 +1,525 @@
+package spec
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TableModel is the grid of slots a table element forms.
+// https://html.spec.whatwg.org/multipage/tables.html#table-processing-model
+type TableModel struct {
+	Table, Caption *Node
+	Width, Height  int
+	// Cells are in the order their elements are processed. Rows holds the tr
+	// element of each row.
+	Cells        []*TableCell
+	Rows         []*Node
+	RowGroups    []*TableGroup
+	ColumnGroups []*TableGroup
+	// Errors are the table model errors found while forming the table.
+	Errors []*TableModelError
+
+	slots map[tableSlot][]*TableCell
+	// dataRows and dataColumns are the rows and columns a data cell covers.
+	dataRows, dataColumns []bool
+	// ids are the cells by id, for the headers attribute.
+	ids map[string]*TableCell
+	// groupHeaders are the header cells with a rowgroup or colgroup scope by
+	// the group they're in.
+	groupHeaders map[*TableGroup][]*TableCell
+}
+
+// TableCell is a cell anchored at (X, Y) that covers Width by Height slots.
+// Headers are the header cells assigned to it.
+// https://html.spec.whatwg.org/multipage/tables.html#concept-cell
+type TableCell struct {
+	Node                *Node
+	X, Y, Width, Height int
+	Header              bool
+	Headers             []*TableCell
+
+	rowGroup, columnGroup   *TableGroup
+	columnHeader, rowHeader bool
+}
+
+// TableGroup is a row group or column group. Start and Span are the first
+// row or column and how many it covers.
+// https://html.spec.whatwg.org/multipage/tables.html#concept-row-group
+type TableGroup struct {
+	Node        *Node
+	Start, Span int
+}
+
+// TableModelError is https://html.spec.whatwg.org/multipage/tables.html#table-model-error
+type TableModelError struct {
+	X, Y   int
+	Reason string
+}
+
+func (e *TableModelError) Error() string {
+	return fmt.Sprintf("table model error at (%d, %d): %s", e.X, e.Y, e.Reason)
+}
+
+type tableSlot struct{ x, y int }
+
+// downwardGrowingCell is a cell with rowspan=0 and the columns it covers.
+type downwardGrowingCell struct {
+	cell         *TableCell
+	cellX, width int
+}
+
+// https://html.spec.whatwg.org/multipage/tables.html#forming-a-table
+type tableFormer struct {
+	model           *TableModel
+	xWidth, yHeight int
+	yCurrent        int
+	downwardGrowing []downwardGrowingCell
+	currentRowGroup *TableGroup
+	quirks          bool
+}
+
+// Model forms the table's grid and assigns header cells to its cells.
+// Unlike the spec, and like browsers, a rowspan doesn't reach past the rows
+// of its row group so the grid can't be much larger than the markup.
+// https://html.spec.whatwg.org/multipage/tables.html#forming-a-table
+func (t *HTMLTable) Model() *TableModel {
+	f := &tableFormer{model: &TableModel{Table: t.node, slots: map[tableSlot][]*TableCell{}}}
+	if doc := t.node.OwnerDocument; doc != nil && doc.Document != nil {
+		f.quirks = doc.Mode == "quirks"
+	}
+	children := []*Node{}
+	for _, child := range t.node.ChildNodes {
+		if child.NodeType == ElementNode && child.Element != nil && child.NamespaceURI == Htmlns {
+			children = append(children, child)
+		}
+	}
+	if len(children) == 0 {
+		return f.model
+	}
+	for _, child := range children {
+		if child.NodeName == "caption" {
+			f.model.Caption = child
+			break
+		}
+	}
+
+	i := 0
+	skip := func() {
+		for i < len(children) {
+			switch children[i].NodeName {
+			case "colgroup", "thead", "tbody", "tfoot", "tr":
+				return
+			}
+			i++
+		}
+	}
+	skip()
+	for ; i < len(children) && children[i].NodeName == "colgroup"; skip() {
+		f.processColumnGroup(children[i])
+		i++
+	}
+
+	pendingFoot := []*Node{}
+	for ; ; i++ {
+		skip()
+		if i == len(children) {
+			break
+		}
+		current := children[i]
+		if current.NodeName == "tr" {
+			rowsLeft := 0
+			for j := i; j < len(children) && children[j].NodeName == "tr"; j++ {
+				rowsLeft++
+			}
+			f.currentRowGroup = nil
+			f.processRow(current, rowsLeft)
+			continue
+		}
+		f.endRowGroup()
+		if current.NodeName == "tfoot" {
+			pendingFoot = append(pendingFoot, current)
+			continue
+		}
+		f.processRowGroup(current)
+	}
+	for _, foot := range pendingFoot {
+		f.processRowGroup(foot)
+	}
+
+	m := f.model
+	m.Width, m.Height = f.xWidth, f.yHeight
+	area := 0
+	for _, cell := range m.Cells {
+		area += cell.Width * cell.Height
+	}
+	if area < m.Width*m.Height {
+		m.Errors = append(m.Errors, &TableModelError{Reason: "some slots have no cell"})
+	}
+	m.indexCells()
+	for _, cell := range m.Cells {
+		cell.columnHeader = m.isColumnHeader(cell)
+		cell.rowHeader = m.isRowHeader(cell)
+	}
+	for _, cell := range m.Cells {
+		cell.Headers = m.assignHeaderCells(cell)
+	}
+	return m
+}
+
+// indexCells indexes the cells once so assigning header cells doesn't have to
+// look through all of them for every cell.
+func (m *TableModel) indexCells() {
+	m.dataRows, m.dataColumns = make([]bool, m.Height), make([]bool, m.Width)
+	m.ids = map[string]*TableCell{}
+	m.groupHeaders = map[*TableGroup][]*TableCell{}
+	for _, cell := range m.Cells {
+		if id := cell.Node.attribute("id"); id != "" {
+			if _, ok := m.ids[id]; !ok {
+				m.ids[id] = cell
+			}
+		}
+		if cell.Header {
+			switch cell.scope() {
+			case "rowgroup":
+				if cell.rowGroup != nil {
+					m.groupHeaders[cell.rowGroup] = append(m.groupHeaders[cell.rowGroup], cell)
+				}
+			case "colgroup":
+				if cell.columnGroup != nil {
+					m.groupHeaders[cell.columnGroup] = append(m.groupHeaders[cell.columnGroup], cell)
+				}
+			}
+			continue
+		}
+		for y := cell.Y; y < cell.Y+cell.Height && y < m.Height; y++ {
+			m.dataRows[y] = true
+		}
+		for x := cell.X; x < cell.X+cell.Width && x < m.Width; x++ {
+			m.dataColumns[x] = true
+		}
+	}
+}
+
+func (f *tableFormer) processColumnGroup(colgroup *Node) {
+	start := f.xWidth
+	cols := 0
+	for _, col := range colgroup.ChildNodes {
+		if !isHTMLElement(col, "col") {
+			continue
+		}
+		cols++
+		f.xWidth += clampedSpan(col.attribute("span"), 1000)
+	}
+	if cols == 0 {
+		f.xWidth += clampedSpan(colgroup.attribute("span"), 1000)
+	}
+	if f.xWidth > start {
+		f.model.ColumnGroups = append(f.model.ColumnGroups, &TableGroup{Node: colgroup, Start: start, Span: f.xWidth - start})
+	}
+}
+
+// clampedSpan parses a span, colspan or rowspan attribute that defaults to 1
+// when it's missing, invalid or zero.
+func clampedSpan(value string, max int) int {
+	span, ok := nonNegativeInteger(value)
+	if !ok || span == 0 {
+		return 1
+	}
+	if span > max {
+		return max
+	}
+	return span
+}
+
+// https://html.spec.whatwg.org/multipage/tables.html#algorithm-for-processing-row-groups
+func (f *tableFormer) processRowGroup(section *Node) {
+	yStart := f.yHeight
+	group := &TableGroup{Node: section, Start: yStart}
+	f.currentRowGroup = group
+	rows := []*Node{}
+	for _, child := range section.ChildNodes {
+		if isHTMLElement(child, "tr") {
+			rows = append(rows, child)
+		}
+	}
+	for i, row := range rows {
+		f.processRow(row, len(rows)-i)
+	}
+	if f.yHeight > yStart {
+		group.Span = f.yHeight - yStart
+		f.model.RowGroups = append(f.model.RowGroups, group)
+	}
+	f.endRowGroup()
+	f.currentRowGroup = nil
+}
+
+// https://html.spec.whatwg.org/multipage/tables.html#algorithm-for-ending-a-row-group
+func (f *tableFormer) endRowGroup() {
+	for f.yCurrent < f.yHeight {
+		f.growDownwardGrowingCells()
+		f.yCurrent++
+	}
+	f.downwardGrowing = nil
+}
+
+// https://html.spec.whatwg.org/multipage/tables.html#algorithm-for-growing-downward-growing-cells
+func (f *tableFormer) growDownwardGrowingCells() {
+	for _, d := range f.downwardGrowing {
+		if d.cell.Y+d.cell.Height > f.yCurrent {
+			continue
+		}
+		d.cell.Height = f.yCurrent - d.cell.Y + 1
+		for x := d.cellX; x < d.cellX+d.width; x++ {
+			f.cover(x, f.yCurrent, d.cell)
+		}
+	}
+}
+
+// processRow is the algorithm for processing rows. rowsLeft is the number of
+// rows left in the row group, this one included.
+// https://html.spec.whatwg.org/multipage/tables.html#algorithm-for-processing-rows
+func (f *tableFormer) processRow(tr *Node, rowsLeft int) {
+	if f.yHeight == f.yCurrent {
+		f.yHeight++
+	}
+	for len(f.model.Rows) < f.yHeight {
+		f.model.Rows = append(f.model.Rows, nil)
+	}
+	f.model.Rows[f.yCurrent] = tr
+	xCurrent := 0
+	f.growDownwardGrowingCells()
+
+	for _, current := range tr.ChildNodes {
+		if !isHTMLElement(current, "td") && !isHTMLElement(current, "th") {
+			continue
+		}
+		for xCurrent < f.xWidth && len(f.model.slots[tableSlot{xCurrent, f.yCurrent}]) > 0 {
+			xCurrent++
+		}
+		if xCurrent == f.xWidth {
+			f.xWidth++
+		}
+		colspan := clampedSpan(current.attribute("colspan"), 1000)
+		rowspan, growsDownward := 1, false
+		if span, ok := nonNegativeInteger(current.attribute("rowspan")); ok {
+			rowspan = span
+			if span > 65534 {
+				rowspan = 65534
+			}
+		}
+		if rowspan == 0 {
+			growsDownward, rowspan = !f.quirks, 1
+		}
+		if rowspan > rowsLeft {
+			rowspan = rowsLeft
+		}
+		if f.xWidth < xCurrent+colspan {
+			f.xWidth = xCurrent + colspan
+		}
+		if f.yHeight < f.yCurrent+rowspan {
+			f.yHeight = f.yCurrent + rowspan
+		}
+
+		cell := &TableCell{
+			Node: current, X: xCurrent, Y: f.yCurrent, Width: colspan, Height: rowspan,
+			Header: current.NodeName == "th", rowGroup: f.currentRowGroup,
+		}
+		for _, group := range f.model.ColumnGroups {
+			if xCurrent >= group.Start && xCurrent < group.Start+group.Span {
+				cell.columnGroup = group
+			}
+		}
+		f.model.Cells = append(f.model.Cells, cell)
+		for x := xCurrent; x < xCurrent+colspan; x++ {
+			for y := f.yCurrent; y < f.yCurrent+rowspan; y++ {
+				f.cover(x, y, cell)
+			}
+		}
+		if growsDownward {
+			f.downwardGrowing = append(f.downwardGrowing, downwardGrowingCell{cell, xCurrent, colspan})
+		}
+		xCurrent += colspan
+	}
+	f.yCurrent++
+}
+
+// cover adds the cell to the cells covering the slot. Cells overlapping is a
+// table model error.
+func (f *tableFormer) cover(x, y int, cell *TableCell) {
+	slot := tableSlot{x, y}
+	if len(f.model.slots[slot]) > 0 {
+		f.model.Errors = append(f.model.Errors, &TableModelError{X: x, Y: y, Reason: "cells overlap"})
+	}
+	f.model.slots[slot] = append(f.model.slots[slot], cell)
+}
+
+// CellsAt returns the cells covering the slot, which there's more than one of
+// when cells overlap.
+func (m *TableModel) CellsAt(x, y int) []*TableCell {
+	return m.slots[tableSlot{x, y}]
+}
+
+// Cell returns the cell covering the slot or nil if it's empty.
+func (m *TableModel) Cell(x, y int) *TableCell {
+	if cells := m.CellsAt(x, y); len(cells) > 0 {
+		return cells[0]
+	}
+	return nil
+}
+
+// https://html.spec.whatwg.org/multipage/tables.html#column-header
+func (m *TableModel) isColumnHeader(c *TableCell) bool {
+	if !c.Header {
+		return false
+	}
+	switch c.scope() {
+	case "col":
+		return true
+	case "":
+		return !anyTrue(m.dataRows, c.Y, c.Height)
+	}
+	return false
+}
+
+// https://html.spec.whatwg.org/multipage/tables.html#row-header
+func (m *TableModel) isRowHeader(c *TableCell) bool {
+	if !c.Header {
+		return false
+	}
+	switch c.scope() {
+	case "row":
+		return true
+	case "":
+		return !c.columnHeader && !anyTrue(m.dataColumns, c.X, c.Width)
+	}
+	return false
+}
+
+// scope is the state of a th element's scope attribute, the empty string
+// being the auto state.
+// https://html.spec.whatwg.org/multipage/tables.html#attr-th-scope
+func (c *TableCell) scope() string {
+	switch s := strings.ToLower(c.Node.attribute("scope")); s {
+	case "row", "col", "rowgroup", "colgroup":
+		return s
+	}
+	return ""
+}
+
+// anyTrue reports if any of the n values from start are true.
+func anyTrue(values []bool, start, n int) bool {
+	for i := start; i < start+n && i < len(values); i++ {
+		if values[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// https://html.spec.whatwg.org/multipage/tables.html#internal-algorithm-for-scanning-and-assigning-header-cells
+func (m *TableModel) assignHeaderCells(principal *TableCell) []*TableCell {
+	headers := []*TableCell{}
+	if principal.Node.hasAttribute("headers") {
+		for _, id := range strings.Fields(principal.Node.attribute("headers")) {
+			if cell, ok := m.ids[id]; ok {
+				headers = append(headers, cell)
+			}
+		}
+	} else {
+		for y := principal.Y; y < principal.Y+principal.Height; y++ {
+			headers = m.scanHeaderCells(headers, principal, principal.X, y, -1, 0)
+		}
+		for x := principal.X; x < principal.X+principal.Width; x++ {
+			headers = m.scanHeaderCells(headers, principal, x, principal.Y, 0, -1)
+		}
+		for _, group := range []*TableGroup{principal.rowGroup, principal.columnGroup} {
+			if group == nil {
+				continue
+			}
+			for _, cell := range m.groupHeaders[group] {
+				if cell.X <= principal.X+principal.Width-1 && cell.Y <= principal.Y+principal.Height-1 {
+					headers = append(headers, cell)
+				}
+			}
+		}
+	}
+
+	assigned := []*TableCell{}
+	seen := map[*TableCell]bool{principal: true}
+	for _, header := range headers {
+		if seen[header] || header.isEmpty() {
+			continue
+		}
+		seen[header] = true
+		assigned = append(assigned, header)
+	}
+	return assigned
+}
+
+// https://html.spec.whatwg.org/multipage/tables.html#internal-algorithm-for-scanning-and-assigning-header-cells
+func (m *TableModel) scanHeaderCells(headers []*TableCell, principal *TableCell, x, y, dx, dy int) []*TableCell {
+	opaque := []*TableCell{}
+	inHeaderBlock := principal.Header
+	currentBlock := []*TableCell{}
+	if inHeaderBlock {
+		currentBlock = append(currentBlock, principal)
+	}
+	for {
+		x, y = x+dx, y+dy
+		if x < 0 || y < 0 {
+			return headers
+		}
+		cells := m.CellsAt(x, y)
+		if len(cells) != 1 {
+			continue
+		}
+		current := cells[0]
+		if !current.Header {
+			if inHeaderBlock {
+				inHeaderBlock = false
+				opaque = append(opaque, currentBlock...)
+				currentBlock = nil
+			}
+			continue
+		}
+
+		inHeaderBlock = true
+		currentBlock = append(currentBlock, current)
+		blocked := false
+		if dx == 0 {
+			for _, h := range opaque {
+				if h.X == current.X && h.Width == current.Width {
+					blocked = true
+				}
+			}
+			if !current.columnHeader {
+				blocked = true
+			}
+		}
+		if dy == 0 {
+			for _, h := range opaque {
+				if h.Y == current.Y && h.Height == current.Height {
+					blocked = true
+				}
+			}
+			if !current.rowHeader {
+				blocked = true
+			}
+		}
+		if !blocked {
+			headers = append(headers, current)
+		}
+	}
+}
+
+// isEmpty reports if the cell has no elements and only white space text.
+// https://html.spec.whatwg.org/multipage/tables.html#empty-cell
+func (c *TableCell) isEmpty() bool {
+	for _, child := range c.Node.ChildNodes {
+		if child.NodeType == ElementNode {
+			return false
+		}
+	}
+	text, _ := c.Node.TextContent()
+	return strings.Trim(text, asciiWhitespace) == ""
+}
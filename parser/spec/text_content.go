@@ -0,0 +1,87 @@
+package spec
+
+import "strings"
+
+// characterData returns the data of a Text, CDATASection, ProcessingInstruction
+// or Comment node.
+func (n *Node) characterData() *CharacterData {
+	switch {
+	case n.NodeType == TextNode && n.Text != nil:
+		return n.Text.CharacterData
+	case n.NodeType == CDATASectionNode && n.CDATASection != nil && n.CDATASection.Text != nil:
+		return n.CDATASection.Text.CharacterData
+	case n.NodeType == ProcessingInstructionNode && n.ProcessingInstruction != nil:
+		return n.ProcessingInstruction.CharacterData
+	case n.NodeType == CommentNode && n.Comment != nil:
+		return n.Comment.CharacterData
+	}
+	return nil
+}
+
+// https://dom.spec.whatwg.org/#concept-cd-replace
+func (c *CharacterData) replaceAll(data string) {
+	c.Data = data
+	c.Length = len(data)
+}
+
+// NodeValue is https://dom.spec.whatwg.org/#dom-node-nodevalue. It returns
+// false when the value is null.
+func (n *Node) NodeValue() (string, bool) {
+	if n.NodeType == AttrNode && n.Attr != nil {
+		return n.Attr.Value, true
+	}
+	if cd := n.characterData(); cd != nil {
+		return cd.Data, true
+	}
+	return "", false
+}
+
+// SetNodeValue is https://dom.spec.whatwg.org/#dom-node-nodevalue
+func (n *Node) SetNodeValue(value string) {
+	if n.NodeType == AttrNode && n.Attr != nil {
+		n.Attr.Value = value
+		return
+	}
+	if cd := n.characterData(); cd != nil {
+		cd.replaceAll(value)
+	}
+}
+
+// TextContent is https://dom.spec.whatwg.org/#dom-node-textcontent. It
+// returns false when the value is null, which it is for documents and
+// doctypes.
+func (n *Node) TextContent() (string, bool) {
+	switch n.NodeType {
+	case ElementNode, DocumentFragmentNode:
+		var b strings.Builder
+		n.writeDescendantText(&b)
+		return b.String(), true
+	}
+	return n.NodeValue()
+}
+
+// https://dom.spec.whatwg.org/#concept-descendant-text-content
+func (n *Node) writeDescendantText(b *strings.Builder) {
+	for _, child := range n.ChildNodes {
+		if child.NodeType == TextNode {
+			b.WriteString(child.Text.Data)
+			continue
+		}
+		child.writeDescendantText(b)
+	}
+}
+
+// SetTextContent is https://dom.spec.whatwg.org/#dom-node-textcontent
+func (n *Node) SetTextContent(value string) {
+	switch n.NodeType {
+	case ElementNode, DocumentFragmentNode:
+		// https://dom.spec.whatwg.org/#string-replace-all
+		var nodes []*Node
+		if value != "" {
+			nodes = append(nodes, NewTextNode(n.OwnerDocument, value))
+		}
+		n.replaceAll(nodes)
+		return
+	}
+	n.SetNodeValue(value)
+}
@@ -8,10 +8,17 @@ type TreeWalker struct {
 	currentNode *Node
 }
 
-func (t *TreeWalker) parentNode() *Node      { return nil }
-func (t *TreeWalker) firstChild() *Node      { return nil }
-func (t *TreeWalker) lastChild() *Node       { return nil }
-func (t *TreeWalker) previousSibling() *Node { return nil }
-func (t *TreeWalker) nextSibling() *Node     { return nil }
-func (t *TreeWalker) previousNode() *Node    { return nil }
-func (t *TreeWalker) nextNode() *Node        { return nil }
+// Root is https://dom.spec.whatwg.org/#dom-treewalker-root
+func (t *TreeWalker) Root() *Node { return t.root }
+
+// WhatToShow is https://dom.spec.whatwg.org/#dom-treewalker-whattoshow
+func (t *TreeWalker) WhatToShow() uint { return t.whatToShow }
+
+// Filter is https://dom.spec.whatwg.org/#dom-treewalker-filter
+func (t *TreeWalker) Filter() NodeFilter { return t.filter }
+
+// CurrentNode is https://dom.spec.whatwg.org/#dom-treewalker-currentnode
+func (t *TreeWalker) CurrentNode() *Node { return t.currentNode }
+
+// SetCurrentNode is https://dom.spec.whatwg.org/#dom-treewalker-currentnode
+func (t *TreeWalker) SetCurrentNode(node *Node) { t.currentNode = node }
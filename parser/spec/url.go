@@ -0,0 +1,891 @@
+package spec
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// URL is a URL record together with the URL interface.
+// https://url.spec.whatwg.org/#concept-url
+// https://url.spec.whatwg.org/#url-class
+type URL struct {
+	scheme, username, password string
+	// host is nil when the URL has no host.
+	host *string
+	// port is -1 when the URL has no port.
+	port int
+	path []string
+	// opaque is set when the path is an opaque path, which is path[0].
+	opaque          bool
+	query, fragment *string
+
+	searchParams *URLSearchParams
+}
+
+// ErrInvalidURL is returned when a URL can't be parsed. It stands in for the
+// TypeError the URL interface throws.
+var ErrInvalidURL = errors.New("invalid URL")
+
+func urlFailure(reason string) error {
+	return fmt.Errorf("%w: %s", ErrInvalidURL, reason)
+}
+
+// ParseURL parses input relative to base, which can be nil.
+// https://url.spec.whatwg.org/#concept-url-parser
+func ParseURL(input string, base *URL) (*URL, error) {
+	return basicURLParse(input, base, nil, noURLState)
+}
+
+// Parse parses ref relative to the URL.
+func (u *URL) Parse(ref string) (*URL, error) {
+	return ParseURL(ref, u)
+}
+
+// https://url.spec.whatwg.org/#special-scheme
+var specialSchemes = map[string]int{
+	"ftp":   21,
+	"file":  -1,
+	"http":  80,
+	"https": 443,
+	"ws":    80,
+	"wss":   443,
+}
+
+func isSpecialScheme(scheme string) bool {
+	_, ok := specialSchemes[scheme]
+	return ok
+}
+
+// https://url.spec.whatwg.org/#default-port
+func defaultPort(scheme string) int {
+	if port, ok := specialSchemes[scheme]; ok {
+		return port
+	}
+	return -1
+}
+
+// https://url.spec.whatwg.org/#is-special
+func (u *URL) isSpecial() bool { return isSpecialScheme(u.scheme) }
+
+// IncludesCredentials is https://url.spec.whatwg.org/#include-credentials
+func (u *URL) IncludesCredentials() bool { return u.username != "" || u.password != "" }
+
+// https://url.spec.whatwg.org/#cannot-have-a-username-password-port
+func (u *URL) cannotHaveUsernamePasswordPort() bool {
+	return u.host == nil || *u.host == "" || u.scheme == "file"
+}
+
+// https://url.spec.whatwg.org/#shorten-a-urls-path
+func (u *URL) shortenPath() {
+	if u.scheme == "file" && len(u.path) == 1 && isNormalizedWindowsDriveLetter(u.path[0]) {
+		return
+	}
+	if len(u.path) > 0 {
+		u.path = u.path[:len(u.path)-1]
+	}
+}
+
+// https://url.spec.whatwg.org/#strip-trailing-spaces-from-an-opaque-path
+func (u *URL) stripTrailingSpacesFromOpaquePath() {
+	if !u.opaque || u.fragment != nil || u.query != nil {
+		return
+	}
+	u.path[0] = strings.TrimRight(u.path[0], " ")
+}
+
+func isASCIIAlpha(r rune) bool { return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') }
+
+func isASCIIDigit(r rune) bool { return r >= '0' && r <= '9' }
+
+func asciiLower(r rune) rune {
+	if r >= 'A' && r <= 'Z' {
+		return r + 'a' - 'A'
+	}
+	return r
+}
+
+// https://url.spec.whatwg.org/#windows-drive-letter
+func isWindowsDriveLetter(s string) bool {
+	return len(s) == 2 && isASCIIAlpha(rune(s[0])) && (s[1] == ':' || s[1] == '|')
+}
+
+// https://url.spec.whatwg.org/#normalized-windows-drive-letter
+func isNormalizedWindowsDriveLetter(s string) bool {
+	return isWindowsDriveLetter(s) && s[1] == ':'
+}
+
+// https://url.spec.whatwg.org/#start-with-a-windows-drive-letter
+func startsWithWindowsDriveLetter(s []rune) bool {
+	if len(s) < 2 || !isWindowsDriveLetter(string(s[:2])) {
+		return false
+	}
+	return len(s) == 2 || strings.ContainsRune("/\\?#", s[2])
+}
+
+// https://url.spec.whatwg.org/#single-dot-path-segment
+func isSingleDotSegment(s string) bool {
+	return s == "." || strings.EqualFold(s, "%2e")
+}
+
+// https://url.spec.whatwg.org/#double-dot-path-segment
+func isDoubleDotSegment(s string) bool {
+	switch strings.ToLower(s) {
+	case "..", ".%2e", "%2e.", "%2e%2e":
+		return true
+	}
+	return false
+}
+
+type urlState int
+
+const (
+	noURLState urlState = iota
+	schemeStartState
+	schemeState
+	noSchemeState
+	specialRelativeOrAuthorityState
+	pathOrAuthorityState
+	relativeState
+	relativeSlashState
+	specialAuthoritySlashesState
+	specialAuthorityIgnoreSlashesState
+	authorityState
+	hostState
+	hostnameState
+	portState
+	fileState
+	fileSlashState
+	fileHostState
+	pathStartState
+	pathState
+	opaquePathState
+	queryState
+	fragmentState
+)
+
+const eof rune = -1
+
+// basicURLParse is the basic URL parser. When u and override are given the
+// URL is modified in place for the setters and the returned error can be
+// ignored.
+// https://url.spec.whatwg.org/#concept-basic-url-parser
+func basicURLParse(input string, base, u *URL, override urlState) (*URL, error) {
+	if u == nil {
+		u = &URL{port: -1}
+		input = strings.TrimFunc(input, func(r rune) bool { return r <= 0x20 })
+	}
+	input = strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' {
+			return -1
+		}
+		return r
+	}, input)
+
+	state := override
+	if state == noURLState {
+		state = schemeStartState
+	}
+	var buffer strings.Builder
+	atSignSeen, insideBrackets, passwordTokenSeen := false, false, false
+	in := []rune(input)
+	pointer := 0
+	at := func(i int) rune {
+		if i >= 0 && i < len(in) {
+			return in[i]
+		}
+		return eof
+	}
+	remainingStartsWith := func(r rune) bool { return at(pointer+1) == r }
+	setQuery := func(s string) { u.query = &s }
+	setFragment := func(s string) { u.fragment = &s }
+	setHost := func(s string) { u.host = &s }
+
+	for ; ; pointer++ {
+		c := at(pointer)
+		special := u.isSpecial()
+		isSlash := c == '/' || (special && c == '\\')
+		endsAuthority := c == eof || c == '/' || c == '?' || c == '#' || (special && c == '\\')
+
+		switch state {
+		case schemeStartState:
+			if isASCIIAlpha(c) {
+				buffer.WriteRune(asciiLower(c))
+				state = schemeState
+			} else if override == noURLState {
+				state = noSchemeState
+				pointer--
+			} else {
+				return u, urlFailure("invalid scheme")
+			}
+
+		case schemeState:
+			if isASCIIAlpha(c) || isASCIIDigit(c) || c == '+' || c == '-' || c == '.' {
+				buffer.WriteRune(asciiLower(c))
+			} else if c == ':' {
+				scheme := buffer.String()
+				if override != noURLState {
+					if u.isSpecial() != isSpecialScheme(scheme) {
+						return u, nil
+					}
+					if (u.IncludesCredentials() || u.port != -1) && scheme == "file" {
+						return u, nil
+					}
+					if u.scheme == "file" && u.host != nil && *u.host == "" {
+						return u, nil
+					}
+				}
+				u.scheme = scheme
+				if override != noURLState {
+					if u.port == defaultPort(u.scheme) {
+						u.port = -1
+					}
+					return u, nil
+				}
+				buffer.Reset()
+				if u.scheme == "file" {
+					state = fileState
+				} else if u.isSpecial() && base != nil && base.scheme == u.scheme {
+					state = specialRelativeOrAuthorityState
+				} else if u.isSpecial() {
+					state = specialAuthoritySlashesState
+				} else if remainingStartsWith('/') {
+					state = pathOrAuthorityState
+					pointer++
+				} else {
+					u.path = []string{""}
+					u.opaque = true
+					state = opaquePathState
+				}
+			} else if override == noURLState {
+				buffer.Reset()
+				state = noSchemeState
+				pointer = -1
+			} else {
+				return u, urlFailure("invalid scheme")
+			}
+
+		case noSchemeState:
+			if base == nil || (base.opaque && c != '#') {
+				return nil, urlFailure("missing scheme, non-relative URL")
+			} else if base.opaque && c == '#' {
+				u.scheme = base.scheme
+				u.path = append([]string{}, base.path...)
+				u.opaque = true
+				u.query = base.query
+				setFragment("")
+				state = fragmentState
+			} else if base.scheme != "file" {
+				state = relativeState
+				pointer--
+			} else {
+				state = fileState
+				pointer--
+			}
+
+		case specialRelativeOrAuthorityState:
+			if c == '/' && remainingStartsWith('/') {
+				state = specialAuthorityIgnoreSlashesState
+				pointer++
+			} else {
+				state = relativeState
+				pointer--
+			}
+
+		case pathOrAuthorityState:
+			if c == '/' {
+				state = authorityState
+			} else {
+				state = pathState
+				pointer--
+			}
+
+		case relativeState:
+			u.scheme = base.scheme
+			special = u.isSpecial()
+			if c == '/' || (special && c == '\\') {
+				state = relativeSlashState
+			} else {
+				u.username, u.password, u.host, u.port = base.username, base.password, base.host, base.port
+				u.path = append([]string{}, base.path...)
+				u.query = base.query
+				if c == '?' {
+					setQuery("")
+					state = queryState
+				} else if c == '#' {
+					setFragment("")
+					state = fragmentState
+				} else if c != eof {
+					u.query = nil
+					u.shortenPath()
+					state = pathState
+					pointer--
+				}
+			}
+
+		case relativeSlashState:
+			if special && (c == '/' || c == '\\') {
+				state = specialAuthorityIgnoreSlashesState
+			} else if c == '/' {
+				state = authorityState
+			} else {
+				u.username, u.password, u.host, u.port = base.username, base.password, base.host, base.port
+				state = pathState
+				pointer--
+			}
+
+		case specialAuthoritySlashesState:
+			state = specialAuthorityIgnoreSlashesState
+			if c == '/' && remainingStartsWith('/') {
+				pointer++
+			} else {
+				pointer--
+			}
+
+		case specialAuthorityIgnoreSlashesState:
+			if c != '/' && c != '\\' {
+				state = authorityState
+				pointer--
+			}
+
+		case authorityState:
+			if c == '@' {
+				credentials := buffer.String()
+				if atSignSeen {
+					credentials = "%40" + credentials
+				}
+				atSignSeen = true
+				for _, r := range credentials {
+					if r == ':' && !passwordTokenSeen {
+						passwordTokenSeen = true
+						continue
+					}
+					encoded := utf8PercentEncode(string(r), userinfoPercentEncodeSet)
+					if passwordTokenSeen {
+						u.password += encoded
+					} else {
+						u.username += encoded
+					}
+				}
+				buffer.Reset()
+			} else if endsAuthority {
+				if atSignSeen && buffer.Len() == 0 {
+					return nil, urlFailure("host missing")
+				}
+				pointer -= len([]rune(buffer.String())) + 1
+				buffer.Reset()
+				state = hostState
+			} else {
+				buffer.WriteRune(c)
+			}
+
+		case hostState, hostnameState:
+			if override != noURLState && u.scheme == "file" {
+				pointer--
+				state = fileHostState
+			} else if c == ':' && !insideBrackets {
+				if buffer.Len() == 0 {
+					return nil, urlFailure("host missing")
+				}
+				if override == hostnameState {
+					return u, urlFailure("unexpected port")
+				}
+				host, err := parseHost(buffer.String(), !special)
+				if err != nil {
+					return nil, err
+				}
+				setHost(host)
+				buffer.Reset()
+				state = portState
+			} else if endsAuthority {
+				pointer--
+				if special && buffer.Len() == 0 {
+					return nil, urlFailure("host missing")
+				} else if override != noURLState && buffer.Len() == 0 && (u.IncludesCredentials() || u.port != -1) {
+					return u, urlFailure("host missing")
+				}
+				host, err := parseHost(buffer.String(), !special)
+				if err != nil {
+					return nil, err
+				}
+				setHost(host)
+				buffer.Reset()
+				state = pathStartState
+				if override != noURLState {
+					return u, nil
+				}
+			} else {
+				if c == '[' {
+					insideBrackets = true
+				} else if c == ']' {
+					insideBrackets = false
+				}
+				buffer.WriteRune(c)
+			}
+
+		case portState:
+			if isASCIIDigit(c) {
+				buffer.WriteRune(c)
+			} else if endsAuthority || override != noURLState {
+				if buffer.Len() != 0 {
+					port := 0
+					for _, d := range buffer.String() {
+						port = port*10 + int(d-'0')
+						if port > 65535 {
+							return nil, urlFailure("port out of range")
+						}
+					}
+					if port == defaultPort(u.scheme) {
+						port = -1
+					}
+					u.port = port
+					buffer.Reset()
+					if override != noURLState {
+						return u, nil
+					}
+				}
+				if override != noURLState {
+					return u, urlFailure("invalid port")
+				}
+				state = pathStartState
+				pointer--
+			} else {
+				return nil, urlFailure("invalid port")
+			}
+
+		case fileState:
+			u.scheme = "file"
+			setHost("")
+			if c == '/' || c == '\\' {
+				state = fileSlashState
+			} else if base != nil && base.scheme == "file" {
+				u.host = base.host
+				u.path = append([]string{}, base.path...)
+				u.query = base.query
+				if c == '?' {
+					setQuery("")
+					state = queryState
+				} else if c == '#' {
+					setFragment("")
+					state = fragmentState
+				} else if c != eof {
+					u.query = nil
+					if !startsWithWindowsDriveLetter(in[pointer:]) {
+						u.shortenPath()
+					} else {
+						u.path = nil
+					}
+					state = pathState
+					pointer--
+				}
+			} else {
+				state = pathState
+				pointer--
+			}
+
+		case fileSlashState:
+			if c == '/' || c == '\\' {
+				state = fileHostState
+			} else {
+				if base != nil && base.scheme == "file" {
+					u.host = base.host
+					if !startsWithWindowsDriveLetter(in[pointer:]) && len(base.path) > 0 && isNormalizedWindowsDriveLetter(base.path[0]) {
+						u.path = append(u.path, base.path[0])
+					}
+				}
+				state = pathState
+				pointer--
+			}
+
+		case fileHostState:
+			if c == eof || c == '/' || c == '\\' || c == '?' || c == '#' {
+				pointer--
+				if override == noURLState && isWindowsDriveLetter(buffer.String()) {
+					state = pathState
+				} else if buffer.Len() == 0 {
+					setHost("")
+					if override != noURLState {
+						return u, nil
+					}
+					state = pathStartState
+				} else {
+					host, err := parseHost(buffer.String(), !special)
+					if err != nil {
+						return nil, err
+					}
+					if host == "localhost" {
+						host = ""
+					}
+					setHost(host)
+					if override != noURLState {
+						return u, nil
+					}
+					buffer.Reset()
+					state = pathStartState
+				}
+			} else {
+				buffer.WriteRune(c)
+			}
+
+		case pathStartState:
+			if special {
+				state = pathState
+				if c != '/' && c != '\\' {
+					pointer--
+				}
+			} else if override == noURLState && c == '?' {
+				setQuery("")
+				state = queryState
+			} else if override == noURLState && c == '#' {
+				setFragment("")
+				state = fragmentState
+			} else if c != eof {
+				state = pathState
+				if c != '/' {
+					pointer--
+				}
+			} else if override != noURLState && u.host == nil {
+				u.path = append(u.path, "")
+			}
+
+		case pathState:
+			if c == eof || isSlash || (override == noURLState && (c == '?' || c == '#')) {
+				segment := buffer.String()
+				if isDoubleDotSegment(segment) {
+					u.shortenPath()
+					if !isSlash {
+						u.path = append(u.path, "")
+					}
+				} else if isSingleDotSegment(segment) && !isSlash {
+					u.path = append(u.path, "")
+				} else if !isSingleDotSegment(segment) {
+					if u.scheme == "file" && len(u.path) == 0 && isWindowsDriveLetter(segment) {
+						segment = segment[:1] + ":"
+					}
+					u.path = append(u.path, segment)
+				}
+				buffer.Reset()
+				if c == '?' {
+					setQuery("")
+					state = queryState
+				} else if c == '#' {
+					setFragment("")
+					state = fragmentState
+				}
+			} else {
+				buffer.WriteString(utf8PercentEncode(string(c), pathPercentEncodeSet))
+			}
+
+		case opaquePathState:
+			if c == '?' {
+				setQuery("")
+				state = queryState
+			} else if c == '#' {
+				setFragment("")
+				state = fragmentState
+			} else if c != eof {
+				u.path[0] += utf8PercentEncode(string(c), c0ControlPercentEncodeSet)
+			}
+
+		case queryState:
+			if (override == noURLState && c == '#') || c == eof {
+				set := queryPercentEncodeSet
+				if special {
+					set = specialQueryPercentEncodeSet
+				}
+				setQuery(*u.query + utf8PercentEncode(buffer.String(), set))
+				buffer.Reset()
+				if c == '#' {
+					setFragment("")
+					state = fragmentState
+				}
+			} else if c != eof {
+				buffer.WriteRune(c)
+			}
+
+		case fragmentState:
+			if c != eof {
+				setFragment(*u.fragment + utf8PercentEncode(string(c), fragmentPercentEncodeSet))
+			}
+		}
+
+		if pointer >= len(in) {
+			break
+		}
+	}
+	return u, nil
+}
+
+// https://url.spec.whatwg.org/#url-path-serializer
+func (u *URL) pathString() string {
+	if u.opaque {
+		return u.path[0]
+	}
+	var b strings.Builder
+	for _, segment := range u.path {
+		b.WriteString("/" + segment)
+	}
+	return b.String()
+}
+
+// https://url.spec.whatwg.org/#concept-url-serializer
+func (u *URL) serialize(excludeFragment bool) string {
+	var b strings.Builder
+	b.WriteString(u.scheme + ":")
+	if u.host != nil {
+		b.WriteString("//")
+		if u.IncludesCredentials() {
+			b.WriteString(u.username)
+			if u.password != "" {
+				b.WriteString(":" + u.password)
+			}
+			b.WriteString("@")
+		}
+		b.WriteString(*u.host)
+		if u.port != -1 {
+			b.WriteString(":" + strconv.Itoa(u.port))
+		}
+	}
+	if u.host == nil && !u.opaque && len(u.path) > 1 && u.path[0] == "" {
+		b.WriteString("/.")
+	}
+	b.WriteString(u.pathString())
+	if u.query != nil {
+		b.WriteString("?" + *u.query)
+	}
+	if !excludeFragment && u.fragment != nil {
+		b.WriteString("#" + *u.fragment)
+	}
+	return b.String()
+}
+
+// Clone returns a copy of the URL record.
+func (u *URL) Clone() *URL {
+	c := *u
+	c.path = append([]string{}, u.path...)
+	c.searchParams = nil
+	return &c
+}
+
+// IsPotentiallyTrustworthy is https://w3c.github.io/webappsec-secure-contexts/#is-url-trustworthy
+func (u *URL) IsPotentiallyTrustworthy() bool {
+	if u.matchesAbout("blank") || u.matchesAbout("srcdoc") || u.scheme == "data" {
+		return true
+	}
+	switch u.scheme {
+	case "https", "wss", "file":
+		return true
+	}
+	host := u.Hostname()
+	return host == "localhost" || strings.HasSuffix(host, ".localhost") ||
+		strings.HasPrefix(host, "127.") || host == "[::1]"
+}
+
+// String is the serialization of the URL.
+func (u *URL) String() string { return u.serialize(false) }
+
+// NetURL converts the URL to a net/url URL for making requests.
+func (u *URL) NetURL() (*url.URL, error) {
+	return url.Parse(u.serialize(false))
+}
+
+// Scheme is the URL's scheme without the trailing colon.
+func (u *URL) Scheme() string { return u.scheme }
+
+// Href is https://url.spec.whatwg.org/#dom-url-href
+func (u *URL) Href() string { return u.serialize(false) }
+
+// SetHref is https://url.spec.whatwg.org/#dom-url-href
+func (u *URL) SetHref(href string) error {
+	parsed, err := ParseURL(href, nil)
+	if err != nil {
+		return err
+	}
+	searchParams := u.searchParams
+	*u = *parsed
+	u.searchParams = searchParams
+	u.updateSearchParams()
+	return nil
+}
+
+// Origin is https://url.spec.whatwg.org/#dom-url-origin
+func (u *URL) Origin() string {
+	// https://url.spec.whatwg.org/#concept-url-origin
+	switch u.scheme {
+	case "blob":
+		if path, err := ParseURL(u.pathString(), nil); err == nil && (path.scheme == "http" || path.scheme == "https") {
+			return path.Origin()
+		}
+	case "ftp", "http", "https", "ws", "wss":
+		// https://html.spec.whatwg.org/multipage/browsers.html#ascii-serialisation-of-an-origin
+		origin := u.scheme + "://" + *u.host
+		if u.port != -1 {
+			origin += ":" + strconv.Itoa(u.port)
+		}
+		return origin
+	}
+	return "null"
+}
+
+// Protocol is https://url.spec.whatwg.org/#dom-url-protocol
+func (u *URL) Protocol() string { return u.scheme + ":" }
+
+// SetProtocol is https://url.spec.whatwg.org/#dom-url-protocol
+func (u *URL) SetProtocol(protocol string) {
+	basicURLParse(protocol+":", nil, u, schemeStartState)
+}
+
+// Username is https://url.spec.whatwg.org/#dom-url-username
+func (u *URL) Username() string { return u.username }
+
+// SetUsername is https://url.spec.whatwg.org/#dom-url-username
+func (u *URL) SetUsername(username string) {
+	if u.cannotHaveUsernamePasswordPort() {
+		return
+	}
+	u.username = utf8PercentEncode(username, userinfoPercentEncodeSet)
+}
+
+// Password is https://url.spec.whatwg.org/#dom-url-password
+func (u *URL) Password() string { return u.password }
+
+// SetPassword is https://url.spec.whatwg.org/#dom-url-password
+func (u *URL) SetPassword(password string) {
+	if u.cannotHaveUsernamePasswordPort() {
+		return
+	}
+	u.password = utf8PercentEncode(password, userinfoPercentEncodeSet)
+}
+
+// Host is https://url.spec.whatwg.org/#dom-url-host
+func (u *URL) Host() string {
+	if u.host == nil {
+		return ""
+	}
+	if u.port == -1 {
+		return *u.host
+	}
+	return *u.host + ":" + strconv.Itoa(u.port)
+}
+
+// SetHost is https://url.spec.whatwg.org/#dom-url-host
+func (u *URL) SetHost(host string) {
+	if u.opaque {
+		return
+	}
+	basicURLParse(host, nil, u, hostState)
+}
+
+// Hostname is https://url.spec.whatwg.org/#dom-url-hostname
+func (u *URL) Hostname() string {
+	if u.host == nil {
+		return ""
+	}
+	return *u.host
+}
+
+// SetHostname is https://url.spec.whatwg.org/#dom-url-hostname
+func (u *URL) SetHostname(hostname string) {
+	if u.opaque {
+		return
+	}
+	basicURLParse(hostname, nil, u, hostnameState)
+}
+
+// Port is https://url.spec.whatwg.org/#dom-url-port
+func (u *URL) Port() string {
+	if u.port == -1 {
+		return ""
+	}
+	return strconv.Itoa(u.port)
+}
+
+// SetPort is https://url.spec.whatwg.org/#dom-url-port
+func (u *URL) SetPort(port string) {
+	if u.cannotHaveUsernamePasswordPort() {
+		return
+	}
+	if port == "" {
+		u.port = -1
+		return
+	}
+	basicURLParse(port, nil, u, portState)
+}
+
+// Pathname is https://url.spec.whatwg.org/#dom-url-pathname
+func (u *URL) Pathname() string { return u.pathString() }
+
+// SetPathname is https://url.spec.whatwg.org/#dom-url-pathname
+func (u *URL) SetPathname(pathname string) {
+	if u.opaque {
+		return
+	}
+	u.path = nil
+	basicURLParse(pathname, nil, u, pathStartState)
+}
+
+// Search is https://url.spec.whatwg.org/#dom-url-search
+func (u *URL) Search() string {
+	if u.query == nil || *u.query == "" {
+		return ""
+	}
+	return "?" + *u.query
+}
+
+// SetSearch is https://url.spec.whatwg.org/#dom-url-search
+func (u *URL) SetSearch(search string) {
+	if search == "" {
+		u.query = nil
+		u.stripTrailingSpacesFromOpaquePath()
+		u.updateSearchParams()
+		return
+	}
+	search = strings.TrimPrefix(search, "?")
+	query := ""
+	u.query = &query
+	basicURLParse(search, nil, u, queryState)
+	u.updateSearchParams()
+}
+
+// SearchParams is https://url.spec.whatwg.org/#dom-url-searchparams
+func (u *URL) SearchParams() *URLSearchParams {
+	if u.searchParams == nil {
+		u.searchParams = &URLSearchParams{url: u}
+		u.updateSearchParams()
+	}
+	return u.searchParams
+}
+
+// updateSearchParams sets the URL's URLSearchParams list from its query.
+func (u *URL) updateSearchParams() {
+	if u.searchParams == nil {
+		return
+	}
+	u.searchParams.list = nil
+	if u.query != nil {
+		u.searchParams.list = parseURLEncoded(*u.query)
+	}
+}
+
+// Hash is https://url.spec.whatwg.org/#dom-url-hash
+func (u *URL) Hash() string {
+	if u.fragment == nil || *u.fragment == "" {
+		return ""
+	}
+	return "#" + *u.fragment
+}
+
+// SetHash is https://url.spec.whatwg.org/#dom-url-hash
+func (u *URL) SetHash(hash string) {
+	if hash == "" {
+		u.fragment = nil
+		u.stripTrailingSpacesFromOpaquePath()
+		return
+	}
+	hash = strings.TrimPrefix(hash, "#")
+	fragment := ""
+	u.fragment = &fragment
+	basicURLParse(hash, nil, u, fragmentState)
+}
@@ -0,0 +1,88 @@
+package spec
+
+// ResolveURL parses url relative to the base URL of the node's document.
+// https://html.spec.whatwg.org/multipage/urls-and-fetching.html#encoding-parsing-a-url
+func (n *Node) ResolveURL(url string) (*URL, error) {
+	return ParseURL(url, n.document().BaseURL())
+}
+
+// reflectURL is the getter of an IDL attribute reflecting a URL content
+// attribute. Values that can't be parsed are returned as they are.
+// https://html.spec.whatwg.org/multipage/common-dom-interfaces.html#reflecting-content-attributes-in-idl-attributes
+func (n *Node) reflectURL(name string) string {
+	if !n.hasAttribute(name) {
+		return ""
+	}
+	value := n.attribute(name)
+	u, err := n.ResolveURL(value)
+	if err != nil {
+		return value
+	}
+	return u.Href()
+}
+
+// Href is the resolved href attribute of a, area, link and base elements.
+// https://html.spec.whatwg.org/multipage/links.html#dom-hyperlink-href
+// https://html.spec.whatwg.org/multipage/semantics.html#dom-link-href
+func (n *Node) Href() string {
+	if isHTMLElement(n, "base") {
+		// https://html.spec.whatwg.org/multipage/semantics.html#dom-base-href
+		href := n.attribute("href")
+		u, err := ParseURL(href, n.document().FallbackBaseURL())
+		if err != nil {
+			return href
+		}
+		return u.Href()
+	}
+	return n.reflectURL("href")
+}
+
+// IsHyperlink reports if the node is an a or area element with an href
+// attribute.
+// https://html.spec.whatwg.org/multipage/links.html#hyperlink
+func (n *Node) IsHyperlink() bool {
+	return (isHTMLElement(n, "a") || isHTMLElement(n, "area")) && n.hasAttribute("href")
+}
+
+// SetHref is https://html.spec.whatwg.org/multipage/links.html#dom-hyperlink-href
+func (n *Node) SetHref(href string) { n.setAttributeValue("href", href) }
+
+// Src is the resolved src attribute of img, script, iframe and media
+// elements.
+// https://html.spec.whatwg.org/multipage/embedded-content.html#dom-img-src
+func (n *Node) Src() string { return n.reflectURL("src") }
+
+// SetSrc is https://html.spec.whatwg.org/multipage/embedded-content.html#dom-img-src
+func (n *Node) SetSrc(src string) { n.SetAttribute("src", src) }
+
+// Action is https://html.spec.whatwg.org/multipage/forms.html#dom-fs-action
+func (n *Node) Action() string { return n.reflectAction("action") }
+
+// FormAction is https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#dom-fs-formaction
+func (n *Node) FormAction() string { return n.reflectAction("formaction") }
+
+// reflectAction is reflectURL except the document's URL is returned for a
+// missing or empty attribute.
+func (n *Node) reflectAction(name string) string {
+	if n.attribute(name) == "" {
+		return n.document().URLRecord().Href()
+	}
+	return n.reflectURL(name)
+}
+
+// SrcsetCandidates returns the image candidates of the srcset attribute of
+// img and source elements with their URLs resolved. Candidates that can't be
+// parsed are dropped.
+// https://html.spec.whatwg.org/multipage/images.html#update-the-source-set
+func (n *Node) SrcsetCandidates() []ImageCandidate {
+	candidates := []ImageCandidate{}
+	for _, candidate := range ParseSrcset(n.attribute("srcset")) {
+		u, err := n.ResolveURL(candidate.URL)
+		if err != nil {
+			continue
+		}
+		candidate.URL = u.Href()
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
@@ -0,0 +1,533 @@
+package spec
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// https://url.spec.whatwg.org/#concept-host-parser
+func parseHost(input string, isOpaque bool) (string, error) {
+	if strings.HasPrefix(input, "[") {
+		if !strings.HasSuffix(input, "]") {
+			return "", urlFailure("IPv6-unclosed")
+		}
+		address, err := parseIPv6([]rune(input[1 : len(input)-1]))
+		if err != nil {
+			return "", err
+		}
+		return "[" + serializeIPv6(address) + "]", nil
+	}
+	if isOpaque {
+		return parseOpaqueHost(input)
+	}
+
+	domain := utf8DecodeWithoutBOM(PercentDecode(input))
+	asciiDomain, err := domainToASCII(domain)
+	if err != nil {
+		return "", err
+	}
+	if strings.IndexFunc(asciiDomain, isForbiddenDomainCodePoint) != -1 {
+		return "", urlFailure("domain-invalid-code-point")
+	}
+	if endsInANumber(asciiDomain) {
+		address, err := parseIPv4(asciiDomain)
+		if err != nil {
+			return "", err
+		}
+		return serializeIPv4(address), nil
+	}
+	return asciiDomain, nil
+}
+
+// https://url.spec.whatwg.org/#forbidden-host-code-point
+func isForbiddenHostCodePoint(r rune) bool {
+	return strings.ContainsRune("\x00\t\n\r #/:<>?@[\\]^|", r)
+}
+
+// https://url.spec.whatwg.org/#forbidden-domain-code-point
+func isForbiddenDomainCodePoint(r rune) bool {
+	return isForbiddenHostCodePoint(r) || r <= 0x1F || r == '%' || r == 0x7F
+}
+
+// https://url.spec.whatwg.org/#concept-opaque-host-parser
+func parseOpaqueHost(input string) (string, error) {
+	if strings.IndexFunc(input, isForbiddenHostCodePoint) != -1 {
+		return "", urlFailure("host-invalid-code-point")
+	}
+	return utf8PercentEncode(input, c0ControlPercentEncodeSet), nil
+}
+
+// https://url.spec.whatwg.org/#ends-in-a-number-checker
+func endsInANumber(input string) bool {
+	parts := strings.Split(input, ".")
+	if parts[len(parts)-1] == "" {
+		if len(parts) == 1 {
+			return false
+		}
+		parts = parts[:len(parts)-1]
+	}
+	last := parts[len(parts)-1]
+	if last != "" && strings.Trim(last, "0123456789") == "" {
+		return true
+	}
+	_, err := parseIPv4Number(last)
+	return err == nil
+}
+
+// https://url.spec.whatwg.org/#concept-ipv4-parser
+func parseIPv4(input string) (uint32, error) {
+	parts := strings.Split(input, ".")
+	if parts[len(parts)-1] == "" && len(parts) > 1 {
+		parts = parts[:len(parts)-1]
+	}
+	if len(parts) > 4 {
+		return 0, urlFailure("IPv4-too-many-parts")
+	}
+	numbers := make([]uint64, len(parts))
+	for i, part := range parts {
+		n, err := parseIPv4Number(part)
+		if err != nil {
+			return 0, urlFailure("IPv4-non-numeric-part")
+		}
+		numbers[i] = n
+	}
+	for _, n := range numbers[:len(numbers)-1] {
+		if n > 255 {
+			return 0, urlFailure("IPv4-out-of-range-part")
+		}
+	}
+	last := numbers[len(numbers)-1]
+	if last >= uint64(math.Pow(256, float64(5-len(numbers)))) {
+		return 0, urlFailure("IPv4-out-of-range-part")
+	}
+	ipv4 := last
+	for i, n := range numbers[:len(numbers)-1] {
+		ipv4 += n << (8 * uint(3-i))
+	}
+	return uint32(ipv4), nil
+}
+
+var errIPv4Number = errors.New("not an IPv4 number")
+
+// https://url.spec.whatwg.org/#ipv4-number-parser
+func parseIPv4Number(input string) (uint64, error) {
+	if input == "" {
+		return 0, errIPv4Number
+	}
+	radix := 10
+	switch {
+	case len(input) >= 2 && (input[:2] == "0x" || input[:2] == "0X"):
+		input, radix = input[2:], 16
+	case len(input) >= 2 && input[0] == '0':
+		input, radix = input[1:], 8
+	}
+	if input == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseUint(input, radix, 64)
+	if err != nil {
+		var numErr *strconv.NumError
+		if errors.As(err, &numErr) && numErr.Err == strconv.ErrRange {
+			// too large for any part but still a number.
+			return math.MaxUint64, nil
+		}
+		return 0, errIPv4Number
+	}
+	return n, nil
+}
+
+// https://url.spec.whatwg.org/#concept-ipv4-serializer
+func serializeIPv4(address uint32) string {
+	parts := make([]string, 4)
+	for i := range parts {
+		parts[3-i] = strconv.Itoa(int(address & 0xFF))
+		address >>= 8
+	}
+	return strings.Join(parts, ".")
+}
+
+// https://url.spec.whatwg.org/#concept-ipv6-parser
+func parseIPv6(input []rune) ([8]uint16, error) {
+	var address [8]uint16
+	pieceIndex, compress, pointer := 0, -1, 0
+	c := func() rune {
+		if pointer < len(input) {
+			return input[pointer]
+		}
+		return eof
+	}
+	isDigit := func(r rune) bool { return r >= '0' && r <= '9' }
+
+	if c() == ':' {
+		if pointer+1 >= len(input) || input[pointer+1] != ':' {
+			return address, urlFailure("IPv6-invalid-compression")
+		}
+		pointer += 2
+		pieceIndex++
+		compress = pieceIndex
+	}
+	for c() != eof {
+		if pieceIndex == 8 {
+			return address, urlFailure("IPv6-too-many-pieces")
+		}
+		if c() == ':' {
+			if compress != -1 {
+				return address, urlFailure("IPv6-multiple-compression")
+			}
+			pointer++
+			pieceIndex++
+			compress = pieceIndex
+			continue
+		}
+
+		value, length := 0, 0
+		for length < 4 && isASCIIHexDigit(c()) {
+			value = value*0x10 + int(hexValue(c()))
+			pointer++
+			length++
+		}
+		if c() == '.' {
+			if length == 0 {
+				return address, urlFailure("IPv4-in-IPv6-invalid-code-point")
+			}
+			pointer -= length
+			if pieceIndex > 6 {
+				return address, urlFailure("IPv4-in-IPv6-too-many-pieces")
+			}
+			numbersSeen := 0
+			for c() != eof {
+				ipv4Piece := -1
+				if numbersSeen > 0 {
+					if c() != '.' || numbersSeen >= 4 {
+						return address, urlFailure("IPv4-in-IPv6-invalid-code-point")
+					}
+					pointer++
+				}
+				if !isDigit(c()) {
+					return address, urlFailure("IPv4-in-IPv6-invalid-code-point")
+				}
+				for isDigit(c()) {
+					number := int(c() - '0')
+					switch ipv4Piece {
+					case -1:
+						ipv4Piece = number
+					case 0:
+						return address, urlFailure("IPv4-in-IPv6-invalid-code-point")
+					default:
+						ipv4Piece = ipv4Piece*10 + number
+					}
+					if ipv4Piece > 255 {
+						return address, urlFailure("IPv4-in-IPv6-out-of-range-part")
+					}
+					pointer++
+				}
+				address[pieceIndex] = address[pieceIndex]*0x100 + uint16(ipv4Piece)
+				numbersSeen++
+				if numbersSeen == 2 || numbersSeen == 4 {
+					pieceIndex++
+				}
+			}
+			if numbersSeen != 4 {
+				return address, urlFailure("IPv4-in-IPv6-too-few-parts")
+			}
+			break
+		} else if c() == ':' {
+			pointer++
+			if c() == eof {
+				return address, urlFailure("IPv6-invalid-code-point")
+			}
+		} else if c() != eof {
+			return address, urlFailure("IPv6-invalid-code-point")
+		}
+		address[pieceIndex] = uint16(value)
+		pieceIndex++
+	}
+
+	if compress != -1 {
+		swaps := pieceIndex - compress
+		pieceIndex = 7
+		for pieceIndex != 0 && swaps > 0 {
+			address[pieceIndex], address[compress+swaps-1] = address[compress+swaps-1], address[pieceIndex]
+			pieceIndex--
+			swaps--
+		}
+	} else if pieceIndex != 8 {
+		return address, urlFailure("IPv6-too-few-pieces")
+	}
+	return address, nil
+}
+
+// https://url.spec.whatwg.org/#concept-ipv6-serializer
+func serializeIPv6(address [8]uint16) string {
+	// compress the first longest run of two or more zero pieces.
+	compress, longest := -1, 1
+	for i := 0; i < 8; {
+		if address[i] != 0 {
+			i++
+			continue
+		}
+		start := i
+		for i < 8 && address[i] == 0 {
+			i++
+		}
+		if i-start > longest {
+			compress, longest = start, i-start
+		}
+	}
+
+	var b strings.Builder
+	ignore0 := false
+	for i, piece := range address {
+		if ignore0 && piece == 0 {
+			continue
+		}
+		ignore0 = false
+		if compress == i {
+			if i == 0 {
+				b.WriteString("::")
+			} else {
+				b.WriteString(":")
+			}
+			ignore0 = true
+			continue
+		}
+		b.WriteString(strconv.FormatUint(uint64(piece), 16))
+		if i != 7 {
+			b.WriteString(":")
+		}
+	}
+	return b.String()
+}
+
+// domainToASCII is https://url.spec.whatwg.org/#concept-domain-to-ascii with
+// beStrict false. There are no IDNA tables so UTS #46 mapping is approximated
+// by simple lowercasing, mapping full-width forms and dots and removing the
+// common ignored code points. The bidi and joiner checks aren't done.
+func domainToASCII(domain string) (string, error) {
+	mapped := nfc(strings.Map(uts46Map, domain))
+	labels := strings.Split(mapped, ".")
+	for i, label := range labels {
+		if strings.HasPrefix(label, "xn--") {
+			decoded, err := punycodeDecode(label[4:])
+			if err != nil || !validDecodedLabel(string(decoded)) {
+				return "", urlFailure("domain-to-ASCII")
+			}
+			continue
+		}
+		if strings.IndexFunc(label, func(r rune) bool { return r >= 0x80 }) == -1 {
+			continue
+		}
+		if r := []rune(label)[0]; unicode.Is(unicode.M, r) {
+			return "", urlFailure("domain-to-ASCII")
+		}
+		encoded, err := punycodeEncode([]rune(label))
+		if err != nil {
+			return "", urlFailure("domain-to-ASCII")
+		}
+		labels[i] = "xn--" + encoded
+	}
+	result := strings.Join(labels, ".")
+	if result == "" {
+		return "", urlFailure("domain-to-ASCII")
+	}
+	return result, nil
+}
+
+// validDecodedLabel checks the validity criteria of a label decoded from
+// punycode. It has to be what mapping and normalizing the label would give,
+// so mapped code points like full-width or compatibility forms are invalid.
+// https://www.unicode.org/reports/tr46/#Validity_Criteria
+func validDecodedLabel(label string) bool {
+	if label == "" || strings.HasPrefix(label, "xn--") || nfc(label) != label {
+		return false
+	}
+	ascii := true
+	for i, r := range label {
+		switch {
+		case r >= 0x80:
+			ascii = false
+		case r == '.':
+			return false
+		}
+		if i == 0 && unicode.Is(unicode.M, r) {
+			return false
+		}
+		if uts46Map(r) != r || unicode.Is(compatibilityMapped, r) || !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return !ascii
+}
+
+// https://www.unicode.org/reports/tr46/#IDNA_Mapping_Table
+func uts46Map(r rune) rune {
+	switch {
+	case r == 0xAD, r == 0x34F, r == 0x200B, r == 0x2060, r == 0xFEFF,
+		r >= 0x180B && r <= 0x180D, r >= 0xFE00 && r <= 0xFE0F:
+		return -1
+	case r == 0x3002, r == 0xFF0E, r == 0xFF61:
+		return '.'
+	case r >= 0xFF01 && r <= 0xFF5E:
+		r -= 0xFEE0
+	}
+	return unicode.ToLower(r)
+}
+
+// https://www.rfc-editor.org/rfc/rfc3492#section-5
+const (
+	punycodeBase        = 36
+	punycodeTMin        = 1
+	punycodeTMax        = 26
+	punycodeSkew        = 38
+	punycodeDamp        = 700
+	punycodeInitialBias = 72
+	punycodeInitialN    = 128
+)
+
+var errPunycode = errors.New("invalid punycode")
+
+// https://www.rfc-editor.org/rfc/rfc3492#section-6.1
+func punycodeAdapt(delta, numPoints int, firstTime bool) int {
+	if firstTime {
+		delta /= punycodeDamp
+	} else {
+		delta /= 2
+	}
+	delta += delta / numPoints
+	k := 0
+	for delta > ((punycodeBase-punycodeTMin)*punycodeTMax)/2 {
+		delta /= punycodeBase - punycodeTMin
+		k += punycodeBase
+	}
+	return k + (punycodeBase-punycodeTMin+1)*delta/(delta+punycodeSkew)
+}
+
+func punycodeThreshold(k, bias int) int {
+	t := k - bias
+	if t < punycodeTMin {
+		return punycodeTMin
+	}
+	if t > punycodeTMax {
+		return punycodeTMax
+	}
+	return t
+}
+
+func punycodeDigit(d int) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+	return byte('0' + d - 26)
+}
+
+// https://www.rfc-editor.org/rfc/rfc3492#section-6.3
+func punycodeEncode(input []rune) (string, error) {
+	out := []byte{}
+	for _, r := range input {
+		if r < 0x80 {
+			out = append(out, byte(r))
+		}
+	}
+	b := len(out)
+	h := b
+	if b > 0 {
+		out = append(out, '-')
+	}
+	n, delta, bias := punycodeInitialN, 0, punycodeInitialBias
+	for h < len(input) {
+		m := math.MaxInt32
+		for _, r := range input {
+			if int(r) >= n && int(r) < m {
+				m = int(r)
+			}
+		}
+		if (m - n) > (math.MaxInt32-delta)/(h+1) {
+			return "", errPunycode
+		}
+		delta += (m - n) * (h + 1)
+		n = m
+		for _, r := range input {
+			if int(r) < n {
+				delta++
+			}
+			if int(r) != n {
+				continue
+			}
+			q := delta
+			for k := punycodeBase; ; k += punycodeBase {
+				t := punycodeThreshold(k, bias)
+				if q < t {
+					break
+				}
+				out = append(out, punycodeDigit(t+(q-t)%(punycodeBase-t)))
+				q = (q - t) / (punycodeBase - t)
+			}
+			out = append(out, punycodeDigit(q))
+			bias = punycodeAdapt(delta, h+1, h == b)
+			delta = 0
+			h++
+		}
+		delta++
+		n++
+	}
+	return string(out), nil
+}
+
+// https://www.rfc-editor.org/rfc/rfc3492#section-6.2
+func punycodeDecode(input string) ([]rune, error) {
+	output := []rune{}
+	if pos := strings.LastIndexByte(input, '-'); pos >= 0 {
+		for i := 0; i < pos; i++ {
+			if input[i] >= 0x80 {
+				return nil, errPunycode
+			}
+			output = append(output, rune(input[i]))
+		}
+		input = input[pos+1:]
+	}
+	n, i, bias := punycodeInitialN, 0, punycodeInitialBias
+	for in := 0; in < len(input); {
+		oldi, w := i, 1
+		for k := punycodeBase; ; k += punycodeBase {
+			if in >= len(input) {
+				return nil, errPunycode
+			}
+			var d int
+			switch c := input[in]; {
+			case c >= 'a' && c <= 'z':
+				d = int(c - 'a')
+			case c >= 'A' && c <= 'Z':
+				d = int(c - 'A')
+			case c >= '0' && c <= '9':
+				d = int(c-'0') + 26
+			default:
+				return nil, errPunycode
+			}
+			in++
+			if d > (math.MaxInt32-i)/w {
+				return nil, errPunycode
+			}
+			i += d * w
+			t := punycodeThreshold(k, bias)
+			if d < t {
+				break
+			}
+			if w > math.MaxInt32/(punycodeBase-t) {
+				return nil, errPunycode
+			}
+			w *= punycodeBase - t
+		}
+		bias = punycodeAdapt(i-oldi, len(output)+1, oldi == 0)
+		n += i / (len(output) + 1)
+		i %= len(output) + 1
+		if n > unicode.MaxRune {
+			return nil, errPunycode
+		}
+		output = append(output[:i], append([]rune{rune(n)}, output[i:]...)...)
+		i++
+	}
+	return output, nil
+}
@@ -0,0 +1,100 @@
+package spec
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// percentEncodeSet reports if a code point has to be percent-encoded.
+// https://url.spec.whatwg.org/#percent-encoded-bytes
+type percentEncodeSet func(r rune) bool
+
+// https://url.spec.whatwg.org/#c0-control-percent-encode-set
+func c0ControlPercentEncodeSet(r rune) bool {
+	return r <= 0x1F || r > 0x7E
+}
+
+// https://url.spec.whatwg.org/#fragment-percent-encode-set
+func fragmentPercentEncodeSet(r rune) bool {
+	return c0ControlPercentEncodeSet(r) || strings.ContainsRune(" \"<>`", r)
+}
+
+// https://url.spec.whatwg.org/#query-percent-encode-set
+func queryPercentEncodeSet(r rune) bool {
+	return c0ControlPercentEncodeSet(r) || strings.ContainsRune(" \"#<>", r)
+}
+
+// https://url.spec.whatwg.org/#special-query-percent-encode-set
+func specialQueryPercentEncodeSet(r rune) bool {
+	return queryPercentEncodeSet(r) || r == '\''
+}
+
+// https://url.spec.whatwg.org/#path-percent-encode-set
+func pathPercentEncodeSet(r rune) bool {
+	return queryPercentEncodeSet(r) || strings.ContainsRune("?^`{}", r)
+}
+
+// https://url.spec.whatwg.org/#userinfo-percent-encode-set
+func userinfoPercentEncodeSet(r rune) bool {
+	return pathPercentEncodeSet(r) || strings.ContainsRune("/:;=@[\\]|", r)
+}
+
+// https://url.spec.whatwg.org/#component-percent-encode-set
+func componentPercentEncodeSet(r rune) bool {
+	return userinfoPercentEncodeSet(r) || strings.ContainsRune("$%&+,", r)
+}
+
+// utf8PercentEncode percent-encodes the UTF-8 bytes of the code points of s
+// in the set.
+// https://url.spec.whatwg.org/#string-utf-8-percent-encode
+func utf8PercentEncode(s string, set percentEncodeSet) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for _, r := range s {
+		if !set(r) {
+			b.WriteRune(r)
+			continue
+		}
+		var buf [utf8.UTFMax]byte
+		for _, c := range buf[:utf8.EncodeRune(buf[:], r)] {
+			b.WriteByte('%')
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&0xF])
+		}
+	}
+	return b.String()
+}
+
+// PercentDecode is https://url.spec.whatwg.org/#percent-decode
+func PercentDecode(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) && isASCIIHexDigit(rune(s[i+1])) && isASCIIHexDigit(rune(s[i+2])) {
+			out = append(out, hexValue(rune(s[i+1]))<<4|hexValue(rune(s[i+2])))
+			i += 2
+			continue
+		}
+		out = append(out, s[i])
+	}
+	return out
+}
+
+// utf8DecodeWithoutBOM replaces invalid UTF-8 with U+FFFD.
+// https://encoding.spec.whatwg.org/#utf-8-decode-without-bom
+func utf8DecodeWithoutBOM(b []byte) string {
+	return strings.ToValidUTF8(string(b), "�")
+}
+
+func isASCIIHexDigit(r rune) bool {
+	return (r >= '0' && r <= '9') || (r >= 'a' && r <= 'f') || (r >= 'A' && r <= 'F')
+}
+
+func hexValue(r rune) byte {
+	switch {
+	case r >= '0' && r <= '9':
+		return byte(r - '0')
+	case r >= 'a' && r <= 'f':
+		return byte(r-'a') + 10
+	}
+	return byte(r-'A') + 10
+}
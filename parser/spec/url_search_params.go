@@ -0,0 +1,165 @@
+package spec
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// URLSearchParams is https://url.spec.whatwg.org/#interface-urlsearchparams
+type URLSearchParams struct {
+	list [][2]string
+	// url is the URL whose query the list is, if any.
+	url *URL
+}
+
+// NewURLSearchParams parses init as an application/x-www-form-urlencoded
+// string. A leading "?" is ignored.
+// https://url.spec.whatwg.org/#dom-urlsearchparams-urlsearchparams
+func NewURLSearchParams(init string) *URLSearchParams {
+	return &URLSearchParams{list: parseURLEncoded(strings.TrimPrefix(init, "?"))}
+}
+
+// https://url.spec.whatwg.org/#concept-urlencoded-parser
+func parseURLEncoded(input string) [][2]string {
+	list := [][2]string{}
+	for _, sequence := range strings.Split(input, "&") {
+		if sequence == "" {
+			continue
+		}
+		name, value := sequence, ""
+		if i := strings.IndexByte(sequence, '='); i >= 0 {
+			name, value = sequence[:i], sequence[i+1:]
+		}
+		name = utf8DecodeWithoutBOM(PercentDecode(strings.ReplaceAll(name, "+", " ")))
+		value = utf8DecodeWithoutBOM(PercentDecode(strings.ReplaceAll(value, "+", " ")))
+		list = append(list, [2]string{name, value})
+	}
+	return list
+}
+
+// https://url.spec.whatwg.org/#concept-urlsearchparams-update
+func (p *URLSearchParams) update() {
+	if p.url == nil {
+		return
+	}
+	serialized := p.String()
+	if serialized == "" {
+		p.url.query = nil
+		p.url.stripTrailingSpacesFromOpaquePath()
+		return
+	}
+	p.url.query = &serialized
+}
+
+// Size is https://url.spec.whatwg.org/#dom-urlsearchparams-size
+func (p *URLSearchParams) Size() int { return len(p.list) }
+
+// Append is https://url.spec.whatwg.org/#dom-urlsearchparams-append
+func (p *URLSearchParams) Append(name, value string) {
+	p.list = append(p.list, [2]string{name, value})
+	p.update()
+}
+
+// Delete removes the pairs named name. When value is given only the pair with
+// that value is removed.
+// https://url.spec.whatwg.org/#dom-urlsearchparams-delete
+func (p *URLSearchParams) Delete(name string, value ...string) {
+	list := p.list[:0]
+	for _, pair := range p.list {
+		if pair[0] == name && (len(value) == 0 || pair[1] == value[0]) {
+			continue
+		}
+		list = append(list, pair)
+	}
+	p.list = list
+	p.update()
+}
+
+// Get is https://url.spec.whatwg.org/#dom-urlsearchparams-get
+func (p *URLSearchParams) Get(name string) (string, bool) {
+	for _, pair := range p.list {
+		if pair[0] == name {
+			return pair[1], true
+		}
+	}
+	return "", false
+}
+
+// GetAll is https://url.spec.whatwg.org/#dom-urlsearchparams-getall
+func (p *URLSearchParams) GetAll(name string) []string {
+	values := []string{}
+	for _, pair := range p.list {
+		if pair[0] == name {
+			values = append(values, pair[1])
+		}
+	}
+	return values
+}
+
+// Has is https://url.spec.whatwg.org/#dom-urlsearchparams-has
+func (p *URLSearchParams) Has(name string, value ...string) bool {
+	for _, pair := range p.list {
+		if pair[0] == name && (len(value) == 0 || pair[1] == value[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Set is https://url.spec.whatwg.org/#dom-urlsearchparams-set
+func (p *URLSearchParams) Set(name, value string) {
+	list := p.list[:0]
+	found := false
+	for _, pair := range p.list {
+		if pair[0] != name {
+			list = append(list, pair)
+		} else if !found {
+			found = true
+			list = append(list, [2]string{name, value})
+		}
+	}
+	if !found {
+		list = append(list, [2]string{name, value})
+	}
+	p.list = list
+	p.update()
+}
+
+// Sort is https://url.spec.whatwg.org/#dom-urlsearchparams-sort
+func (p *URLSearchParams) Sort() {
+	sort.SliceStable(p.list, func(i, j int) bool {
+		return lessUTF16(p.list[i][0], p.list[j][0])
+	})
+	p.update()
+}
+
+// lessUTF16 compares strings by their UTF-16 code units.
+func lessUTF16(a, b string) bool {
+	ua, ub := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return ua[i] < ub[i]
+		}
+	}
+	return len(ua) < len(ub)
+}
+
+// Pairs returns the name-value pairs in order.
+func (p *URLSearchParams) Pairs() [][2]string {
+	return append([][2]string{}, p.list...)
+}
+
+// String is https://url.spec.whatwg.org/#concept-urlencoded-serializer
+func (p *URLSearchParams) String() string {
+	var b strings.Builder
+	for i, pair := range p.list {
+		if i > 0 {
+			b.WriteByte('&')
+		}
+		b.WriteString(urlencode(pair[0]))
+		b.WriteByte('=')
+		b.WriteString(urlencode(pair[1]))
+	}
+	return b.String()
+}
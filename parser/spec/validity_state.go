@@ -1,3 +1,12 @@
 package spec
 
-type ValidityState struct{}
+// ValidityState is https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#validitystate
+type ValidityState struct {
+	ValidityStateFlags
+	// Valid is set when none of the flags are.
+	Valid bool
+}
+
+func newValidityState(flags ValidityStateFlags) ValidityState {
+	return ValidityState{ValidityStateFlags: flags, Valid: !flags.any()}
+}
@@ -1,3 +1,12 @@
 package spec
 
-type ValidityStateFlags struct{}
+// ValidityStateFlags is https://html.spec.whatwg.org/multipage/custom-elements.html#validitystateflags
+type ValidityStateFlags struct {
+	ValueMissing, TypeMismatch, PatternMismatch, TooLong, TooShort     bool
+	RangeUnderflow, RangeOverflow, StepMismatch, BadInput, CustomError bool
+}
+
+// any reports if any of the flags are set.
+func (f ValidityStateFlags) any() bool {
+	return f != ValidityStateFlags{}
+}
@@ -0,0 +1,315 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/heathj/gobrowse/parser/spec"
+)
+
+// TableExportConfig configures how a TableExporter turns tables into records.
+type TableExportConfig struct {
+	// CellText returns the text of a td or th element. It defaults to the
+	// exporter's CellText method.
+	CellText func(cell *spec.Node) string
+	// NestedTableText is the text a table nested in a cell is replaced with.
+	// index is the nested table's position in the exported tables.
+	NestedTableText func(index int) string
+	// RepeatSpans repeats the text of cells that span several rows or
+	// columns in every slot they cover instead of only the first.
+	RepeatSpans bool
+	// HeaderRows is the number of header rows. Zero detects them from thead
+	// elements or leading rows of th elements and a negative number means
+	// there aren't any.
+	HeaderRows int
+}
+
+// DefaultTableExportConfig repeats spanning cells and detects header rows.
+var DefaultTableExportConfig = TableExportConfig{
+	NestedTableText: func(index int) string { return fmt.Sprintf("[table %d]", index) },
+	RepeatSpans:     true,
+}
+
+// ExportedTable is a table as rectangular records.
+type ExportedTable struct {
+	Node *spec.Node
+	// Header has a name for every column or is nil when the table has no
+	// header rows.
+	Header  []string
+	Records [][]string
+}
+
+// TableExporter exports the tables of a document.
+type TableExporter struct {
+	config  TableExportConfig
+	indexes map[*spec.Node]int
+}
+
+// NewTableExporter creates a TableExporter for the configuration.
+func NewTableExporter(config TableExportConfig) *TableExporter {
+	if config.NestedTableText == nil {
+		config.NestedTableText = DefaultTableExportConfig.NestedTableText
+	}
+	return &TableExporter{config: config}
+}
+
+// Export returns every HTML table in node, nested ones included, in tree
+// order.
+func (e *TableExporter) Export(node *spec.Node) []*ExportedTable {
+	tables := e.index(node)
+	exported := make([]*ExportedTable, 0, len(tables))
+	for _, table := range tables {
+		exported = append(exported, e.ExportTable(table))
+	}
+	return exported
+}
+
+// index numbers the tables in node in tree order for NestedTableText.
+func (e *TableExporter) index(node *spec.Node) []*spec.Node {
+	tables := []*spec.Node{}
+	var find func(n *spec.Node)
+	find = func(n *spec.Node) {
+		if n.NodeType == spec.ElementNode && n.NamespaceURI == spec.Htmlns && n.NodeName == "table" {
+			tables = append(tables, n)
+		}
+		for _, child := range n.ChildNodes {
+			find(child)
+		}
+	}
+	find(node)
+
+	e.indexes = make(map[*spec.Node]int, len(tables))
+	for i, table := range tables {
+		e.indexes[table] = i
+	}
+	return tables
+}
+
+// ExportTable returns a table element's records. Rows and columns that cells
+// span are expanded so every record has a field for each column. Unless it's
+// called by Export, nested tables are numbered from the table.
+func (e *TableExporter) ExportTable(table *spec.Node) *ExportedTable {
+	if _, ok := e.indexes[table]; !ok {
+		e.index(table)
+	}
+	m := table.HTMLTable.Model()
+	cellText := e.config.CellText
+	if cellText == nil {
+		cellText = e.CellText
+	}
+	texts := map[*spec.TableCell]string{}
+	grid := make([][]string, m.Height)
+	for y := range grid {
+		grid[y] = make([]string, m.Width)
+		for x := range grid[y] {
+			cell := m.Cell(x, y)
+			if cell == nil || (!e.config.RepeatSpans && (cell.X != x || cell.Y != y)) {
+				continue
+			}
+			text, ok := texts[cell]
+			if !ok {
+				text = cellText(cell.Node)
+				texts[cell] = text
+			}
+			grid[y][x] = text
+		}
+	}
+
+	exported := &ExportedTable{Node: table, Records: grid}
+	headerRows := e.headerRows(m)
+	if headerRows > 0 {
+		exported.Header = headerNames(m, grid[:headerRows], texts)
+		exported.Records = grid[headerRows:]
+	}
+	return exported
+}
+
+// headerRows returns the number of rows of the header.
+func (e *TableExporter) headerRows(m *spec.TableModel) int {
+	if e.config.HeaderRows != 0 {
+		if e.config.HeaderRows < 0 {
+			return 0
+		}
+		if e.config.HeaderRows > m.Height {
+			return m.Height
+		}
+		return e.config.HeaderRows
+	}
+	for _, group := range m.RowGroups {
+		if group.Node.NodeName == "thead" && group.Start == 0 {
+			return group.Span
+		}
+	}
+	rows := 0
+	for y := 0; y < m.Height; y++ {
+		for x := 0; x < m.Width; x++ {
+			if cell := m.Cell(x, y); cell == nil || !cell.Header {
+				return rows
+			}
+		}
+		rows++
+	}
+	// a table of only header cells has records rather than a header.
+	return 0
+}
+
+// headerNames joins the header rows' text of each column into a unique
+// name. Text is only repeated for columns that span header rows.
+func headerNames(m *spec.TableModel, rows [][]string, texts map[*spec.TableCell]string) []string {
+	names := make([]string, m.Width)
+	seen := map[string]int{}
+	for x := range names {
+		parts := []string{}
+		var previous *spec.TableCell
+		for y := range rows {
+			cell := m.Cell(x, y)
+			if cell == nil || cell == previous {
+				continue
+			}
+			previous = cell
+			if text := texts[cell]; text != "" {
+				parts = append(parts, text)
+			}
+		}
+		name := strings.Join(parts, " ")
+		if name == "" {
+			name = fmt.Sprintf("column %d", x+1)
+		}
+		seen[name]++
+		if seen[name] > 1 {
+			name = fmt.Sprintf("%s (%d)", name, seen[name])
+		}
+		names[x] = name
+	}
+	return names
+}
+
+// CellText returns the text of a cell with white space collapsed, line breaks
+// kept and nested tables replaced by NestedTableText.
+func (e *TableExporter) CellText(cell *spec.Node) string {
+	var b strings.Builder
+	var write func(n *spec.Node)
+	write = func(n *spec.Node) {
+		for _, child := range n.ChildNodes {
+			switch child.NodeType {
+			case spec.TextNode:
+				b.WriteString(child.Text.Data)
+				continue
+			case spec.ElementNode:
+			default:
+				continue
+			}
+			switch child.NodeName {
+			case "script", "style", "template":
+			case "br":
+				b.WriteString("\n")
+			case "table":
+				b.WriteString(" " + e.config.NestedTableText(e.indexes[child]) + " ")
+			case "p", "div", "li", "tr":
+				b.WriteString("\n")
+				write(child)
+				b.WriteString("\n")
+			default:
+				write(child)
+			}
+		}
+	}
+	write(cell)
+
+	lines := []string{}
+	for _, line := range strings.Split(b.String(), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// WriteCSV writes the header, if there is one, and the records as CSV.
+func (t *ExportedTable) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if t.Header != nil {
+		if err := cw.Write(t.Header); err != nil {
+			return err
+		}
+	}
+	if err := cw.WriteAll(t.Records); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// WriteTSV writes the header, if there is one, and the records as
+// tab-separated values. Tabs and line breaks in fields become spaces since
+// the format can't escape them.
+func (t *ExportedTable) WriteTSV(w io.Writer) error {
+	clean := strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ", "\r", " ")
+	var b bytes.Buffer
+	writeRow := func(fields []string) {
+		for i, field := range fields {
+			if i > 0 {
+				b.WriteByte('\t')
+			}
+			b.WriteString(clean.Replace(field))
+		}
+		b.WriteByte('\n')
+	}
+	if t.Header != nil {
+		writeRow(t.Header)
+	}
+	for _, record := range t.Records {
+		writeRow(record)
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// WriteJSON writes the records as a JSON array. Records are objects keyed by
+// the header names, or arrays of strings when there's no header.
+func (t *ExportedTable) WriteJSON(w io.Writer) error {
+	b, err := t.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// MarshalJSON encodes the records like WriteJSON. The object keys keep the
+// column order.
+func (t *ExportedTable) MarshalJSON() ([]byte, error) {
+	if t.Header == nil {
+		return json.Marshal(t.Records)
+	}
+	var b bytes.Buffer
+	b.WriteByte('[')
+	for i, record := range t.Records {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('{')
+		for x, name := range t.Header {
+			if x > 0 {
+				b.WriteByte(',')
+			}
+			key, err := json.Marshal(name)
+			if err != nil {
+				return nil, err
+			}
+			value, err := json.Marshal(record[x])
+			if err != nil {
+				return nil, err
+			}
+			b.Write(key)
+			b.WriteByte(':')
+			b.Write(value)
+		}
+		b.WriteByte('}')
+	}
+	b.WriteByte(']')
+	return b.Bytes(), nil
+}
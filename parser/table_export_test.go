@@ -0,0 +1,60 @@
+package parser
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/heathj/gobrowse/parser/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTableExport(t *testing.T) {
+	doc := parseTestDocument(t, `<table>
+<thead><tr><th rowspan=2>Name<th colspan=2>Score</tr><tr><th>Q1<th>Q2</tr></thead>
+<tr><td>Ann<br>Lee<td colspan=2>  7 </tr>
+<tr><td>Bo<td>5<td><table><tr><td>nested</table></tr>
+</table>`)
+	tables := NewTableExporter(DefaultTableExportConfig).Export(doc)
+	assert.Len(t, tables, 2)
+	assert.Equal(t, []string{"Name", "Score Q1", "Score Q2"}, tables[0].Header)
+	assert.Equal(t, [][]string{{"Ann\nLee", "7", "7"}, {"Bo", "5", "[table 1]"}}, tables[0].Records)
+	assert.Nil(t, tables[1].Header)
+	assert.Equal(t, [][]string{{"nested"}}, tables[1].Records)
+
+	var b bytes.Buffer
+	assert.NoError(t, tables[0].WriteCSV(&b))
+	assert.Equal(t, "Name,Score Q1,Score Q2\n\"Ann\nLee\",7,7\nBo,5,[table 1]\n", b.String())
+	b.Reset()
+	assert.NoError(t, tables[0].WriteTSV(&b))
+	assert.Equal(t, "Name\tScore Q1\tScore Q2\nAnn Lee\t7\t7\nBo\t5\t[table 1]\n", b.String())
+	b.Reset()
+	assert.NoError(t, tables[0].WriteJSON(&b))
+	assert.Equal(t, `[{"Name":"Ann\nLee","Score Q1":"7","Score Q2":"7"},{"Name":"Bo","Score Q1":"5","Score Q2":"[table 1]"}]`, b.String())
+	b.Reset()
+	assert.NoError(t, tables[1].WriteJSON(&b))
+	assert.Equal(t, `[["nested"]]`, b.String())
+}
+
+func TestTableExportConfig(t *testing.T) {
+	doc := parseTestDocument(t, `<table><tr><th>a<th>a<th></tr><tr><td rowspan=2>1<td>2<td>3<tr><td>4</table>`)
+	table := findElement(doc, "table")
+
+	exported := NewTableExporter(DefaultTableExportConfig).ExportTable(table)
+	assert.Equal(t, []string{"a", "a (2)", "column 3"}, exported.Header)
+	assert.Equal(t, [][]string{{"1", "2", "3"}, {"1", "4", ""}}, exported.Records)
+
+	exported = NewTableExporter(TableExportConfig{
+		HeaderRows: -1,
+		CellText: func(cell *spec.Node) string {
+			text, _ := cell.TextContent()
+			return strings.ToUpper(text)
+		},
+	}).ExportTable(table)
+	assert.Nil(t, exported.Header)
+	assert.Equal(t, [][]string{{"A", "A", ""}, {"1", "2", "3"}, {"", "4", ""}}, exported.Records)
+
+	exported = NewTableExporter(TableExportConfig{HeaderRows: 2}).ExportTable(table)
+	assert.Equal(t, []string{"a 1", "a 2", "3"}, exported.Header)
+	assert.Equal(t, [][]string{{"", "4", ""}}, exported.Records)
+}
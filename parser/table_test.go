@@ -0,0 +1,140 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/heathj/gobrowse/parser/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+// cellText returns the text of the cell covering each slot, row by row.
+func cellText(m *spec.TableModel) [][]string {
+	grid := [][]string{}
+	for y := 0; y < m.Height; y++ {
+		row := []string{}
+		for x := 0; x < m.Width; x++ {
+			text := ""
+			if cell := m.Cell(x, y); cell != nil {
+				text, _ = cell.Node.TextContent()
+			}
+			row = append(row, text)
+		}
+		grid = append(grid, row)
+	}
+	return grid
+}
+
+func TestTableModel(t *testing.T) {
+	doc := parseTestDocument(t, `<table>
+<caption>c</caption>
+<colgroup span=2></colgroup>
+<tfoot><tr><td>f1<td>f2<td>f3</tfoot>
+<thead><tr><th>h1<th colspan=2>h2</thead>
+<tbody><tr><td rowspan=2>a<td>b<td rowspan=5>c<tr><td>d</tbody>
+</table>`)
+	m := findElement(doc, "table").HTMLTable.Model()
+	assert.Equal(t, "c", m.Caption.InnerText())
+	assert.Equal(t, [][]string{
+		{"h1", "h2", "h2"},
+		{"a", "b", "c"},
+		{"a", "d", "c"},
+		{"f1", "f2", "f3"},
+	}, cellText(m))
+	assert.Len(t, m.RowGroups, 3)
+	assert.Equal(t, 1, m.RowGroups[1].Start)
+	assert.Equal(t, 2, m.RowGroups[1].Span)
+	assert.Len(t, m.ColumnGroups, 1)
+	assert.Empty(t, m.Errors)
+}
+
+func TestTableModelDownwardGrowingCells(t *testing.T) {
+	doc := parseTestDocument(t, `<!DOCTYPE html><table><tr><td rowspan=0>a<td>b<tr><td>c<tr><td>d</table>`)
+	m := findElement(doc, "table").HTMLTable.Model()
+	assert.Equal(t, [][]string{{"a", "b"}, {"a", "c"}, {"a", "d"}}, cellText(m))
+	assert.Empty(t, m.Errors)
+
+	doc = parseTestDocument(t, `<table><tr><td>a<td>b<tr><td>c</table>`)
+	m = findElement(doc, "table").HTMLTable.Model()
+	assert.Equal(t, []*spec.TableModelError{{Reason: "some slots have no cell"}}, m.Errors)
+
+	doc = parseTestDocument(t, `<table><tr><td>a<td rowspan=2>b<tr><td colspan=3>c</table>`)
+	m = findElement(doc, "table").HTMLTable.Model()
+	assert.Equal(t, []*spec.TableModelError{{X: 1, Y: 1, Reason: "cells overlap"}}, m.Errors)
+}
+
+func TestTableHeaderCells(t *testing.T) {
+	doc := parseTestDocument(t, `<table>
+<tr><th><th>Q1<th>Q2
+<tr><th>North<td id=n1>1<td>2
+<tr><th scope=rowgroup>South<td headers=x>3<td>4
+<tr><th id=x>x<td>5<td>6
+</table>`)
+	m := findElement(doc, "table").HTMLTable.Model()
+	headerText := func(x, y int) []string {
+		texts := []string{}
+		for _, h := range m.Cell(x, y).Headers {
+			text, _ := h.Node.TextContent()
+			texts = append(texts, strings.TrimSpace(text))
+		}
+		return texts
+	}
+	assert.Equal(t, []string{"North", "Q1"}, headerText(1, 1))
+	assert.Equal(t, []string{"x"}, headerText(1, 2))
+	assert.Equal(t, []string{"x", "Q2", "South"}, headerText(2, 3))
+}
+
+func TestHTMLTableElement(t *testing.T) {
+	doc := parseTestDocument(t, `<table><tr><td>a</td></tr></table>`)
+	table := findElement(doc, "table").HTMLTable
+	assert.Nil(t, table.Caption())
+	assert.Len(t, table.TBodies(), 1)
+
+	thead := table.CreateTHead()
+	assert.Same(t, thead, table.CreateTHead())
+	_, err := thead.HTMLTHead.InsertRow(0)
+	assert.NoError(t, err)
+	tfoot := table.CreateTFoot()
+	foot, err := tfoot.HTMLTFoot.InsertRow(-1)
+	assert.NoError(t, err)
+	table.CreateCaption().SetTextContent("caption")
+
+	row, err := table.InsertRow(1)
+	assert.NoError(t, err)
+	rows := table.Rows()
+	assert.Len(t, rows, 4)
+	assert.Same(t, row, rows[1])
+	assert.Same(t, foot, rows[3])
+	assert.Equal(t, 1, row.HTMLTr.RowIndex())
+	assert.Equal(t, 0, row.HTMLTr.SectionRowIndex())
+
+	cell, err := row.HTMLTr.InsertCell(0)
+	assert.NoError(t, err)
+	assert.Equal(t, []*spec.Node{cell}, row.HTMLTr.Cells())
+	_, err = row.HTMLTr.InsertCell(5)
+	assert.ErrorIs(t, err, spec.ErrIndexSize)
+
+	assert.Equal(t, "<caption>caption</caption><thead><tr></tr></thead><tbody><tr><td></td></tr><tr><td>a</td></tr></tbody><tfoot><tr></tr></tfoot>",
+		findElement(doc, "table").InnerHTML())
+
+	_, err = table.InsertRow(9)
+	assert.ErrorIs(t, err, spec.ErrIndexSize)
+	assert.NoError(t, table.DeleteRow(-1))
+	assert.NoError(t, table.DeleteRow(0))
+	assert.ErrorIs(t, table.DeleteRow(5), spec.ErrIndexSize)
+	assert.Len(t, table.Rows(), 2)
+	assert.ErrorIs(t, table.SetTHead(row), spec.ErrHierarchyRequest)
+	table.DeleteTHead()
+	table.DeleteCaption()
+	assert.Nil(t, table.THead())
+
+	empty := spec.NewDOMElement(doc, "table", spec.Htmlns)
+	row, err = empty.HTMLTable.InsertRow(-1)
+	assert.NoError(t, err)
+	assert.Equal(t, "tbody", row.ParentNode.NodeName)
+
+	doc = parseTestDocument(t, "<table> <caption>c</caption> <tr><td>a</td></tr></table>")
+	table = findElement(doc, "table").HTMLTable
+	table.CreateTHead()
+	assert.Equal(t, " <caption>c</caption> <thead></thead><tbody><tr><td>a</td></tr></tbody>", findElement(doc, "table").InnerHTML())
+}
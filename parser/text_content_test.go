@@ -0,0 +1,70 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/heathj/gobrowse/parser/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTextContent(t *testing.T) {
+	doc := parseTestDocument(t, "<!DOCTYPE html><div id=a>one <b>two</b><!--c--> three</div>")
+	div := findElement(doc, "div")
+	text, ok := div.TextContent()
+	assert.True(t, ok)
+	assert.Equal(t, "one two three", text)
+
+	_, ok = doc.TextContent()
+	assert.False(t, ok)
+	_, ok = doc.ChildNodes[0].TextContent()
+	assert.False(t, ok)
+	_, ok = div.NodeValue()
+	assert.False(t, ok)
+
+	comment := div.ChildNodes[2]
+	value, ok := comment.NodeValue()
+	assert.True(t, ok)
+	assert.Equal(t, "c", value)
+	comment.SetTextContent("d")
+	assert.Equal(t, "<!--d-->", comment.OuterHTML())
+
+	div.SetTextContent("<new>")
+	assert.Equal(t, "&lt;new&gt;", div.InnerHTML())
+	assert.Equal(t, div, div.FirstChild.ParentNode)
+	div.SetTextContent("")
+	assert.Empty(t, div.ChildNodes)
+}
+
+type innerTextTestcase struct {
+	htmlIn, expected string
+}
+
+func TestInnerText(t *testing.T) {
+	tests := []innerTextTestcase{
+		{"<div>  one\n  two  </div>", "one two"},
+		{"<div>a<br>b</div><div>c</div>", "a\nb\nc"},
+		{"<p>a</p><p>b</p>x", "a\n\nb\n\nx"},
+		{"<span>a </span> <span> b</span>", "a b"},
+		{"<table><tr><td>a</td><td>b</td></tr><tr><th>c<td>d</table>", "a\tb\nc\td"},
+		{"a<span hidden>b</span><span style='display: none'>c</span><script>d</script>e", "ae"},
+		{"<pre>  a\n  b</pre>", "  a\n  b"},
+		{"<ul><li>a</li><li>b</li></ul>", "a\nb"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.htmlIn, func(t *testing.T) {
+			t.Parallel()
+			body := findElement(parseTestDocument(t, tt.htmlIn), "body")
+			assert.Equal(t, tt.expected, body.InnerText())
+		})
+	}
+}
+
+func TestSetInnerText(t *testing.T) {
+	div := findElement(parseTestDocument(t, "<div><b>old</b></div>"), "div")
+	div.SetInnerText("a\r\nb\n<c>")
+	assert.Equal(t, "a<br>b<br>&lt;c&gt;", div.InnerHTML())
+	assert.Equal(t, spec.ElementNode, div.ChildNodes[1].NodeType)
+	assert.Equal(t, "a\nb\n<c>", div.InnerText())
+}
@@ -1,10 +1,8 @@
 package parser
 
 import (
-	"bufio"
 	"bytes"
 	"io"
-	"strings"
 
 	"github.com/heathj/gobrowse/parser/spec"
 )
@@ -13,11 +11,13 @@ import (
 type HTMLTokenizer struct {
 	done                      bool
 	returnState, currentState tokenizerState
-	inputStream               *bufio.Reader
+	inputStream               *inputStream
 	adjustedCurrentNode       *spec.Node
 	emittedTokens             []Token
 	tokenBuilder              *TokenBuilder
 	lastEmittedStartTagName   string
+	// maxTokenSize is the most bytes a token may hold. 0 means no limit.
+	maxTokenSize int
 }
 
 // NewHTMLTokenizer creates an HTML parser that can be used to process
@@ -25,7 +25,7 @@ type HTMLTokenizer struct {
 func NewHTMLTokenizer(io io.Reader) *HTMLTokenizer {
 	return &HTMLTokenizer{
 		emittedTokens: []Token{},
-		inputStream:   bufio.NewReader(io),
+		inputStream:   newInputStream(io),
 		tokenBuilder:  MakeTokenBuilder(),
 	}
 }
@@ -1785,120 +1785,41 @@ func (p *HTMLTokenizer) characterReferenceStateParser(r rune, eof bool) (bool, t
 	}
 }
 
-func (p *HTMLTokenizer) anyFilteredTable(filteredTable map[string][]rune, match string) (bool, string) {
-	smallest := ""
-	// one byte at a time, remove things that don't match
-	for k := range filteredTable {
-		if !strings.HasPrefix(k, match) {
-			delete(filteredTable, k)
-			continue
-		}
-
-		// if there is an exact match, we need to add these characters to the
-		// temp buffer
-		if k == match {
-			smallest = k
-			p.tokenBuilder.ResetTempBuffer()
-			// don't erase the & character from character reference state though
-			p.tokenBuilder.WriteTempBuffer('&')
-			for _, r := range smallest {
-				p.tokenBuilder.WriteTempBuffer(r)
-			}
-		}
-	}
-
-	return len(filteredTable) > 0, smallest
-}
-
 func (p *HTMLTokenizer) namedCharacterReferenceStateParser(r rune, eof bool) (bool, tokenizerState) {
 	if eof {
 		p.flushCodePointsAsCharacterReference()
 		return false, ambiguousAmpersandState
 	}
 
-	filteredTable := map[string][]rune{}
-	for k, v := range charRefTable {
-		filteredTable[k] = v
-	}
-
-	consumed := []byte{byte(r)}
-	hasMatches, smallest := p.anyFilteredTable(filteredTable, string(consumed))
-
-	var (
-		b, sep, next []byte
-		err          error
-		newSmallest  string
-		firstDiscard bool = true
-	)
-	match := bytes.Join([][]byte{consumed, b}, sep)
-
-	// consume one character until there are no more matches in the filtered table
-	for i := 1; hasMatches; i++ {
-		b, err = p.inputStream.Peek(i)
-		// if we can't peek any more bytes, leave the loop and use the currently
-		// calculated smallest match as the match.
-		if err != nil {
-			break
-		}
-
-		// concatenate the read bytes to the consumed
-		match = bytes.Join([][]byte{consumed, b}, sep)
-		hasMatches, newSmallest = p.anyFilteredTable(filteredTable, string(match))
-
-		// if we found a larger, better match in the table, update the reader and
-		// the loop counter
-		if len(newSmallest) > len(smallest) {
-			i = 0
-			diff := len(newSmallest) - len(smallest)
-
-			// because we already dicarded the first rune in the main loop
-			// we don't need discard it again here.
-			if firstDiscard {
-				_, err = p.inputStream.Discard(diff - 1)
-				firstDiscard = false
-			} else {
-				_, err = p.inputStream.Discard(diff)
-			}
-			if err != nil {
-				break
-			}
+	// r has already been consumed so only look ahead for the rest of the
+	// longest possible reference.
+	var lookahead [maxCharRefLength]byte
+	lookahead[0] = byte(r)
+	peeked, _ := p.inputStream.Peek(maxCharRefLength - 1)
+	n := copy(lookahead[1:], peeked)
 
-			smallest = newSmallest
-			consumed = match
-		}
+	length, value := matchCharRef(lookahead[:n+1])
+	if length == 0 {
+		p.flushCodePointsAsCharacterReference()
+		return true, ambiguousAmpersandState
 	}
 
-	// there wasn't a match in the table, we haven't added to the temp buffer yet
-	// so when we try to flush the consumed code points, nothing will be there.
-	// add the consumed character to the temp buffer in this case.
-	if smallest == "" {
-		for i, mb := range match {
-			if i != 0 {
-				p.inputStream.Discard(1)
-			}
-			p.tokenBuilder.WriteTempBuffer(rune(mb))
-		}
-		p.flushCodePointsAsCharacterReference()
-		return false, ambiguousAmpersandState
+	name := lookahead[:length]
+	p.inputStream.Discard(length - 1)
+	for _, b := range name {
+		p.tokenBuilder.WriteTempBuffer(rune(b))
 	}
 
-	endsInSemiColon := bytes.HasSuffix(consumed, []byte{';'})
-	if wasConsumedByAttribute(p.returnState) && !endsInSemiColon {
-		next, err = p.inputStream.Peek(1)
-		if err == nil {
-			switch next[0] {
-			case '=', 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n', 'o', 'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z', 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
-				for _, r := range next {
-					p.tokenBuilder.WriteTempBuffer(rune(r))
-					p.inputStream.Discard(1)
-				}
-				p.flushCodePointsAsCharacterReference()
-				return false, p.returnState
-			}
+	if wasConsumedByAttribute(p.returnState) && name[length-1] != ';' && length <= n {
+		switch next := lookahead[length]; {
+		case next == '=', isASCIIAlphanumeric(next):
+			p.flushCodePointsAsCharacterReference()
+			return false, p.returnState
 		}
 	}
+
 	p.tokenBuilder.ResetTempBuffer()
-	for _, r := range charRefTable[string(consumed)] {
+	for _, r := range value {
 		p.tokenBuilder.WriteTempBuffer(r)
 	}
 	p.flushCodePointsAsCharacterReference()
@@ -1906,6 +1827,10 @@ func (p *HTMLTokenizer) namedCharacterReferenceStateParser(r rune, eof bool) (bo
 	return false, p.returnState
 }
 
+func isASCIIAlphanumeric(b byte) bool {
+	return ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z') || ('0' <= b && b <= '9')
+}
+
 func (p *HTMLTokenizer) ambiguousAmpersandStateParser(r rune, eof bool) (bool, tokenizerState) {
 	if eof {
 		return true, p.returnState
@@ -2213,13 +2138,41 @@ func (p *HTMLTokenizer) Token(progress *Progress) (*Token, error) {
 			return token, nil
 		}
 
+		if text := p.scanText(); text != "" {
+			p.emit(p.tokenBuilder.CharacterTokens(text))
+			continue
+		}
+
 		r, _, err := p.inputStream.ReadRune()
 		if err != nil && err != io.EOF {
 			return nil, err
 		}
 
 		p.processRune(p.normalizeNewlines(r), err == io.EOF)
+		if p.maxTokenSize > 0 && p.tokenBuilder.Size() > p.maxTokenSize {
+			return nil, &LimitError{Limit: "MaxTokenSize", Max: int64(p.maxTokenSize)}
+		}
+	}
+}
+
+// textStateStops are the bytes that the text states need the state machine
+// for. Everything else is emitted as is.
+var textStateStops = map[tokenizerState]string{
+	dataState:       "<&\u0000\r",
+	rcDataState:     "<&\u0000\r",
+	rawTextState:    "<\u0000\r",
+	scriptDataState: "<\u0000\r",
+	plaintextState:  "\u0000\r",
+}
+
+// scanText consumes a run of characters that the current state would emit as
+// character tokens one by one without changing state.
+func (p *HTMLTokenizer) scanText() string {
+	stops, ok := textStateStops[p.currentState]
+	if !ok {
+		return ""
 	}
+	return p.inputStream.scanText(stops)
 }
 
 func (p *HTMLTokenizer) processRune(r rune, eof bool) {
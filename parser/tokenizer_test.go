@@ -1066,6 +1066,20 @@ func formatOutputs(outputs [][]interface{}, doubleEscape bool) []Token {
 	return tokens
 }
 
+// coalesceCharacterTokens merges adjacent character tokens into one.
+func coalesceCharacterTokens(tokens []Token) []Token {
+	coalesced := []Token{}
+	for _, token := range tokens {
+		last := len(coalesced) - 1
+		if token.TokenType == characterToken && last >= 0 && coalesced[last].TokenType == characterToken {
+			coalesced[last].Data += token.Data
+			continue
+		}
+		coalesced = append(coalesced, token)
+	}
+	return coalesced
+}
+
 func doubleEscape(s string) (string, error) {
 	ns := strconv.QuoteToASCII(s)
 	rs := strings.ReplaceAll(ns, "\\\\", "\\")
@@ -1093,7 +1107,7 @@ func runHTML5Test(test HTML5Test, t *testing.T) {
 		if len(test.InitialStates) == 0 {
 			test.InitialStates = []string{"Data state"}
 		}
-		expectedTokens := formatOutputs(test.Output, test.DoubleEscaped)
+		expectedTokens := coalesceCharacterTokens(formatOutputs(test.Output, test.DoubleEscaped))
 		for _, initState := range test.InitialStates {
 			p := NewParser(strings.NewReader(test.Input))
 
@@ -1112,6 +1126,8 @@ func runHTML5Test(test HTML5Test, t *testing.T) {
 			}
 			// the expected tokens don't include the EOF token, but `tokens` does
 			tokens = tokens[:len(tokens)-1]
+			// how runs of characters are split up between tokens doesn't matter
+			tokens = coalesceCharacterTokens(tokens)
 			if len(tokens) != len(expectedTokens) {
 				t.Fatalf("Unexpected number of tokens. Expected %d, got %d", len(expectedTokens), len(tokens))
 			}
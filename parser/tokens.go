@@ -5,6 +5,7 @@ import (
 	"math/big"
 	"strings"
 	"sync"
+	"unicode/utf8"
 
 	"github.com/heathj/gobrowse/parser/spec"
 )
@@ -156,6 +157,10 @@ type TokenBuilder struct {
 	removeNextAttr         bool
 	curTagType             tagType
 	characterReferenceCode *big.Int
+
+	// the bytes used by committed attributes and the limits on them.
+	attributeBytes                         int
+	maxAttributes, maxAttributeValueLength int
 }
 
 func MakeTokenBuilder() *TokenBuilder {
@@ -183,6 +188,13 @@ func (t *TokenBuilder) Reset() {
 	t.forceQuirks = false
 	t.removeNextAttr = false
 	t.characterReferenceCode = big.NewInt(0)
+	t.attributeBytes = 0
+}
+
+// Size is the number of bytes held by the token being built.
+func (t *TokenBuilder) Size() int {
+	return t.name.Len() + t.data.Len() + t.attributeKey.Len() + t.attributeValue.Len() +
+		t.publicID.Len() + t.systemID.Len() + t.attributeBytes
 }
 
 // EnableSelfClosing changes to the self-closing flag to "set".
@@ -247,6 +259,9 @@ func (t *TokenBuilder) WriteData(r rune) {
 // WriteAttributeValue appends a character to the current
 // attribute's value.
 func (t *TokenBuilder) WriteAttributeValue(r rune) {
+	if t.maxAttributeValueLength > 0 && t.attributeValue.Len()+utf8.RuneLen(r) > t.maxAttributeValueLength {
+		return
+	}
 	_, err := t.attributeValue.WriteRune(r)
 	if err != nil {
 		fmt.Print(err)
@@ -280,8 +295,9 @@ func (t *TokenBuilder) CommitAttribute() {
 		k := t.attributeKey.String()
 		v := t.attributeValue.String()
 
-		if k != "" {
+		if k != "" && (t.maxAttributes == 0 || len(t.attributes) < t.maxAttributes) {
 			t.attributes[k] = &spec.Attr{LocalName: k, Value: v, Namespace: spec.Htmlns}
+			t.attributeBytes += len(k) + len(v)
 		}
 	}
 	t.attributeKey.Reset()
@@ -367,6 +383,13 @@ func (t *TokenBuilder) CharacterToken(r rune) Token {
 	return *token
 }
 
+// CharacterTokens creates a single character token for a run of characters.
+func (t *TokenBuilder) CharacterTokens(s string) Token {
+	token := MakeToken(characterToken)
+	token.Data = s
+	return *token
+}
+
 // EndOfFileToken create an end of file token.
 func (t *TokenBuilder) EndOfFileToken() Token {
 	return *MakeToken(endOfFileToken)
@@ -131,7 +131,7 @@ func runTreeConstructorTest(t *testing.T, test treeTest, scriptingEnabled bool)
 }
 
 func testParseHTMLFragment(assert *assert.Assertions, test treeTest, scriptingEnabled bool) error {
-	nodes := ParseHTMLFragment(test.docFrag.context, test.htmlIn, noQuirks, scriptingEnabled)
+	nodes := ParseHTMLFragment(test.docFrag.context, test.htmlIn, scriptingEnabled)
 	n := spec.NewHTMLDocumentNode()
 	for _, node := range nodes {
 		n.AppendChild(node)
@@ -141,8 +141,7 @@ func testParseHTMLFragment(assert *assert.Assertions, test treeTest, scriptingEn
 }
 
 func testTreeConstructor(assert *assert.Assertions, test treeTest, scriptingEnabled bool) error {
-	p := NewParser(strings.NewReader(test.htmlIn))
-	p.TreeConstructor.scriptingEnabled = scriptingEnabled
+	p := NewParser(strings.NewReader(test.htmlIn), WithScripting(scriptingEnabled))
 	tree, err := p.Start()
 	if err != nil {
 		return errors.Wrap(err, "error running the parser")
@@ -151,3 +150,14 @@ func testTreeConstructor(assert *assert.Assertions, test treeTest, scriptingEnab
 	assert.Equal(test.expected, tree.String(), "these trees should be equal")
 	return nil
 }
+
+func BenchmarkParseLargeText(b *testing.B) {
+	input := "<p>" + strings.Repeat("lots of text &amp; an entity ", 5000) + "</p>"
+	b.ReportAllocs()
+	b.SetBytes(int64(len(input)))
+	for i := 0; i < b.N; i++ {
+		if _, err := NewParser(strings.NewReader(input)).Start(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -6,14 +6,35 @@ import (
 	"github.com/heathj/gobrowse/parser/spec"
 )
 
-type quirksMode uint
+// QuirksMode is the mode of a document.
+// https://dom.spec.whatwg.org/#concept-document-mode
+type QuirksMode uint
 
 const (
-	noQuirks quirksMode = iota
-	quirks
-	limitedQuirks
+	NoQuirks QuirksMode = iota
+	Quirks
+	LimitedQuirks
 )
 
+// String returns the name the DOM uses for the mode.
+func (m QuirksMode) String() string {
+	switch m {
+	case Quirks:
+		return "quirks"
+	case LimitedQuirks:
+		return "limited-quirks"
+	}
+	return "no-quirks"
+}
+
+// compatMode is https://dom.spec.whatwg.org/#dom-document-compatmode
+func (m QuirksMode) compatMode() string {
+	if m == Quirks {
+		return "BackCompat"
+	}
+	return "CSS1Compat"
+}
+
 type frameset uint
 
 const (
@@ -26,8 +47,9 @@ type HTMLTreeConstructor struct {
 	nextTokenizerState                              *tokenizerState
 	curInsertionMode                                insertionMode
 	HTMLDocument                                    *spec.HTMLDocument
-	quirksMode                                      quirksMode
+	quirksMode                                      QuirksMode
 	fosterParenting, scriptingEnabled               bool
+	iframeSrcdoc, forcedQuirksMode                  bool
 	originalInsertionMode                           insertionMode
 	stackOfOpenElements                             spec.StackOfOpenElements
 	activeFormattingElements                        spec.ActiveFormattingElements
@@ -35,12 +57,38 @@ type HTMLTreeConstructor struct {
 	headElementPointer, formElementPointer, context *spec.Node
 	pendingTableCharacterTokens                     []Token
 	frameset                                        frameset
+
+	// the input stream of the tokenizer feeding this tree constructor. it is
+	// used to move the insertion point around when running scripts.
+	inputStream      *inputStream
+	stopped, aborted bool
+
+	// the text node characters are currently being added to and its data.
+	pendingText *spec.Node
+	textBuffer  strings.Builder
+
+	// limits on the tree being built, the number of nodes created so far and
+	// the error that stopped parsing if one was exceeded.
+	limits Limits
+	nodes  int
+	err    error
+
+	// script processing model state
+	scriptExecutor                                   ScriptExecutor
+	scriptNestingLevel                               int
+	parserPause                                      bool
+	pendingParsingBlockingScript                     *spec.Node
+	scriptsAfterParsing, scriptsInOrder, scriptsASAP []*spec.Node
 }
 
 // NewHTMLTreeConstructor creates an HTMLTreeConstructor.
 func NewHTMLTreeConstructor() *HTMLTreeConstructor {
+	doc := spec.NewHTMLDocumentNode()
+	doc.ReadyState = spec.Loading
+	doc.Mode = NoQuirks.String()
+	doc.CompatMode = NoQuirks.compatMode()
 	return &HTMLTreeConstructor{
-		HTMLDocument: spec.NewHTMLDocumentNode(),
+		HTMLDocument: doc,
 	}
 }
 
@@ -105,8 +153,9 @@ func (c *HTMLTreeConstructor) getCurrentNode() *spec.Node {
 	return c.stackOfOpenElements.NodeList[len(c.stackOfOpenElements.NodeList)-1]
 }
 
+// https://html.spec.whatwg.org/multipage/parsing.html#adjusted-current-node
 func (c *HTMLTreeConstructor) getAdjustedCurrentNode() *spec.Node {
-	if c.context == nil {
+	if c.context == nil || len(c.stackOfOpenElements.NodeList) != 1 {
 		return c.getCurrentNode()
 	}
 
@@ -117,6 +166,7 @@ func (c *HTMLTreeConstructor) getAdjustedCurrentNode() *spec.Node {
 // https://html.spec.whatwg.org/multipage/parsing.html#insert-a-comment
 func (c *HTMLTreeConstructor) insertCommentAt(t Token, il *insertionLocation) {
 	commentNode := spec.NewComment(t.Data, il.node.OwnerDocument)
+	c.countNode()
 	il.insert(commentNode)
 }
 
@@ -139,10 +189,21 @@ func (c *HTMLTreeConstructor) getLastElemInStackOfOpenElements(elem string) int
 }
 
 type insertionLocation struct {
-	node   *spec.Node
+	node *spec.Node
+	// the child of node the new node is inserted before. nil if it is appended.
+	before *spec.Node
 	insert func(*spec.Node)
 }
 
+// previousSibling returns the node that will come right before a node inserted
+// at the location.
+func (il *insertionLocation) previousSibling() *spec.Node {
+	if il.before != nil {
+		return il.before.PreviousSibling
+	}
+	return il.node.LastChild
+}
+
 func targetInTable(name string) bool {
 	switch name {
 	case "table", "tbody", "tfoot", "thead", "tr":
@@ -267,6 +328,11 @@ func (c *HTMLTreeConstructor) getAppropriatePlaceForInsertion(target *spec.Node)
 		if target == nil {
 			target = c.HTMLDocument.Node
 		}
+		// elements nested too deeply are kept on the stack of open elements but
+		// inserted into the deepest allowed element instead.
+		if max := c.limits.MaxDepth; max > 0 && len(c.stackOfOpenElements.NodeList) > max {
+			target = c.stackOfOpenElements.NodeList[max-1]
+		}
 	}
 	ail := &insertionLocation{}
 	if c.fosterParenting && targetInTable(string(target.NodeName)) {
@@ -293,12 +359,14 @@ func (c *HTMLTreeConstructor) getAppropriatePlaceForInsertion(target *spec.Node)
 
 		if c.stackOfOpenElements.NodeList[lastTable].ParentNode != nil {
 			ail.node = c.stackOfOpenElements.NodeList[lastTable].ParentNode
+			ail.before = c.stackOfOpenElements.NodeList[lastTable]
 			ail.insert = func(n *spec.Node) {
 				c.stackOfOpenElements.NodeList[lastTable].ParentNode.InsertBefore(n, c.stackOfOpenElements.NodeList[lastTable])
 			}
 			return ail
 		}
 
+		ail.node = c.stackOfOpenElements.NodeList[lastTable-1]
 		ail.insert = func(n *spec.Node) {
 			c.stackOfOpenElements.NodeList[lastTable-1].AppendChild(n)
 		}
@@ -314,7 +382,7 @@ func (c *HTMLTreeConstructor) getAppropriatePlaceForInsertion(target *spec.Node)
 type CustomElementDefinition struct {
 }
 
-//https://html.spec.whatwg.org/multipage/custom-elements.html#look-up-a-custom-element-definition
+// https://html.spec.whatwg.org/multipage/custom-elements.html#look-up-a-custom-element-definition
 func (c *HTMLTreeConstructor) lookUpCustomElementDefinition(document *spec.Node, ns spec.Namespace, localName, is string) *CustomElementDefinition {
 	//TODO:
 	// browsing context
@@ -331,8 +399,9 @@ func (c *HTMLTreeConstructor) clearListOfActiveFormattingElementsToLastMarker()
 	}
 }
 
+// https://html.spec.whatwg.org/multipage/parsing.html#reset-the-insertion-mode-appropriately
 func (c *HTMLTreeConstructor) resetInsertionMode() insertionMode {
-	return c.resetInsertionModeWithContext(nil)
+	return c.resetInsertionModeWithContext(c.context)
 }
 
 func (c *HTMLTreeConstructor) resetInsertionModeWithContext(context *spec.Node) insertionMode {
@@ -406,10 +475,22 @@ func (c *HTMLTreeConstructor) resetInsertionModeWithContext(context *spec.Node)
 
 // https://html.spec.whatwg.org/multipage/parsing.html#stop-parsing
 func (c *HTMLTreeConstructor) stopParsing() (bool, insertionMode) {
+	c.inputStream.undefineInsertionPoint()
+	c.HTMLDocument.ReadyState = spec.Interactive
 	for len(c.stackOfOpenElements.NodeList) > 0 {
 		c.stackOfOpenElements.Pop()
 	}
 
+	c.runScriptsAfterParsing()
+	doc := c.HTMLDocument
+	doc.QueueTask(spec.DOMManipulationTaskSource, func() {
+		doc.ReadyState = spec.Complete
+		e := spec.NewEvent("load", false, false)
+		e.IsTrusted = true
+		doc.Window().DispatchEvent(e)
+	})
+	c.stopped = true
+
 	return false, stopParser
 }
 
@@ -431,30 +512,93 @@ func (c *HTMLTreeConstructor) createElementForToken(t Token, ns spec.Namespace,
 	}
 
 	if executeScript {
-		//TODO: executeScript
+		c.HTMLDocument.ThrowOnDynamicMarkupInsertionCounter++
+		c.HTMLDocument.PerformMicrotaskCheckpoint()
 	}
 
 	element := spec.NewDOMElement(document, localName, ns)
+	c.countNode()
 	element.Attributes = spec.NewNamedNodeMap(t.Attributes, element)
 	element.ParentNode = ip.ParentNode
+	if executeScript {
+		c.HTMLDocument.ThrowOnDynamicMarkupInsertionCounter--
+	}
+
+	_, hasFormAttr := element.Attributes.Attrs["form"]
+	if element.IsFormAssociated() && c.formElementPointer != nil &&
+		len(c.containedInStackOpenElements("template")) == 0 &&
+		(!element.IsListed() || !hasFormAttr) &&
+		rootNode(ip) == rootNode(c.formElementPointer) {
+		element.SetParserInsertedFormOwner(c.formElementPointer)
+	}
 	return element
 }
 
+// rootNode returns the root of the tree the node is in.
+func rootNode(n *spec.Node) *spec.Node {
+	for n.ParentNode != nil {
+		n = n.ParentNode
+	}
+	return n
+}
+
+// countNode records that a node was created. Once there are more than the
+// limit allows parsing stops with a LimitError.
+func (c *HTMLTreeConstructor) countNode() {
+	c.nodes++
+	if max := c.limits.MaxNodes; max > 0 && c.nodes > max && c.err == nil {
+		c.err = &LimitError{Limit: "MaxNodes", Max: int64(max)}
+	}
+}
+
+// https://html.spec.whatwg.org/multipage/parsing.html#insert-a-character
 func (c *HTMLTreeConstructor) insertCharacter(t Token) {
 	il := c.getAppropriatePlaceForInsertion(nil)
 	if il.node != nil && il.node.NodeType == spec.DocumentNode {
 		return
 	}
 
+	// characters are appended to the text buffer of the pending text node. its
+	// data is only updated when the buffer is flushed.
+	prev := il.previousSibling()
+	if prev != nil && prev == c.pendingText {
+		c.bufferText(t.Data)
+		return
+	}
+
+	c.flushText()
+	if prev != nil && prev.NodeType == spec.TextNode {
+		c.pendingText = prev
+		c.textBuffer.WriteString(prev.Text.Data)
+		c.bufferText(t.Data)
+		return
+	}
+
 	tn := spec.NewTextNode(il.node.OwnerDocument, t.Data)
+	c.countNode()
 	il.insert(tn)
-	if tn.PreviousSibling != nil && tn.PreviousSibling.NodeType == spec.TextNode {
-		tn.PreviousSibling.Text.CharacterData.Data += t.Data
-		il.node.RemoveChild(tn)
-	} else if tn.NextSibling != nil && tn.NextSibling.NodeType == spec.TextNode {
-		tn.NextSibling.Text.CharacterData.Data = t.Data + tn.NextSibling.Text.CharacterData.Data
-		il.node.RemoveChild(tn)
+	c.pendingText = tn
+	c.bufferText(t.Data)
+}
+
+// bufferText appends characters to the text buffer, stopping the parser once
+// the pending text node holds more than the limit.
+func (c *HTMLTreeConstructor) bufferText(data string) {
+	c.textBuffer.WriteString(data)
+	if max := c.limits.MaxTextLength; max > 0 && c.textBuffer.Len() > max && c.err == nil {
+		c.err = &LimitError{Limit: "MaxTextLength", Max: int64(max)}
+	}
+}
+
+// flushText writes the text buffer to the pending text node. It has to be
+// called before anything looks at the tree.
+func (c *HTMLTreeConstructor) flushText() {
+	if c.pendingText == nil {
+		return
 	}
+	c.pendingText.Text.Data = c.textBuffer.String()
+	c.pendingText = nil
+	c.textBuffer.Reset()
 }
 
 func (c *HTMLTreeConstructor) insertHTMLElementForToken(t Token) *spec.Node {
@@ -525,6 +669,19 @@ func (c *HTMLTreeConstructor) generateImpliedEndTags(denylist ...string) {
 	}
 }
 
+// https://html.spec.whatwg.org/multipage/parsing.html#generate-all-implied-end-tags-thoroughly
+func (c *HTMLTreeConstructor) generateAllImpliedEndTagsThoroughly() {
+	for {
+		switch c.getCurrentNode().NodeName {
+		case "caption", "colgroup", "dd", "dt", "li", "optgroup", "option", "p",
+			"rb", "rp", "rt", "rtc", "tbody", "td", "tfoot", "th", "thead", "tr":
+			c.stackOfOpenElements.Pop()
+			continue
+		}
+		return
+	}
+}
+
 func (c *HTMLTreeConstructor) closePElement() {
 	c.generateImpliedEndTags("p")
 	// skipping parse error check
@@ -915,8 +1072,20 @@ var knownPublicIdentifiers = []string{
 	webTechsDTDMozillaHTML,
 }
 
+// https://html.spec.whatwg.org/multipage/iframe-embed-object.html#an-iframe-srcdoc-document
 func (c *HTMLTreeConstructor) isIframeSrcDoc() bool {
-	return false
+	return c.iframeSrcdoc
+}
+
+// setQuirksMode sets the mode of the document unless it was forced when the
+// parser was created.
+func (c *HTMLTreeConstructor) setQuirksMode(mode QuirksMode) {
+	if c.forcedQuirksMode {
+		return
+	}
+	c.quirksMode = mode
+	c.HTMLDocument.Mode = mode.String()
+	c.HTMLDocument.CompatMode = mode.compatMode()
 }
 
 func (c *HTMLTreeConstructor) isForceQuirks(t Token) bool {
@@ -974,8 +1143,9 @@ func (c *HTMLTreeConstructor) isLimitedQuirks(t Token) bool {
 }
 
 func (c *HTMLTreeConstructor) defaultInitialModeHandler() (bool, insertionMode) {
-	// TODO:only if not an iframe src document
-	c.quirksMode = quirks
+	if !c.isIframeSrcDoc() {
+		c.setQuirksMode(Quirks)
+	}
 	return true, beforeHTML
 }
 
@@ -992,15 +1162,16 @@ func (c *HTMLTreeConstructor) initialModeHandler(t Token) (bool, insertionMode)
 		return false, initial
 	case docTypeToken:
 		doctype := spec.NewDocTypeNode(t.TagName, t.PublicIdentifier, t.SystemIdentifier)
+		c.countNode()
 		c.HTMLDocument.AppendChild(doctype)
 		c.HTMLDocument.Node.Document.Doctype = doctype
 
 		if c.isForceQuirks(t) {
-			c.quirksMode = quirks
+			c.setQuirksMode(Quirks)
 		} else if c.isLimitedQuirks(t) {
-			c.quirksMode = limitedQuirks
+			c.setQuirksMode(LimitedQuirks)
 		} else {
-			c.quirksMode = noQuirks
+			c.setQuirksMode(NoQuirks)
 		}
 
 		return false, beforeHTML
@@ -1010,6 +1181,7 @@ func (c *HTMLTreeConstructor) initialModeHandler(t Token) (bool, insertionMode)
 
 func (c *HTMLTreeConstructor) defaultBeforeHTMLModeHandler(t Token) (bool, insertionMode) {
 	n := spec.NewDOMElement(c.HTMLDocument.Node, "html", spec.Htmlns)
+	c.countNode()
 	n.OwnerDocument = c.HTMLDocument.Node
 	c.HTMLDocument.AppendChild(n)
 	c.stackOfOpenElements.Push(n)
@@ -1160,12 +1332,20 @@ func (c *HTMLTreeConstructor) inHeadModeHandler(t Token) (bool, insertionMode) {
 			elem := c.createElementForToken(t, spec.Htmlns, il.node)
 			elem.ParserDocument = c.HTMLDocument
 			elem.NonBlocking = false
+			if c.context != nil {
+				elem.AlreadyStated = true
+			}
 			il.insert(elem)
 			c.stackOfOpenElements.Push(elem)
 			c.switchTokenizerState(scriptDataState)
 			c.originalInsertionMode = c.curInsertionMode
 			return false, text
 		case "template":
+			c.insertHTMLElementForToken(t)
+			c.activeFormattingElements.Push(spec.ScopeMarker)
+			c.frameset = framesetNotOK
+			c.stackOfTemplateInsertionModes = append(c.stackOfTemplateInsertionModes, inTemplate)
+			return false, inTemplate
 		case "head":
 			return false, inHead
 		}
@@ -1177,6 +1357,14 @@ func (c *HTMLTreeConstructor) inHeadModeHandler(t Token) (bool, insertionMode) {
 		case "body", "html", "br":
 			return c.defaultInHeadModeHandler(t)
 		case "template":
+			if len(c.containedInStackOpenElements("template")) == 0 {
+				return false, c.curInsertionMode
+			}
+			c.generateAllImpliedEndTagsThoroughly()
+			c.stackOfOpenElements.PopUntil("template")
+			c.clearListOfActiveFormattingElementsToLastMarker()
+			c.stackOfTemplateInsertionModes = c.stackOfTemplateInsertionModes[:len(c.stackOfTemplateInsertionModes)-1]
+			return false, c.resetInsertionMode()
 		default:
 			return false, inHead
 		}
@@ -1534,7 +1722,7 @@ func (c *HTMLTreeConstructor) inBodyModeHandler(t Token) (bool, insertionMode) {
 			c.activeFormattingElements.Push(spec.ScopeMarker)
 			c.frameset = framesetNotOK
 		case "table":
-			if c.quirksMode != quirks && c.stackOfOpenElements.ContainsElementInButtonScope("p") {
+			if c.quirksMode != Quirks && c.stackOfOpenElements.ContainsElementInButtonScope("p") {
 				c.closePElement()
 			}
 			c.insertHTMLElementForToken(t)
@@ -1771,8 +1959,11 @@ func (c *HTMLTreeConstructor) textModeHandler(t Token) (bool, insertionMode) {
 	case endTagToken:
 		switch t.TagName {
 		case "script":
-			c.stackOfOpenElements.Pop()
-			return false, c.originalInsertionMode
+			// the script can change the insertion mode through document.write
+			// so switch back before running it.
+			c.curInsertionMode = c.originalInsertionMode
+			c.processScriptEndTag(c.getCurrentNode())
+			return false, c.curInsertionMode
 		default:
 			c.stackOfOpenElements.Pop()
 			return false, c.originalInsertionMode
@@ -2230,11 +2421,31 @@ func (c *HTMLTreeConstructor) inSelectInTableModeHandler(t Token) (bool, inserti
 }
 func (c *HTMLTreeConstructor) inTemplateModeHandler(t Token) (bool, insertionMode) {
 	switch t.TokenType {
-	case characterToken:
-	case commentToken:
-	case docTypeToken:
+	case characterToken, commentToken, docTypeToken:
+		return c.useRulesFor(t, inBody)
 	case startTagToken:
+		var mode insertionMode
+		switch t.TagName {
+		case "base", "basefont", "bgsound", "link", "meta", "noframes", "script", "style", "template", "title":
+			return c.useRulesFor(t, inHead)
+		case "caption", "colgroup", "tbody", "tfoot", "thead":
+			mode = inTable
+		case "col":
+			mode = inColumnGroup
+		case "tr":
+			mode = inTableBody
+		case "td", "th":
+			mode = inRow
+		default:
+			mode = inBody
+		}
+		c.stackOfTemplateInsertionModes[len(c.stackOfTemplateInsertionModes)-1] = mode
+		return true, mode
 	case endTagToken:
+		if t.TagName == "template" {
+			return c.useRulesFor(t, inHead)
+		}
+		return false, inTemplate
 	case endOfFileToken:
 		nodes := c.containedInStackOpenElements("template")
 		if len(nodes) == 0 {
@@ -2449,11 +2660,71 @@ func (c *HTMLTreeConstructor) takeNextTokenizerState() *tokenizerState {
 }
 
 func (c *HTMLTreeConstructor) ProcessToken(t Token) *Progress {
+	if t.TokenType == characterToken {
+		c.processCharacters(t)
+	} else {
+		c.flushText()
+		c.processTokenInCurrentMode(t)
+	}
+	return MakeProgress(c.getAdjustedCurrentNode(), c.takeNextTokenizerState())
+}
+
+func (c *HTMLTreeConstructor) processTokenInCurrentMode(t Token) {
 	reprocess := true
 	for reprocess {
 		reprocess, c.curInsertionMode = c.processToken(t, c.curInsertionMode)
 	}
-	return MakeProgress(c.getAdjustedCurrentNode(), c.takeNextTokenizerState())
+}
+
+// processCharacters processes a character token that may hold a run of
+// characters. Runs are inserted at once when the current insertion mode would
+// insert each of the characters the same way, otherwise they are processed one
+// character at a time.
+func (c *HTMLTreeConstructor) processCharacters(t Token) {
+	hasNull := strings.IndexByte(t.Data, 0) != -1
+	for i, r := range t.Data {
+		if !hasNull && c.canInsertCharactersInBulk() {
+			t.Data = t.Data[i:]
+			c.insertCharactersInBulk(t)
+			return
+		}
+
+		c.processTokenInCurrentMode(Token{TokenType: characterToken, Data: string(r)})
+	}
+}
+
+// canInsertCharactersInBulk reports if the current insertion mode inserts all
+// characters other than NUL without switching modes.
+func (c *HTMLTreeConstructor) canInsertCharactersInBulk() bool {
+	if len(c.stackOfOpenElements.NodeList) == 0 {
+		return false
+	}
+	if !c.usesHTMLContentRules(characterToken, "") {
+		return true
+	}
+	switch c.curInsertionMode {
+	case inBody, text:
+		return true
+	}
+	return false
+}
+
+// insertCharactersInBulk does the same as processing each of the characters in
+// the token in turn for the modes allowed by canInsertCharactersInBulk.
+func (c *HTMLTreeConstructor) insertCharactersInBulk(t Token) {
+	foreign := !c.usesHTMLContentRules(characterToken, "")
+	if !foreign && c.curInsertionMode == text {
+		c.insertCharacter(t)
+		return
+	}
+
+	if !foreign {
+		c.reconstructActiveFormattingElements()
+	}
+	c.insertCharacter(t)
+	if strings.TrimLeft(t.Data, "\u0009\u000A\u000C\u000D\u0020") != "" {
+		c.frameset = framesetNotOK
+	}
 }
 
 func isMathmlIntPoint(e *spec.Node) bool {
@@ -2530,10 +2801,9 @@ var svgTable = map[string]string{
 }
 
 func (c *HTMLTreeConstructor) defaultParseTokensInForeignContentEndScriptTag(t Token, startMode insertionMode) (bool, insertionMode) {
-	c.stackOfOpenElements.Pop()
+	script := c.stackOfOpenElements.Pop()
 	// insertion point
-	// parser pause flag
-	// process svg script tags
+	c.processSVGScript(script)
 	return false, startMode
 }
 
@@ -2591,10 +2861,6 @@ func (c *HTMLTreeConstructor) parseTokensInForeignContent(t Token, startMode ins
 			"hr", "i", "img", "li", "listing", "menu", "meta", "nobr", "ol", "p",
 			"pre", "ruby", "s", "small", "span", "strong", "strike", "sub", "sup",
 			"table", "tt", "u", "ul", "var":
-			if c.context != nil {
-				return c.defaultParseTokensInForeignContentStartTag(t, startMode)
-			}
-			c.stackOfOpenElements.Pop()
 			c.stackOfOpenElements.PopUntilConditions(
 				isHTMLIntPoint,
 				isMathmlIntPoint,
@@ -2606,10 +2872,6 @@ func (c *HTMLTreeConstructor) parseTokensInForeignContent(t Token, startMode ins
 			_, face := t.Attributes["face"]
 			_, size := t.Attributes["size"]
 			if color || face || size {
-				if c.context != nil {
-					return c.defaultParseTokensInForeignContentStartTag(t, startMode)
-				}
-				c.stackOfOpenElements.Pop()
 				c.stackOfOpenElements.PopUntilConditions(
 					isHTMLIntPoint,
 					isMathmlIntPoint,
@@ -2622,7 +2884,7 @@ func (c *HTMLTreeConstructor) parseTokensInForeignContent(t Token, startMode ins
 			return c.defaultParseTokensInForeignContentStartTag(t, startMode)
 		}
 	case endTagToken:
-		if t.TagName == "script" && c.getCurrentNode().NodeName == "svg" && c.getCurrentNode().Element.NamespaceURI == spec.Svgns {
+		if t.TagName == "script" && c.getCurrentNode().NodeName == "script" && c.getCurrentNode().Element.NamespaceURI == spec.Svgns {
 			return c.defaultParseTokensInForeignContentEndScriptTag(t, startMode)
 		}
 
@@ -2646,20 +2908,27 @@ func (c *HTMLTreeConstructor) parseTokensInForeignContent(t Token, startMode ins
 	return false, startMode
 }
 
+// https://html.spec.whatwg.org/multipage/parsing.html#tree-construction-dispatcher
 func (c *HTMLTreeConstructor) dispatch(t Token, startMode insertionMode) (bool, insertionMode) {
-	acn := c.getAdjustedCurrentNode()
-	if len(c.stackOfOpenElements.NodeList) == 0 ||
-		acn.Element.NamespaceURI == spec.Htmlns ||
-		(isMathmlIntPoint(acn) && t.TokenType == startTagToken && t.TagName != "mglyph" && t.TagName != "malignmark") ||
-		(isMathmlIntPoint(acn) && t.TokenType == characterToken) ||
-		(acn.NodeName == "annotation-xml" && t.TokenType == startTagToken && t.TagName == "svg") ||
-		(isHTMLIntPoint(acn) && (t.TokenType == startTagToken || t.TokenType == characterToken)) {
+	if c.usesHTMLContentRules(t.TokenType, t.TagName) {
 		return c.modeToModeHandler(startMode)(t)
 	}
 
 	return c.parseTokensInForeignContent(t, startMode)
 }
 
+// usesHTMLContentRules reports if a token is processed using the rules of the
+// current insertion mode rather than the rules for foreign content.
+func (c *HTMLTreeConstructor) usesHTMLContentRules(tokenType tokenType, tagName string) bool {
+	acn := c.getAdjustedCurrentNode()
+	return len(c.stackOfOpenElements.NodeList) == 0 ||
+		acn.Element.NamespaceURI == spec.Htmlns ||
+		(isMathmlIntPoint(acn) && tokenType == startTagToken && tagName != "mglyph" && tagName != "malignmark") ||
+		(isMathmlIntPoint(acn) && tokenType == characterToken) ||
+		(acn.NodeName == "annotation-xml" && tokenType == startTagToken && tagName == "svg") ||
+		(isHTMLIntPoint(acn) && (tokenType == startTagToken || tokenType == characterToken))
+}
+
 func (c *HTMLTreeConstructor) processToken(t Token, startMode insertionMode) (bool, insertionMode) {
 	//old := c.HTMLDocument.Node.String()
 	reprocess, nextMode := c.dispatch(t, startMode)
@@ -0,0 +1,160 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/heathj/gobrowse/parser/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+type validityTestcase struct {
+	htmlIn   string
+	expected spec.ValidityStateFlags
+	message  string
+}
+
+func TestValidity(t *testing.T) {
+	tests := []validityTestcase{
+		{`<input required>`, spec.ValidityStateFlags{ValueMissing: true}, "Please fill out this field."},
+		{`<input required value=x>`, spec.ValidityStateFlags{}, ""},
+		{`<input type=checkbox required>`, spec.ValidityStateFlags{ValueMissing: true}, "Please check this box if you want to proceed."},
+		{`<input type=radio name=r required><input type=radio name=r checked>`, spec.ValidityStateFlags{}, ""},
+		{`<input type=radio name=r required><input type=radio name=s checked>`, spec.ValidityStateFlags{ValueMissing: true}, "Please select one of these options."},
+		{`<select required><option value="">pick</option><option>a</option></select>`, spec.ValidityStateFlags{ValueMissing: true}, "Please select an item in the list."},
+		{`<select required><option value="">pick</option><option selected>a</option></select>`, spec.ValidityStateFlags{}, ""},
+		{`<textarea required></textarea>`, spec.ValidityStateFlags{ValueMissing: true}, "Please fill out this field."},
+		{`<input type=email value=nope>`, spec.ValidityStateFlags{TypeMismatch: true}, "Please enter an email address."},
+		{`<input type=email multiple value="a@b.c, d@e">`, spec.ValidityStateFlags{}, ""},
+		{`<input type=email multiple value="a@b.c, d">`, spec.ValidityStateFlags{TypeMismatch: true}, "Please enter an email address."},
+		{`<input type=url value=/relative>`, spec.ValidityStateFlags{TypeMismatch: true}, "Please enter a URL."},
+		{`<input type=url value=http://example.com:99999>`, spec.ValidityStateFlags{TypeMismatch: true}, "Please enter a URL."},
+		{`<input type=url value="mailto:a@b.c">`, spec.ValidityStateFlags{}, ""},
+		{`<input pattern="[a-z]+" value=abc1 title="Lowercase letters">`, spec.ValidityStateFlags{PatternMismatch: true}, "Please match the requested format. Lowercase letters"},
+		{`<input pattern="[a-z]+" value=abc>`, spec.ValidityStateFlags{}, ""},
+		{`<input pattern="(" value=abc>`, spec.ValidityStateFlags{}, ""},
+		{`<input type=number min=1 max=10 value=0>`, spec.ValidityStateFlags{RangeUnderflow: true}, "Value must be greater than or equal to 1."},
+		{`<input type=number min=1 max=10 value=11>`, spec.ValidityStateFlags{RangeOverflow: true}, "Value must be less than or equal to 10."},
+		{`<input type=number min=1 step=2 value=4>`, spec.ValidityStateFlags{StepMismatch: true}, "Please enter a valid value."},
+		{`<input type=number step=0.1 value=0.3>`, spec.ValidityStateFlags{}, ""},
+		{`<input type=number step=any value=0.33>`, spec.ValidityStateFlags{}, ""},
+		{`<input type=number value=abc>`, spec.ValidityStateFlags{}, ""},
+		{`<input type=range value=150>`, spec.ValidityStateFlags{}, ""},
+		{`<input type=date min=2020-01-01 value=2019-12-31>`, spec.ValidityStateFlags{RangeUnderflow: true}, "Value must be greater than or equal to 2020-01-01."},
+		{`<input type=time value=10:30>`, spec.ValidityStateFlags{}, ""},
+		{`<input type=time min=10:00 value=10:30:30>`, spec.ValidityStateFlags{StepMismatch: true}, "Please enter a valid value."},
+		{`<input minlength=5 value=abc>`, spec.ValidityStateFlags{}, ""},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.htmlIn, func(t *testing.T) {
+			t.Parallel()
+			doc := parseTestDocument(t, tt.htmlIn)
+			el := findElement(findElement(doc, "body"), "input")
+			if el == nil {
+				el = findElement(doc, "body").ChildNodes[0]
+			}
+			assert.True(t, el.WillValidate())
+			validity := el.Validity()
+			assert.Equal(t, tt.expected, validity.ValidityStateFlags)
+			assert.Equal(t, tt.message == "", validity.Valid)
+			assert.Equal(t, tt.message, el.ValidationMessage())
+		})
+	}
+}
+
+func TestCompiledPattern(t *testing.T) {
+	doc := parseTestDocument(t, `<input pattern="[a-z]+"><input pattern="(?!x).*" value=x><input>`)
+	inputs := findElement(doc, "body").ChildNodes
+	pattern, err := inputs[0].CompiledPattern()
+	assert.NoError(t, err)
+	assert.True(t, pattern.MatchString("abc"))
+	assert.False(t, pattern.MatchString("abc1"))
+
+	pattern, err = inputs[1].CompiledPattern()
+	assert.Nil(t, pattern)
+	assert.ErrorIs(t, err, spec.ErrInvalidPattern)
+	assert.True(t, inputs[1].Validity().Valid)
+
+	pattern, err = inputs[2].CompiledPattern()
+	assert.Nil(t, pattern)
+	assert.NoError(t, err)
+}
+
+func TestCheckValidity(t *testing.T) {
+	doc := parseTestDocument(t, `<form><input name=a required><input name=b required disabled><input type=hidden required><input name=c value=x required></form>`)
+	form := findElement(doc, "form")
+	input := findElement(doc, "input")
+
+	invalid := []*spec.Node{}
+	form.AddEventListener("invalid", &spec.EventListener{Callback: func(e *spec.Event) {
+		invalid = append(invalid, e.Target)
+		assert.True(t, e.IsTrusted)
+	}, Capture: true})
+	assert.False(t, form.CheckValidity())
+	assert.False(t, form.HTMLForm.ReportValidity())
+	assert.Equal(t, []*spec.Node{input, input}, invalid)
+
+	input.SetCustomValidity("Taken")
+	assert.Equal(t, "Taken", input.ValidationMessage())
+	assert.True(t, input.Validity().CustomError)
+
+	input.SetCustomValidity("")
+	input.Attributes.Attrs["value"] = &spec.Attr{LocalName: "value", Name: "value", Value: "y"}
+	assert.True(t, input.CheckValidity())
+	assert.True(t, form.CheckValidity())
+	assert.Len(t, invalid, 2)
+}
+
+func TestElementInternalsValidity(t *testing.T) {
+	doc := parseTestDocument(t, `<form></form>`)
+	form := findElement(doc, "form")
+	el := spec.NewDOMElement(doc, "my-control", spec.Htmlns)
+	el.FormAssociatedCustomElement = &formAssociatedCustomElement{}
+	form.AppendChild(el)
+	internals, err := el.AttachInternals()
+	assert.NoError(t, err)
+
+	assert.Error(t, internals.SetValidity(spec.ValidityStateFlags{ValueMissing: true}, ""))
+	assert.NoError(t, internals.SetValidity(spec.ValidityStateFlags{ValueMissing: true}, "Required"))
+	assert.True(t, internals.WillValidate())
+	assert.False(t, internals.Validity().Valid)
+	assert.Equal(t, "Required", internals.ValidationMessage())
+	assert.False(t, form.CheckValidity())
+
+	assert.NoError(t, internals.SetValidity(spec.ValidityStateFlags{}, ""))
+	assert.True(t, internals.CheckValidity())
+}
+
+func TestDispatchEvent(t *testing.T) {
+	doc := parseTestDocument(t, `<div><p>x</p></div>`)
+	div, p := findElement(doc, "div"), findElement(doc, "p")
+	calls := []string{}
+	listener := func(name string) *spec.EventListener {
+		return &spec.EventListener{Callback: func(e *spec.Event) {
+			calls = append(calls, name+":"+e.CurrentTarget.NodeName)
+		}}
+	}
+	div.AddEventListener("x", &spec.EventListener{Callback: func(e *spec.Event) {
+		calls = append(calls, "capture:"+e.CurrentTarget.NodeName)
+	}, Capture: true})
+	div.AddEventListener("x", listener("bubble"))
+	once := listener("once")
+	once.Once = true
+	p.AddEventListener("x", once)
+	p.AddEventListener("x", &spec.EventListener{Callback: func(e *spec.Event) { e.PreventDefault() }})
+
+	assert.False(t, p.DispatchEvent(spec.NewEvent("x", true, true)))
+	assert.True(t, p.DispatchEvent(spec.NewEvent("x", false, false)))
+	assert.Equal(t, []string{"capture:div", "once:p", "bubble:div", "capture:div"}, calls)
+
+	stop := &spec.EventListener{Callback: func(e *spec.Event) { e.StopPropagation() }, Capture: true}
+	div.AddEventListener("x", stop)
+	calls = nil
+	p.DispatchEvent(spec.NewEvent("x", true, false))
+	assert.Equal(t, []string{"capture:div"}, calls)
+	div.RemoveEventListener("x", stop)
+	calls = nil
+	p.DispatchEvent(spec.NewEvent("x", true, false))
+	assert.Equal(t, []string{"capture:div", "bubble:div"}, calls)
+}
@@ -0,0 +1,192 @@
+// Package webidl parses the WebIDL fragments of the DOM and HTML standards,
+// https://webidl.spec.whatwg.org/, and generates the Go declarations of the
+// spec package from them.
+package webidl
+
+// Definitions are the definitions of one or more IDL fragments. Partial
+// definitions and includes statements are kept as they appear; Resolve
+// merges them into the definitions they extend.
+type Definitions struct {
+	Interfaces   []*Interface
+	Dictionaries []*Dictionary
+	Enums        []*Enum
+	Typedefs     []*Typedef
+	Callbacks    []*Callback
+	Includes     []*Includes
+}
+
+// ExtendedAttribute is https://webidl.spec.whatwg.org/#idl-extended-attributes
+// Value is the identifier after =, like for in [Reflect=for], and Args are
+// the identifiers of an identifier list or the arguments of an argument
+// list.
+type ExtendedAttribute struct {
+	Name  string
+	Value string
+	Args  []string
+}
+
+// ExtendedAttributes are the extended attributes of a definition, member,
+// argument or type.
+type ExtendedAttributes []*ExtendedAttribute
+
+// Get returns the extended attribute with the name, or nil if there isn't
+// one.
+func (attrs ExtendedAttributes) Get(name string) *ExtendedAttribute {
+	for _, a := range attrs {
+		if a.Name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+// Interface is an interface, interface mixin, callback interface or
+// namespace.
+// https://webidl.spec.whatwg.org/#idl-interfaces
+type Interface struct {
+	Name      string
+	Inherits  string
+	Partial   bool
+	Mixin     bool
+	Callback  bool
+	Namespace bool
+	Members   []Member
+	ExtAttrs  ExtendedAttributes
+	// Doc is the text of the comment lines before the definition.
+	Doc string
+}
+
+// Member is a *Constant, *Attribute, *Operation or *Constructor. Other
+// members like iterable declarations are skipped.
+type Member interface {
+	member()
+}
+
+// Constant is https://webidl.spec.whatwg.org/#idl-constants
+type Constant struct {
+	Name     string
+	Type     *Type
+	Value    *Value
+	ExtAttrs ExtendedAttributes
+	Doc      string
+}
+
+// Attribute is https://webidl.spec.whatwg.org/#idl-attributes
+type Attribute struct {
+	Name        string
+	Type        *Type
+	Readonly    bool
+	Static      bool
+	Inherit     bool
+	Stringifier bool
+	ExtAttrs    ExtendedAttributes
+	Doc         string
+}
+
+// Operation is https://webidl.spec.whatwg.org/#idl-operations
+// Special is getter, setter or deleter for special operations and Name is
+// empty for special operations without an identifier.
+type Operation struct {
+	Name     string
+	Return   *Type
+	Args     []*Argument
+	Static   bool
+	Special  string
+	ExtAttrs ExtendedAttributes
+	Doc      string
+}
+
+// Constructor is https://webidl.spec.whatwg.org/#idl-constructors
+type Constructor struct {
+	Args     []*Argument
+	ExtAttrs ExtendedAttributes
+	Doc      string
+}
+
+func (*Constant) member()    {}
+func (*Attribute) member()   {}
+func (*Operation) member()   {}
+func (*Constructor) member() {}
+
+// Argument is https://webidl.spec.whatwg.org/#idl-operations
+type Argument struct {
+	Name     string
+	Type     *Type
+	Optional bool
+	Variadic bool
+	Default  *Value
+	ExtAttrs ExtendedAttributes
+}
+
+// Dictionary is https://webidl.spec.whatwg.org/#idl-dictionaries
+type Dictionary struct {
+	Name     string
+	Inherits string
+	Partial  bool
+	Members  []*DictionaryMember
+	ExtAttrs ExtendedAttributes
+	Doc      string
+}
+
+// DictionaryMember is https://webidl.spec.whatwg.org/#dfn-dictionary-member
+type DictionaryMember struct {
+	Name     string
+	Type     *Type
+	Required bool
+	Default  *Value
+	ExtAttrs ExtendedAttributes
+	Doc      string
+}
+
+// Enum is https://webidl.spec.whatwg.org/#idl-enums
+type Enum struct {
+	Name     string
+	Values   []string
+	ExtAttrs ExtendedAttributes
+	Doc      string
+}
+
+// Typedef is https://webidl.spec.whatwg.org/#idl-typedefs
+type Typedef struct {
+	Name     string
+	Type     *Type
+	ExtAttrs ExtendedAttributes
+	Doc      string
+}
+
+// Callback is a callback function.
+// https://webidl.spec.whatwg.org/#idl-callback-functions
+type Callback struct {
+	Name     string
+	Return   *Type
+	Args     []*Argument
+	ExtAttrs ExtendedAttributes
+	Doc      string
+}
+
+// Includes is https://webidl.spec.whatwg.org/#include-statement
+type Includes struct {
+	Target string
+	Mixin  string
+}
+
+// Type is https://webidl.spec.whatwg.org/#idl-types
+// Name is the name of the type, like unsigned long, DOMString or Node, or
+// sequence, FrozenArray, ObservableArray, Promise or record for the generic
+// types with their type arguments in Params. Union types have an empty Name
+// and their member types in Union.
+type Type struct {
+	Name     string
+	Params   []*Type
+	Union    []*Type
+	Nullable bool
+	ExtAttrs ExtendedAttributes
+}
+
+// Value is a constant value or default value. Kind is one of boolean,
+// null, integer, decimal, string, sequence ([]) or dictionary ({}) and Text
+// is how the value was written.
+type Value struct {
+	Kind string
+	Text string
+}
@@ -0,0 +1,837 @@
+package webidl
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/types"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	// ErrUnsupported is returned for definitions the generator can't turn
+	// into Go, which need hand-written declarations.
+	ErrUnsupported = errors.New("unsupported IDL")
+	// ErrMismatch is returned when a hand-written method doesn't have the
+	// signature the IDL gives it.
+	ErrMismatch = errors.New("hand-written declaration doesn't match the IDL")
+	// ErrConflict is returned when a generated method would hide a method
+	// the type gets from a type it embeds.
+	ErrConflict = errors.New("generated declaration conflicts with a promoted method")
+)
+
+// Generator generates the Go declarations of IDL definitions for a package.
+//
+// Interfaces that inherit from Node are implemented by *Node, and their
+// attributes and operations are methods of *Node. Other interfaces are
+// structs that embed the struct of the interface they inherit from.
+// Dictionaries are structs, enums are string types with a constant for each
+// value and callback functions are func types. Attributes are a getter and,
+// unless they're readonly, a Set method. Attributes of Node interfaces with
+// the [Reflect] extended attribute reflect a content attribute; other
+// attributes and operations are unexported stubs returning zero values, so
+// they aren't part of the package's API until they're implemented.
+//
+// The hand-written declarations of the package are the hook for
+// implementations: a type, constant, method or field the package already has
+// isn't generated. Only the fields and methods declared on the type itself
+// count, not the ones promoted from the types it embeds. Hand-written methods
+// must have the signature the generated one would have, except that they may
+// return an error after the result for the exceptions they throw, and
+// hand-written fields must have the attribute's type. Overloaded operations,
+// constructors and static members are never generated.
+type Generator struct {
+	// Package is the name of the package of the generated files.
+	Package string
+	// Existing is the type-checked hand-written code of the package, without
+	// its generated files. It may be nil.
+	Existing *types.Package
+
+	interfaces   map[string]*Interface
+	partials     map[string][]*Interface
+	dictionaries map[string]*Dictionary
+	dictPartials map[string][]*Dictionary
+	enums        map[string]*Enum
+	typedefs     map[string]*Typedef
+	callbacks    map[string]*Callback
+}
+
+// NewGenerator creates a Generator for the definitions of all the fragments
+// of the package, which may refer to each other.
+func NewGenerator(pkg string, existing *types.Package, fragments ...*Definitions) *Generator {
+	g := &Generator{
+		Package:      pkg,
+		Existing:     existing,
+		interfaces:   map[string]*Interface{},
+		partials:     map[string][]*Interface{},
+		dictionaries: map[string]*Dictionary{},
+		dictPartials: map[string][]*Dictionary{},
+		enums:        map[string]*Enum{},
+		typedefs:     map[string]*Typedef{},
+		callbacks:    map[string]*Callback{},
+	}
+	for _, defs := range fragments {
+		for _, i := range defs.Interfaces {
+			if i.Partial {
+				g.partials[i.Name] = append(g.partials[i.Name], i)
+			} else {
+				g.interfaces[i.Name] = i
+			}
+		}
+		for _, d := range defs.Dictionaries {
+			if d.Partial {
+				g.dictPartials[d.Name] = append(g.dictPartials[d.Name], d)
+			} else {
+				g.dictionaries[d.Name] = d
+			}
+		}
+		for _, e := range defs.Enums {
+			g.enums[e.Name] = e
+		}
+		for _, t := range defs.Typedefs {
+			g.typedefs[t.Name] = t
+		}
+		for _, c := range defs.Callbacks {
+			g.callbacks[c.Name] = c
+		}
+	}
+	return g
+}
+
+// generation is the output of generating one fragment.
+type generation struct {
+	*Generator
+	source string
+	buf    bytes.Buffer
+	errs   errorList
+	// methods are the generated methods by receiver and name, so members
+	// generated for several interfaces implemented by the same type are
+	// only generated once.
+	methods map[string]string
+}
+
+// Generate returns the gofmt-ed Go file of the fragment's definitions.
+// source names the fragment in the file's header.
+func (g *Generator) Generate(source string, defs *Definitions) ([]byte, error) {
+	gen := &generation{Generator: g, source: source, methods: map[string]string{}}
+	fmt.Fprintf(&gen.buf, "// Code generated by specgen from %s. DO NOT EDIT.\n\npackage %s\n", source, g.Package)
+	for _, e := range defs.Enums {
+		gen.enum(e)
+	}
+	for _, c := range defs.Callbacks {
+		gen.callback(c)
+	}
+	for _, d := range defs.Dictionaries {
+		if !d.Partial {
+			gen.dictionary(d)
+		}
+	}
+	for _, i := range defs.Interfaces {
+		if i.Mixin || i.Namespace {
+			continue
+		}
+		if !i.Partial {
+			gen.interfaceType(i)
+		}
+		gen.members(i.Name, i)
+	}
+	for _, inc := range defs.Includes {
+		mixin, ok := g.interfaces[inc.Mixin]
+		if !ok || !mixin.Mixin {
+			gen.errorf("%w: %s includes unknown mixin %s", ErrUnsupported, inc.Target, inc.Mixin)
+			continue
+		}
+		gen.members(inc.Target, mixin)
+		for _, partial := range g.partials[inc.Mixin] {
+			gen.members(inc.Target, partial)
+		}
+	}
+	if len(gen.errs) > 0 {
+		return nil, gen.errs
+	}
+	return format.Source(gen.buf.Bytes())
+}
+
+func (gen *generation) errorf(format string, args ...interface{}) {
+	gen.errs = append(gen.errs, fmt.Errorf("%s: %w", gen.source, fmt.Errorf(format, args...)))
+}
+
+// errorList is every error of a generation, so a fragment is fixed in one go.
+type errorList []error
+
+func (l errorList) Error() string {
+	msgs := make([]string, len(l))
+	for i, err := range l {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Is reports if any of the errors is target.
+func (l errorList) Is(target error) bool {
+	for _, err := range l {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (gen *generation) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&gen.buf, format, args...)
+}
+
+// doc prints the doc comment of a declaration. A doc that's only a URL is
+// the URL of the declaration's definition.
+func (gen *generation) doc(name, doc string) {
+	if doc == "" {
+		return
+	}
+	if strings.HasPrefix(doc, "https://") && !strings.ContainsAny(doc, " \n") {
+		doc = name + " is " + doc
+	}
+	for _, line := range strings.Split(doc, "\n") {
+		gen.printf("// %s\n", line)
+	}
+}
+
+// declared reports if the package has a hand-written declaration with the
+// name.
+func (g *Generator) declared(name string) bool {
+	return g.Existing != nil && g.Existing.Scope().Lookup(name) != nil
+}
+
+// lookup finds the field or method of the hand-written type. It reports if
+// it's declared on the type itself rather than promoted from a type it
+// embeds, and is nil when the type doesn't have one or it's ambiguous.
+func (g *Generator) lookup(typeName, name string) (obj types.Object, own bool) {
+	if g.Existing == nil {
+		return nil, false
+	}
+	t := g.Existing.Scope().Lookup(typeName)
+	if t == nil {
+		return nil, false
+	}
+	obj, index, _ := types.LookupFieldOrMethod(types.NewPointer(t.Type()), true, g.Existing, name)
+	return obj, obj != nil && len(index) == 1
+}
+
+// exported is the Go name of an IDL identifier.
+func exported(name string) string {
+	if name == "" {
+		return ""
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// camel joins the words of an IDL constant name or enum value, like
+// SHOW_ELEMENT or no-referrer, into a Go name.
+func camel(s string) string {
+	words := strings.FieldsFunc(s, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	for i, w := range words {
+		words[i] = strings.ToUpper(w[:1]) + strings.ToLower(w[1:])
+	}
+	if len(words) == 0 {
+		return "Empty"
+	}
+	return strings.Join(words, "")
+}
+
+// isNode reports if the interface is Node or inherits from it.
+func (g *Generator) isNode(name string) bool {
+	for seen := 0; name != "" && seen < 100; seen++ {
+		if name == "Node" {
+			return true
+		}
+		i, ok := g.interfaces[name]
+		if !ok {
+			return false
+		}
+		name = i.Inherits
+	}
+	return false
+}
+
+// receiverType is the Go type implementing the interface.
+func (g *Generator) receiverType(name string) string {
+	if g.isNode(name) {
+		return "Node"
+	}
+	return name
+}
+
+var primitiveTypes = map[string]string{
+	"boolean":             "bool",
+	"byte":                "int8",
+	"octet":               "uint8",
+	"short":               "int16",
+	"unsigned short":      "uint16",
+	"long":                "int",
+	"unsigned long":       "uint",
+	"long long":           "int64",
+	"unsigned long long":  "uint64",
+	"float":               "float32",
+	"unrestricted float":  "float32",
+	"double":              "float64",
+	"unrestricted double": "float64",
+	"DOMString":           "string",
+	"ByteString":          "string",
+	"USVString":           "string",
+	"any":                 "interface{}",
+	"object":              "interface{}",
+	"symbol":              "interface{}",
+	"Promise":             "interface{}",
+	"undefined":           "",
+}
+
+// goType is the Go type of an IDL type. Nullable types are the same as
+// their inner type, so null is the zero value of primitive types.
+func (g *Generator) goType(t *Type) (string, error) {
+	if t.Union != nil {
+		return "interface{}", nil
+	}
+	if goType, ok := primitiveTypes[t.Name]; ok {
+		return goType, nil
+	}
+	switch t.Name {
+	case "sequence", "FrozenArray", "ObservableArray":
+		elem, err := g.goType(t.Params[0])
+		return "[]" + elem, err
+	case "record":
+		if len(t.Params) != 2 {
+			return "", fmt.Errorf("%w: record type %s", ErrUnsupported, t.Name)
+		}
+		value, err := g.goType(t.Params[1])
+		return "map[string]" + value, err
+	}
+	if td, ok := g.typedefs[t.Name]; ok {
+		return g.goType(td.Type)
+	}
+	if _, ok := g.enums[t.Name]; ok {
+		return t.Name, nil
+	}
+	if _, ok := g.dictionaries[t.Name]; ok {
+		return t.Name, nil
+	}
+	if _, ok := g.callbacks[t.Name]; ok {
+		return t.Name, nil
+	}
+	if i, ok := g.interfaces[t.Name]; ok {
+		if i.Callback {
+			return t.Name, nil
+		}
+		return "*" + g.receiverType(t.Name), nil
+	}
+	if g.Existing != nil {
+		if obj, ok := g.Existing.Scope().Lookup(t.Name).(*types.TypeName); ok {
+			if _, ok := obj.Type().Underlying().(*types.Struct); ok {
+				return "*" + t.Name, nil
+			}
+			return t.Name, nil
+		}
+	}
+	return "", fmt.Errorf("%w: unknown type %s", ErrUnsupported, t.Name)
+}
+
+// zero is the zero value of the Go type.
+func (g *Generator) zero(goType string) string {
+	switch goType {
+	case "bool":
+		return "false"
+	case "string":
+		return `""`
+	case "int8", "uint8", "int16", "uint16", "int", "uint", "int64", "uint64", "float32", "float64":
+		return "0"
+	}
+	if strings.HasPrefix(goType, "*") || strings.HasPrefix(goType, "[]") || strings.HasPrefix(goType, "map[") ||
+		goType == "interface{}" {
+		return "nil"
+	}
+	if _, ok := g.enums[goType]; ok {
+		return `""`
+	}
+	if _, ok := g.callbacks[goType]; ok {
+		return "nil"
+	}
+	if i, ok := g.interfaces[goType]; ok && i.Callback && !g.declared(goType) {
+		return "nil"
+	}
+	if g.Existing != nil {
+		if obj, ok := g.Existing.Scope().Lookup(goType).(*types.TypeName); ok {
+			switch u := obj.Type().Underlying().(type) {
+			case *types.Basic:
+				if u.Info()&types.IsString != 0 {
+					return `""`
+				}
+				if u.Info()&types.IsBoolean != 0 {
+					return "false"
+				}
+				return "0"
+			case *types.Struct:
+			default:
+				return "nil"
+			}
+		}
+	}
+	return goType + "{}"
+}
+
+func (gen *generation) enum(e *Enum) {
+	if gen.declared(e.Name) {
+		return
+	}
+	gen.printf("\n")
+	gen.doc(e.Name, e.Doc)
+	gen.printf("type %s string\n\nconst (\n", e.Name)
+	for _, v := range e.Values {
+		gen.printf("\t%s%s %s = %q\n", e.Name, camel(v), e.Name, v)
+	}
+	gen.printf(")\n")
+}
+
+func (gen *generation) callback(c *Callback) {
+	if gen.declared(c.Name) {
+		return
+	}
+	signature, err := gen.signature(c.Args, c.Return)
+	if err != nil {
+		gen.errorf("callback %s: %w", c.Name, err)
+		return
+	}
+	gen.printf("\n")
+	gen.doc(c.Name, c.Doc)
+	gen.printf("type %s func%s\n", c.Name, signature)
+}
+
+func (gen *generation) dictionary(d *Dictionary) {
+	if gen.declared(d.Name) {
+		return
+	}
+	members := append([]*DictionaryMember{}, d.Members...)
+	for _, partial := range gen.dictPartials[d.Name] {
+		members = append(members, partial.Members...)
+	}
+	gen.printf("\n")
+	gen.doc(d.Name, d.Doc)
+	gen.printf("type %s struct {\n", d.Name)
+	if d.Inherits != "" {
+		gen.printf("\t%s\n", d.Inherits)
+	}
+	defaults := []string{}
+	if d.Inherits != "" && gen.hasDefaults(d.Inherits) {
+		defaults = append(defaults, fmt.Sprintf("%s: Default%s()", d.Inherits, d.Inherits))
+	}
+	for _, m := range members {
+		goType, err := gen.goType(m.Type)
+		if err != nil {
+			gen.errorf("dictionary %s: %w", d.Name, err)
+			continue
+		}
+		if m.Doc != "" {
+			gen.doc(exported(m.Name), m.Doc)
+		}
+		gen.printf("\t%s %s\n", exported(m.Name), goType)
+		if value, ok := gen.defaultValue(m.Default, goType); ok {
+			defaults = append(defaults, fmt.Sprintf("%s: %s", exported(m.Name), value))
+		}
+	}
+	gen.printf("}\n")
+	if len(defaults) > 0 {
+		gen.printf("\n// Default%s returns a %s with the default values of its members.\n", d.Name, d.Name)
+		gen.printf("func Default%s() %s {\n\treturn %s{%s}\n}\n", d.Name, d.Name, d.Name, strings.Join(defaults, ", "))
+	}
+}
+
+// hasDefaults reports if the dictionary or one it inherits from has a
+// member with a default value other than the zero value.
+func (g *Generator) hasDefaults(name string) bool {
+	d, ok := g.dictionaries[name]
+	if !ok || g.declared(name) {
+		return false
+	}
+	members := append([]*DictionaryMember{}, d.Members...)
+	for _, partial := range g.dictPartials[name] {
+		members = append(members, partial.Members...)
+	}
+	for _, m := range members {
+		goType, err := g.goType(m.Type)
+		if err != nil {
+			continue
+		}
+		if _, ok := g.defaultValue(m.Default, goType); ok {
+			return true
+		}
+	}
+	return d.Inherits != "" && g.hasDefaults(d.Inherits)
+}
+
+// defaultValue is the Go expression of a default value, which is false if
+// it's the zero value.
+func (g *Generator) defaultValue(v *Value, goType string) (string, bool) {
+	if v == nil {
+		return "", false
+	}
+	switch v.Kind {
+	case "boolean":
+		return v.Text, v.Text == "true"
+	case "integer":
+		n, err := strconv.ParseInt(v.Text, 0, 64)
+		return v.Text, err == nil && n != 0
+	case "decimal":
+		f, err := strconv.ParseFloat(v.Text, 64)
+		return v.Text, err == nil && f != 0 && !math.IsInf(f, 0) && !math.IsNaN(f)
+	case "string":
+		return strconv.Quote(v.Text), v.Text != ""
+	}
+	return "", false
+}
+
+func (gen *generation) interfaceType(i *Interface) {
+	if i.Callback {
+		if !gen.declared(i.Name) {
+			gen.callbackInterface(i)
+		}
+	} else if !gen.isNode(i.Name) && !gen.declared(i.Name) {
+		gen.printf("\n")
+		gen.doc(i.Name, i.Doc)
+		gen.printf("type %s struct {\n", i.Name)
+		if i.Inherits != "" && !gen.isNode(i.Inherits) {
+			gen.printf("\t%s\n", i.Inherits)
+		}
+		gen.printf("}\n")
+	}
+	gen.constants(i)
+}
+
+// callbackInterface declares a Go interface with the operations of the
+// callback interface.
+func (gen *generation) callbackInterface(i *Interface) {
+	gen.printf("\n")
+	gen.doc(i.Name, i.Doc)
+	gen.printf("type %s interface {\n", i.Name)
+	for _, m := range i.Members {
+		op, ok := m.(*Operation)
+		if !ok || op.Name == "" {
+			continue
+		}
+		signature, err := gen.signature(op.Args, op.Return)
+		if err != nil {
+			gen.errorf("%s.%s: %w", i.Name, op.Name, err)
+			continue
+		}
+		gen.printf("\t%s%s\n", exported(op.Name), signature)
+	}
+	gen.printf("}\n")
+}
+
+func (gen *generation) constants(i *Interface) {
+	var consts []string
+	for _, m := range i.Members {
+		c, ok := m.(*Constant)
+		if !ok {
+			continue
+		}
+		name := i.Name + camel(c.Name)
+		if gen.declared(name) {
+			continue
+		}
+		goType, err := gen.goType(c.Type)
+		if err != nil {
+			gen.errorf("%s.%s: %w", i.Name, c.Name, err)
+			continue
+		}
+		if c.Value.Kind != "integer" && c.Value.Kind != "boolean" && c.Value.Kind != "decimal" {
+			gen.errorf("%w: %s.%s has a %s value", ErrUnsupported, i.Name, c.Name, c.Value.Kind)
+			continue
+		}
+		if f, err := strconv.ParseFloat(c.Value.Text, 64); c.Value.Kind == "decimal" && (err != nil || math.IsInf(f, 0) || math.IsNaN(f)) {
+			gen.errorf("%w: %s.%s is %s", ErrUnsupported, i.Name, c.Name, c.Value.Text)
+			continue
+		}
+		consts = append(consts, fmt.Sprintf("\t%s %s = %s\n", name, goType, c.Value.Text))
+	}
+	if len(consts) == 0 {
+		return
+	}
+	gen.printf("\n// The constants of %s.\nconst (\n%s)\n", i.Name, strings.Join(consts, ""))
+}
+
+// members generates the methods of the attributes and operations of the
+// interface, partial interface or mixin for the target interface.
+func (gen *generation) members(target string, i *Interface) {
+	if i.Callback {
+		return
+	}
+	base := ""
+	if doc := gen.interfaces[target]; doc != nil && strings.HasPrefix(doc.Doc, "https://") {
+		base = doc.Doc
+		if hash := strings.IndexByte(base, '#'); hash >= 0 {
+			base = base[:hash]
+		}
+	}
+	overloaded := map[string]int{}
+	for _, m := range i.Members {
+		if op, ok := m.(*Operation); ok {
+			overloaded[op.Name]++
+		}
+	}
+	for _, m := range i.Members {
+		switch m := m.(type) {
+		case *Attribute:
+			if m.Static {
+				continue
+			}
+			doc := m.Doc
+			if doc == "" && base != "" {
+				doc = base + "#dom-" + strings.ToLower(target+"-"+m.Name)
+			}
+			gen.attribute(target, m, doc)
+		case *Operation:
+			if m.Static || m.Name == "" || overloaded[m.Name] > 1 {
+				continue
+			}
+			doc := m.Doc
+			if doc == "" && base != "" {
+				doc = base + "#dom-" + strings.ToLower(target+"-"+m.Name)
+			}
+			gen.operation(target, m, doc)
+		}
+	}
+}
+
+func (gen *generation) attribute(target string, a *Attribute, doc string) {
+	goType, err := gen.goType(a.Type)
+	if err != nil {
+		gen.errorf("%s.%s: %w", target, a.Name, err)
+		return
+	}
+	name := exported(a.Name)
+	// A field with the attribute's name is the hand-written implementation
+	// of both the getter and setter.
+	if obj, own := gen.lookup(gen.receiverType(target), name); own {
+		if field, isField := obj.(*types.Var); isField {
+			if got := gen.typeString(field.Type()); got != goType {
+				gen.errorf("%w: %s.%s is %s, the IDL gives %s", ErrMismatch, gen.receiverType(target), name, got, goType)
+			}
+			return
+		}
+	}
+	if reflect := a.ExtAttrs.Get("Reflect"); reflect != nil {
+		if !gen.isNode(target) {
+			gen.errorf("%w: %s.%s reflects a content attribute but %s isn't an element", ErrUnsupported, target, a.Name, target)
+			return
+		}
+		contentAttribute := strings.ToLower(a.Name)
+		if reflect.Value != "" {
+			contentAttribute = reflect.Value
+		}
+		gen.reflect(name, goType, contentAttribute, a.Readonly)
+		return
+	}
+	receiver := gen.receiverType(target)
+	gen.method(receiver, name, doc, nil, goType)
+	if !a.Readonly {
+		gen.method(receiver, "Set"+name, doc, []string{"v " + goType}, "")
+	}
+}
+
+// reflect generates the methods of an attribute of an element that reflects
+// the content attribute.
+// https://html.spec.whatwg.org/multipage/common-dom-interfaces.html#reflecting-content-attributes-in-idl-attributes
+func (gen *generation) reflect(name, goType, contentAttribute string, readonly bool) {
+	var get, set string
+	switch goType {
+	case "string":
+		get = fmt.Sprintf("return n.GetAttribute(%q)", contentAttribute)
+		set = fmt.Sprintf("n.SetAttribute(%q, v)", contentAttribute)
+	case "bool":
+		get = fmt.Sprintf("return n.HasAttribute(%q)", contentAttribute)
+		set = fmt.Sprintf("n.setBooleanAttribute(%q, v)", contentAttribute)
+	case "int":
+		get = fmt.Sprintf("return n.reflectLong(%q)", contentAttribute)
+		set = fmt.Sprintf("n.setLongAttribute(%q, v)", contentAttribute)
+	default:
+		gen.errorf("%w: %s reflects %s as %s", ErrUnsupported, name, contentAttribute, goType)
+		return
+	}
+	doc := fmt.Sprintf("%s reflects the %s content attribute.", name, contentAttribute)
+	if gen.generateMethod("Node", name, nil, goType, doc+get) {
+		gen.printf("\n// %s\nfunc (n *Node) %s() %s { %s }\n", doc, name, goType, get)
+	}
+	doc = fmt.Sprintf("Set%s sets the %s content attribute.", name, contentAttribute)
+	if !readonly && gen.generateMethod("Node", "Set"+name, []string{"v " + goType}, "", doc+set) {
+		gen.printf("\n// %s\nfunc (n *Node) Set%s(v %s) { %s }\n", doc, name, goType, set)
+	}
+}
+
+func (gen *generation) operation(target string, op *Operation, doc string) {
+	params := []string{}
+	for _, arg := range op.Args {
+		goType, err := gen.goType(arg.Type)
+		if err != nil {
+			gen.errorf("%s.%s: %w", target, op.Name, err)
+			return
+		}
+		if arg.Variadic {
+			goType = "..." + goType
+		}
+		params = append(params, paramName(arg.Name)+" "+goType)
+	}
+	result := ""
+	if op.Return != nil {
+		var err error
+		if result, err = gen.goType(op.Return); err != nil {
+			gen.errorf("%s.%s: %w", target, op.Name, err)
+			return
+		}
+	}
+	gen.method(gen.receiverType(target), exported(op.Name), doc, params, result)
+}
+
+// method generates an unexported stub of the method unless the type has a
+// hand-written one.
+func (gen *generation) method(receiver, name, doc string, params []string, result string) {
+	body := ""
+	if result != "" {
+		body = " return " + gen.zero(result) + " "
+	}
+	if !gen.generateMethod(receiver, name, params, result, body) {
+		return
+	}
+	stub := paramName(strings.ToLower(name[:1]) + name[1:])
+	if obj, _ := gen.lookup(receiver, stub); obj != nil {
+		gen.errorf("%w: the stub of %s.%s is hidden by %s", ErrConflict, receiver, name, obj.Name())
+		return
+	}
+	recv := strings.ToLower(receiver[:1])
+	gen.printf("\n")
+	gen.doc(stub, doc)
+	gen.printf("func (%s *%s) %s(%s) %s {%s}\n", recv, receiver, stub, strings.Join(params, ", "), result, body)
+}
+
+// generateMethod reports if the method should be generated. Hand-written
+// methods are checked against the signature and methods already generated
+// by another interface must be the same.
+func (gen *generation) generateMethod(receiver, name string, params []string, result, body string) bool {
+	key := receiver + "." + name
+	if prev, ok := gen.methods[key]; ok {
+		if prev != body {
+			gen.errorf("%w: %s is generated differently by two interfaces", ErrUnsupported, key)
+		}
+		return false
+	}
+	gen.methods[key] = body
+	obj, own := gen.lookup(receiver, name)
+	if obj == nil {
+		return true
+	}
+	fn, isFunc := obj.(*types.Func)
+	if !own {
+		// promoted fields are hidden by the method, but promoted methods
+		// may be hand-written implementations on the wrong type.
+		if isFunc {
+			gen.errorf("%w: %s hides %s", ErrConflict, key, fn.FullName())
+			return false
+		}
+		return true
+	}
+	if isFunc {
+		want := "(" + paramTypes(params) + ")" + result
+		if got := gen.signatureOf(fn); got != want && got != strings.TrimSuffix(want, result)+resultWithError(result) {
+			gen.errorf("%w: %s is func%s, the IDL gives func%s", ErrMismatch, key, got, want)
+		}
+	}
+	return false
+}
+
+// paramTypes drops the names of the parameters.
+func paramTypes(params []string) string {
+	ts := make([]string, len(params))
+	for i, p := range params {
+		ts[i] = p[strings.IndexByte(p, ' ')+1:]
+	}
+	return strings.Join(ts, ", ")
+}
+
+func resultWithError(result string) string {
+	if result == "" {
+		return "error"
+	}
+	return "(" + result + ", error)"
+}
+
+// typeString formats a hand-written type like the generator writes it.
+func (g *Generator) typeString(t types.Type) string {
+	return types.TypeString(t, func(p *types.Package) string {
+		if p == g.Existing {
+			return ""
+		}
+		return p.Name()
+	})
+}
+
+// signatureOf formats the parameter and result types of a hand-written
+// method like the generator writes them.
+func (g *Generator) signatureOf(fn *types.Func) string {
+	sig := fn.Type().(*types.Signature)
+	params := make([]string, sig.Params().Len())
+	for i := range params {
+		t := sig.Params().At(i).Type()
+		if sig.Variadic() && i == len(params)-1 {
+			params[i] = "..." + g.typeString(t.(*types.Slice).Elem())
+		} else {
+			params[i] = g.typeString(t)
+		}
+	}
+	results := make([]string, sig.Results().Len())
+	for i := range results {
+		results[i] = g.typeString(sig.Results().At(i).Type())
+	}
+	result := strings.Join(results, ", ")
+	if len(results) > 1 {
+		result = "(" + result + ")"
+	}
+	return "(" + strings.Join(params, ", ") + ")" + result
+}
+
+// signature is the Go signature of the arguments and return type.
+func (g *Generator) signature(args []*Argument, ret *Type) (string, error) {
+	params := []string{}
+	for _, arg := range args {
+		goType, err := g.goType(arg.Type)
+		if err != nil {
+			return "", err
+		}
+		if arg.Variadic {
+			goType = "..." + goType
+		}
+		params = append(params, paramName(arg.Name)+" "+goType)
+	}
+	result, err := g.goType(ret)
+	if err != nil {
+		return "", err
+	}
+	if result != "" {
+		result = " " + result
+	}
+	return "(" + strings.Join(params, ", ") + ")" + result, nil
+}
+
+var goKeywords = map[string]bool{
+	"break": true, "case": true, "chan": true, "const": true, "continue": true, "default": true,
+	"defer": true, "else": true, "fallthrough": true, "for": true, "func": true, "go": true, "goto": true,
+	"if": true, "import": true, "interface": true, "map": true, "package": true, "range": true,
+	"return": true, "select": true, "struct": true, "switch": true, "type": true, "var": true,
+}
+
+// paramName is the Go name of an argument, which can't be a keyword.
+func paramName(name string) string {
+	if goKeywords[name] {
+		return name + "_"
+	}
+	return name
+}
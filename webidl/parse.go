@@ -0,0 +1,626 @@
+package webidl
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrSyntax is returned for fragments that don't match the WebIDL grammar
+// or use parts of it that aren't supported.
+var ErrSyntax = errors.New("WebIDL syntax error")
+
+type tokenKind int
+
+const (
+	identifierToken tokenKind = iota
+	integerToken
+	decimalToken
+	stringToken
+	otherToken
+	eofToken
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	line int
+	// doc is the text of the comment lines right before the token.
+	doc string
+}
+
+// https://webidl.spec.whatwg.org/#idl-grammar
+var (
+	integerPattern    = regexp.MustCompile(`^-?([1-9][0-9]*|0[Xx][0-9A-Fa-f]+|0[0-7]*)`)
+	decimalPattern    = regexp.MustCompile(`^-?(([0-9]+\.[0-9]*|[0-9]*\.[0-9]+)([Ee][+-]?[0-9]+)?|[0-9]+[Ee][+-]?[0-9]+)`)
+	identifierPattern = regexp.MustCompile(`^[_-]?[A-Za-z][0-9A-Z_a-z-]*`)
+	stringPattern     = regexp.MustCompile(`^"[^"]*"`)
+)
+
+// tokenize splits the fragment into tokens, skipping whitespace and
+// comments. The lines of // comments right before a token are its doc.
+func tokenize(file, src string) ([]*token, error) {
+	tokens := []*token{}
+	line := 1
+	var doc []string
+	for len(src) > 0 {
+		switch {
+		case src[0] == '\n':
+			if len(doc) > 0 && strings.HasPrefix(strings.TrimLeft(src[1:], " \t"), "\n") {
+				doc = nil
+			}
+			line++
+			src = src[1:]
+			continue
+		case src[0] == ' ' || src[0] == '\t' || src[0] == '\r':
+			src = src[1:]
+			continue
+		case strings.HasPrefix(src, "//"):
+			end := strings.IndexByte(src, '\n')
+			if end < 0 {
+				end = len(src)
+			}
+			doc = append(doc, strings.TrimSpace(src[2:end]))
+			src = src[end:]
+			continue
+		case strings.HasPrefix(src, "/*"):
+			end := strings.Index(src, "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("%w: %s:%d: unterminated comment", ErrSyntax, file, line)
+			}
+			line += strings.Count(src[:end], "\n")
+			src = src[end+2:]
+			continue
+		}
+
+		t := &token{kind: otherToken, line: line, doc: strings.Join(doc, "\n")}
+		doc = nil
+		if m := decimalPattern.FindString(src); m != "" {
+			t.kind, t.text = decimalToken, m
+		} else if m := integerPattern.FindString(src); m != "" {
+			t.kind, t.text = integerToken, m
+		} else if m := identifierPattern.FindString(src); m != "" {
+			t.kind, t.text = identifierToken, m
+		} else if m := stringPattern.FindString(src); m != "" {
+			t.kind, t.text = stringToken, m
+		} else if strings.HasPrefix(src, "...") {
+			t.text = "..."
+		} else {
+			t.text = src[:1]
+		}
+		src = src[len(t.text):]
+		tokens = append(tokens, t)
+	}
+	return append(tokens, &token{kind: eofToken, line: line}), nil
+}
+
+type parser struct {
+	file   string
+	tokens []*token
+	pos    int
+}
+
+// Parse parses an IDL fragment. file is only used in errors.
+func Parse(file, src string) (*Definitions, error) {
+	tokens, err := tokenize(file, src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{file: file, tokens: tokens}
+	defs := &Definitions{}
+	for p.peek().kind != eofToken {
+		if err := p.parseDefinition(defs); err != nil {
+			return nil, err
+		}
+	}
+	return defs, nil
+}
+
+func (p *parser) peek() *token { return p.tokens[p.pos] }
+
+func (p *parser) peekAt(i int) *token {
+	if p.pos+i >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+i]
+}
+
+func (p *parser) next() *token {
+	t := p.tokens[p.pos]
+	if t.kind != eofToken {
+		p.pos++
+	}
+	return t
+}
+
+// is reports if the next token is the identifier or punctuation.
+func (p *parser) is(text string) bool {
+	t := p.peek()
+	return (t.kind == identifierToken || t.kind == otherToken) && t.text == text
+}
+
+// accept consumes the next token if it's the identifier or punctuation.
+func (p *parser) accept(text string) bool {
+	if p.is(text) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	t := p.peek()
+	found := t.text
+	if t.kind == eofToken {
+		found = "end of file"
+	}
+	return fmt.Errorf("%w: %s:%d: %s, found %q", ErrSyntax, p.file, t.line, fmt.Sprintf(format, args...), found)
+}
+
+func (p *parser) expect(text string) error {
+	if !p.accept(text) {
+		return p.errorf("expected %q", text)
+	}
+	return nil
+}
+
+func (p *parser) identifier() (string, error) {
+	if p.peek().kind != identifierToken {
+		return "", p.errorf("expected an identifier")
+	}
+	return strings.TrimPrefix(p.next().text, "_"), nil
+}
+
+func (p *parser) parseDefinition(defs *Definitions) error {
+	doc := p.peek().doc
+	extAttrs, err := p.parseExtendedAttributes()
+	if err != nil {
+		return err
+	}
+	partial := p.accept("partial")
+	switch {
+	case p.accept("callback"):
+		if p.accept("interface") {
+			i, err := p.parseInterfaceBody(true)
+			if err != nil {
+				return err
+			}
+			i.Callback, i.ExtAttrs, i.Doc = true, extAttrs, doc
+			defs.Interfaces = append(defs.Interfaces, i)
+			return nil
+		}
+		c := &Callback{ExtAttrs: extAttrs, Doc: doc}
+		if c.Name, err = p.identifier(); err != nil {
+			return err
+		}
+		if err := p.expect("="); err != nil {
+			return err
+		}
+		if c.Return, err = p.parseType(); err != nil {
+			return err
+		}
+		if c.Args, err = p.parseArguments(); err != nil {
+			return err
+		}
+		defs.Callbacks = append(defs.Callbacks, c)
+		return p.expect(";")
+	case p.accept("interface"):
+		mixin := p.accept("mixin")
+		i, err := p.parseInterfaceBody(!mixin)
+		if err != nil {
+			return err
+		}
+		i.Partial, i.Mixin, i.ExtAttrs, i.Doc = partial, mixin, extAttrs, doc
+		defs.Interfaces = append(defs.Interfaces, i)
+		return nil
+	case p.accept("namespace"):
+		i, err := p.parseInterfaceBody(false)
+		if err != nil {
+			return err
+		}
+		i.Partial, i.Namespace, i.ExtAttrs, i.Doc = partial, true, extAttrs, doc
+		defs.Interfaces = append(defs.Interfaces, i)
+		return nil
+	case p.accept("dictionary"):
+		d, err := p.parseDictionary()
+		if err != nil {
+			return err
+		}
+		d.Partial, d.ExtAttrs, d.Doc = partial, extAttrs, doc
+		defs.Dictionaries = append(defs.Dictionaries, d)
+		return nil
+	case partial:
+		return p.errorf("expected interface, namespace or dictionary")
+	case p.accept("enum"):
+		e, err := p.parseEnum()
+		if err != nil {
+			return err
+		}
+		e.ExtAttrs, e.Doc = extAttrs, doc
+		defs.Enums = append(defs.Enums, e)
+		return nil
+	case p.accept("typedef"):
+		t := &Typedef{ExtAttrs: extAttrs, Doc: doc}
+		if t.Type, err = p.parseType(); err != nil {
+			return err
+		}
+		if t.Name, err = p.identifier(); err != nil {
+			return err
+		}
+		defs.Typedefs = append(defs.Typedefs, t)
+		return p.expect(";")
+	}
+
+	target, err := p.identifier()
+	if err != nil {
+		return p.errorf("expected a definition")
+	}
+	if err := p.expect("includes"); err != nil {
+		return err
+	}
+	mixin, err := p.identifier()
+	if err != nil {
+		return err
+	}
+	defs.Includes = append(defs.Includes, &Includes{Target: target, Mixin: mixin})
+	return p.expect(";")
+}
+
+// parseInterfaceBody parses the name, inheritance and members of an
+// interface-like definition.
+func (p *parser) parseInterfaceBody(inherits bool) (*Interface, error) {
+	i := &Interface{}
+	var err error
+	if i.Name, err = p.identifier(); err != nil {
+		return nil, err
+	}
+	if inherits && p.accept(":") {
+		if i.Inherits, err = p.identifier(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	for !p.accept("}") {
+		m, err := p.parseMember()
+		if err != nil {
+			return nil, err
+		}
+		if m != nil {
+			i.Members = append(i.Members, m)
+		}
+	}
+	return i, p.expect(";")
+}
+
+// parseMember parses an interface member. It returns nil for members that
+// are skipped.
+func (p *parser) parseMember() (Member, error) {
+	doc := p.peek().doc
+	extAttrs, err := p.parseExtendedAttributes()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case p.accept("const"):
+		c := &Constant{ExtAttrs: extAttrs, Doc: doc}
+		if c.Type, err = p.parseType(); err != nil {
+			return nil, err
+		}
+		if c.Name, err = p.identifier(); err != nil {
+			return nil, err
+		}
+		if err := p.expect("="); err != nil {
+			return nil, err
+		}
+		if c.Value, err = p.parseValue(); err != nil {
+			return nil, err
+		}
+		return c, p.expect(";")
+	case p.accept("constructor"):
+		c := &Constructor{ExtAttrs: extAttrs, Doc: doc}
+		if c.Args, err = p.parseArguments(); err != nil {
+			return nil, err
+		}
+		return c, p.expect(";")
+	case p.is("iterable") || p.is("async") || p.is("maplike") || p.is("setlike") ||
+		(p.is("readonly") && (p.peekAt(1).text == "maplike" || p.peekAt(1).text == "setlike")):
+		for !p.accept(";") {
+			if p.next().kind == eofToken {
+				return nil, p.errorf("expected %q", ";")
+			}
+		}
+		return nil, nil
+	}
+
+	static := p.accept("static")
+	stringifier := p.accept("stringifier")
+	if stringifier && p.accept(";") {
+		return nil, nil
+	}
+	inherit := p.accept("inherit")
+	readonly := p.accept("readonly")
+	if p.accept("attribute") {
+		a := &Attribute{Readonly: readonly, Static: static, Inherit: inherit, Stringifier: stringifier, ExtAttrs: extAttrs, Doc: doc}
+		if a.Type, err = p.parseType(); err != nil {
+			return nil, err
+		}
+		if a.Name, err = p.identifier(); err != nil {
+			return nil, err
+		}
+		return a, p.expect(";")
+	}
+	if inherit || readonly {
+		return nil, p.errorf("expected %q", "attribute")
+	}
+
+	o := &Operation{Static: static, ExtAttrs: extAttrs, Doc: doc}
+	for _, special := range []string{"getter", "setter", "deleter"} {
+		if p.accept(special) {
+			o.Special = special
+			break
+		}
+	}
+	if o.Return, err = p.parseType(); err != nil {
+		return nil, err
+	}
+	if p.peek().kind == identifierToken {
+		o.Name, _ = p.identifier()
+	}
+	if o.Args, err = p.parseArguments(); err != nil {
+		return nil, err
+	}
+	return o, p.expect(";")
+}
+
+func (p *parser) parseArguments() ([]*Argument, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	args := []*Argument{}
+	for !p.accept(")") {
+		if len(args) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		a := &Argument{}
+		var err error
+		if a.ExtAttrs, err = p.parseExtendedAttributes(); err != nil {
+			return nil, err
+		}
+		a.Optional = p.accept("optional")
+		if a.Type, err = p.parseType(); err != nil {
+			return nil, err
+		}
+		a.Variadic = !a.Optional && p.accept("...")
+		if a.Name, err = p.identifier(); err != nil {
+			return nil, err
+		}
+		if a.Optional && p.accept("=") {
+			if a.Default, err = p.parseValue(); err != nil {
+				return nil, err
+			}
+		}
+		args = append(args, a)
+	}
+	return args, nil
+}
+
+func (p *parser) parseDictionary() (*Dictionary, error) {
+	d := &Dictionary{}
+	var err error
+	if d.Name, err = p.identifier(); err != nil {
+		return nil, err
+	}
+	if p.accept(":") {
+		if d.Inherits, err = p.identifier(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	for !p.accept("}") {
+		m := &DictionaryMember{Doc: p.peek().doc}
+		if m.ExtAttrs, err = p.parseExtendedAttributes(); err != nil {
+			return nil, err
+		}
+		m.Required = p.accept("required")
+		if m.Type, err = p.parseType(); err != nil {
+			return nil, err
+		}
+		if m.Name, err = p.identifier(); err != nil {
+			return nil, err
+		}
+		if !m.Required && p.accept("=") {
+			if m.Default, err = p.parseValue(); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.expect(";"); err != nil {
+			return nil, err
+		}
+		d.Members = append(d.Members, m)
+	}
+	return d, p.expect(";")
+}
+
+func (p *parser) parseEnum() (*Enum, error) {
+	e := &Enum{}
+	var err error
+	if e.Name, err = p.identifier(); err != nil {
+		return nil, err
+	}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	for !p.accept("}") {
+		if p.peek().kind != stringToken {
+			return nil, p.errorf("expected a string")
+		}
+		value := p.next().text
+		e.Values = append(e.Values, value[1:len(value)-1])
+		if !p.accept(",") {
+			if err := p.expect("}"); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	return e, p.expect(";")
+}
+
+// typeNames are the types named by more than one identifier.
+var typeNames = map[string][]string{
+	"unsigned":     {"short", "long"},
+	"unrestricted": {"float", "double"},
+}
+
+// parseType parses a type with its extended attributes.
+func (p *parser) parseType() (*Type, error) {
+	extAttrs, err := p.parseExtendedAttributes()
+	if err != nil {
+		return nil, err
+	}
+	t := &Type{ExtAttrs: extAttrs}
+	if p.accept("(") {
+		for {
+			member, err := p.parseType()
+			if err != nil {
+				return nil, err
+			}
+			t.Union = append(t.Union, member)
+			if p.accept(")") {
+				break
+			}
+			if err := p.expect("or"); err != nil {
+				return nil, err
+			}
+		}
+		t.Nullable = p.accept("?")
+		return t, nil
+	}
+
+	name, err := p.identifier()
+	if err != nil {
+		return nil, p.errorf("expected a type")
+	}
+	if next, ok := typeNames[name]; ok {
+		for _, n := range next {
+			if p.is(n) {
+				name += " " + p.next().text
+				break
+			}
+		}
+	}
+	if strings.HasSuffix(name, "long") && p.accept("long") {
+		name += " long"
+	}
+	t.Name = name
+	switch name {
+	case "sequence", "FrozenArray", "ObservableArray", "Promise", "record":
+		if err := p.expect("<"); err != nil {
+			return nil, err
+		}
+		for {
+			param, err := p.parseType()
+			if err != nil {
+				return nil, err
+			}
+			t.Params = append(t.Params, param)
+			if p.accept(">") {
+				break
+			}
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+	}
+	t.Nullable = p.accept("?")
+	return t, nil
+}
+
+// parseValue parses a constant value or default value.
+func (p *parser) parseValue() (*Value, error) {
+	t := p.next()
+	switch {
+	case t.kind == integerToken:
+		return &Value{Kind: "integer", Text: t.text}, nil
+	case t.kind == decimalToken:
+		return &Value{Kind: "decimal", Text: t.text}, nil
+	case t.kind == stringToken:
+		return &Value{Kind: "string", Text: t.text[1 : len(t.text)-1]}, nil
+	case t.text == "true" || t.text == "false":
+		return &Value{Kind: "boolean", Text: t.text}, nil
+	case t.text == "null":
+		return &Value{Kind: "null", Text: t.text}, nil
+	case t.text == "Infinity" || t.text == "-Infinity" || t.text == "NaN":
+		return &Value{Kind: "decimal", Text: t.text}, nil
+	case t.text == "[" && p.accept("]"):
+		return &Value{Kind: "sequence", Text: "[]"}, nil
+	case t.text == "{" && p.accept("}"):
+		return &Value{Kind: "dictionary", Text: "{}"}, nil
+	}
+	p.pos--
+	return nil, p.errorf("expected a value")
+}
+
+// parseExtendedAttributes parses an optional extended attribute list.
+func (p *parser) parseExtendedAttributes() (ExtendedAttributes, error) {
+	if !p.accept("[") {
+		return nil, nil
+	}
+	attrs := ExtendedAttributes{}
+	for {
+		a := &ExtendedAttribute{}
+		var err error
+		if a.Name, err = p.identifier(); err != nil {
+			return nil, err
+		}
+		if p.accept("=") {
+			switch {
+			case p.accept("*"):
+				a.Value = "*"
+			case p.accept("("):
+				for !p.accept(")") {
+					if len(a.Args) > 0 {
+						if err := p.expect(","); err != nil {
+							return nil, err
+						}
+					}
+					arg, err := p.identifier()
+					if err != nil {
+						return nil, err
+					}
+					a.Args = append(a.Args, arg)
+				}
+			case p.peek().kind == stringToken:
+				value := p.next().text
+				a.Value = value[1 : len(value)-1]
+			default:
+				if a.Value, err = p.identifier(); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if p.is("(") {
+			args, err := p.parseArguments()
+			if err != nil {
+				return nil, err
+			}
+			for _, arg := range args {
+				a.Args = append(a.Args, arg.Name)
+			}
+		}
+		attrs = append(attrs, a)
+		if p.accept("]") {
+			return attrs, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Command specgen generates the Go declarations of a package from WebIDL
+// fragments. Each fragment is generated into a _idl.go file named after it
+// in the package's directory. Declarations the package's hand-written code
+// already has aren't generated.
+//
+//	specgen [-dir dir] fragment.webidl...
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/heathj/gobrowse/webidl"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "directory of the package")
+	flag.Parse()
+	if err := run(*dir, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(dir string, fragments []string) error {
+	if len(fragments) == 0 {
+		return fmt.Errorf("no WebIDL fragments")
+	}
+	defs := make([]*webidl.Definitions, len(fragments))
+	for i, name := range fragments {
+		src, err := ioutil.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		if defs[i], err = webidl.Parse(name, string(src)); err != nil {
+			return err
+		}
+	}
+	pkg, err := loadPackage(dir)
+	if err != nil {
+		return err
+	}
+
+	g := webidl.NewGenerator(pkg.Name(), pkg, defs...)
+	outs := make([][]byte, len(fragments))
+	for i, name := range fragments {
+		if outs[i], err = g.Generate(filepath.ToSlash(name), defs[i]); err != nil {
+			return err
+		}
+	}
+	for i, name := range fragments {
+		base := strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+		if err := ioutil.WriteFile(filepath.Join(dir, base+"_idl.go"), outs[i], 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// https://golang.org/s/generatedcode
+var generated = regexp.MustCompile(`(?m)^// Code generated .* DO NOT EDIT\.$`)
+
+// loadPackage type-checks the hand-written files of the package in the
+// directory. Type errors are ignored since the hand-written code may use the
+// declarations that are generated.
+func loadPackage(dir string) (*types.Package, error) {
+	fset := token.NewFileSet()
+	names, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	files := []*ast.File{}
+	for _, name := range names {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		src, err := ioutil.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		if generated.Match(src) {
+			continue
+		}
+		f, err := parser.ParseFile(fset, name, src, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no Go files in %s", dir)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil), Error: func(error) {}}
+	pkg, _ := conf.Check(files[0].Name.Name, fset, files, nil)
+	return pkg, nil
+}
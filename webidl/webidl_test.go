@@ -0,0 +1,279 @@
+package webidl
+
+import (
+	"go/ast"
+	goparser "go/parser"
+	gotoken "go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	defs, err := Parse("test.webidl", `
+// https://example.com/#widget
+[Exposed=Window, LegacyFactoryFunction=Image(optional unsigned long width), Global=(Window,Worker)]
+interface Widget : Base {
+  constructor(optional WidgetInit init = {});
+  const unsigned long long BIG = 0xFF;
+  // The widget's name.
+  [CEReactions, Reflect=data-name] attribute DOMString name;
+  readonly attribute (Node or sequence<DOMString>)? value;
+  static Widget? create(record<DOMString, unrestricted double> values, long... rest);
+  getter any (unsigned long index);
+  stringifier;
+  iterable<Node>;
+  undefined _interface(optional [Clamp] long interface = -1);
+};
+
+partial interface Widget {
+  inherit attribute Promise<undefined> ready;
+};
+
+interface mixin Sized {
+  attribute unsigned short width;
+};
+Widget includes Sized;
+
+dictionary WidgetInit : BaseInit {
+  required boolean enabled;
+  DOMString label = "none";
+  sequence<long> sizes = [];
+};
+
+enum WidgetMode { "on", "off", };
+typedef (long or DOMString) Key;
+callback WidgetCallback = boolean (Widget widget, any... args);
+callback interface WidgetFilter {
+  const short SKIP = 3;
+  short filter(Widget widget);
+};
+`)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if assert.Len(t, defs.Interfaces, 4) {
+		widget := defs.Interfaces[0]
+		assert.Equal(t, "Widget", widget.Name)
+		assert.Equal(t, "Base", widget.Inherits)
+		assert.Equal(t, "https://example.com/#widget", widget.Doc)
+		assert.Equal(t, &ExtendedAttribute{Name: "Global", Args: []string{"Window", "Worker"}}, widget.ExtAttrs.Get("Global"))
+		assert.Equal(t, []string{"width"}, widget.ExtAttrs.Get("LegacyFactoryFunction").Args)
+		if assert.Len(t, widget.Members, 7) {
+			assert.Equal(t, &Constructor{Args: []*Argument{{Name: "init", Type: &Type{Name: "WidgetInit"}, Optional: true, Default: &Value{Kind: "dictionary", Text: "{}"}}}}, widget.Members[0])
+			assert.Equal(t, &Constant{Name: "BIG", Type: &Type{Name: "unsigned long long"}, Value: &Value{Kind: "integer", Text: "0xFF"}}, widget.Members[1])
+			name := widget.Members[2].(*Attribute)
+			assert.Equal(t, "The widget's name.", name.Doc)
+			assert.Equal(t, "data-name", name.ExtAttrs.Get("Reflect").Value)
+			value := widget.Members[3].(*Attribute)
+			assert.True(t, value.Readonly)
+			assert.True(t, value.Type.Nullable)
+			assert.Equal(t, []*Type{{Name: "Node"}, {Name: "sequence", Params: []*Type{{Name: "DOMString"}}}}, value.Type.Union)
+			create := widget.Members[4].(*Operation)
+			assert.True(t, create.Static)
+			assert.Equal(t, &Type{Name: "Widget", Nullable: true}, create.Return)
+			assert.Equal(t, "record", create.Args[0].Type.Name)
+			assert.Equal(t, "unrestricted double", create.Args[0].Type.Params[1].Name)
+			assert.True(t, create.Args[1].Variadic)
+			getter := widget.Members[5].(*Operation)
+			assert.Equal(t, "getter", getter.Special)
+			assert.Equal(t, "", getter.Name)
+			op := widget.Members[6].(*Operation)
+			assert.Equal(t, "interface", op.Name)
+			assert.Equal(t, "Clamp", op.Args[0].Type.ExtAttrs[0].Name)
+			assert.Equal(t, &Value{Kind: "integer", Text: "-1"}, op.Args[0].Default)
+		}
+		assert.True(t, defs.Interfaces[1].Partial)
+		assert.True(t, defs.Interfaces[1].Members[0].(*Attribute).Inherit)
+		assert.True(t, defs.Interfaces[2].Mixin)
+		assert.True(t, defs.Interfaces[3].Callback)
+	}
+	assert.Equal(t, []*Includes{{Target: "Widget", Mixin: "Sized"}}, defs.Includes)
+	if assert.Len(t, defs.Dictionaries, 1) {
+		d := defs.Dictionaries[0]
+		assert.Equal(t, "BaseInit", d.Inherits)
+		assert.True(t, d.Members[0].Required)
+		assert.Equal(t, &Value{Kind: "string", Text: "none"}, d.Members[1].Default)
+		assert.Equal(t, &Value{Kind: "sequence", Text: "[]"}, d.Members[2].Default)
+	}
+	assert.Equal(t, []string{"on", "off"}, defs.Enums[0].Values)
+	assert.Len(t, defs.Typedefs[0].Type.Union, 2)
+	assert.True(t, defs.Callbacks[0].Args[1].Variadic)
+
+	for _, src := range []string{
+		"interface {};",
+		"interface A { attribute long; };",
+		"interface A { readonly long a(); };",
+		"dictionary A { long a = ; };",
+		"enum A { a };",
+		"A includes ;",
+		"/* unterminated",
+	} {
+		_, err := Parse("bad.webidl", src)
+		assert.ErrorIs(t, err, ErrSyntax, src)
+	}
+	_, err = Parse("bad.webidl", "interface A {\n  attribute long 1;\n};")
+	assert.EqualError(t, err, `WebIDL syntax error: bad.webidl:2: expected an identifier, found "1"`)
+}
+
+// checkPackage type-checks the Go files, failing the test on errors.
+func checkPackage(t *testing.T, srcs ...string) *types.Package {
+	fset := gotoken.NewFileSet()
+	files := []*ast.File{}
+	for _, src := range srcs {
+		f, err := goparser.ParseFile(fset, "", src, 0)
+		if !assert.NoError(t, err, src) {
+			t.FailNow()
+		}
+		files = append(files, f)
+	}
+	pkg, err := (&types.Config{}).Check("spec", fset, files, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return pkg
+}
+
+const existing = `package spec
+
+type Node struct {
+	Name  string
+	attrs map[string]string
+	*Document
+}
+
+type Document struct{ Title string }
+
+func (n *Node) GetAttribute(name string) string   { return n.attrs[name] }
+func (n *Node) SetAttribute(name, value string)   { n.attrs[name] = value }
+func (n *Node) HasAttribute(name string) bool     { _, ok := n.attrs[name]; return ok }
+func (n *Node) RemoveAttribute(name string)       { delete(n.attrs, name) }
+func (n *Node) setBooleanAttribute(name string, v bool) {}
+func (n *Node) reflectLong(name string) int       { return 0 }
+func (n *Node) setLongAttribute(name string, v int) {}
+
+type Widget struct{ size uint }
+
+func (w *Widget) Size() uint               { return w.size }
+func (w *Widget) Resize(size uint) error   { return nil }
+
+type Mode int
+`
+
+func TestGenerate(t *testing.T) {
+	defs, err := Parse("test.webidl", `
+interface Node {};
+interface HTMLThingElement : Node {
+  [Reflect] attribute DOMString name;
+  [Reflect] attribute DOMString title;
+  [Reflect] attribute DOMString label;
+  [Reflect=aria-busy] attribute boolean ariaBusy;
+  [Reflect] attribute long rows;
+};
+interface HTMLOtherElement : Node {
+  [Reflect] attribute DOMString label;
+};
+
+// https://example.com/#widget
+interface Widget {
+  const unsigned short MAX_SIZE = 3;
+  readonly attribute unsigned long size;
+  attribute Node? owner;
+  undefined resize(unsigned long size);
+  sequence<Widget> children(WidgetOptions options, Node... nodes);
+  undefined paint();
+  undefined paint(boolean now);
+  undefined range();
+};
+interface Gadget : Widget {};
+
+dictionary BaseOptions { boolean deep = true; };
+dictionary WidgetOptions : BaseOptions {
+  // The widget's color.
+  WidgetColor color = "dark-red";
+  long count = 0;
+};
+enum WidgetColor { "dark-red", "" };
+enum Mode { "a" };
+callback WidgetCallback = boolean (Widget widget);
+callback interface WidgetFilter {
+  short accept(Widget widget);
+};
+`)
+	if !assert.NoError(t, err) {
+		return
+	}
+	g := NewGenerator("spec", checkPackage(t, existing), defs)
+	out, err := g.Generate("test.webidl", defs)
+	if !assert.NoError(t, err) {
+		return
+	}
+	src := string(out)
+	checkPackage(t, existing, src)
+
+	assert.Contains(t, src, "// Code generated by specgen from test.webidl. DO NOT EDIT.\n")
+	assert.NotContains(t, src, "Name()", "hand-written fields implement attributes")
+	assert.NotContains(t, src, "SetName")
+	assert.Contains(t, src, "func (n *Node) Title() string", "promoted fields don't implement attributes")
+	assert.Contains(t, src, "// Label reflects the label content attribute.\nfunc (n *Node) Label() string { return n.GetAttribute(\"label\") }")
+	assert.Equal(t, 1, countOf(src, "func (n *Node) Label()"), "reflections shared by elements are generated once")
+	assert.Contains(t, src, `func (n *Node) AriaBusy() bool { return n.HasAttribute("aria-busy") }`)
+	assert.Contains(t, src, `func (n *Node) SetRows(v int) { n.setLongAttribute("rows", v) }`)
+
+	assert.Contains(t, src, "WidgetMaxSize uint16 = 3")
+	assert.NotContains(t, src, "Size()", "hand-written methods aren't generated")
+	assert.NotContains(t, src, "Resize", "hand-written methods may return an error")
+	assert.Contains(t, src, "// owner is https://example.com/#dom-widget-owner\nfunc (w *Widget) owner() *Node { return nil }",
+		"stubs are unexported until they're implemented")
+	assert.Contains(t, src, "func (w *Widget) setOwner(v *Node) {}")
+	assert.Contains(t, src, "func (w *Widget) children(options WidgetOptions, nodes ...*Node) []*Widget { return nil }")
+	assert.Contains(t, src, "func (w *Widget) range_() {}", "stubs named after keywords are renamed")
+	assert.NotContains(t, src, "Paint", "overloaded operations aren't generated")
+	assert.Contains(t, src, "type Gadget struct {\n\tWidget\n}")
+
+	assert.Contains(t, src, "type WidgetOptions struct {\n\tBaseOptions\n\t// The widget's color.\n\tColor WidgetColor\n\tCount int\n}")
+	assert.Contains(t, src, "return WidgetOptions{BaseOptions: DefaultBaseOptions(), Color: \"dark-red\"}")
+	assert.Contains(t, src, "WidgetColorDarkRed WidgetColor = \"dark-red\"")
+	assert.Contains(t, src, "WidgetColorEmpty   WidgetColor = \"\"")
+	assert.NotContains(t, src, "ModeA", "hand-written types own their values")
+	assert.Contains(t, src, "type WidgetCallback func(widget *Widget) bool")
+	assert.Contains(t, src, "type WidgetFilter interface {\n\tAccept(widget *Widget) int16\n}")
+
+	mismatched := checkPackage(t, existing+"\nfunc (w *Widget) Children() []*Widget { return nil }\n")
+	_, err = NewGenerator("spec", mismatched, defs).Generate("test.webidl", defs)
+	assert.ErrorIs(t, err, ErrMismatch)
+	assert.Contains(t, err.Error(), "Widget.Children is func()[]*Widget, the IDL gives func(WidgetOptions, ...*Node)[]*Widget")
+
+	embedded := checkPackage(t, strings.Replace(existing, "type Widget struct{ size uint }",
+		"type Widget struct {\n\tsize uint\n\t*Base\n}\n\ntype Base struct{}\n\nfunc (b *Base) Owner() *Node { return nil }", 1))
+	_, err = NewGenerator("spec", embedded, defs).Generate("test.webidl", defs)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Contains(t, err.Error(), "Widget.Owner hides (*spec.Base).Owner")
+
+	taken := checkPackage(t, existing+"\nfunc (w *Widget) owner() {}\n")
+	_, err = NewGenerator("spec", taken, defs).Generate("test.webidl", defs)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Contains(t, err.Error(), "the stub of Widget.Owner is hidden by owner")
+
+	field := checkPackage(t, strings.Replace(existing, "Name  string", "Name  int", 1))
+	_, err = NewGenerator("spec", field, defs).Generate("test.webidl", defs)
+	assert.ErrorIs(t, err, ErrMismatch)
+	assert.Contains(t, err.Error(), "Node.Name is int, the IDL gives string")
+
+	unknown, _ := Parse("unknown.webidl", "interface A { attribute Missing m; };")
+	_, err = NewGenerator("spec", nil, unknown).Generate("unknown.webidl", unknown)
+	assert.ErrorIs(t, err, ErrUnsupported)
+	assert.Contains(t, err.Error(), "unknown type Missing")
+}
+
+func countOf(s, substr string) int {
+	n := 0
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if s[i:i+len(substr)] == substr {
+			n++
+		}
+	}
+	return n
+}